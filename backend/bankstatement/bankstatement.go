@@ -0,0 +1,98 @@
+// Package bankstatement reads bank statements exported by internet banking
+// so that they can be reconciled with the books.
+//
+// Three formats are understood: CSV with a configurable column mapping,
+// OFX (both the SGML 1.x and the XML 2.x flavour) and SWIFT MT940. Every
+// parser returns the same Statement, in which credits to the account are
+// positive amounts and debits negative ones.
+package bankstatement
+
+import (
+	"errors"
+	"fmt"
+	"gkjw-finance-backend/models"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Formats lists the statement formats Parse understands.
+var Formats = []string{"csv", "ofx", "mt940"}
+
+// ErrNoLines is returned for a statement without a single transaction.
+var ErrNoLines = errors.New("the statement has no transactions")
+
+// Statement is a parsed bank statement. StartDate and EndDate are those
+// the file states, widened to cover every line. Balances are nil when the
+// file does not state them.
+type Statement struct {
+	AccountNumber  string
+	StartDate      time.Time
+	EndDate        time.Time
+	OpeningBalance *models.Money
+	ClosingBalance *models.Money
+	Lines          []Line
+}
+
+// Line is one transaction on a statement. Amount is positive for money
+// coming into the account.
+type Line struct {
+	Date        time.Time
+	Amount      models.Money
+	Description string
+	Reference   string
+}
+
+// DetectFormat guesses the format of a file from its name, or returns "".
+func DetectFormat(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv", ".txt":
+		return "csv"
+	case ".ofx", ".qfx":
+		return "ofx"
+	case ".sta", ".mt940", ".940":
+		return "mt940"
+	}
+	return ""
+}
+
+// Parse reads a statement in format. mapping is only used for CSV.
+func Parse(format string, data []byte, mapping CSVMapping) (*Statement, error) {
+	var statement *Statement
+	var err error
+	switch format {
+	case "csv":
+		statement, err = ParseCSV(data, mapping)
+	case "ofx":
+		statement, err = ParseOFX(data)
+	case "mt940":
+		statement, err = ParseMT940(data)
+	default:
+		return nil, fmt.Errorf("unknown statement format %q; use one of %s", format, strings.Join(Formats, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(statement.Lines) == 0 {
+		return nil, ErrNoLines
+	}
+	statement.fillPeriod()
+	return statement, nil
+}
+
+// fillPeriod widens the period of s to cover every line.
+func (s *Statement) fillPeriod() {
+	for _, line := range s.Lines {
+		if s.StartDate.IsZero() || line.Date.Before(s.StartDate) {
+			s.StartDate = line.Date
+		}
+		if s.EndDate.IsZero() || line.Date.After(s.EndDate) {
+			s.EndDate = line.Date
+		}
+	}
+}
+
+// date returns the calendar date of t at midnight UTC, as dates are stored.
+func date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
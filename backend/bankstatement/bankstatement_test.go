@@ -0,0 +1,381 @@
+package bankstatement
+
+import (
+	"errors"
+	"gkjw-finance-backend/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func day(value string) time.Time {
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return date
+}
+
+func money(value string) models.Money {
+	amount, err := models.ParseMoney(value)
+	if err != nil {
+		panic(err)
+	}
+	return amount
+}
+
+// checkLines compares the date, amount, description and reference of got
+// with want.
+func checkLines(t *testing.T, got, want []Line) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if !got[i].Date.Equal(want[i].Date) || got[i].Amount != want[i].Amount ||
+			got[i].Description != want[i].Description || got[i].Reference != want[i].Reference {
+			t.Errorf("line %d = {%s %s %q %q}, want {%s %s %q %q}", i+1,
+				got[i].Date.Format("2006-01-02"), got[i].Amount, got[i].Description, got[i].Reference,
+				want[i].Date.Format("2006-01-02"), want[i].Amount, want[i].Description, want[i].Reference)
+		}
+	}
+}
+
+func checkBalance(t *testing.T, name string, got *models.Money, want string) {
+	t.Helper()
+	switch {
+	case want == "" && got != nil:
+		t.Errorf("%s balance = %s, want none", name, *got)
+	case want != "" && (got == nil || *got != money(want)):
+		t.Errorf("%s balance = %v, want %s", name, got, want)
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in           string
+		decimalComma bool
+		want         string
+		wantErr      bool
+	}{
+		{"150000", false, "150000", false},
+		{"250,000.00", false, "250000", false},
+		{"1.500.000,50", true, "1500000.50", false},
+		{"Rp 1.500.000,00 CR", true, "1500000", false},
+		{"75.000,00 DB", true, "-75000", false},
+		{"75,000.00 D", false, "-75000", false},
+		{"(250,000.00)", false, "-250000", false},
+		{"-75000", false, "-75000", false},
+		{"+1000", false, "1000", false},
+		{"1 000", false, "1000", false},
+		{"", false, "0", false},
+		{"-", false, "0", false},
+		{"abc", false, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseAmount(tt.in, tt.decimalComma)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != money(tt.want) {
+				t.Errorf("ParseAmount(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		in, layout string
+		want       string
+		wantErr    bool
+	}{
+		{"2025-01-31", "", "2025-01-31", false},
+		{"31/01/2025", "", "2025-01-31", false},
+		{"31-01-2025", "", "2025-01-31", false},
+		{"5/1/2025", "", "2025-01-05", false},
+		{"31/01/25", "", "2025-01-31", false},
+		{"31 Jan 2025", "", "2025-01-31", false},
+		{"01/31/2025", "01/02/2006", "2025-01-31", false},
+		{"01/31/2025", "", "", true},
+		{"yesterday", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseDate(tt.in, tt.layout)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(day(tt.want)) {
+				t.Errorf("ParseDate(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name             string
+		data             string
+		mapping          CSVMapping
+		want             []Line
+		opening, closing string
+		wantErr          string
+	}{
+		{
+			name: "signed amount with balance",
+			data: "\xef\xbb\xbfTanggal,Keterangan,Jumlah,Saldo\n" +
+				"02/01/2025,Setoran persembahan,\"1,500,000.00\",\"11,500,000.00\"\n" +
+				"\n" +
+				"03/01/2025,Biaya admin,-15000,\"11,485,000.00\"\n",
+			mapping: CSVMapping{Date: "tanggal", Amount: "Jumlah", Description: "Keterangan", Balance: "Saldo"},
+			want: []Line{
+				{Date: day("2025-01-02"), Amount: money("1500000"), Description: "Setoran persembahan"},
+				{Date: day("2025-01-03"), Amount: money("-15000"), Description: "Biaya admin"},
+			},
+			opening: "10000000",
+			closing: "11485000",
+		},
+		{
+			name: "debit and credit columns with decimal comma",
+			data: "Rekening 123;;;\nTgl;Uraian;Debet;Kredit;Ref\n" +
+				"05-01-2025;Listrik;350.000,00;;INV-1\n" +
+				"06-01-2025;Transfer masuk;;2.000.000,00;TRF-9\n" +
+				"07-01-2025;Saldo awal bulan;;;\n",
+			mapping: CSVMapping{Delimiter: ";", SkipRows: 1, Date: "Tgl", Debit: "Debet", Credit: "Kredit",
+				Description: "Uraian", Reference: "Ref", DecimalComma: true},
+			want: []Line{
+				{Date: day("2025-01-05"), Amount: money("-350000"), Description: "Listrik", Reference: "INV-1"},
+				{Date: day("2025-01-06"), Amount: money("2000000"), Description: "Transfer masuk", Reference: "TRF-9"},
+			},
+		},
+		{
+			name:    "numbered columns without header",
+			data:    "2025-01-10,INV-2,250000 CR\n",
+			mapping: CSVMapping{NoHeader: true, Date: "1", Reference: "2", Amount: "3"},
+			want:    []Line{{Date: day("2025-01-10"), Amount: money("250000"), Reference: "INV-2"}},
+		},
+		{
+			name:    "no date column",
+			mapping: CSVMapping{Amount: "1"},
+			wantErr: "needs a date column",
+		},
+		{
+			name:    "no amount column",
+			mapping: CSVMapping{Date: "1"},
+			wantErr: "needs an amount column",
+		},
+		{
+			name:    "unknown column",
+			data:    "Tanggal,Jumlah\n2025-01-10,1\n",
+			mapping: CSVMapping{Date: "Tanggal", Amount: "Nominal"},
+			wantErr: `column "Nominal" not found`,
+		},
+		{
+			name:    "bad date names the row",
+			data:    "Tanggal,Jumlah\n2025-01-10,1\nbesok,2\n",
+			mapping: CSVMapping{Date: "Tanggal", Amount: "Jumlah"},
+			wantErr: "row 3: invalid date",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statement, err := ParseCSV([]byte(tt.data), tt.mapping)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkLines(t, statement.Lines, tt.want)
+			checkBalance(t, "opening", statement.OpeningBalance, tt.opening)
+			checkBalance(t, "closing", statement.ClosingBalance, tt.closing)
+		})
+	}
+}
+
+const ofxSGML = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>IDR
+<BANKACCTFROM><BANKID>014<ACCTID>1234567890<ACCTTYPE>CHECKING</BANKACCTFROM>
+<BANKTRANLIST>
+<DTSTART>20250101
+<DTEND>20250131
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20250105120000.000[+7:WIB]
+<TRNAMT>1500000.00
+<FITID>F001
+<NAME>Persembahan
+<MEMO>Ibadah Minggu
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20250110
+<TRNAMT>-350000,00
+<FITID>F002
+<CHECKNUM>CHK-7
+<NAME>PLN &amp; PDAM
+<MEMO>PLN &amp; PDAM
+</STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL><BALAMT>11150000.00<DTASOF>20250131</LEDGERBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+const ofxXML = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<BANKACCTFROM><ACCTID>987</ACCTID></BANKACCTFROM>
+<BANKTRANLIST>
+<STMTTRN><DTPOSTED>20250203</DTPOSTED><TRNAMT>-25000</TRNAMT><FITID>X1</FITID><REFNUM>R-1</REFNUM><NAME>Biaya admin</NAME></STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL><BALAMT>975000</BALAMT><DTASOF>20250228</DTASOF></LEDGERBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>
+`
+
+func TestParseOFX(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		account    string
+		start, end string
+		want       []Line
+		closing    string
+	}{
+		{
+			name:    "SGML",
+			data:    ofxSGML,
+			account: "1234567890",
+			start:   "2025-01-01",
+			end:     "2025-01-31",
+			want: []Line{
+				{Date: day("2025-01-05"), Amount: money("1500000"), Description: "Persembahan Ibadah Minggu", Reference: "F001"},
+				{Date: day("2025-01-10"), Amount: money("-350000"), Description: "PLN & PDAM", Reference: "CHK-7"},
+			},
+			closing: "11150000",
+		},
+		{
+			name:    "XML",
+			data:    ofxXML,
+			account: "987",
+			end:     "2025-02-28",
+			want:    []Line{{Date: day("2025-02-03"), Amount: money("-25000"), Description: "Biaya admin", Reference: "R-1"}},
+			closing: "975000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statement, err := ParseOFX([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if statement.AccountNumber != tt.account {
+				t.Errorf("account = %q, want %q", statement.AccountNumber, tt.account)
+			}
+			if tt.start != "" && !statement.StartDate.Equal(day(tt.start)) {
+				t.Errorf("start = %s, want %s", statement.StartDate, tt.start)
+			}
+			if !statement.EndDate.Equal(day(tt.end)) {
+				t.Errorf("end = %s, want %s", statement.EndDate, tt.end)
+			}
+			checkLines(t, statement.Lines, tt.want)
+			checkBalance(t, "closing", statement.ClosingBalance, tt.closing)
+		})
+	}
+
+	if _, err := ParseOFX([]byte("<STMTTRN><DTPOSTED>2025<TRNAMT>1<FITID>F9</STMTTRN>")); err == nil {
+		t.Error("ParseOFX accepted an invalid date")
+	}
+}
+
+const mt940 = `{1:F01BANKIDJAXXXX0000000000}{2:I940BANKIDJAXXXXN}{4:
+:20:STMT250131
+:25:1234567890
+:28C:00001/001
+:60F:C250101IDR10000000,00
+:61:2501020102C1500000,NTRFNONREF//BANKREF1
+:86:SETORAN PERSEMBAHAN
+ IBADAH MINGGU
+:61:250103D15000,NCHGADM-JAN
+:86:BIAYA ADMIN
+:62F:C250103IDR11485000,
+-}
+{4:
+:20:STMT250104
+:25:1234567890
+:60F:C250104IDR11485000,00
+:61:250104RD200000,NTRFREV-1
+:86:KOREKSI
+:62F:C250104IDR11685000,00
+-}
+`
+
+func TestParseMT940(t *testing.T) {
+	statement, err := ParseMT940([]byte(mt940))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statement.AccountNumber != "1234567890" {
+		t.Errorf("account = %q", statement.AccountNumber)
+	}
+	if !statement.StartDate.Equal(day("2025-01-01")) || !statement.EndDate.Equal(day("2025-01-04")) {
+		t.Errorf("period = %s to %s, want 2025-01-01 to 2025-01-04", statement.StartDate, statement.EndDate)
+	}
+	checkLines(t, statement.Lines, []Line{
+		{Date: day("2025-01-02"), Amount: money("1500000"), Description: "SETORAN PERSEMBAHAN IBADAH MINGGU", Reference: "BANKREF1"},
+		{Date: day("2025-01-03"), Amount: money("-15000"), Description: "BIAYA ADMIN", Reference: "ADM-JAN"},
+		{Date: day("2025-01-04"), Amount: money("200000"), Description: "KOREKSI", Reference: "REV-1"},
+	})
+	checkBalance(t, "opening", statement.OpeningBalance, "10000000")
+	checkBalance(t, "closing", statement.ClosingBalance, "11685000")
+
+	for _, bad := range []string{":61:250102X100,NTRF\n", ":60F:C2501IDR1,00\n"} {
+		if _, err := ParseMT940([]byte(bad)); err == nil {
+			t.Errorf("ParseMT940 accepted %q", bad)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	if _, err := Parse("qif", nil, CSVMapping{}); err == nil || !strings.Contains(err.Error(), "unknown statement format") {
+		t.Errorf("err = %v for an unknown format", err)
+	}
+	if _, err := Parse("ofx", []byte("<OFX></OFX>"), CSVMapping{}); !errors.Is(err, ErrNoLines) {
+		t.Errorf("err = %v for a statement without lines, want ErrNoLines", err)
+	}
+
+	// The period is widened to cover every line.
+	statement, err := Parse("csv", []byte("Tanggal,Jumlah\n2025-03-05,1\n2025-03-01,2\n"), CSVMapping{Date: "Tanggal", Amount: "Jumlah"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !statement.StartDate.Equal(day("2025-03-01")) || !statement.EndDate.Equal(day("2025-03-05")) {
+		t.Errorf("period = %s to %s, want 2025-03-01 to 2025-03-05", statement.StartDate, statement.EndDate)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	for name, want := range map[string]string{
+		"mutasi.CSV":   "csv",
+		"mutasi.txt":   "csv",
+		"bca.ofx":      "ofx",
+		"bca.qfx":      "ofx",
+		"mandiri.sta":  "mt940",
+		"rekening.940": "mt940",
+		"mutasi.pdf":   "",
+	} {
+		if got := DetectFormat(name); got != want {
+			t.Errorf("DetectFormat(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
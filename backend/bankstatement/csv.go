@@ -0,0 +1,220 @@
+package bankstatement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"gkjw-finance-backend/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVMapping tells ParseCSV where to find each field. Columns are named by
+// their header, case-insensitively, or by their 1-based number.
+//
+// The amount is either one signed Amount column or a pair of Debit and
+// Credit columns. Amounts may carry a currency symbol, thousands
+// separators and a trailing "CR" or "DB" mark, as Indonesian banks export
+// them; DecimalComma reads "1.234,56" as 1234.56. Balance, when mapped, is
+// the running balance after each line; the last one becomes the closing
+// balance.
+type CSVMapping struct {
+	Delimiter    string `json:"delimiter"`
+	NoHeader     bool   `json:"noHeader"`
+	SkipRows     int    `json:"skipRows"`
+	Date         string `json:"date"`
+	DateFormat   string `json:"dateFormat"`
+	Amount       string `json:"amount"`
+	Debit        string `json:"debit"`
+	Credit       string `json:"credit"`
+	Description  string `json:"description"`
+	Reference    string `json:"reference"`
+	Balance      string `json:"balance"`
+	DecimalComma bool   `json:"decimalComma"`
+}
+
+// defaultDateFormats are tried in turn when the mapping names no format.
+var defaultDateFormats = []string{"2006-01-02", "02/01/2006", "02-01-2006", "2/1/2006", "02/01/06", "02 Jan 2006"}
+
+// ParseCSV reads a CSV statement with the given column mapping.
+func ParseCSV(data []byte, mapping CSVMapping) (*Statement, error) {
+	if mapping.Date == "" {
+		return nil, errors.New("the CSV mapping needs a date column")
+	}
+	if mapping.Amount == "" && mapping.Debit == "" && mapping.Credit == "" {
+		return nil, errors.New("the CSV mapping needs an amount column or debit and credit columns")
+	}
+
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if mapping.Delimiter != "" {
+		delimiter := []rune(mapping.Delimiter)
+		if len(delimiter) != 1 {
+			return nil, errors.New("the CSV delimiter must be a single character")
+		}
+		reader.Comma = delimiter[0]
+	}
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if mapping.SkipRows > 0 {
+		if mapping.SkipRows >= len(rows) {
+			return &Statement{}, nil
+		}
+		rows = rows[mapping.SkipRows:]
+	}
+
+	var header []string
+	if !mapping.NoHeader && len(rows) > 0 {
+		header, rows = rows[0], rows[1:]
+	}
+	column := func(name string) (int, error) {
+		if name == "" {
+			return -1, nil
+		}
+		if number, err := strconv.Atoi(name); err == nil && number > 0 {
+			return number - 1, nil
+		}
+		for i, title := range header {
+			if strings.EqualFold(strings.TrimSpace(title), strings.TrimSpace(name)) {
+				return i, nil
+			}
+		}
+		return -1, fmt.Errorf("column %q not found in the CSV header", name)
+	}
+	var columns [7]int
+	for i, name := range []string{mapping.Date, mapping.Amount, mapping.Debit, mapping.Credit, mapping.Description, mapping.Reference, mapping.Balance} {
+		if columns[i], err = column(name); err != nil {
+			return nil, err
+		}
+	}
+	dateColumn, amountColumn, debitColumn, creditColumn, descriptionColumn, referenceColumn, balanceColumn :=
+		columns[0], columns[1], columns[2], columns[3], columns[4], columns[5], columns[6]
+
+	statement := &Statement{}
+	for i, row := range rows {
+		rowNumber := i + 1 + mapping.SkipRows
+		if !mapping.NoHeader {
+			rowNumber++
+		}
+		cell := func(index int) string {
+			if index < 0 || index >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[index])
+		}
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+
+		date, err := ParseDate(cell(dateColumn), mapping.DateFormat)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", rowNumber, err)
+		}
+		var amount models.Money
+		if amountColumn >= 0 {
+			amount, err = ParseAmount(cell(amountColumn), mapping.DecimalComma)
+		} else {
+			var debit, credit models.Money
+			if debit, err = ParseAmount(cell(debitColumn), mapping.DecimalComma); err == nil {
+				credit, err = ParseAmount(cell(creditColumn), mapping.DecimalComma)
+			}
+			amount = abs(credit) - abs(debit)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", rowNumber, err)
+		}
+		if amount == 0 {
+			continue
+		}
+		if balanceColumn >= 0 && cell(balanceColumn) != "" {
+			balance, err := ParseAmount(cell(balanceColumn), mapping.DecimalComma)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %w", rowNumber, err)
+			}
+			if statement.OpeningBalance == nil {
+				opening := balance - amount
+				statement.OpeningBalance = &opening
+			}
+			statement.ClosingBalance = &balance
+		}
+
+		statement.Lines = append(statement.Lines, Line{
+			Date:        date,
+			Amount:      amount,
+			Description: cell(descriptionColumn),
+			Reference:   cell(referenceColumn),
+		})
+	}
+	return statement, nil
+}
+
+// ParseDate reads a date in layout, or in one of the layouts banks and
+// spreadsheets commonly use when layout is empty.
+func ParseDate(value, layout string) (time.Time, error) {
+	layouts := defaultDateFormats
+	if layout != "" {
+		layouts = []string{layout}
+	}
+	for _, layout := range layouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return date(parsed), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+// ParseAmount reads an amount as banks print it: "Rp 1.500.000,00 CR",
+// "(250,000.00)" or "-75000". An empty cell is zero.
+func ParseAmount(value string, decimalComma bool) (models.Money, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	if s == "" || s == "-" {
+		return 0, nil
+	}
+	negative := false
+	for _, mark := range []string{"DB", "DR", "D"} {
+		if strings.HasSuffix(s, mark) {
+			s, negative = strings.TrimSuffix(s, mark), true
+			break
+		}
+	}
+	for _, mark := range []string{"CR", "K", "C"} {
+		if !negative && strings.HasSuffix(s, mark) {
+			s = strings.TrimSuffix(s, mark)
+			break
+		}
+	}
+	s = strings.TrimSpace(strings.TrimPrefix(s, "RP"))
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		s, negative = s[1:len(s)-1], !negative
+	}
+	if strings.HasPrefix(s, "-") {
+		s, negative = s[1:], !negative
+	}
+	s = strings.ReplaceAll(strings.TrimSpace(strings.TrimPrefix(s, "+")), " ", "")
+	if decimalComma {
+		s = strings.ReplaceAll(strings.ReplaceAll(s, ".", ""), ",", ".")
+	} else {
+		s = strings.ReplaceAll(s, ",", "")
+	}
+
+	amount, err := models.ParseMoney(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+func abs(amount models.Money) models.Money {
+	if amount < 0 {
+		return -amount
+	}
+	return amount
+}
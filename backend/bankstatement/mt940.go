@@ -0,0 +1,159 @@
+package bankstatement
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"gkjw-finance-backend/models"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// mt940Field matches the start of a field, such as ":61:" or ":60F:".
+var mt940Field = regexp.MustCompile(`^:(\d{2}[A-Z]?):(.*)$`)
+
+// mt940Entry is the statement line of field 61: value date, optional entry
+// date, debit/credit mark, optional funds code, amount, transaction type,
+// the account owner's reference and, after "//", the bank's reference.
+var mt940Entry = regexp.MustCompile(`^(\d{6})(\d{4})?(R?[CD])([A-Z])?(\d+,\d{0,2})([NFS][A-Z0-9]{3})(.*?)(?://(.*))?$`)
+
+// mt940Balance is a balance of field 60 or 62: debit/credit mark, date,
+// currency and amount.
+var mt940Balance = regexp.MustCompile(`^([CD])(\d{6})([A-Z]{3})(\d+,\d{0,2})`)
+
+// ParseMT940 reads a SWIFT MT940 statement. When a file holds several
+// statements, as consecutive days often are, their lines are joined and
+// the balances are those of the first opening and the last closing. The
+// description of a line is its field 86.
+func ParseMT940(data []byte) (*Statement, error) {
+	statement := &Statement{}
+	var tag string
+	var value strings.Builder
+
+	flush := func() error {
+		defer value.Reset()
+		text := strings.TrimSpace(value.String())
+		switch tag {
+		case "25":
+			if statement.AccountNumber == "" {
+				statement.AccountNumber = text
+			}
+		case "60F", "60M":
+			if statement.OpeningBalance == nil {
+				balance, balanceDate, err := parseMT940Balance(text)
+				if err != nil {
+					return err
+				}
+				statement.OpeningBalance = &balance
+				statement.StartDate = balanceDate
+			}
+		case "62F", "62M":
+			balance, balanceDate, err := parseMT940Balance(text)
+			if err != nil {
+				return err
+			}
+			statement.ClosingBalance = &balance
+			statement.EndDate = balanceDate
+		case "61":
+			line, err := parseMT940Entry(text)
+			if err != nil {
+				return err
+			}
+			statement.Lines = append(statement.Lines, line)
+		case "86":
+			if n := len(statement.Lines); n > 0 && statement.Lines[n-1].Description == "" {
+				statement.Lines[n-1].Description = strings.Join(strings.Fields(text), " ")
+			}
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if match := mt940Field.FindStringSubmatch(text); match != nil {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			tag = match[1]
+			value.WriteString(match[2])
+			continue
+		}
+		if strings.HasPrefix(text, "-}") || strings.HasPrefix(text, "{") || text == "-" {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			tag = ""
+			continue
+		}
+		// A field continues on the following lines.
+		value.WriteString("\n" + text)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return statement, nil
+}
+
+func parseMT940Entry(text string) (Line, error) {
+	first, supplement, _ := strings.Cut(text, "\n")
+	match := mt940Entry.FindStringSubmatch(first)
+	if match == nil {
+		return Line{}, fmt.Errorf("invalid statement line %q", first)
+	}
+	valueDate, err := time.Parse("060102", match[1])
+	if err != nil {
+		return Line{}, fmt.Errorf("invalid statement line date %q", match[1])
+	}
+	amount, err := parseMT940Amount(match[5])
+	if err != nil {
+		return Line{}, err
+	}
+	// RC and RD reverse a credit and a debit.
+	if match[3] == "D" || match[3] == "RC" {
+		amount = -amount
+	}
+
+	reference := strings.TrimSpace(match[7])
+	if reference == "" || strings.EqualFold(reference, "NONREF") {
+		reference = strings.TrimSpace(match[8])
+	}
+	return Line{
+		Date:        date(valueDate),
+		Amount:      amount,
+		Reference:   reference,
+		Description: strings.TrimSpace(supplement),
+	}, nil
+}
+
+func parseMT940Balance(text string) (models.Money, time.Time, error) {
+	match := mt940Balance.FindStringSubmatch(text)
+	if match == nil {
+		return 0, time.Time{}, fmt.Errorf("invalid balance %q", text)
+	}
+	balanceDate, err := time.Parse("060102", match[2])
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("invalid balance date %q", match[2])
+	}
+	amount, err := parseMT940Amount(match[4])
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if match[1] == "D" {
+		amount = -amount
+	}
+	return amount, date(balanceDate), nil
+}
+
+// parseMT940Amount reads an amount with a decimal comma, such as "1500,".
+func parseMT940Amount(value string) (models.Money, error) {
+	amount, err := models.ParseMoney(strings.TrimSuffix(strings.Replace(value, ",", ".", 1), "."))
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	return amount, nil
+}
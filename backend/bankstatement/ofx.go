@@ -0,0 +1,153 @@
+package bankstatement
+
+import (
+	"fmt"
+	"gkjw-finance-backend/models"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ofxTag matches an opening or closing OFX tag and the text after it. In
+// OFX 1.x leaf elements are not closed, so the value of an element runs up
+// to the next tag in both flavours.
+var ofxTag = regexp.MustCompile(`<(/?[A-Za-z0-9.]+)>([^<]*)`)
+
+// ParseOFX reads the first bank statement of an OFX file. The reference of
+// a line is its check or reference number, or else the bank's FITID.
+func ParseOFX(data []byte) (*Statement, error) {
+	statement := &Statement{}
+	var line *ofxLine
+	var inLedgerBalance bool
+	var ledgerAmount, ledgerDate string
+
+	for _, match := range ofxTag.FindAllStringSubmatch(string(data), -1) {
+		tag := strings.ToUpper(match[1])
+		value := strings.TrimSpace(html.UnescapeString(match[2]))
+
+		switch tag {
+		case "STMTTRN":
+			line = &ofxLine{}
+			continue
+		case "/STMTTRN":
+			if line != nil {
+				parsed, err := line.parse()
+				if err != nil {
+					return nil, err
+				}
+				statement.Lines = append(statement.Lines, parsed)
+			}
+			line = nil
+			continue
+		case "LEDGERBAL":
+			inLedgerBalance = true
+			continue
+		case "/LEDGERBAL":
+			inLedgerBalance = false
+			continue
+		}
+
+		switch {
+		case line != nil:
+			line.set(tag, value)
+		case inLedgerBalance && tag == "BALAMT":
+			ledgerAmount = value
+		case inLedgerBalance && tag == "DTASOF":
+			ledgerDate = value
+		case tag == "ACCTID" && statement.AccountNumber == "":
+			statement.AccountNumber = value
+		case tag == "DTSTART" && statement.StartDate.IsZero():
+			statement.StartDate, _ = parseOFXDate(value)
+		case tag == "DTEND" && statement.EndDate.IsZero():
+			statement.EndDate, _ = parseOFXDate(value)
+		}
+	}
+	if line != nil {
+		// The file was cut short inside the last transaction.
+		parsed, err := line.parse()
+		if err != nil {
+			return nil, err
+		}
+		statement.Lines = append(statement.Lines, parsed)
+	}
+
+	if ledgerAmount != "" {
+		balance, err := parseOFXAmount(ledgerAmount)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ledger balance %q", ledgerAmount)
+		}
+		statement.ClosingBalance = &balance
+		if statement.EndDate.IsZero() {
+			statement.EndDate, _ = parseOFXDate(ledgerDate)
+		}
+	}
+	return statement, nil
+}
+
+// ofxLine collects the elements of one STMTTRN.
+type ofxLine struct {
+	posted, amount, fitID, checkNumber, refNumber, name, memo string
+}
+
+func (l *ofxLine) set(tag, value string) {
+	switch tag {
+	case "DTPOSTED":
+		l.posted = value
+	case "TRNAMT":
+		l.amount = value
+	case "FITID":
+		l.fitID = value
+	case "CHECKNUM":
+		l.checkNumber = value
+	case "REFNUM":
+		l.refNumber = value
+	case "NAME":
+		l.name = value
+	case "MEMO":
+		l.memo = value
+	}
+}
+
+func (l *ofxLine) parse() (Line, error) {
+	posted, err := parseOFXDate(l.posted)
+	if err != nil {
+		return Line{}, fmt.Errorf("transaction %s: invalid date %q", l.fitID, l.posted)
+	}
+	amount, err := parseOFXAmount(l.amount)
+	if err != nil {
+		return Line{}, fmt.Errorf("transaction %s: invalid amount %q", l.fitID, l.amount)
+	}
+
+	reference := l.checkNumber
+	if reference == "" {
+		reference = l.refNumber
+	}
+	if reference == "" {
+		reference = l.fitID
+	}
+	description := l.name
+	if l.memo != "" && l.memo != l.name {
+		description = strings.TrimSpace(description + " " + l.memo)
+	}
+	return Line{Date: posted, Amount: amount, Description: description, Reference: reference}, nil
+}
+
+// parseOFXDate reads the date part of an OFX datetime such as
+// "20250131120000.000[+7:WIB]".
+func parseOFXDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	parsed, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, err
+	}
+	return date(parsed), nil
+}
+
+// parseOFXAmount reads a signed OFX amount. Some banks write a decimal
+// comma.
+func parseOFXAmount(value string) (models.Money, error) {
+	return models.ParseMoney(strings.ReplaceAll(value, ",", "."))
+}
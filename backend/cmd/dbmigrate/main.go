@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gkjw-finance-backend/config"
+	"gkjw-finance-backend/migrations"
+	"log"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+func usage() {
+	fmt.Println("Usage: go run ./cmd/dbmigrate <command> [options]")
+	fmt.Println("Commands:")
+	fmt.Println("  up                 Apply all pending migrations")
+	fmt.Println("  down [-steps N]    Roll back the last N migrations (default 1)")
+	fmt.Println("  status             Show applied and pending migrations")
+	fmt.Println("Options:")
+	fmt.Println("  -lock-timeout dur  How long to wait for another migration to finish (default 1m)")
+}
+
+func main() {
+	// Load .env file
+	godotenv.Load()
+	godotenv.Load(".env.local")
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+	command := os.Args[1]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	steps := flags.Int("steps", 1, "Number of migrations to roll back")
+	lockTimeout := flags.Duration("lock-timeout", time.Minute, "How long to wait for the migration lock")
+	flags.Parse(os.Args[2:])
+
+	config.InitDB()
+
+	runner, err := migrations.NewRunner(config.DB, config.Driver)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	runner.LockTimeout = *lockTimeout
+
+	switch command {
+	case "up":
+		applied, err := runner.Up()
+		for _, m := range applied {
+			fmt.Printf("✓ Applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("Database is up to date")
+		}
+
+	case "down":
+		reverted, err := runner.Down(*steps)
+		for _, m := range reverted {
+			fmt.Printf("✓ Rolled back %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("Rollback failed: %v", err)
+		}
+		if len(reverted) == 0 {
+			fmt.Println("Nothing to roll back")
+		}
+
+	case "status":
+		statuses, err := runner.Status()
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		fmt.Printf("%-8s %-45s %-10s %s\n", "VERSION", "NAME", "STATE", "APPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "-"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d     %-45s %-10s %s\n", s.Version, s.Name, s.State, appliedAt)
+		}
+
+	default:
+		usage()
+		os.Exit(1)
+	}
+}
@@ -1,73 +1,118 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"gkjw-finance-backend/config"
+	"gkjw-finance-backend/handlers"
 	"gkjw-finance-backend/models"
-	"strings"
+	"gkjw-finance-backend/repository"
+	"log"
+	"os"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/joho/godotenv"
 )
 
+// fix_income runs the categorization rules over the transactions already
+// recorded. The income keywords it used to hard-code are the seeded
+// "Kata kunci pemasukan" rule; add or change rules through the API.
 func main() {
-	config.InitDB()
+	godotenv.Load()
 
-	// Get first admin user
-	var admin models.User
-	config.DB.Where("role = ?", "admin").First(&admin)
-	if admin.ID == uuid.Nil {
-		fmt.Println("No admin user found")
-		return
-	}
+	var start, end, ruleID, userEmail string
+	var apply bool
 
-	// Check current status
-	var incomeCount, expenseCount int64
-	config.DB.Model(&models.Transaction{}).Where("type = ?", "income").Count(&incomeCount)
-	config.DB.Model(&models.Transaction{}).Where("type = ?", "expense").Count(&expenseCount)
+	flag.StringVar(&start, "start", "", "Only transactions dated on or after this day (YYYY-MM-DD)")
+	flag.StringVar(&end, "end", "", "Only transactions dated on or before this day (YYYY-MM-DD)")
+	flag.StringVar(&ruleID, "rule", "", "Run this rule alone (default: every active rule)")
+	flag.StringVar(&userEmail, "user", "", "Email of the user recording the changes (default: the first admin)")
+	flag.BoolVar(&apply, "apply", false, "Make the changes instead of only listing them")
+	flag.Parse()
 
-	fmt.Printf("Current - Income: %d, Expense: %d\n\n", incomeCount, expenseCount)
+	var options handlers.RuleRunOptions
+	var err error
+	if start != "" {
+		if options.StartDate, err = time.Parse("2006-01-02", start); err != nil {
+			log.Fatalf("Invalid -start: %v", err)
+		}
+	}
+	if end != "" {
+		if options.EndDate, err = time.Parse("2006-01-02", end); err != nil {
+			log.Fatalf("Invalid -end: %v", err)
+		}
+	}
+	if ruleID != "" {
+		id, err := uuid.Parse(ruleID)
+		if err != nil {
+			log.Fatalf("Invalid -rule: %v", err)
+		}
+		options.RuleID = &id
+	}
 
-	// List some transactions to understand the data
-	var txs []models.Transaction
-	config.DB.Limit(30).Order("date DESC").Find(&txs)
+	config.InitDB()
+	repos := repository.NewGormRepositories(config.DB)
 
-	fmt.Println("=== Sample Transactions ===")
-	for i, tx := range txs {
-		fmt.Printf("%d. Type: %s | Amount: %.0f | Category: %s | Desc: %s\n", 
-			i+1, tx.Type, tx.Amount, tx.Category, tx.Description)
+	user, err := ruleUser(repos, userEmail)
+	if err != nil {
+		log.Fatalf("%v", err)
 	}
+	options.Actor = user.ID
+	options.Apply = apply
 
-	// Identify which ones should be income based on keywords
-	incomeKeywords := []string{
-		"sumbangan",
-		"donasi",
-		"setor",
-		"masuk",
-		"pemasukan",
-		"usaha",
-		"penjualan",
-		"pigora",
-		"sisa dana",
-		"transfer hadiah",
-		"pengembalian",
-		"reimburse",
+	result, err := handlers.RunCategorizationRules(nil, repos, options)
+	if err != nil {
+		log.Fatalf("Failed to run categorization rules: %v", err)
 	}
 
-	fmt.Println("\n=== Checking for Income Transactions ===")
-	var shouldBeIncome []models.Transaction
-	config.DB.Where("type = ?", "expense").Find(&shouldBeIncome)
+	fmt.Println("=== Categorization Rules ===")
+	skipped := 0
+	for _, item := range result.Items {
+		fmt.Printf("%s | %s | %s | rule %q:", item.Date.Format("2006-01-02"), item.EventName, item.Status, item.RuleName)
+		for _, change := range item.Changes {
+			fmt.Printf(" %s %s -> %s;", change.Field, change.Before, change.After)
+		}
+		fmt.Println()
+		if item.Skipped != "" {
+			fmt.Printf("    skipped: %s\n", item.Skipped)
+			skipped++
+		}
+	}
 
-	incomeCount = 0
-	for _, tx := range shouldBeIncome {
-		descLower := strings.ToLower(tx.Description)
-		for _, keyword := range incomeKeywords {
-			if strings.Contains(descLower, keyword) {
-				fmt.Printf("Found income: %s (%.0f) - %s\n", tx.Description, tx.Amount, descLower)
-				incomeCount++
-				break
-			}
+	fmt.Printf("\nMatched: %d\n", result.Matched)
+	fmt.Printf("Skipped: %d\n", skipped)
+	if apply {
+		fmt.Printf("Changed: %d\n", result.Changed)
+	} else {
+		fmt.Printf("Would change: %d\n", result.Changed)
+		if result.Changed > 0 {
+			fmt.Println("\nRun again with -apply to make the changes.")
 		}
 	}
+	if skipped > 0 {
+		os.Exit(1)
+	}
+}
 
-	fmt.Printf("\nFound %d transactions that should be income\n", incomeCount)
+// ruleUser returns the user with email, or the first admin.
+func ruleUser(repos *repository.Repositories, email string) (*models.User, error) {
+	if email != "" {
+		user, err := repos.Users.FindByEmail(email)
+		if err != nil {
+			return nil, fmt.Errorf("user %s not found", email)
+		}
+		return user, nil
+	}
+	users, err := repos.Users.List()
+	if err != nil {
+		return nil, err
+	}
+	// List returns the newest first.
+	for i := len(users) - 1; i >= 0; i-- {
+		if users[i].Role == "admin" {
+			return &users[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no admin user found; pass -user")
 }
@@ -1,14 +1,18 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"gkjw-finance-backend/config"
-	"gkjw-finance-backend/tools"
+	"gkjw-finance-backend/handlers"
+	"gkjw-finance-backend/importer"
+	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
 	"log"
 	"os"
+	"strings"
 
-	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 )
 
@@ -16,53 +20,125 @@ func main() {
 	// Load .env file
 	godotenv.Load()
 
-	var csvFile string
-	var importOnly bool
+	var file, format, mappingFile, userEmail, reportFile string
+	var dryRun, skipInvalid bool
 
-	flag.StringVar(&csvFile, "file", "", "Path to CSV file to import")
-	flag.BoolVar(&importOnly, "import-only", false, "Skip database initialization, only import")
+	flag.StringVar(&file, "file", "", "Path to CSV or Excel file to import")
+	flag.StringVar(&format, "format", "", "csv or xlsx (default: from the file extension)")
+	flag.StringVar(&mappingFile, "mapping", "", "Path to a JSON column mapping")
+	flag.StringVar(&userEmail, "user", "", "Email of the user recording the transactions (default: the first admin)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Check every row without importing")
+	flag.BoolVar(&skipInvalid, "skip-invalid", false, "Import the valid rows even when others have errors")
+	flag.StringVar(&reportFile, "report", "", "Where to write the error report (default: <file>.errors.csv)")
 	flag.Parse()
 
-	if csvFile == "" {
-		fmt.Println("Usage: go run cmd/migrate/main.go -file=<path-to-csv>")
+	if file == "" {
+		fmt.Println("Usage: go run ./cmd/migrate -file=<path-to-csv-or-xlsx> [options]")
 		fmt.Println("Options:")
-		fmt.Println("  -file string       Path to CSV file (required)")
-		fmt.Println("  -import-only       Skip database init, only import data")
+		fmt.Println("  -file string       Path to CSV or Excel file (required)")
+		fmt.Println("  -format string     csv or xlsx (default: from the file extension)")
+		fmt.Println("  -mapping string    Path to a JSON column mapping")
+		fmt.Println("  -user string       Email of the user recording the transactions (default: the first admin)")
+		fmt.Println("  -dry-run           Check every row without importing")
+		fmt.Println("  -skip-invalid      Import the valid rows even when others have errors")
+		fmt.Println("  -report string     Where to write the error report (default: <file>.errors.csv)")
 		os.Exit(1)
 	}
 
-	// Check if file exists
-	if _, err := os.Stat(csvFile); os.IsNotExist(err) {
-		log.Fatalf("CSV file not found: %s", csvFile)
+	data, err := os.ReadFile(file)
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", file, err)
 	}
-
-	// Initialize database
-	if !importOnly {
-		fmt.Println("Initializing database...")
-		config.InitDB()
-		fmt.Println("Database initialized successfully")
-	} else {
-		config.InitDB()
+	if format == "" {
+		format = importer.DetectFormat(file)
+	}
+	var mapping importer.Mapping
+	if mappingFile != "" {
+		raw, err := os.ReadFile(mappingFile)
+		if err != nil {
+			log.Fatalf("Failed to read mapping: %v", err)
+		}
+		if err := json.Unmarshal(raw, &mapping); err != nil {
+			log.Fatalf("Invalid mapping: %v", err)
+		}
+	}
+	table, err := importer.Read(format, data, mapping)
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", file, err)
 	}
 
-	// Get admin user (for created_by field)
-	// Using a fixed UUID for the system admin
-	adminUserID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	config.InitDB()
+	repos := repository.NewGormRepositories(config.DB)
 
-	// Run import
-	fmt.Printf("Starting import from: %s\n\n", csvFile)
+	user, err := importUser(repos, userEmail)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
 
-	successCount, errorCount, err := tools.ImportTransactions(csvFile, adminUserID)
+	if dryRun {
+		fmt.Printf("Checking %d rows from: %s\n\n", len(table.Rows), file)
+	} else {
+		fmt.Printf("Importing %d rows from: %s as %s\n\n", len(table.Rows), file, user.Email)
+	}
+	result, err := handlers.ImportTransactions(nil, repos, table, handlers.ImportOptions{
+		DryRun:      dryRun,
+		SkipInvalid: skipInvalid,
+		CreatedBy:   user.ID,
+	})
 	if err != nil {
 		log.Fatalf("Import failed: %v", err)
 	}
 
+	for _, rowErrors := range result.Errors {
+		fmt.Printf("Row %d: %s\n", rowErrors.Row, strings.Join(rowErrors.Errors, "; "))
+	}
+	if len(result.Errors) > 0 {
+		if reportFile == "" {
+			reportFile = file + ".errors.csv"
+		}
+		report, err := importer.ErrorReport(table)
+		if err == nil {
+			err = os.WriteFile(reportFile, report, 0644)
+		}
+		if err != nil {
+			log.Printf("Failed to write error report: %v", err)
+		} else {
+			fmt.Printf("\nError report written to %s\n", reportFile)
+		}
+	}
+
 	fmt.Printf("\n=== Import Complete ===\n")
-	fmt.Printf("Success: %d\n", successCount)
-	fmt.Printf("Errors:  %d\n", errorCount)
-	fmt.Printf("Total:   %d\n", successCount+errorCount)
+	fmt.Printf("Rows:     %d\n", result.Rows)
+	fmt.Printf("Valid:    %d\n", result.Valid)
+	fmt.Printf("Errors:   %d\n", len(result.Errors))
+	fmt.Printf("Imported: %d\n", result.Imported)
+	if result.Imported > 0 {
+		fmt.Println("\nThe imported transactions are pending review.")
+	}
 
-	if errorCount > 0 {
+	if len(result.Errors) > 0 {
 		os.Exit(1)
 	}
 }
+
+// importUser returns the user with email, or the first admin.
+func importUser(repos *repository.Repositories, email string) (*models.User, error) {
+	if email != "" {
+		user, err := repos.Users.FindByEmail(email)
+		if err != nil {
+			return nil, fmt.Errorf("user %s not found", email)
+		}
+		return user, nil
+	}
+	users, err := repos.Users.List()
+	if err != nil {
+		return nil, err
+	}
+	// List returns the newest first.
+	for i := len(users) - 1; i >= 0; i-- {
+		if users[i].Role == "admin" {
+			return &users[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no admin user found; pass -user")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"gkjw-finance-backend/config"
 	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
 	"log"
 
 	"github.com/joho/godotenv"
@@ -41,7 +42,7 @@ func main() {
 	
 	fmt.Println("\nFirst 5 Transactions:")
 	for _, t := range txns {
-		fmt.Printf("  %s | %s | %.2f | %s | %s\n", 
+		fmt.Printf("  %s | %s | %s | %s | %s\n", 
 			t.Date.Format("2006-01-02"), 
 			t.EventName, 
 			t.Amount, 
@@ -52,7 +53,7 @@ func main() {
 	config.DB.Order("date desc").Limit(5).Find(&txns)
 	fmt.Println("\nLast 5 Transactions:")
 	for _, t := range txns {
-		fmt.Printf("  %s | %s | %.2f | %s | %s\n", 
+		fmt.Printf("  %s | %s | %s | %s | %s\n", 
 			t.Date.Format("2006-01-02"), 
 			t.EventName, 
 			t.Amount, 
@@ -60,30 +61,36 @@ func main() {
 			t.PaymentMethod)
 	}
 
-	// Check for any suspicious amounts (very small or very large)
-	var suspicious []models.Transaction
-	config.DB.Where("amount < 100 OR amount > 100000000").Find(&suspicious)
-	
-	if len(suspicious) > 0 {
-		fmt.Printf("\n⚠️  Found %d transactions with unusual amounts:\n", len(suspicious))
-		for _, t := range suspicious {
-			fmt.Printf("  %s | %s | %.2f | %s\n", 
-				t.Date.Format("2006-01-02"), 
-				t.Description, 
-				t.Amount,
-				t.Type)
+	// Show the transactions flagged by the anomaly checks
+	flags, err := repository.NewGormRepositories(config.DB).TransactionFlags.List("open", "")
+	if err != nil {
+		log.Fatalf("Failed to load flags: %v", err)
+	}
+
+	if len(flags) > 0 {
+		fmt.Printf("\n⚠️  Found %d open flags to review:\n", len(flags))
+		for _, f := range flags {
+			if f.Transaction == nil {
+				continue
+			}
+			fmt.Printf("  %s | %s | %s | %s | %s\n",
+				f.Transaction.Date.Format("2006-01-02"),
+				f.Transaction.EventName,
+				f.Transaction.Amount,
+				f.Kind,
+				f.Message)
 		}
 	} else {
-		fmt.Println("\n✓ All amounts look reasonable!")
+		fmt.Println("\n✓ No open flags to review!")
 	}
 
 	// Calculate total balance
-	var totalIncome, totalExpense float64
+	var totalIncome, totalExpense models.Money
 	config.DB.Model(&models.Transaction{}).Where("type = ?", "income").Select("COALESCE(SUM(amount), 0)").Scan(&totalIncome)
 	config.DB.Model(&models.Transaction{}).Where("type = ?", "expense").Select("COALESCE(SUM(amount), 0)").Scan(&totalExpense)
 	
 	fmt.Printf("\n=== Financial Summary ===\n")
-	fmt.Printf("Total Income:  Rp %.2f\n", totalIncome)
-	fmt.Printf("Total Expense: Rp %.2f\n", totalExpense)
-	fmt.Printf("Balance:       Rp %.2f\n", totalIncome - totalExpense)
+	fmt.Printf("Total Income:  Rp %s\n", totalIncome)
+	fmt.Printf("Total Expense: Rp %s\n", totalExpense)
+	fmt.Printf("Balance:       Rp %s\n", totalIncome-totalExpense)
 }
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -12,24 +14,29 @@ import (
 
 var DB *gorm.DB
 
-func InitDB() {
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
+// Driver is the database driver selected by DB_DRIVER: "postgres" (default)
+// or "sqlite".
+var Driver string
 
-	// Validate required environment variables
-	if host == "" || port == "" || user == "" || password == "" || dbname == "" {
-		log.Fatal("Missing required database environment variables")
+func InitDB() {
+	Driver = os.Getenv("DB_DRIVER")
+	if Driver == "" {
+		Driver = "postgres"
 	}
 
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=require",
-		host, port, user, password, dbname)
+	var dialector gorm.Dialector
+	switch Driver {
+	case "postgres":
+		dialector = postgres.Open(postgresDSN())
+	case "sqlite":
+		dialector = sqlite.Open(sqliteDSN())
+	default:
+		log.Fatalf("Unsupported DB_DRIVER %q (use postgres or sqlite)", Driver)
+	}
 
 	var err error
 	// Use Silent logger to suppress slow query warnings
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
+	DB, err = gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
 	})
 	if err != nil {
@@ -39,7 +46,44 @@ func InitDB() {
 	// Database is already initialized in production.
 	// Do NOT run AutoMigrate - it causes slow queries and timeouts.
 	// Tables are pre-created and managed separately.
-	// Only run AutoMigrate in development via separate cmd tool.
+	// Schema changes are applied with the versioned runner in cmd/dbmigrate.
+
+	log.Printf("Database connected successfully (%s)", Driver)
+}
+
+func postgresDSN() string {
+	host := os.Getenv("DB_HOST")
+	port := os.Getenv("DB_PORT")
+	user := os.Getenv("DB_USER")
+	password := os.Getenv("DB_PASSWORD")
+	dbname := os.Getenv("DB_NAME")
+
+	// Validate required environment variables
+	if host == "" || port == "" || user == "" || password == "" || dbname == "" {
+		log.Fatal("Missing required database environment variables")
+	}
+
+	sslmode := os.Getenv("DB_SSLMODE")
+	if sslmode == "" {
+		sslmode = "require"
+	}
+
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		host, port, user, password, dbname, sslmode)
+}
+
+// sqliteDSN returns the SQLite file DSN from DB_PATH, creating its
+// directory if needed. Foreign keys are enforced and writers wait for
+// locks instead of failing immediately.
+func sqliteDSN() string {
+	path := os.Getenv("DB_PATH")
+	if path == "" {
+		path = "./data/gkjw_finance.db"
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Fatal("Failed to create SQLite directory:", err)
+	}
 
-	log.Println("Database connected successfully")
+	return path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
 }
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"fmt"
+	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Kinds of models.TransactionFlag.
+const (
+	flagDuplicate    = "duplicate"
+	flagOutlier      = "outlier"
+	flagTypeMismatch = "type_mismatch"
+	flagSuggestion   = "suggestion"
+)
+
+// A transaction is a probable duplicate of another with the same type,
+// fund, date and amount when the words of their descriptions overlap at
+// least this much.
+const duplicateSimilarity = 0.5
+
+// An amount is an outlier for its category when its modified z-score
+// against the approved amounts of the category is above outlierScore and
+// it is at least outlierRatio times their median, or at most the median
+// divided by it. Categories with fewer than outlierMinSamples approved
+// amounts fall back to the fixed range below.
+const (
+	outlierMinSamples = 5
+	outlierScore      = 3.5
+	outlierRatio      = 2
+)
+
+// minUsualAmount and maxUsualAmount bound the amounts that look plausible
+// for a category without enough history to compare with.
+var (
+	minUsualAmount = models.Rupiah(100)
+	maxUsualAmount = models.Rupiah(100000000)
+)
+
+// incomeKeywords and expenseKeywords are words that give the type of a
+// transaction away. Each matches the start of a word, so "masuk" does not
+// match "termasuk".
+var (
+	incomeKeywords = []string{
+		"sumbangan", "donasi", "setor", "masuk", "pemasukan", "usaha",
+		"penjualan", "pigora", "sisa dana", "transfer hadiah",
+		"pengembalian", "reimburse", "persembahan", "kolekte",
+	}
+	expenseKeywords = []string{
+		"beli", "pembelian", "bayar", "pembayaran", "biaya", "gaji", "honor",
+		"konsumsi", "sewa", "listrik", "ongkos", "transport", "cetak",
+		"fotokopi", "servis", "perbaikan",
+	}
+)
+
+// detectAnomalies runs the checks of models.TransactionFlag on
+// transaction and returns a flag for each one it fails. The flags are not
+// saved.
+func detectAnomalies(repos *repository.Repositories, transaction *models.Transaction) ([]models.TransactionFlag, error) {
+	var flags []models.TransactionFlag
+	duplicates, err := findDuplicates(repos, transaction)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range duplicates {
+		id := other.ID
+		flags = append(flags, models.TransactionFlag{
+			Kind:                 flagDuplicate,
+			Message:              fmt.Sprintf("Possible duplicate of %q recorded for the same fund, date and amount", other.EventName),
+			RelatedTransactionID: &id,
+		})
+	}
+
+	outliers, err := findOutliers(repos, transaction)
+	if err != nil {
+		return nil, err
+	}
+	for _, message := range outliers {
+		flags = append(flags, models.TransactionFlag{Kind: flagOutlier, Message: message})
+	}
+
+	suggestion, changes, err := suggestCategorization(repos, transaction)
+	if err != nil {
+		return nil, err
+	}
+	suggestsType := false
+	if suggestion != nil {
+		flags = append(flags, *suggestion)
+		for _, change := range changes {
+			suggestsType = suggestsType || change.Field == "type"
+		}
+	}
+	// A rule that suggests another type already says what the keyword
+	// check would.
+	if message := typeMismatch(transaction); message != "" && !suggestsType {
+		flags = append(flags, models.TransactionFlag{Kind: flagTypeMismatch, Message: message})
+	}
+
+	for i := range flags {
+		flags[i].TransactionID = transaction.ID
+		flags[i].Status = "open"
+	}
+	return flags, nil
+}
+
+// findDuplicates returns the other live transactions of the same type,
+// fund, date and amount as transaction whose descriptions are similar.
+func findDuplicates(repos *repository.Repositories, transaction *models.Transaction) ([]models.Transaction, error) {
+	candidates, err := repos.Transactions.FindAll(repository.TransactionFilter{
+		Type:      transaction.Type,
+		FundID:    transaction.FundID,
+		StartDate: transaction.Date,
+		EndDate:   transaction.Date,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var duplicates []models.Transaction
+	for _, other := range candidates {
+		if other.ID == transaction.ID || other.ReversalOfID != nil ||
+			other.Status == "rejected" || other.Status == "voided" ||
+			other.FundID != transaction.FundID || other.Amount != transaction.Amount ||
+			!sameDestination(other, *transaction) {
+			continue
+		}
+		if descriptionSimilarity(describe(other), describe(*transaction)) >= duplicateSimilarity {
+			duplicates = append(duplicates, other)
+		}
+	}
+	return duplicates, nil
+}
+
+// sameDestination reports whether a and b move money to the same place;
+// it is always true for income and expense.
+func sameDestination(a, b models.Transaction) bool {
+	if a.ToFundID == nil || b.ToFundID == nil {
+		return a.ToFundID == nil && b.ToFundID == nil
+	}
+	return *a.ToFundID == *b.ToFundID && a.ToPaymentMethod == b.ToPaymentMethod
+}
+
+// describe returns the event name and description of tx, the text the
+// description checks look at.
+func describe(tx models.Transaction) string {
+	return tx.EventName + " " + tx.Description
+}
+
+// words returns the lower-case words of s.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// descriptionSimilarity returns how much the words of a and b overlap,
+// from 0 to 1: 1 when one contains all the words of the other, and the
+// Jaccard index of the two word sets otherwise.
+func descriptionSimilarity(a, b string) float64 {
+	setA, setB := map[string]bool{}, map[string]bool{}
+	for _, word := range words(a) {
+		setA[word] = true
+	}
+	for _, word := range words(b) {
+		setB[word] = true
+	}
+	if len(setA) == 0 || len(setB) == 0 {
+		if len(setA) == len(setB) {
+			return 1
+		}
+		return 0
+	}
+	shared := 0
+	for word := range setA {
+		if setB[word] {
+			shared++
+		}
+	}
+	if shared == len(setA) || shared == len(setB) {
+		return 1
+	}
+	return float64(shared) / float64(len(setA)+len(setB)-shared)
+}
+
+// findOutliers compares each line of an income or expense with the
+// approved amounts of its category and describes the lines that stand
+// out.
+func findOutliers(repos *repository.Repositories, transaction *models.Transaction) ([]string, error) {
+	if transaction.Type != "income" && transaction.Type != "expense" {
+		return nil, nil
+	}
+	var messages []string
+	for _, line := range transaction.Splits() {
+		filter := repository.TransactionFilter{Status: "approved", Type: transaction.Type, Category: line.Category}
+		history, err := repos.Transactions.FindAll(filter)
+		if err != nil {
+			return nil, err
+		}
+		var amounts []float64
+		for _, tx := range history {
+			if tx.ID == transaction.ID || tx.ReversalOfID != nil {
+				continue
+			}
+			for _, past := range filter.Lines(tx) {
+				amounts = append(amounts, past.Amount.Float64())
+			}
+		}
+		if message := outlierMessage(line, amounts); message != "" {
+			messages = append(messages, message)
+		}
+	}
+	return messages, nil
+}
+
+// outlierMessage describes why the amount of line stands out from
+// amounts, the approved amounts of its category, or returns "".
+func outlierMessage(line models.TransactionLine, amounts []float64) string {
+	if len(amounts) < outlierMinSamples {
+		if line.Amount < minUsualAmount || line.Amount > maxUsualAmount {
+			return fmt.Sprintf("%s for %s is outside the usual range of %s to %s",
+				line.Amount.Format(), line.Category, minUsualAmount.Format(), maxUsualAmount.Format())
+		}
+		return ""
+	}
+
+	median := medianOf(amounts)
+	deviations := make([]float64, len(amounts))
+	for i, amount := range amounts {
+		deviations[i] = math.Abs(amount - median)
+	}
+	mad := medianOf(deviations)
+
+	x := line.Amount.Float64()
+	if x < median*outlierRatio && x > median/outlierRatio {
+		return ""
+	}
+	// With no spread at all every differing amount is unusual, and the
+	// ratio alone decides.
+	if mad > 0 && 0.6745*math.Abs(x-median)/mad <= outlierScore {
+		return ""
+	}
+	usual, _ := models.ParseMoney(fmt.Sprintf("%.2f", median))
+	return fmt.Sprintf("%s for %s is far from the usual %s (median of %d approved amounts)",
+		line.Amount.Format(), line.Category, usual.Format(), len(amounts))
+}
+
+// medianOf returns the median of values, which it sorts.
+func medianOf(values []float64) float64 {
+	sort.Float64s(values)
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
+
+// typeMismatch describes why the description of an income or expense
+// reads like the other type, or returns "". Descriptions with keywords of
+// both types are left alone.
+func typeMismatch(transaction *models.Transaction) string {
+	text := " " + strings.Join(words(describe(*transaction)), " ")
+	income := matchKeyword(text, incomeKeywords)
+	expense := matchKeyword(text, expenseKeywords)
+	switch {
+	case transaction.Type == "expense" && income != "" && expense == "":
+		return fmt.Sprintf("Recorded as expense but the description mentions %q, which suggests income", income)
+	case transaction.Type == "income" && expense != "" && income == "":
+		return fmt.Sprintf("Recorded as income but the description mentions %q, which suggests an expense", expense)
+	}
+	return ""
+}
+
+// matchKeyword returns the first of keywords that starts a word of text,
+// which is a space followed by space-separated lower-case words.
+func matchKeyword(text string, keywords []string) string {
+	for _, keyword := range keywords {
+		if strings.Contains(text, " "+keyword) {
+			return keyword
+		}
+	}
+	return ""
+}
+
+// flagTransaction detects the anomalies of a newly recorded transaction
+// and saves them as its open flags.
+func flagTransaction(repos *repository.Repositories, transaction *models.Transaction) error {
+	flags, err := detectAnomalies(repos, transaction)
+	if err != nil {
+		return err
+	}
+	for i := range flags {
+		if err := repos.TransactionFlags.Create(&flags[i]); err != nil {
+			return err
+		}
+	}
+	transaction.Flags = flags
+	return nil
+}
+
+// reflagTransaction runs the checks again after transaction changed. Its
+// open flags are replaced; a flag an approver dismissed is not raised
+// again for the same kind and related transaction.
+func reflagTransaction(repos *repository.Repositories, transaction *models.Transaction) error {
+	existing, err := repos.TransactionFlags.ListByTransaction(transaction.ID)
+	if err != nil {
+		return err
+	}
+	detected, err := detectAnomalies(repos, transaction)
+	if err != nil {
+		return err
+	}
+	if err := repos.TransactionFlags.DeleteOpen(transaction.ID); err != nil {
+		return err
+	}
+
+	dismissed := map[string]bool{}
+	flags := []models.TransactionFlag{}
+	for _, flag := range existing {
+		if flag.Status == "dismissed" {
+			dismissed[flagKey(flag)] = true
+			flags = append(flags, flag)
+		}
+	}
+	for i := range detected {
+		if dismissed[flagKey(detected[i])] {
+			continue
+		}
+		if err := repos.TransactionFlags.Create(&detected[i]); err != nil {
+			return err
+		}
+		flags = append(flags, detected[i])
+	}
+	transaction.Flags = flags
+	return nil
+}
+
+// flagKey identifies what a flag is about, to recognise it when the
+// checks run again.
+func flagKey(flag models.TransactionFlag) string {
+	key := flag.Kind
+	if flag.RelatedTransactionID != nil {
+		key += ":" + flag.RelatedTransactionID.String()
+	}
+	return key
+}
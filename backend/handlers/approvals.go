@@ -0,0 +1,394 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ApprovalPolicyHandler manages the approval policies.
+type ApprovalPolicyHandler struct {
+	repos *repository.Repositories
+}
+
+func NewApprovalPolicyHandler(repos *repository.Repositories) *ApprovalPolicyHandler {
+	return &ApprovalPolicyHandler{repos: repos}
+}
+
+// ApprovalStepRequest names either a user role or a user. Steps are signed
+// in the order they are listed.
+type ApprovalStepRequest struct {
+	Role   string `json:"role" binding:"omitempty,oneof=admin member viewer"`
+	UserID string `json:"userId"`
+}
+
+type ApprovalPolicyRequest struct {
+	Name      string                `json:"name" binding:"required"`
+	Priority  int                   `json:"priority"`
+	Type      string                `json:"type" binding:"omitempty,oneof=income expense transfer"`
+	FundID    string                `json:"fundId"`
+	Category  string                `json:"category"`
+	MinAmount models.Money          `json:"minAmount"`
+	MaxAmount *models.Money         `json:"maxAmount"`
+	Status    string                `json:"status" binding:"omitempty,oneof=active archived"`
+	Steps     []ApprovalStepRequest `json:"steps" binding:"required,min=1,dive"`
+}
+
+// defaultApprovalPolicy applies when no active policy matches: one
+// signature from an admin.
+var defaultApprovalPolicy = models.ApprovalPolicy{
+	Name:  "Default",
+	Steps: []models.ApprovalStep{{StepOrder: 1, Role: "admin"}},
+}
+
+// approvalState is where a pending transaction stands in its policy.
+type approvalState struct {
+	Policy *models.ApprovalPolicy `json:"policy"`
+	// Signed are the current approvals, one per step signed so far.
+	Signed   []models.Approval    `json:"signed"`
+	NextStep *models.ApprovalStep `json:"nextStep,omitempty"`
+}
+
+// loadApprovalState finds the policy of transaction and its current
+// signatures. Once a step is signed, the policy it was signed under stays in
+// force for the transaction even if other policies change.
+func loadApprovalState(repos *repository.Repositories, transaction *models.Transaction) (*approvalState, []models.Approval, error) {
+	all, err := repos.Approvals.ListByTransaction(transaction.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	state := &approvalState{Signed: []models.Approval{}}
+	for _, approval := range all {
+		if !approval.Superseded && approval.Decision == "approved" {
+			state.Signed = append(state.Signed, approval)
+		}
+	}
+
+	if len(state.Signed) > 0 && state.Signed[0].PolicyID != nil {
+		policy, err := repos.ApprovalPolicies.FindByID(*state.Signed[0].PolicyID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, err
+		}
+		state.Policy = policy
+	}
+	if state.Policy == nil && (len(state.Signed) == 0 || state.Signed[0].PolicyID != nil) {
+		policies, err := repos.ApprovalPolicies.List()
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range policies {
+			if policies[i].Status == "active" && policies[i].Matches(transaction) {
+				state.Policy = &policies[i]
+				break
+			}
+		}
+	}
+	if state.Policy == nil {
+		policy := defaultApprovalPolicy
+		state.Policy = &policy
+	}
+
+	if len(state.Signed) < len(state.Policy.Steps) {
+		state.NextStep = &state.Policy.Steps[len(state.Signed)]
+	}
+	return state, all, nil
+}
+
+func describeStep(step *models.ApprovalStep) string {
+	if step.User != nil {
+		return step.User.Name
+	}
+	if step.UserID != nil {
+		return "user " + step.UserID.String()
+	}
+	return "role " + step.Role
+}
+
+// reviewTransaction records actor's decision on a pending transaction under
+// its approval policy. An approval signs the next step and approves the
+// transaction once every step is signed; a rejection, by an admin or the
+// next step's approver, rejects it at once. It returns a *transitionError
+// when actor may not sign.
+func reviewTransaction(repos *repository.Repositories, transaction *models.Transaction, decision string, actor *models.User, note string) error {
+	state, _, err := loadApprovalState(repos, transaction)
+	if err != nil {
+		return err
+	}
+	step := state.NextStep
+	if step == nil {
+		// The policy lost steps after they were signed; an admin finishes
+		// the review.
+		step = &models.ApprovalStep{StepOrder: len(state.Signed) + 1, Role: "admin"}
+	}
+
+	for _, signed := range state.Signed {
+		if signed.ApproverID == actor.ID {
+			return &transitionError{status: http.StatusForbidden, message: "You have already signed this transaction"}
+		}
+	}
+	if !step.Allows(actor) && !(decision == "rejected" && actor.Role == "admin") {
+		return &transitionError{
+			status: http.StatusForbidden,
+			message: fmt.Sprintf("Step %d of approval policy %q must be signed by %s",
+				step.StepOrder, state.Policy.Name, describeStep(step)),
+		}
+	}
+
+	approval := models.Approval{
+		TransactionID: transaction.ID,
+		StepOrder:     step.StepOrder,
+		ApproverID:    actor.ID,
+		Decision:      decision,
+		Note:          note,
+	}
+	if state.Policy.ID != uuid.Nil {
+		approval.PolicyID = &state.Policy.ID
+	}
+	if err := repos.Approvals.Create(&approval); err != nil {
+		return err
+	}
+
+	if decision == "approved" && step.StepOrder < len(state.Policy.Steps) {
+		return nil
+	}
+	now := time.Now()
+	transaction.Status = decision
+	transaction.ReviewedBy = &actor.ID
+	transaction.ReviewedAt = &now
+	if decision == "rejected" {
+		transaction.RejectionReason = note
+	}
+	return nil
+}
+
+// GetTransactionApprovals returns the approval policy of a transaction,
+// every signature given on it (including superseded ones) and the next
+// step to be signed while it is pending.
+func (h *TransactionHandler) GetTransactionApprovals(c *gin.Context) {
+	transaction, ok := h.findTransaction(c)
+	if !ok {
+		return
+	}
+
+	state, all, err := loadApprovalState(h.repos, transaction)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch approvals"})
+		return
+	}
+	if all == nil {
+		all = []models.Approval{}
+	}
+	if transaction.Status != "pending" {
+		state.NextStep = nil
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     all,
+		"policy":   state.Policy,
+		"signed":   len(state.Signed),
+		"required": len(state.Policy.Steps),
+		"nextStep": state.NextStep,
+	})
+}
+
+func (h *ApprovalPolicyHandler) GetPolicies(c *gin.Context) {
+	policies, err := h.repos.ApprovalPolicies.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch approval policies"})
+		return
+	}
+	if policies == nil {
+		policies = []models.ApprovalPolicy{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": policies})
+}
+
+// applyPolicyRequest validates req and copies it onto policy.
+func (h *ApprovalPolicyHandler) applyPolicyRequest(req ApprovalPolicyRequest, policy *models.ApprovalPolicy) error {
+	policy.Name = req.Name
+	policy.Priority = req.Priority
+	policy.Type = req.Type
+	policy.Category = strings.TrimSpace(req.Category)
+	policy.MinAmount = req.MinAmount
+	policy.MaxAmount = req.MaxAmount
+	policy.Status = req.Status
+	if policy.Status == "" {
+		policy.Status = "active"
+	}
+	if req.MaxAmount != nil && *req.MaxAmount < req.MinAmount {
+		return errors.New("maxAmount must not be less than minAmount")
+	}
+
+	// Lines carry the canonical category name, which Matches compares
+	// with; a name that matches no category would never apply.
+	if policy.Category != "" {
+		categories, err := h.repos.Categories.List()
+		if err != nil {
+			return err
+		}
+		category := findCategory(categories, policy.Category, policy.Type)
+		if category == nil || policy.Type != "" && !category.Allows(policy.Type) {
+			return errors.New("Category not found")
+		}
+		policy.Category = category.Name
+	}
+
+	policy.FundID = nil
+	policy.Fund = nil
+	if req.FundID != "" {
+		fundID, err := uuid.Parse(req.FundID)
+		if err != nil {
+			return errors.New("Invalid fundId")
+		}
+		if _, err := h.repos.Funds.FindByID(fundID); err != nil {
+			return errors.New("Fund not found")
+		}
+		policy.FundID = &fundID
+	}
+
+	policy.Steps = make([]models.ApprovalStep, 0, len(req.Steps))
+	for i, reqStep := range req.Steps {
+		step := models.ApprovalStep{StepOrder: i + 1}
+		switch {
+		case reqStep.UserID != "" && reqStep.Role == "":
+			userID, err := uuid.Parse(reqStep.UserID)
+			if err != nil {
+				return errors.New("Invalid userId in step " + fmt.Sprint(i+1))
+			}
+			if _, err := h.repos.Users.FindByID(userID); err != nil {
+				return errors.New("User not found in step " + fmt.Sprint(i+1))
+			}
+			step.UserID = &userID
+		case reqStep.Role != "" && reqStep.UserID == "":
+			step.Role = reqStep.Role
+		default:
+			return errors.New("Step " + fmt.Sprint(i+1) + " needs either a role or a userId")
+		}
+		policy.Steps = append(policy.Steps, step)
+	}
+	return nil
+}
+
+func (h *ApprovalPolicyHandler) CreatePolicy(c *gin.Context) {
+	var req ApprovalPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var policy models.ApprovalPolicy
+	if err := h.applyPolicyRequest(req, &policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.ApprovalPolicies.Create(&policy); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "approval_policy",
+			EntityID:   policy.ID,
+			Action:     "create",
+			Summary:    "Created approval policy: " + policy.Name,
+			After:      policy,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create approval policy"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Approval policy created successfully",
+		"data":    policy,
+	})
+}
+
+// UpdatePolicy replaces a policy and its steps. Transactions already
+// signed under it keep counting their signatures against the new steps.
+func (h *ApprovalPolicyHandler) UpdatePolicy(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Approval policy not found"})
+		return
+	}
+	policy, err := h.repos.ApprovalPolicies.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Approval policy not found"})
+		return
+	}
+
+	var req ApprovalPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	before := *policy
+	if err := h.applyPolicyRequest(req, policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.ApprovalPolicies.Update(policy); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "approval_policy",
+			EntityID:   policy.ID,
+			Action:     "update",
+			Summary:    "Updated approval policy: " + policy.Name,
+			Before:     before,
+			After:      policy,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update approval policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Approval policy updated successfully",
+		"data":    policy,
+	})
+}
+
+func (h *ApprovalPolicyHandler) DeletePolicy(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Approval policy not found"})
+		return
+	}
+	policy, err := h.repos.ApprovalPolicies.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Approval policy not found"})
+		return
+	}
+
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.ApprovalPolicies.Delete(id); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "approval_policy",
+			EntityID:   policy.ID,
+			Action:     "delete",
+			Summary:    "Deleted approval policy: " + policy.Name,
+			Before:     policy,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete approval policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Approval policy deleted successfully"})
+}
@@ -0,0 +1,71 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestApprovalPolicySteps(t *testing.T) {
+	s := newTestServer(t)
+	s.do(s.admin, http.MethodPost, "/api/approval-policies", map[string]interface{}{
+		"name":      "Pengeluaran besar",
+		"type":      "expense",
+		"minAmount": "1000000",
+		"steps": []interface{}{
+			map[string]interface{}{"role": "admin"},
+			map[string]interface{}{"userId": s.member.ID},
+		},
+	}, http.StatusCreated)
+
+	small := s.createTransaction(s.admin, map[string]interface{}{"type": "expense", "category": s.expense.Name, "amount": "999999"})
+	out := s.do(s.reviewer, http.MethodPut, "/api/transactions/"+small+"/status", map[string]interface{}{"status": "approved"}, http.StatusOK)
+	if got := data(out)["status"]; got != "approved" {
+		t.Errorf("small expense: status %v after one signature, want approved under the default policy", got)
+	}
+
+	large := s.createTransaction(s.admin, map[string]interface{}{"type": "expense", "category": s.expense.Name, "amount": "1000000"})
+	path := "/api/transactions/" + large + "/status"
+	approve := map[string]interface{}{"status": "approved"}
+
+	s.do(s.member, http.MethodPut, path, approve, http.StatusForbidden)
+	out = s.do(s.reviewer, http.MethodPut, path, approve, http.StatusOK)
+	if got := data(out)["status"]; got != "pending" {
+		t.Fatalf("large expense: status %v after the first step, want pending", got)
+	}
+	s.do(s.reviewer, http.MethodPut, path, approve, http.StatusForbidden)
+
+	approvals := s.do(s.admin, http.MethodGet, "/api/transactions/"+large+"/approvals", nil, http.StatusOK)
+	if next, _ := approvals["nextStep"].(map[string]interface{}); approvals["signed"] != 1.0 || next["userId"] != s.member.ID.String() {
+		t.Errorf("signed %v, next step %v; want 1 signed and the member next", approvals["signed"], approvals["nextStep"])
+	}
+
+	out = s.do(s.member, http.MethodPut, path, approve, http.StatusOK)
+	if got := data(out)["status"]; got != "approved" {
+		t.Errorf("large expense: status %v after both steps, want approved", got)
+	}
+}
+
+func TestApprovalPolicyCategory(t *testing.T) {
+	s := newTestServer(t)
+	policy := func(category string) map[string]interface{} {
+		return map[string]interface{}{
+			"name":     "Konsumsi",
+			"type":     "expense",
+			"category": category,
+			"steps":    []interface{}{map[string]interface{}{"role": "admin"}, map[string]interface{}{"userId": s.member.ID}},
+		}
+	}
+
+	s.do(s.admin, http.MethodPost, "/api/approval-policies", policy("Konsumsi Rapat"), http.StatusBadRequest)
+	s.do(s.admin, http.MethodPost, "/api/approval-policies", policy(s.income.Name), http.StatusBadRequest)
+	out := s.do(s.admin, http.MethodPost, "/api/approval-policies", policy(" konsumsi "), http.StatusCreated)
+	if got := data(out)["category"]; got != s.expense.Name {
+		t.Errorf("category = %q, want the category's own name %q", got, s.expense.Name)
+	}
+
+	id := s.createTransaction(s.admin, map[string]interface{}{"type": "expense", "category": "KONSUMSI"})
+	out = s.do(s.reviewer, http.MethodPut, "/api/transactions/"+id+"/status", map[string]interface{}{"status": "approved"}, http.StatusOK)
+	if got := data(out)["status"]; got != "pending" {
+		t.Errorf("status %v after one signature, want pending under the category policy", got)
+	}
+}
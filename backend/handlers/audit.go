@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// auditEntry describes one change for the audit trail. Before and After
+// are the entity as it was and as it is now; either is nil when the entity
+// was created or deleted. Actor defaults to the logged-in user.
+type auditEntry struct {
+	EntityType string
+	EntityID   uuid.UUID
+	Action     string
+	Summary    string
+	Before     interface{}
+	After      interface{}
+	Actor      uuid.UUID
+}
+
+// auditIgnoredFields are not worth recording in a diff. Transaction flags
+// are raised by the checks, not edited, and have entries of their own when
+// dismissed.
+var auditIgnoredFields = map[string]bool{
+	"createdAt": true,
+	"updatedAt": true,
+	"flags":     true,
+}
+
+// recordAudit writes entry to the audit trail together with the client IP,
+// user agent and request id of c. Call it with the Repositories passed to
+// WithTx so that the entry is written, or rolled back, with the change.
+// Background jobs pass a nil c and name the actor in entry.
+func recordAudit(c *gin.Context, repos *repository.Repositories, entry auditEntry) error {
+	actor := entry.Actor
+	if actor == uuid.Nil && c != nil {
+		userID, _ := c.Get("userId")
+		actor, _ = userID.(uuid.UUID)
+	}
+
+	changes, err := diffFields(entry.Before, entry.After)
+	if err != nil {
+		return err
+	}
+
+	log := models.ActivityLog{
+		UserID:     actor,
+		EntityType: entry.EntityType,
+		Action:     entry.Action,
+		Summary:    entry.Summary,
+		Changes:    changes,
+	}
+	if c != nil {
+		log.IPAddress = c.ClientIP()
+		log.UserAgent = c.Request.UserAgent()
+		log.RequestID = c.GetString("requestId")
+	}
+	if entry.EntityID != uuid.Nil {
+		log.EntityID = &entry.EntityID
+	}
+	return repos.ActivityLogs.Create(&log)
+}
+
+// diffFields compares the JSON forms of before and after and returns the
+// top-level fields that differ. Nested objects, which are preloaded
+// associations, and timestamps are skipped.
+func diffFields(before, after interface{}) (models.FieldChanges, error) {
+	beforeFields, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := models.FieldChanges{}
+	for _, fields := range []map[string]interface{}{beforeFields, afterFields} {
+		for name := range fields {
+			if _, done := changes[name]; done || auditIgnoredFields[name] {
+				continue
+			}
+			old, now := beforeFields[name], afterFields[name]
+			if _, nested := old.(map[string]interface{}); nested {
+				continue
+			}
+			if _, nested := now.(map[string]interface{}); nested {
+				continue
+			}
+			if !reflect.DeepEqual(old, now) {
+				changes[name] = models.FieldChange{Before: old, After: now}
+			}
+		}
+	}
+	return changes, nil
+}
+
+// jsonFields returns the fields of v as it is serialised in API responses,
+// or nil for a nil v.
+func jsonFields(v interface{}) (map[string]interface{}, error) {
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil() {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
@@ -1,8 +1,9 @@
 package handlers
 
 import (
-	"gkjw-finance-backend/config"
+	"errors"
 	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
 	"net/http"
 	"os"
 	"time"
@@ -26,8 +27,17 @@ type RegisterRequest struct {
 }
 
 type LoginResponse struct {
-	Token string       `json:"token"`
-	User  models.User  `json:"user"`
+	Token string      `json:"token"`
+	User  models.User `json:"user"`
+}
+
+// AuthHandler serves login and registration.
+type AuthHandler struct {
+	repos *repository.Repositories
+}
+
+func NewAuthHandler(repos *repository.Repositories) *AuthHandler {
+	return &AuthHandler{repos: repos}
 }
 
 type Claims struct {
@@ -37,15 +47,15 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
-func Login(c *gin.Context) {
+func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	var user models.User
-	if err := config.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
+	user, err := h.repos.Users.FindByEmail(req.Email)
+	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
@@ -74,18 +84,33 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	// Log activity
-	logActivity(user.ID, "User logged in")
+	err = recordAudit(c, h.repos, auditEntry{
+		EntityType: "user",
+		EntityID:   user.ID,
+		Action:     "login",
+		Summary:    "User logged in",
+		Actor:      user.ID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record login"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"data": LoginResponse{
 			Token: tokenString,
-			User:  user,
+			User:  *user,
 		},
 	})
 }
 
-func Register(c *gin.Context) {
+func (h *AuthHandler) Register(c *gin.Context) {
+	registerUser(c, h.repos)
+}
+
+// registerUser creates a user from a RegisterRequest. It backs both the
+// public register endpoint and the admin create-user endpoint.
+func registerUser(c *gin.Context, repos *repository.Repositories) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -93,10 +118,12 @@ func Register(c *gin.Context) {
 	}
 
 	// Check if email already exists
-	var existingUser models.User
-	if err := config.DB.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
+	if _, err := repos.Users.FindByEmail(req.Email); err == nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
 		return
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check email"})
+		return
 	}
 
 	// Hash password
@@ -114,7 +141,24 @@ func Register(c *gin.Context) {
 		Role:         req.Role,
 	}
 
-	if err := config.DB.Create(&user).Error; err != nil {
+	err = repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.Users.Create(&user); err != nil {
+			return err
+		}
+		// Someone registering themselves is their own actor.
+		entry := auditEntry{
+			EntityType: "user",
+			EntityID:   user.ID,
+			Action:     "create",
+			Summary:    "Registered user: " + user.Email,
+			After:      user,
+		}
+		if _, ok := c.Get("userId"); !ok {
+			entry.Actor = user.ID
+		}
+		return recordAudit(c, repos, entry)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
@@ -124,11 +168,3 @@ func Register(c *gin.Context) {
 		"data":    user,
 	})
 }
-
-func logActivity(userID uuid.UUID, action string) {
-	log := models.ActivityLog{
-		UserID: userID,
-		Action: action,
-	}
-	config.DB.Create(&log)
-}
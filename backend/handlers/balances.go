@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// balanceKey identifies where money is held: a fund and a payment method.
+type balanceKey struct {
+	fundID        uuid.UUID
+	paymentMethod string
+}
+
+// balancesBefore returns the balance of every fund and payment method at
+// the start of date (fundID only, unless nil). Each balance is the latest
+// opening balance on or before date plus the posted transactions from
+// its AsOf up to the day before date; without an opening balance it is all
+// posted history before date. A zero date means after all history.
+func balancesBefore(repos *repository.Repositories, fundID uuid.UUID, date time.Time) (map[balanceKey]models.Money, error) {
+	openings, err := repos.OpeningBalances.List(repository.OpeningBalanceFilter{FundID: fundID, Until: date})
+	if err != nil {
+		return nil, err
+	}
+
+	// Openings are oldest first, so the latest one per key wins.
+	latest := map[balanceKey]models.OpeningBalance{}
+	for _, opening := range openings {
+		latest[balanceKey{opening.FundID, opening.PaymentMethod}] = opening
+	}
+
+	// Keys that share a starting date share one query. The first window
+	// has no start and covers keys without an opening balance.
+	type window struct {
+		start time.Time
+		keys  map[balanceKey]bool
+	}
+	windows := []*window{{}}
+	balances := map[balanceKey]models.Money{}
+	for key, opening := range latest {
+		balances[key] = opening.Amount
+		var w *window
+		for _, candidate := range windows[1:] {
+			if candidate.start.Equal(opening.AsOf) {
+				w = candidate
+				break
+			}
+		}
+		if w == nil {
+			w = &window{start: opening.AsOf, keys: map[balanceKey]bool{}}
+			windows = append(windows, w)
+		}
+		w.keys[key] = true
+	}
+
+	for i, w := range windows {
+		filter := repository.TransactionFilter{Status: repository.StatusPosted, FundID: fundID, StartDate: w.start}
+		if !date.IsZero() {
+			filter.EndDate = date.AddDate(0, 0, -1)
+			if !w.start.IsZero() && w.start.After(filter.EndDate) {
+				continue
+			}
+		}
+		totals, err := repos.Transactions.SumByFundAndMethod(filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, total := range totals {
+			key := balanceKey{total.FundID, total.PaymentMethod}
+			if fundID != uuid.Nil && key.fundID != fundID {
+				continue
+			}
+			_, hasOpening := latest[key]
+			if (i == 0 && !hasOpening) || (i > 0 && w.keys[key]) {
+				balances[key] += total.Net()
+			}
+		}
+	}
+	return balances, nil
+}
+
+// defaultStartDate returns the date a period without a start date begins:
+// the earliest opening balance of fundID (any fund when nil), or zero when
+// there is none. History before it is already summed into that balance.
+func defaultStartDate(repos *repository.Repositories, fundID uuid.UUID) (time.Time, error) {
+	openings, err := repos.OpeningBalances.List(repository.OpeningBalanceFilter{FundID: fundID})
+	if err != nil || len(openings) == 0 {
+		return time.Time{}, err
+	}
+	return openings[0].AsOf, nil
+}
+
+// selectedBalance sums the balances of the funds and payment methods that
+// filter selects.
+func selectedBalance(filter repository.TransactionFilter, balances map[balanceKey]models.Money) models.Money {
+	var total models.Money
+	for key, balance := range balances {
+		if filter.Selects(key.fundID, key.paymentMethod) {
+			total += balance
+		}
+	}
+	return total
+}
@@ -0,0 +1,773 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gkjw-finance-backend/bankstatement"
+	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// defaultMatchWindow is how many days apart a statement line and a
+// transaction may be dated and still be matched automatically.
+const defaultMatchWindow = 3
+
+// maxStatementSize is the largest statement file accepted.
+const maxStatementSize = 5 * 1024 * 1024
+
+// BankStatementHandler imports bank statements and reconciles them with
+// the bank transactions in the books.
+type BankStatementHandler struct {
+	repos *repository.Repositories
+}
+
+func NewBankStatementHandler(repos *repository.Repositories) *BankStatementHandler {
+	return &BankStatementHandler{repos: repos}
+}
+
+// ReconciliationReport compares a statement with the books. The bank
+// balance is the closing balance of the statement and the book balance the
+// bank money of the statement's fund (every fund when it has none) at the
+// end of its last day.
+//
+// Bank-only lines are money the bank had moved by then that the books do
+// not show: unmatched and ignored lines, and lines whose transaction is
+// dated later. Outstanding transactions are bank transactions in the books
+// that the bank had not shown by then. Both are carried forward from the
+// first statement imported for the account, so adding each to the other
+// side explains the difference between the balances. The statement is
+// reconciled when the adjusted balances agree and none of its own lines is
+// unmatched.
+type ReconciliationReport struct {
+	StatementID             uuid.UUID                  `json:"statementId"`
+	FundID                  *uuid.UUID                 `json:"fundId,omitempty"`
+	StartDate               time.Time                  `json:"startDate"`
+	EndDate                 time.Time                  `json:"endDate"`
+	BankBalance             *models.Money              `json:"bankBalance"`
+	BookBalance             models.Money               `json:"bookBalance"`
+	MatchedLines            int                        `json:"matchedLines"`
+	UnmatchedLines          int                        `json:"unmatchedLines"`
+	IgnoredLines            int                        `json:"ignoredLines"`
+	BankOnlyLines           []models.BankStatementLine `json:"bankOnlyLines"`
+	BankOnlyTotal           models.Money               `json:"bankOnlyTotal"`
+	OutstandingTransactions []OutstandingTransaction   `json:"outstandingTransactions"`
+	OutstandingTotal        models.Money               `json:"outstandingTotal"`
+	AdjustedBankBalance     *models.Money              `json:"adjustedBankBalance"`
+	AdjustedBookBalance     models.Money               `json:"adjustedBookBalance"`
+	Difference              *models.Money              `json:"difference"`
+	Reconciled              bool                       `json:"reconciled"`
+}
+
+// OutstandingTransaction is a transaction in the books that no statement
+// line matches. Amount is its effect on the bank account.
+type OutstandingTransaction struct {
+	ID        uuid.UUID    `json:"id"`
+	Date      time.Time    `json:"date"`
+	Type      string       `json:"type"`
+	EventName string       `json:"eventName"`
+	Amount    models.Money `json:"amount"`
+}
+
+// MatchLineRequest reconciles a statement line with a transaction by hand.
+type MatchLineRequest struct {
+	TransactionID string `json:"transactionId" binding:"required"`
+}
+
+type IgnoreLineRequest struct {
+	Note string `json:"note"`
+}
+
+// statementFilter selects the bank transactions a statement covers.
+func statementFilter(statement *models.BankStatement) repository.TransactionFilter {
+	filter := repository.TransactionFilter{PaymentMethod: "bank"}
+	if statement.FundID != nil {
+		filter.FundID = *statement.FundID
+	}
+	return filter
+}
+
+// matchWindow reads a dateWindow parameter in days.
+func matchWindow(value string) (int, error) {
+	if value == "" {
+		return defaultMatchWindow, nil
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 || days > 31 {
+		return 0, errors.New("dateWindow must be a number of days from 0 to 31")
+	}
+	return days, nil
+}
+
+// matchedTransactions returns the transactions matched by any statement
+// line.
+func matchedTransactions(repos *repository.Repositories) (map[uuid.UUID]bool, error) {
+	lines, err := repos.BankStatements.ListLines("matched")
+	if err != nil {
+		return nil, err
+	}
+	matched := map[uuid.UUID]bool{}
+	for _, line := range lines {
+		if line.TransactionID != nil {
+			matched[*line.TransactionID] = true
+		}
+	}
+	return matched, nil
+}
+
+// referenceMatches reports whether the bank reference of a line appears in
+// the event name, description or note of transaction. References shorter
+// than four characters are too common to count.
+func referenceMatches(reference string, transaction *models.Transaction) bool {
+	reference = strings.ToLower(strings.TrimSpace(reference))
+	if len(reference) < 4 {
+		return false
+	}
+	for _, field := range []string{transaction.EventName, transaction.Description, transaction.NoteURL} {
+		if strings.Contains(strings.ToLower(field), reference) {
+			return true
+		}
+	}
+	return false
+}
+
+// daysApart returns the number of days between two dates.
+func daysApart(a, b time.Time) int {
+	days := int(a.Sub(b).Hours() / 24)
+	if days < 0 {
+		return -days
+	}
+	return days
+}
+
+// autoMatch matches the unmatched lines of statement with approved bank
+// transactions that move the same amount in the account within window
+// days and are not matched yet. A transaction whose event name,
+// description or note holds the line's reference is preferred, then the
+// one closest in date. It returns how many lines were matched.
+func autoMatch(repos *repository.Repositories, statement *models.BankStatement, window int) (int, error) {
+	filter := statementFilter(statement)
+	filter.Status = "approved"
+	filter.StartDate = statement.StartDate.AddDate(0, 0, -window)
+	filter.EndDate = statement.EndDate.AddDate(0, 0, window)
+	transactions, err := repos.Transactions.FindAll(filter)
+	if err != nil {
+		return 0, err
+	}
+	used, err := matchedTransactions(repos)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	count := 0
+	for i := range statement.Lines {
+		line := &statement.Lines[i]
+		if line.Status != "unmatched" {
+			continue
+		}
+
+		var best *models.Transaction
+		var bestByReference bool
+		var bestDays int
+		for j := range transactions {
+			transaction := &transactions[j]
+			// A reversal cancels a voided transaction in the books only.
+			if used[transaction.ID] || transaction.ReversalOfID != nil || filter.BalanceEffect(*transaction) != line.Amount {
+				continue
+			}
+			days := daysApart(transaction.Date, line.Date)
+			if days > window {
+				continue
+			}
+			byReference := referenceMatches(line.Reference, transaction)
+			if best == nil || byReference && !bestByReference || byReference == bestByReference && days < bestDays {
+				best, bestByReference, bestDays = transaction, byReference, days
+			}
+		}
+		if best == nil {
+			continue
+		}
+
+		line.Status = "matched"
+		line.TransactionID = &best.ID
+		line.MatchedBy = nil
+		line.MatchedAt = &now
+		if err := repos.BankStatements.UpdateLine(line); err != nil {
+			return count, err
+		}
+		used[best.ID] = true
+		count++
+	}
+	return count, nil
+}
+
+// sameFund reports whether two statements cover the same bank money.
+func sameFund(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// buildReconciliation compares statement, with its lines, with the books.
+func buildReconciliation(repos *repository.Repositories, statement *models.BankStatement) (*ReconciliationReport, error) {
+	report := &ReconciliationReport{
+		StatementID:             statement.ID,
+		FundID:                  statement.FundID,
+		StartDate:               statement.StartDate,
+		EndDate:                 statement.EndDate,
+		BankBalance:             statement.ClosingBalance,
+		BankOnlyLines:           []models.BankStatementLine{},
+		OutstandingTransactions: []OutstandingTransaction{},
+	}
+	var movement models.Money
+	for _, line := range statement.Lines {
+		movement += line.Amount
+		switch line.Status {
+		case "matched":
+			report.MatchedLines++
+		case "ignored":
+			report.IgnoredLines++
+		default:
+			report.UnmatchedLines++
+		}
+	}
+	if report.BankBalance == nil && statement.OpeningBalance != nil {
+		closing := *statement.OpeningBalance + movement
+		report.BankBalance = &closing
+	}
+	end := statement.EndDate
+
+	// Earlier statements of the same account carry their open items
+	// forward, from the start of the first one.
+	statements, err := repos.BankStatements.List()
+	if err != nil {
+		return nil, err
+	}
+	scope := map[uuid.UUID]bool{statement.ID: true}
+	start := statement.StartDate
+	for _, other := range statements {
+		if sameFund(other.FundID, statement.FundID) && !other.StartDate.After(end) {
+			scope[other.ID] = true
+			if other.StartDate.Before(start) {
+				start = other.StartDate
+			}
+		}
+	}
+
+	filter := statementFilter(statement)
+	balances, err := balancesBefore(repos, filter.FundID, end.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	report.BookBalance = selectedBalance(filter, balances)
+
+	filter.Status = repository.StatusPosted
+	filter.StartDate = start
+	transactions, err := repos.Transactions.FindAll(filter)
+	if err != nil {
+		return nil, err
+	}
+	booked := map[uuid.UUID]time.Time{}
+	for _, transaction := range transactions {
+		booked[transaction.ID] = transaction.Date
+	}
+
+	lines, err := repos.BankStatements.ListLines("")
+	if err != nil {
+		return nil, err
+	}
+	// cleared holds the transactions the bank had shown by the end.
+	cleared := map[uuid.UUID]bool{}
+	for _, line := range lines {
+		if line.Date.After(end) {
+			continue
+		}
+		if line.Status == "matched" && line.TransactionID != nil {
+			cleared[*line.TransactionID] = true
+			if date, ok := booked[*line.TransactionID]; !ok || !date.After(end) {
+				continue
+			}
+		}
+		if scope[line.StatementID] {
+			report.BankOnlyLines = append(report.BankOnlyLines, line)
+			report.BankOnlyTotal += line.Amount
+		}
+	}
+
+	for _, transaction := range transactions {
+		// A voided transaction and its reversal cancel out, unless the
+		// bank did move the money: then the reversal is outstanding.
+		if transaction.Date.After(end) || cleared[transaction.ID] || transaction.Status == "voided" ||
+			transaction.ReversalOfID != nil && !cleared[*transaction.ReversalOfID] {
+			continue
+		}
+		effect := filter.BalanceEffect(transaction)
+		if effect == 0 {
+			continue
+		}
+		report.OutstandingTransactions = append(report.OutstandingTransactions, OutstandingTransaction{
+			ID:        transaction.ID,
+			Date:      transaction.Date,
+			Type:      transaction.Type,
+			EventName: transaction.EventName,
+			Amount:    effect,
+		})
+		report.OutstandingTotal += effect
+	}
+
+	report.AdjustedBookBalance = report.BookBalance + report.BankOnlyTotal
+	if report.BankBalance != nil {
+		adjusted := *report.BankBalance + report.OutstandingTotal
+		difference := adjusted - report.AdjustedBookBalance
+		report.AdjustedBankBalance = &adjusted
+		report.Difference = &difference
+		report.Reconciled = difference == 0 && report.UnmatchedLines == 0
+	}
+	return report, nil
+}
+
+// ImportStatement reads an uploaded statement, stores its lines and
+// matches them automatically. The form holds the file, its format (csv,
+// ofx or mt940; guessed from the file name when left out), an optional
+// fundId, the CSV column mapping as JSON, the dateWindow in days, and
+// openingBalance and closingBalance for files that do not state them.
+func (h *BankStatementHandler) ImportStatement(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file provided", "details": err.Error()})
+		return
+	}
+	if file.Size > maxStatementSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File size exceeds 5MB limit"})
+		return
+	}
+	opened, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+	data, err := io.ReadAll(opened)
+	opened.Close()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+
+	format := strings.ToLower(c.PostForm("format"))
+	if format == "" {
+		format = bankstatement.DetectFormat(file.Filename)
+	}
+	var mapping bankstatement.CSVMapping
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid CSV mapping: " + err.Error()})
+			return
+		}
+	}
+	window, err := matchWindow(c.PostForm("dateWindow"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	statement := models.BankStatement{
+		Format:   format,
+		FileName: file.Filename,
+	}
+	if fundID := c.PostForm("fundId"); fundID != "" && fundID != "all" {
+		parsed, err := uuid.Parse(fundID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fundId"})
+			return
+		}
+		if _, err := h.repos.Funds.FindByID(parsed); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Fund not found"})
+			return
+		}
+		statement.FundID = &parsed
+	}
+
+	parsed, err := bankstatement.Parse(format, data, mapping)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read statement: " + err.Error()})
+		return
+	}
+	statement.AccountNumber = parsed.AccountNumber
+	statement.StartDate = parsed.StartDate
+	statement.EndDate = parsed.EndDate
+	statement.OpeningBalance = parsed.OpeningBalance
+	statement.ClosingBalance = parsed.ClosingBalance
+	for _, field := range []struct {
+		name    string
+		balance **models.Money
+	}{{"openingBalance", &statement.OpeningBalance}, {"closingBalance", &statement.ClosingBalance}} {
+		if value := c.PostForm(field.name); value != "" {
+			amount, err := models.ParseMoney(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + field.name})
+				return
+			}
+			*field.balance = &amount
+		}
+	}
+	for _, line := range parsed.Lines {
+		statement.Lines = append(statement.Lines, models.BankStatementLine{
+			Date:        line.Date,
+			Amount:      line.Amount,
+			Description: line.Description,
+			Reference:   line.Reference,
+			Status:      "unmatched",
+		})
+	}
+
+	checksum := sha256.Sum256(data)
+	statement.Checksum = hex.EncodeToString(checksum[:])
+	if existing, err := h.repos.BankStatements.FindByChecksum(statement.Checksum); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "This statement was already imported", "data": existing})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	statement.ImportedBy = userID.(uuid.UUID)
+	var matched int
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.BankStatements.Create(&statement); err != nil {
+			return err
+		}
+		if matched, err = autoMatch(repos, &statement, window); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "bank_statement",
+			EntityID:   statement.ID,
+			Action:     "import",
+			Summary: fmt.Sprintf("Imported bank statement %s: %d lines, %d matched",
+				statement.FileName, len(statement.Lines), matched),
+			After: statement,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import bank statement"})
+		return
+	}
+
+	report, err := buildReconciliation(h.repos, &statement)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reconcile bank statement"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message":        fmt.Sprintf("Imported %d statement lines, %d matched", len(statement.Lines), matched),
+		"data":           statement,
+		"reconciliation": report,
+	})
+}
+
+func (h *BankStatementHandler) GetStatements(c *gin.Context) {
+	statements, err := h.repos.BankStatements.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bank statements"})
+		return
+	}
+	if statements == nil {
+		statements = []models.BankStatement{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": statements})
+}
+
+// findStatement loads the statement named by the id parameter with its
+// lines, or answers 404 and returns false.
+func (h *BankStatementHandler) findStatement(c *gin.Context) (*models.BankStatement, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bank statement not found"})
+		return nil, false
+	}
+	statement, err := h.repos.BankStatements.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bank statement not found"})
+		return nil, false
+	}
+	return statement, true
+}
+
+// findLine returns the line of statement named by the lineId parameter,
+// or answers 404 and returns nil.
+func (h *BankStatementHandler) findLine(c *gin.Context, statement *models.BankStatement) *models.BankStatementLine {
+	id, err := uuid.Parse(c.Param("lineId"))
+	if err == nil {
+		for i := range statement.Lines {
+			if statement.Lines[i].ID == id {
+				return &statement.Lines[i]
+			}
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "Statement line not found"})
+	return nil
+}
+
+func (h *BankStatementHandler) GetStatement(c *gin.Context) {
+	statement, ok := h.findStatement(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": statement})
+}
+
+// GetReconciliation returns the reconciliation report of a statement.
+func (h *BankStatementHandler) GetReconciliation(c *gin.Context) {
+	statement, ok := h.findStatement(c)
+	if !ok {
+		return
+	}
+
+	report, err := buildReconciliation(h.repos, statement)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reconcile bank statement"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+// GetQueue returns the statement lines of every statement that still wait
+// for a transaction, oldest first.
+func (h *BankStatementHandler) GetQueue(c *gin.Context) {
+	lines, err := h.repos.BankStatements.ListLines("unmatched")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reconciliation queue"})
+		return
+	}
+	if lines == nil {
+		lines = []models.BankStatementLine{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": lines})
+}
+
+// RematchStatement runs the automatic matching again for the lines of a
+// statement that are still unmatched, for instance after the missing
+// transactions were recorded and approved.
+func (h *BankStatementHandler) RematchStatement(c *gin.Context) {
+	statement, ok := h.findStatement(c)
+	if !ok {
+		return
+	}
+	window, err := matchWindow(c.Query("dateWindow"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var matched int
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if matched, err = autoMatch(repos, statement, window); err != nil || matched == 0 {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "bank_statement",
+			EntityID:   statement.ID,
+			Action:     "match",
+			Summary:    fmt.Sprintf("Matched %d lines of bank statement %s automatically", matched, statement.FileName),
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to match bank statement"})
+		return
+	}
+
+	report, err := buildReconciliation(h.repos, statement)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reconcile bank statement"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": strconv.Itoa(matched) + " statement lines matched",
+		"data":    report,
+	})
+}
+
+// saveLine stores a line changed by hand with its audit entry and answers
+// with it.
+func (h *BankStatementHandler) saveLine(c *gin.Context, statement *models.BankStatement, line *models.BankStatementLine, action, summary string) {
+	err := h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.BankStatements.UpdateLine(line); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "bank_statement",
+			EntityID:   statement.ID,
+			Action:     action,
+			Summary:    summary,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update statement line"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Statement line updated successfully",
+		"data":    line,
+	})
+}
+
+// describeLine names a statement line in the audit trail.
+func describeLine(line *models.BankStatementLine) string {
+	return line.Date.Format("2006-01-02") + " " + line.Amount.String() + " " + line.Description
+}
+
+// MatchLine reconciles a statement line with a transaction chosen by hand.
+// The transaction must be posted, not matched to another line, and move
+// exactly the amount of the line in the statement's account.
+func (h *BankStatementHandler) MatchLine(c *gin.Context) {
+	statement, ok := h.findStatement(c)
+	if !ok {
+		return
+	}
+	line := h.findLine(c, statement)
+	if line == nil {
+		return
+	}
+	var req MatchLineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if line.Status == "matched" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Statement line is already matched; unmatch it first"})
+		return
+	}
+
+	transactionID, err := uuid.Parse(req.TransactionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transactionId"})
+		return
+	}
+	transaction, err := h.repos.Transactions.FindByID(transactionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return
+	}
+	if !transaction.Posted() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only approved transactions can be matched"})
+		return
+	}
+	if effect := statementFilter(statement).BalanceEffect(*transaction); effect != line.Amount {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "The transaction moves " + effect.String() + " in this account, the statement line " + line.Amount.String(),
+		})
+		return
+	}
+	matched, err := matchedTransactions(h.repos)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to match statement line"})
+		return
+	}
+	if matched[transaction.ID] {
+		c.JSON(http.StatusConflict, gin.H{"error": "Transaction is already matched to another statement line"})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	actor := userID.(uuid.UUID)
+	now := time.Now()
+	line.Status = "matched"
+	line.TransactionID = &transaction.ID
+	line.MatchedBy = &actor
+	line.MatchedAt = &now
+	line.Note = ""
+	h.saveLine(c, statement, line, "match", "Matched bank statement line "+describeLine(line)+" to transaction: "+transaction.EventName)
+}
+
+// UnmatchLine puts a matched or ignored line back in the reconciliation
+// queue.
+func (h *BankStatementHandler) UnmatchLine(c *gin.Context) {
+	statement, ok := h.findStatement(c)
+	if !ok {
+		return
+	}
+	line := h.findLine(c, statement)
+	if line == nil {
+		return
+	}
+	if line.Status == "unmatched" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Statement line is not matched"})
+		return
+	}
+
+	line.Status = "unmatched"
+	line.TransactionID = nil
+	line.MatchedBy = nil
+	line.MatchedAt = nil
+	line.Note = ""
+	h.saveLine(c, statement, line, "unmatch", "Returned bank statement line to the reconciliation queue: "+describeLine(line))
+}
+
+// IgnoreLine takes a line that will never have a transaction of its own
+// out of the reconciliation queue. It still counts as bank-only money in
+// the reconciliation report.
+func (h *BankStatementHandler) IgnoreLine(c *gin.Context) {
+	statement, ok := h.findStatement(c)
+	if !ok {
+		return
+	}
+	line := h.findLine(c, statement)
+	if line == nil {
+		return
+	}
+	var req IgnoreLineRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if line.Status != "unmatched" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only unmatched statement lines can be ignored"})
+		return
+	}
+
+	line.Status = "ignored"
+	line.Note = req.Note
+	h.saveLine(c, statement, line, "ignore", "Ignored bank statement line "+describeLine(line))
+}
+
+// DeleteStatement removes an imported statement and its matches, so that
+// it can be imported again.
+func (h *BankStatementHandler) DeleteStatement(c *gin.Context) {
+	statement, ok := h.findStatement(c)
+	if !ok {
+		return
+	}
+
+	err := h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.BankStatements.Delete(statement.ID); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "bank_statement",
+			EntityID:   statement.ID,
+			Action:     "delete",
+			Summary:    "Deleted bank statement: " + statement.FileName,
+			Before:     statement,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bank statement"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bank statement deleted successfully"})
+}
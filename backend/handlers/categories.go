@@ -1,76 +1,654 @@
 package handlers
 
 import (
-	"gkjw-finance-backend/config"
+	"errors"
+	"fmt"
 	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-// GetCategories mengambil semua kategori
-func GetCategories(c *gin.Context) {
-	var categories []models.Category
-	result := config.DB.Find(&categories)
+// CategoryHandler melayani endpoint kategori
+type CategoryHandler struct {
+	repos *repository.Repositories
+}
+
+func NewCategoryHandler(repos *repository.Repositories) *CategoryHandler {
+	return &CategoryHandler{repos: repos}
+}
+
+// categoryByID returns the category of categories with id, or nil.
+func categoryByID(categories []models.Category, id uuid.UUID) *models.Category {
+	for i := range categories {
+		if categories[i].ID == id {
+			return &categories[i]
+		}
+	}
+	return nil
+}
+
+// findCategory returns the category called name, ignoring case. A category
+// of transactionType is preferred over a general one, and either over one
+// of another type, which the caller should refuse. It returns nil when no
+// category has the name.
+func findCategory(categories []models.Category, name, transactionType string) *models.Category {
+	name = strings.TrimSpace(name)
+	var general, other *models.Category
+	for i := range categories {
+		category := &categories[i]
+		if !strings.EqualFold(strings.TrimSpace(category.Name), name) {
+			continue
+		}
+		switch {
+		case category.Type == transactionType:
+			return category
+		case category.Type == "general" && general == nil:
+			general = category
+		case other == nil:
+			other = category
+		}
+	}
+	if general != nil {
+		return general
+	}
+	return other
+}
+
+// resolveCategories books each line of transaction to its category, given
+// by ID or else by name, and checks that transactions of its type may use
+// it. The lines and the transaction take the name of the category.
+// Transfers have no category.
+func resolveCategories(categories []models.Category, transaction *models.Transaction) error {
+	if transaction.Type == "transfer" {
+		transaction.CategoryID = nil
+		return nil
+	}
+	for i := range transaction.Lines {
+		line := &transaction.Lines[i]
+		prefix := ""
+		if len(transaction.Lines) > 1 {
+			prefix = fmt.Sprintf("Line %d: ", i+1)
+		}
+		var category *models.Category
+		if line.CategoryID != nil {
+			if category = categoryByID(categories, *line.CategoryID); category == nil {
+				return errors.New(prefix + "Category not found")
+			}
+		} else if category = findCategory(categories, line.Category, transaction.Type); category == nil {
+			return fmt.Errorf("%sNo %s category named %q", prefix, transaction.Type, line.Category)
+		}
+		if !category.Allows(transaction.Type) {
+			return fmt.Errorf("%sCategory %q is for %s transactions", prefix, category.Name, category.Type)
+		}
+		id := category.ID
+		line.CategoryID = &id
+		line.Category = category.Name
+	}
+	if len(transaction.Lines) > 0 {
+		transaction.CategoryID = transaction.Lines[0].CategoryID
+		transaction.Category = transaction.Lines[0].Category
+	}
+	return nil
+}
+
+// categoryTree returns the IDs of the category id and of all its
+// subcategories.
+func categoryTree(categories []models.Category, id uuid.UUID) []uuid.UUID {
+	ids := []uuid.UUID{id}
+	for i := 0; i < len(ids); i++ {
+		for _, category := range categories {
+			if category.ParentID != nil && *category.ParentID == ids[i] {
+				ids = append(ids, category.ID)
+			}
+		}
+	}
+	return ids
+}
 
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+// rootCategory returns the top-level category above the category id, the
+// category itself when it has no parent, or nil when it does not exist.
+func rootCategory(categories []models.Category, id uuid.UUID) *models.Category {
+	category := categoryByID(categories, id)
+	for seen := 0; category != nil && category.ParentID != nil && seen < len(categories); seen++ {
+		parent := categoryByID(categories, *category.ParentID)
+		if parent == nil {
+			break
+		}
+		category = parent
+	}
+	return category
+}
+
+// checkCategory validates category before it is saved: its type, and that
+// its parent exists, is not the category or one of its subcategories, and
+// allows its type, as the category must allow the type of each of its
+// subcategories.
+func checkCategory(categories []models.Category, category *models.Category) error {
+	category.Name = strings.TrimSpace(category.Name)
+	if category.Name == "" {
+		return errors.New("Name is required")
+	}
+	if category.Type == "" {
+		category.Type = "general"
+	}
+	if category.Type != "income" && category.Type != "expense" && category.Type != "general" {
+		return errors.New("Type must be income, expense or general")
+	}
+
+	if category.ParentID != nil {
+		parent := categoryByID(categories, *category.ParentID)
+		if parent == nil {
+			return errors.New("Parent category not found")
+		}
+		for _, id := range categoryTree(categories, category.ID) {
+			if id == parent.ID {
+				return errors.New("A category cannot be placed under itself or one of its subcategories")
+			}
+		}
+		if !parent.Allows(category.Type) {
+			return fmt.Errorf("Parent category %q is for %s transactions", parent.Name, parent.Type)
+		}
+	}
+	for _, child := range categories {
+		if child.ParentID != nil && *child.ParentID == category.ID && !category.Allows(child.Type) {
+			return fmt.Errorf("Subcategory %q is for %s transactions", child.Name, child.Type)
+		}
+	}
+	return nil
+}
+
+// categoryUseConflict explains why the transactions booked to the category
+// id may not use a category of categoryType, or returns "".
+func categoryUseConflict(repos *repository.Repositories, id uuid.UUID, categoryType string) (string, error) {
+	counts, err := repos.Categories.CountTransactions(id)
+	if err != nil {
+		return "", err
+	}
+	check := models.Category{Type: categoryType}
+	for _, transactionType := range []string{"income", "expense"} {
+		if counts[transactionType] > 0 && !check.Allows(transactionType) {
+			return fmt.Sprintf("Category is used by %d %s transactions", counts[transactionType], transactionType), nil
+		}
+	}
+	return "", nil
+}
+
+// categoryReference is a recurring template, approval policy or
+// categorization rule that names a category. transactionType is the type
+// it is for, or "" for any.
+type categoryReference struct {
+	description     string
+	transactionType string
+	// rename makes the reference name the category name instead.
+	rename func(repos *repository.Repositories, name string) error
+}
+
+// categoryReferences returns what names category: the recurring
+// templates, approval policies and categorization rules with its name and
+// a type it allows.
+func categoryReferences(c *gin.Context, repos *repository.Repositories, category *models.Category) ([]categoryReference, error) {
+	names := func(name, transactionType string) bool {
+		return name == category.Name && (transactionType == "" || category.Allows(transactionType))
+	}
+	var references []categoryReference
+
+	templates, err := repos.RecurringTemplates.List()
+	if err != nil {
+		return nil, err
+	}
+	for i := range templates {
+		template := templates[i]
+		if !names(template.Category, template.Type) {
+			continue
+		}
+		references = append(references, categoryReference{
+			description:     fmt.Sprintf("recurring template %q", template.Name),
+			transactionType: template.Type,
+			rename: func(repos *repository.Repositories, name string) error {
+				before := template
+				template.Category = name
+				template.Fund = nil
+				if err := repos.RecurringTemplates.Update(&template); err != nil {
+					return err
+				}
+				return recordAudit(c, repos, auditEntry{
+					EntityType: "recurring_template",
+					EntityID:   template.ID,
+					Action:     "update",
+					Summary:    "Renamed category " + before.Category + " to " + name + " in recurring template: " + template.Name,
+					Before:     before,
+					After:      template,
+				})
+			},
+		})
+	}
+
+	policies, err := repos.ApprovalPolicies.List()
+	if err != nil {
+		return nil, err
+	}
+	for i := range policies {
+		policy := policies[i]
+		if policy.Category == "" || !names(policy.Category, policy.Type) {
+			continue
+		}
+		references = append(references, categoryReference{
+			description:     fmt.Sprintf("approval policy %q", policy.Name),
+			transactionType: policy.Type,
+			rename: func(repos *repository.Repositories, name string) error {
+				before := policy
+				policy.Category = name
+				policy.Fund = nil
+				if err := repos.ApprovalPolicies.Update(&policy); err != nil {
+					return err
+				}
+				return recordAudit(c, repos, auditEntry{
+					EntityType: "approval_policy",
+					EntityID:   policy.ID,
+					Action:     "update",
+					Summary:    "Renamed category " + before.Category + " to " + name + " in approval policy: " + policy.Name,
+					Before:     before,
+					After:      policy,
+				})
+			},
+		})
+	}
+
+	rules, err := repos.CategorizationRules.List()
+	if err != nil {
+		return nil, err
+	}
+	for i := range rules {
+		rule := rules[i]
+		if rule.SetCategory == "" || !names(rule.SetCategory, rule.SetType) {
+			continue
+		}
+		references = append(references, categoryReference{
+			description:     fmt.Sprintf("categorization rule %q", rule.Name),
+			transactionType: rule.SetType,
+			rename: func(repos *repository.Repositories, name string) error {
+				before := rule
+				rule.SetCategory = name
+				rule.SetFund = nil
+				if err := repos.CategorizationRules.Update(&rule); err != nil {
+					return err
+				}
+				return recordAudit(c, repos, auditEntry{
+					EntityType: "categorization_rule",
+					EntityID:   rule.ID,
+					Action:     "update",
+					Summary:    "Renamed category " + before.SetCategory + " to " + name + " in categorization rule: " + rule.Name,
+					Before:     before,
+					After:      rule,
+				})
+			},
+		})
+	}
+	return references, nil
+}
+
+// GetCategories mengambil semua kategori
+func (h *CategoryHandler) GetCategories(c *gin.Context) {
+	categories, err := h.repos.Categories.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": categories})
 }
 
+// GetCategory mengambil satu kategori beserta ETag versinya
+func (h *CategoryHandler) GetCategory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	category, err := h.repos.Categories.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	setETag(c, category.Version)
+	c.JSON(http.StatusOK, gin.H{"data": category})
+}
+
 // CreateCategory membuat kategori baru (Admin only)
-func CreateCategory(c *gin.Context) {
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
 	var category models.Category
 	if err := c.ShouldBindJSON(&category); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	result := config.DB.Create(&category)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	categories, err := h.repos.Categories.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	category.ID = uuid.New()
+	if err := checkCategory(categories, &category); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.Categories.Create(&category); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "category",
+			EntityID:   category.ID,
+			Action:     "create",
+			Summary:    "Created category: " + category.Name,
+			After:      category,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusCreated, category)
 }
 
-// UpdateCategory memperbarui kategori (Admin only)
-func UpdateCategory(c *gin.Context) {
-	id := c.Param("id")
-	var category models.Category
+// UpdateCategory memperbarui kategori (Admin only). Nama baru ikut
+// tercatat pada transaksi, template berulang, kebijakan persetujuan dan
+// aturan kategorisasi yang memakainya.
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
 
-	if err := config.DB.First(&category, id).Error; err != nil {
+	category, err := h.repos.Categories.FindByID(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
 		return
 	}
 
-	if err := c.ShouldBindJSON(&category); err != nil {
+	if !ifMatch(c, category.Version) {
+		respondStale(c, category, category.Version)
+		return
+	}
+
+	before := *category
+	if err := c.ShouldBindJSON(category); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	category.ID = id
+	category.Version = before.Version
 
-	config.DB.Save(&category)
+	categories, err := h.repos.Categories.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := checkCategory(categories, category); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var references []categoryReference
+	if category.Type != before.Type {
+		conflict, err := categoryUseConflict(h.repos, id, category.Type)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if conflict != "" {
+			c.JSON(http.StatusConflict, gin.H{"error": conflict})
+			return
+		}
+	}
+	if category.Name != before.Name || category.Type != before.Type {
+		if references, err = categoryReferences(c, h.repos, &before); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for _, reference := range references {
+			if reference.transactionType != "" && !category.Allows(reference.transactionType) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Category is used by " + reference.description + " for " + reference.transactionType + " transactions"})
+				return
+			}
+		}
+	}
+
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.Categories.Update(category); err != nil {
+			return err
+		}
+		if category.Name != before.Name {
+			if err := repos.Categories.Relabel(id, category.Name); err != nil {
+				return err
+			}
+			for _, reference := range references {
+				if err := reference.rename(repos, category.Name); err != nil {
+					return err
+				}
+			}
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "category",
+			EntityID:   category.ID,
+			Action:     "update",
+			Summary:    "Updated category: " + category.Name,
+			Before:     before,
+			After:      category,
+		})
+	})
+	if errors.Is(err, repository.ErrVersionConflict) {
+		if current, err := h.repos.Categories.FindByID(id); err == nil {
+			respondStale(c, current, current.Version)
+			return
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	setETag(c, category.Version)
 	c.JSON(http.StatusOK, category)
 }
 
-// DeleteCategory menghapus kategori (Admin only)
-func DeleteCategory(c *gin.Context) {
-	id := c.Param("id")
-	result := config.DB.Delete(&models.Category{}, id)
-
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+// DeleteCategory menghapus kategori (Admin only). Kategori yang masih
+// dipakai harus digabung ke kategori lain lewat MergeCategory.
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
 		return
 	}
 
-	if result.RowsAffected == 0 {
+	category, err := h.repos.Categories.FindByID(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
 		return
 	}
+	if !ifMatch(c, category.Version) {
+		respondStale(c, category, category.Version)
+		return
+	}
+
+	counts, err := h.repos.Categories.CountTransactions(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if used := counts["income"] + counts["expense"]; used > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Category is used by %d transactions; merge it into another category instead", used)})
+		return
+	}
+	categories, err := h.repos.Categories.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(categoryTree(categories, id)) > 1 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Category has subcategories; move them or merge it into another category instead"})
+		return
+	}
+	references, err := categoryReferences(c, h.repos, category)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(references) > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Category is used by " + references[0].description + "; merge it into another category instead"})
+		return
+	}
+
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.Categories.Delete(id); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "category",
+			EntityID:   category.ID,
+			Action:     "delete",
+			Summary:    "Deleted category: " + category.Name,
+			Before:     category,
+		})
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
 }
+
+type MergeCategoryRequest struct {
+	IntoID string `json:"intoId" binding:"required"`
+}
+
+// MergeCategory menggabungkan kategori ke kategori lain (Admin only):
+// transaksi, subkategori, template berulang, kebijakan persetujuan dan
+// aturan kategorisasi pindah ke kategori tujuan, lalu kategori dihapus.
+func (h *CategoryHandler) MergeCategory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	category, err := h.repos.Categories.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+	if !ifMatch(c, category.Version) {
+		respondStale(c, category, category.Version)
+		return
+	}
+
+	var req MergeCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	intoID, err := uuid.Parse(req.IntoID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid intoId"})
+		return
+	}
+	categories, err := h.repos.Categories.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	into := categoryByID(categories, intoID)
+	if into == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Category to merge into not found"})
+		return
+	}
+	for _, descendant := range categoryTree(categories, id) {
+		if descendant == intoID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A category cannot be merged into itself or one of its subcategories"})
+			return
+		}
+	}
+	for _, child := range categories {
+		if child.ParentID != nil && *child.ParentID == id && !into.Allows(child.Type) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Subcategory %q is for %s transactions", child.Name, child.Type)})
+			return
+		}
+	}
+	conflict, err := categoryUseConflict(h.repos, id, into.Type)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if conflict != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": conflict})
+		return
+	}
+	references, err := categoryReferences(c, h.repos, category)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, reference := range references {
+		if reference.transactionType != "" && !into.Allows(reference.transactionType) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Category is used by " + reference.description + " for " + reference.transactionType + " transactions"})
+			return
+		}
+	}
+	counts, err := h.repos.Categories.CountTransactions(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	moved := counts["income"] + counts["expense"]
+	dates, err := h.repos.Categories.TransactionDates(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !checkPeriodsOpen(c, h.repos, dates...) {
+		return
+	}
+
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.Categories.Merge(id, into); err != nil {
+			return err
+		}
+		for _, reference := range references {
+			if err := reference.rename(repos, into.Name); err != nil {
+				return err
+			}
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "category",
+			EntityID:   category.ID,
+			Action:     "merge",
+			Summary:    fmt.Sprintf("Merged category %s into %s (%d transactions)", category.Name, into.Name, moved),
+			Before:     category,
+		})
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Category merged; %d transactions moved to %s", moved, into.Name),
+		"data":    into,
+	})
+}
@@ -0,0 +1,30 @@
+package handlers_test
+
+import (
+	"gkjw-finance-backend/models"
+	"net/http"
+	"testing"
+)
+
+func TestMergeCategoryRespectsClosedPeriods(t *testing.T) {
+	s := newTestServer(t)
+	kolekte := models.Category{Name: "Kolekte", Type: "income"}
+	if err := s.repos.Categories.Create(&kolekte); err != nil {
+		t.Fatal(err)
+	}
+	id := s.createTransaction(s.admin, map[string]interface{}{"category": kolekte.Name, "date": "2025-01-05"})
+	period := data(s.do(s.admin, http.MethodPost, "/api/periods/close", map[string]interface{}{"month": "2025-01"}, http.StatusOK))
+
+	path := "/api/categories/" + kolekte.ID.String() + "/merge"
+	body := map[string]interface{}{"intoId": s.income.ID}
+	s.do(s.admin, http.MethodPost, path, body, http.StatusConflict)
+	if got := data(s.do(s.admin, http.MethodGet, "/api/transactions/"+id, nil, http.StatusOK))["category"]; got != kolekte.Name {
+		t.Errorf("category = %v after a refused merge, want %s", got, kolekte.Name)
+	}
+
+	s.do(s.admin, http.MethodPost, "/api/periods/"+period["id"].(string)+"/reopen", map[string]interface{}{"reason": "Koreksi kategori"}, http.StatusOK)
+	s.do(s.admin, http.MethodPost, path, body, http.StatusOK)
+	if got := data(s.do(s.admin, http.MethodGet, "/api/transactions/"+id, nil, http.StatusOK))["category"]; got != s.income.Name {
+		t.Errorf("category = %v after the merge, want %s", got, s.income.Name)
+	}
+}
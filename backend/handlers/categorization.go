@@ -0,0 +1,584 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CategorizationRuleHandler manages the categorization rules and runs them
+// over transactions already recorded.
+type CategorizationRuleHandler struct {
+	repos *repository.Repositories
+}
+
+func NewCategorizationRuleHandler(repos *repository.Repositories) *CategorizationRuleHandler {
+	return &CategorizationRuleHandler{repos: repos}
+}
+
+type CategorizationRuleRequest struct {
+	Name          string        `json:"name" binding:"required"`
+	Priority      int           `json:"priority"`
+	Pattern       string        `json:"pattern" binding:"required"`
+	Field         string        `json:"field" binding:"omitempty,oneof=any eventName description"`
+	MinAmount     models.Money  `json:"minAmount"`
+	MaxAmount     *models.Money `json:"maxAmount"`
+	PaymentMethod string        `json:"paymentMethod" binding:"omitempty,oneof=cash bank"`
+	SetType       string        `json:"setType" binding:"omitempty,oneof=income expense"`
+	SetCategory   string        `json:"setCategory"`
+	SetFundID     string        `json:"setFundId"`
+	Mode          string        `json:"mode" binding:"omitempty,oneof=set suggest"`
+	Status        string        `json:"status" binding:"omitempty,oneof=active archived"`
+}
+
+// RuleChange is one field a categorization rule changes on a transaction.
+// Funds are given by ID.
+type RuleChange struct {
+	Field  string `json:"field"` // type, category, fundId
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// ruleChanges returns what rule would change on transaction. The category
+// and fund are only changed on transactions with a single line.
+func ruleChanges(rule *models.CategorizationRule, transaction *models.Transaction) []RuleChange {
+	var changes []RuleChange
+	if rule.SetType != "" && rule.SetType != transaction.Type {
+		changes = append(changes, RuleChange{Field: "type", Before: transaction.Type, After: rule.SetType})
+	}
+	if len(transaction.Lines) > 1 {
+		return changes
+	}
+	if rule.SetCategory != "" && rule.SetCategory != transaction.Category {
+		changes = append(changes, RuleChange{Field: "category", Before: transaction.Category, After: rule.SetCategory})
+	}
+	if rule.SetFundID != nil && *rule.SetFundID != transaction.FundID {
+		changes = append(changes, RuleChange{Field: "fundId", Before: transaction.FundID.String(), After: rule.SetFundID.String()})
+	}
+	return changes
+}
+
+// applyRuleChanges makes the changes of ruleChanges on transaction and its
+// line. A new category is given by name; resolveCategories looks it up.
+func applyRuleChanges(transaction *models.Transaction, changes []RuleChange) {
+	for _, change := range changes {
+		switch change.Field {
+		case "type":
+			transaction.Type = change.After
+		case "category":
+			transaction.CategoryID = nil
+			transaction.Category = change.After
+			if len(transaction.Lines) == 1 {
+				transaction.Lines[0].CategoryID = nil
+				transaction.Lines[0].Category = change.After
+			}
+		case "fundId":
+			fundID := uuid.MustParse(change.After)
+			transaction.FundID = fundID
+			transaction.Fund = nil
+			if len(transaction.Lines) == 1 {
+				transaction.Lines[0].FundID = fundID
+			}
+		}
+	}
+}
+
+// describeRuleChanges summarises changes for people, e.g. "type income,
+// category Persembahan".
+func describeRuleChanges(changes []RuleChange) string {
+	parts := make([]string, len(changes))
+	for i, change := range changes {
+		parts[i] = change.Field + " " + change.After
+	}
+	return strings.Join(parts, ", ")
+}
+
+// matchRule returns the first active rule that matches transaction, or
+// nil, with what it would change.
+func matchRule(repos *repository.Repositories, transaction *models.Transaction) (*models.CategorizationRule, []RuleChange, error) {
+	rules, err := repos.CategorizationRules.List()
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range rules {
+		if rules[i].Status == "active" && rules[i].Matches(transaction) {
+			return &rules[i], ruleChanges(&rules[i], transaction), nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// categorizeTransaction applies the first matching rule to a new
+// transaction when the rule is a "set" rule, and returns the rule when it
+// changed anything. A rule that would leave the transaction with a
+// category its type may not use is not applied. Suggestions are raised as
+// flags once the transaction is saved.
+func categorizeTransaction(repos *repository.Repositories, transaction *models.Transaction) (*models.CategorizationRule, error) {
+	rule, changes, err := matchRule(repos, transaction)
+	if err != nil || rule == nil || rule.Mode != "set" || len(changes) == 0 {
+		return nil, err
+	}
+	categories, err := repos.Categories.List()
+	if err != nil {
+		return nil, err
+	}
+	categorized, err := withRuleChanges(categories, transaction, changes)
+	if err != nil {
+		return nil, nil
+	}
+	*transaction = *categorized
+	return rule, nil
+}
+
+// withRuleChanges returns a copy of transaction with changes made and its
+// categories looked up again, or the reason the changes cannot be made.
+func withRuleChanges(categories []models.Category, transaction *models.Transaction, changes []RuleChange) (*models.Transaction, error) {
+	changed := *transaction
+	changed.Lines = append([]models.TransactionLine(nil), transaction.Lines...)
+	applyRuleChanges(&changed, changes)
+	if err := resolveCategories(categories, &changed); err != nil {
+		return nil, err
+	}
+	return &changed, nil
+}
+
+// suggestCategorization describes what the first matching rule would
+// change on transaction, or returns nil.
+func suggestCategorization(repos *repository.Repositories, transaction *models.Transaction) (*models.TransactionFlag, []RuleChange, error) {
+	rule, changes, err := matchRule(repos, transaction)
+	if err != nil || rule == nil || len(changes) == 0 {
+		return nil, nil, err
+	}
+	return &models.TransactionFlag{
+		Kind:    flagSuggestion,
+		Message: fmt.Sprintf("Rule %q suggests %s", rule.Name, describeRuleChanges(changes)),
+	}, changes, nil
+}
+
+func (h *CategorizationRuleHandler) GetRules(c *gin.Context) {
+	rules, err := h.repos.CategorizationRules.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categorization rules"})
+		return
+	}
+	if rules == nil {
+		rules = []models.CategorizationRule{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rules})
+}
+
+// applyRuleRequest validates req and copies it onto rule.
+func (h *CategorizationRuleHandler) applyRuleRequest(req CategorizationRuleRequest, rule *models.CategorizationRule) error {
+	if _, err := regexp.Compile("(?i)" + req.Pattern); err != nil {
+		return errors.New("Invalid pattern: " + err.Error())
+	}
+	if req.MaxAmount != nil && *req.MaxAmount < req.MinAmount {
+		return errors.New("maxAmount must not be less than minAmount")
+	}
+	if req.SetType == "" && req.SetCategory == "" && req.SetFundID == "" {
+		return errors.New("A rule must set a type, a category or a fund")
+	}
+
+	rule.Name = req.Name
+	rule.Priority = req.Priority
+	rule.Pattern = req.Pattern
+	rule.Field = req.Field
+	if rule.Field == "" {
+		rule.Field = "any"
+	}
+	rule.MinAmount = req.MinAmount
+	rule.MaxAmount = req.MaxAmount
+	rule.PaymentMethod = req.PaymentMethod
+	rule.SetType = req.SetType
+	rule.SetCategory = strings.TrimSpace(req.SetCategory)
+	rule.Mode = req.Mode
+	if rule.Mode == "" {
+		rule.Mode = "suggest"
+	}
+	rule.Status = req.Status
+	if rule.Status == "" {
+		rule.Status = "active"
+	}
+
+	if rule.SetCategory != "" {
+		categories, err := h.repos.Categories.List()
+		if err != nil {
+			return err
+		}
+		category := findCategory(categories, rule.SetCategory, rule.SetType)
+		if category == nil || rule.SetType != "" && !category.Allows(rule.SetType) {
+			return errors.New("Category not found")
+		}
+		rule.SetCategory = category.Name
+	}
+
+	rule.SetFundID = nil
+	rule.SetFund = nil
+	if req.SetFundID != "" {
+		fundID, err := uuid.Parse(req.SetFundID)
+		if err != nil {
+			return errors.New("Invalid setFundId")
+		}
+		if _, err := h.repos.Funds.FindByID(fundID); err != nil {
+			return errors.New("Fund not found")
+		}
+		rule.SetFundID = &fundID
+	}
+	return nil
+}
+
+func (h *CategorizationRuleHandler) CreateRule(c *gin.Context) {
+	var req CategorizationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rule models.CategorizationRule
+	if err := h.applyRuleRequest(req, &rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.CategorizationRules.Create(&rule); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "categorization_rule",
+			EntityID:   rule.ID,
+			Action:     "create",
+			Summary:    "Created categorization rule: " + rule.Name,
+			After:      rule,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create categorization rule"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Categorization rule created successfully",
+		"data":    rule,
+	})
+}
+
+// findRule loads the rule named by the id parameter, or answers 404 and
+// returns nil.
+func (h *CategorizationRuleHandler) findRule(c *gin.Context) *models.CategorizationRule {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Categorization rule not found"})
+		return nil
+	}
+	rule, err := h.repos.CategorizationRules.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Categorization rule not found"})
+		return nil
+	}
+	return rule
+}
+
+func (h *CategorizationRuleHandler) UpdateRule(c *gin.Context) {
+	rule := h.findRule(c)
+	if rule == nil {
+		return
+	}
+	var req CategorizationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	before := *rule
+	if err := h.applyRuleRequest(req, rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.CategorizationRules.Update(rule); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "categorization_rule",
+			EntityID:   rule.ID,
+			Action:     "update",
+			Summary:    "Updated categorization rule: " + rule.Name,
+			Before:     before,
+			After:      rule,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update categorization rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Categorization rule updated successfully",
+		"data":    rule,
+	})
+}
+
+func (h *CategorizationRuleHandler) DeleteRule(c *gin.Context) {
+	rule := h.findRule(c)
+	if rule == nil {
+		return
+	}
+
+	err := h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.CategorizationRules.Delete(rule.ID); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "categorization_rule",
+			EntityID:   rule.ID,
+			Action:     "delete",
+			Summary:    "Deleted categorization rule: " + rule.Name,
+			Before:     rule,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete categorization rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Categorization rule deleted successfully"})
+}
+
+// RuleRunOptions controls RunCategorizationRules.
+type RuleRunOptions struct {
+	// StartDate and EndDate limit the run to transactions dated between
+	// them, inclusive; zero values mean no limit.
+	StartDate time.Time
+	EndDate   time.Time
+	// RuleID runs that rule alone, even when it is archived; otherwise the
+	// active rules run as they do on new transactions.
+	RuleID *uuid.UUID
+	// Apply makes the changes; otherwise they are only listed.
+	Apply bool
+	// Actor is the user the changes are recorded for.
+	Actor uuid.UUID
+}
+
+// RuleRunItem is a transaction a rule would change, or changed. Skipped
+// says why a change cannot be made.
+type RuleRunItem struct {
+	TransactionID uuid.UUID    `json:"transactionId"`
+	Date          time.Time    `json:"date"`
+	EventName     string       `json:"eventName"`
+	Status        string       `json:"status"`
+	RuleID        uuid.UUID    `json:"ruleId"`
+	RuleName      string       `json:"ruleName"`
+	Changes       []RuleChange `json:"changes"`
+	Skipped       string       `json:"skipped,omitempty"`
+}
+
+// RuleRunResult lists what a run of the rules changes.
+type RuleRunResult struct {
+	Applied bool          `json:"applied"`
+	Matched int           `json:"matched"`
+	Changed int           `json:"changed"`
+	Items   []RuleRunItem `json:"items"`
+}
+
+// RunCategorizationRules runs the categorization rules over recorded
+// incomes and expenses, both "set" and "suggest" rules alike. Unless
+// options.Apply is set it only lists the changes. Approved transactions
+// are listed but not changed, since they are corrected by voiding them,
+// and neither are those in closed periods. c may be nil outside a
+// request.
+func RunCategorizationRules(c *gin.Context, repos *repository.Repositories, options RuleRunOptions) (*RuleRunResult, error) {
+	var rules []models.CategorizationRule
+	if options.RuleID != nil {
+		rule, err := repos.CategorizationRules.FindByID(*options.RuleID)
+		if err != nil {
+			return nil, err
+		}
+		rules = []models.CategorizationRule{*rule}
+	} else {
+		all, err := repos.CategorizationRules.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range all {
+			if rule.Status == "active" {
+				rules = append(rules, rule)
+			}
+		}
+	}
+
+	transactions, err := repos.Transactions.FindAll(repository.TransactionFilter{
+		StartDate: options.StartDate,
+		EndDate:   options.EndDate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	categories, err := repos.Categories.List()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RuleRunResult{Applied: options.Apply, Items: []RuleRunItem{}}
+	var changed []int
+	for i := range transactions {
+		transaction := &transactions[i]
+		if transaction.ReversalOfID != nil || transaction.Status == "voided" {
+			continue
+		}
+		for j := range rules {
+			if !rules[j].Matches(transaction) {
+				continue
+			}
+			result.Matched++
+			changes := ruleChanges(&rules[j], transaction)
+			if len(changes) == 0 {
+				break
+			}
+			item := RuleRunItem{
+				TransactionID: transaction.ID,
+				Date:          transaction.Date,
+				EventName:     transaction.EventName,
+				Status:        transaction.Status,
+				RuleID:        rules[j].ID,
+				RuleName:      rules[j].Name,
+				Changes:       changes,
+			}
+			var closed *closedPeriodError
+			if transaction.Posted() {
+				item.Skipped = "Approved transactions cannot be changed; void it and record a new one"
+			} else if err := ensurePeriodsOpen(repos, transaction.Date); errors.As(err, &closed) {
+				item.Skipped = closed.Error()
+			} else if err != nil {
+				return nil, err
+			} else if _, err := withRuleChanges(categories, transaction, changes); err != nil {
+				item.Skipped = err.Error()
+			} else {
+				changed = append(changed, i)
+			}
+			result.Items = append(result.Items, item)
+			break
+		}
+	}
+	result.Changed = len(changed)
+	if !options.Apply || len(changed) == 0 {
+		return result, nil
+	}
+
+	byTransaction := map[uuid.UUID]RuleRunItem{}
+	for _, item := range result.Items {
+		byTransaction[item.TransactionID] = item
+	}
+	err = repos.WithTx(func(repos *repository.Repositories) error {
+		for _, i := range changed {
+			transaction := &transactions[i]
+			item := byTransaction[transaction.ID]
+			before := *transaction
+			recategorized, err := withRuleChanges(categories, transaction, item.Changes)
+			if err != nil {
+				return err
+			}
+			*transaction = *recategorized
+			if err := repos.Transactions.Update(transaction); err != nil {
+				return err
+			}
+			if err := repos.Approvals.Supersede(transaction.ID); err != nil {
+				return err
+			}
+			if err := reflagTransaction(repos, transaction); err != nil {
+				return err
+			}
+			err = recordAudit(c, repos, auditEntry{
+				EntityType: "transaction",
+				EntityID:   transaction.ID,
+				Action:     "update",
+				Summary:    "Recategorized transaction by rule " + item.RuleName + ": " + transaction.EventName,
+				Before:     before,
+				After:      transaction,
+				Actor:      options.Actor,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+type RunRulesRequest struct {
+	StartDate string `json:"startDate"`
+	EndDate   string `json:"endDate"`
+	RuleID    string `json:"ruleId"`
+	Apply     bool   `json:"apply"`
+}
+
+// RunRules runs the categorization rules over the transactions already
+// recorded. Without apply it only previews the changes.
+func (h *CategorizationRuleHandler) RunRules(c *gin.Context) {
+	var req RunRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	options := RuleRunOptions{Apply: req.Apply, Actor: userID.(uuid.UUID)}
+	if req.StartDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.StartDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid startDate. Use YYYY-MM-DD"})
+			return
+		}
+		options.StartDate = parsed
+	}
+	if req.EndDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.EndDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid endDate. Use YYYY-MM-DD"})
+			return
+		}
+		options.EndDate = parsed
+	}
+	if req.RuleID != "" {
+		ruleID, err := uuid.Parse(req.RuleID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ruleId"})
+			return
+		}
+		options.RuleID = &ruleID
+	}
+
+	result, err := RunCategorizationRules(c, h.repos, options)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Categorization rule not found"})
+		return
+	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A transaction changed while the rules ran; try again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to run categorization rules"})
+		return
+	}
+
+	message := fmt.Sprintf("%d transactions would change", result.Changed)
+	if req.Apply {
+		message = fmt.Sprintf("%d transactions changed", result.Changed)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    result,
+	})
+}
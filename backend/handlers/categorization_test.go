@@ -0,0 +1,63 @@
+package handlers_test
+
+import (
+	"gkjw-finance-backend/models"
+	"net/http"
+	"testing"
+)
+
+func TestCategorizationRules(t *testing.T) {
+	s := newTestServer(t)
+	utilities := models.Category{Name: "Utilitas", Type: "expense"}
+	if err := s.repos.Categories.Create(&utilities); err != nil {
+		t.Fatal(err)
+	}
+	expense := func(eventName string) map[string]interface{} {
+		return map[string]interface{}{"type": "expense", "category": s.expense.Name, "amount": "350000", "eventName": eventName}
+	}
+	earlier := s.createTransaction(s.admin, expense("Listrik gedung gereja"))
+	approved := s.createTransaction(s.admin, expense("Listrik pastori"))
+	s.approve(approved)
+
+	rule := data(s.do(s.admin, http.MethodPost, "/api/categorization-rules", map[string]interface{}{
+		"name": "Listrik", "priority": 10, "pattern": `\blistrik`, "setCategory": utilities.Name, "mode": "set",
+	}, http.StatusCreated))
+	s.do(s.member, http.MethodGet, "/api/categorization-rules", nil, http.StatusForbidden)
+
+	// A "set" rule categorizes new transactions as they are recorded.
+	created := data(s.do(s.admin, http.MethodGet, "/api/transactions/"+s.createTransaction(s.admin, expense("Bayar listrik Januari")), nil, http.StatusOK))
+	if created["category"] != utilities.Name {
+		t.Errorf("new transaction: category %v, want %s", created["category"], utilities.Name)
+	}
+
+	// A "suggest" rule, like the seeded income keywords, only raises a flag.
+	suggested := s.createTransaction(s.admin, expense("Donasi pembangunan"))
+	tx := data(s.do(s.admin, http.MethodGet, "/api/transactions/"+suggested, nil, http.StatusOK))
+	flags, _ := tx["flags"].([]interface{})
+	if tx["type"] != "expense" || len(flags) != 1 || flags[0].(map[string]interface{})["kind"] != "suggestion" {
+		t.Errorf("suggested transaction: type %v, flags %v; want an expense with a suggestion", tx["type"], tx["flags"])
+	}
+
+	// The batch run previews, then changes what was recorded before the
+	// rule, leaving approved transactions alone.
+	run := map[string]interface{}{"ruleId": rule["id"]}
+	for _, apply := range []bool{false, true} {
+		run["apply"] = apply
+		result := data(s.do(s.admin, http.MethodPost, "/api/categorization-rules/run", run, http.StatusOK))
+		skipped := map[string]string{}
+		for _, item := range result["items"].([]interface{}) {
+			item := item.(map[string]interface{})
+			skipped[item["transactionId"].(string)], _ = item["skipped"].(string)
+		}
+		if result["changed"] != 1.0 || skipped[earlier] != "" || skipped[approved] == "" {
+			t.Errorf("apply %v: changed %v, items %v; want %s changed and %s skipped", apply, result["changed"], skipped, earlier, approved)
+		}
+		category := data(s.do(s.admin, http.MethodGet, "/api/transactions/"+earlier, nil, http.StatusOK))["category"]
+		if want := map[bool]string{false: s.expense.Name, true: utilities.Name}[apply]; category != want {
+			t.Errorf("apply %v: category %v, want %s", apply, category, want)
+		}
+	}
+	if category := data(s.do(s.admin, http.MethodGet, "/api/transactions/"+approved, nil, http.StatusOK))["category"]; category != s.expense.Name {
+		t.Errorf("approved transaction: category %v, want it unchanged", category)
+	}
+}
@@ -2,75 +2,239 @@ package handlers
 
 import (
 	"fmt"
-	"gkjw-finance-backend/config"
 	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
+// DashboardHandler serves the public dashboard aggregations.
+type DashboardHandler struct {
+	repos *repository.Repositories
+}
+
+func NewDashboardHandler(repos *repository.Repositories) *DashboardHandler {
+	return &DashboardHandler{repos: repos}
+}
+
 type DashboardStats struct {
-	TotalIncome         float64 `json:"totalIncome"`
-	TotalExpense        float64 `json:"totalExpense"`
-	CurrentBalance      float64 `json:"currentBalance"`
-	PendingTransactions int64   `json:"pendingTransactions"`
-	MonthlyIncome       float64 `json:"monthlyIncome"`
-	MonthlyExpense      float64 `json:"monthlyExpense"`
+	TotalIncome         models.Money `json:"totalIncome"`
+	TotalExpense        models.Money `json:"totalExpense"`
+	CurrentBalance      models.Money `json:"currentBalance"`
+	PendingTransactions int64        `json:"pendingTransactions"`
+	MonthlyIncome       models.Money `json:"monthlyIncome"`
+	MonthlyExpense      models.Money `json:"monthlyExpense"`
 }
 
 type MonthlyData struct {
-	Month   string  `json:"month"`
-	Income  float64 `json:"income"`
-	Expense float64 `json:"expense"`
+	Month   string       `json:"month"`
+	Income  models.Money `json:"income"`
+	Expense models.Money `json:"expense"`
 }
 
 type CategoryData struct {
-	Category   string  `json:"category"`
-	Amount     float64 `json:"amount"`
-	Percentage float64 `json:"percentage"`
+	CategoryID *uuid.UUID   `json:"categoryId,omitempty"`
+	Category   string       `json:"category"`
+	Amount     models.Money `json:"amount"`
+	Percentage float64      `json:"percentage"`
+}
+
+// FundBalance is the movement of one fund and payment method over a
+// period. Closing is Opening + Income - Expense + TransfersIn - TransfersOut.
+type FundBalance struct {
+	FundID         uuid.UUID    `json:"fundId"`
+	FundName       string       `json:"fundName"`
+	PaymentMethod  string       `json:"paymentMethod"`
+	OpeningBalance models.Money `json:"openingBalance"`
+	Income         models.Money `json:"income"`
+	Expense        models.Money `json:"expense"`
+	TransfersIn    models.Money `json:"transfersIn"`
+	TransfersOut   models.Money `json:"transfersOut"`
+	ClosingBalance models.Money `json:"closingBalance"`
+}
+
+// dashboardFundID reads the optional fundId query parameter. It writes a
+// 400 response and returns false when the value is not a UUID.
+func dashboardFundID(c *gin.Context) (uuid.UUID, bool) {
+	fundID := c.Query("fundId")
+	if fundID == "" || fundID == "all" {
+		return uuid.Nil, true
+	}
+	parsed, err := uuid.Parse(fundID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fundId"})
+		return uuid.Nil, false
+	}
+	return parsed, true
 }
 
-func GetDashboardStats(c *gin.Context) {
+// currentBalance returns the balance of fundID (all funds when nil) after
+// all posted history, starting from its opening balances.
+func (h *DashboardHandler) currentBalance(fundID uuid.UUID) (models.Money, error) {
+	balances, err := balancesBefore(h.repos, fundID, time.Time{})
+	if err != nil {
+		return 0, err
+	}
+	return selectedBalance(repository.TransactionFilter{FundID: fundID}, balances), nil
+}
+
+func (h *DashboardHandler) GetDashboardStats(c *gin.Context) {
+	fundID, ok := dashboardFundID(c)
+	if !ok {
+		return
+	}
+
 	var stats DashboardStats
+	transactions := h.repos.Transactions
 
-	// Total income (approved only)
-	config.DB.Model(&models.Transaction{}).
-		Where("type = ? AND status = ?", "income", "approved").
-		Select("COALESCE(SUM(amount), 0)").
-		Scan(&stats.TotalIncome)
+	// Total income (posted only; a voided transaction is cancelled by its reversal)
+	stats.TotalIncome, _ = transactions.Sum(repository.TransactionFilter{Type: "income", Status: repository.StatusPosted, FundID: fundID})
 
-	// Total expense (approved only)
-	config.DB.Model(&models.Transaction{}).
-		Where("type = ? AND status = ?", "expense", "approved").
-		Select("COALESCE(SUM(amount), 0)").
-		Scan(&stats.TotalExpense)
+	// Total expense (posted only)
+	stats.TotalExpense, _ = transactions.Sum(repository.TransactionFilter{Type: "expense", Status: repository.StatusPosted, FundID: fundID})
 
-	// Current balance
-	stats.CurrentBalance = stats.TotalIncome - stats.TotalExpense
+	// Current balance, including opening balances and transfers into or
+	// out of the fund
+	stats.CurrentBalance, _ = h.currentBalance(fundID)
 
 	// Pending transactions count
-	config.DB.Model(&models.Transaction{}).
-		Where("status = ?", "pending").
-		Count(&stats.PendingTransactions)
+	stats.PendingTransactions, _ = transactions.Count(repository.TransactionFilter{Status: "pending", FundID: fundID})
 
 	// Monthly income (current month)
 	startOfMonth := time.Now().AddDate(0, 0, -time.Now().Day()+1)
-	config.DB.Model(&models.Transaction{}).
-		Where("type = ? AND status = ? AND date >= ?", "income", "approved", startOfMonth).
-		Select("COALESCE(SUM(amount), 0)").
-		Scan(&stats.MonthlyIncome)
+	stats.MonthlyIncome, _ = transactions.Sum(repository.TransactionFilter{
+		Type: "income", Status: repository.StatusPosted, StartDate: startOfMonth, FundID: fundID,
+	})
 
 	// Monthly expense (current month)
-	config.DB.Model(&models.Transaction{}).
-		Where("type = ? AND status = ? AND date >= ?", "expense", "approved", startOfMonth).
-		Select("COALESCE(SUM(amount), 0)").
-		Scan(&stats.MonthlyExpense)
+	stats.MonthlyExpense, _ = transactions.Sum(repository.TransactionFilter{
+		Type: "expense", Status: repository.StatusPosted, StartDate: startOfMonth, FundID: fundID,
+	})
 
 	c.JSON(http.StatusOK, gin.H{"data": stats})
 }
 
-func GetMonthlyData(c *gin.Context) {
+// GetBalances returns opening balance, income, expense, transfers and
+// closing balance for every fund and payment method between startDate and
+// endDate (both optional). Only posted transactions count. Without a
+// startDate the period starts at the earliest opening balance.
+func (h *DashboardHandler) GetBalances(c *gin.Context) {
+	query, err := transactionFilterFromQuery(c, h.repos)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	fundID := query.FundID
+	if query.StartDate.IsZero() {
+		if query.StartDate, err = defaultStartDate(h.repos, fundID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute balances"})
+			return
+		}
+	}
+
+	period := repository.TransactionFilter{
+		Status:    repository.StatusPosted,
+		FundID:    fundID,
+		StartDate: query.StartDate,
+		EndDate:   query.EndDate,
+	}
+	movements, err := h.repos.Transactions.SumByFundAndMethod(period)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute balances"})
+		return
+	}
+
+	var openings map[balanceKey]models.Money
+	if !query.StartDate.IsZero() {
+		if openings, err = balancesBefore(h.repos, fundID, query.StartDate); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute balances"})
+			return
+		}
+	}
+
+	funds, err := h.repos.Funds.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch funds"})
+		return
+	}
+
+	// One row per fund and payment method, plus rows for movements of
+	// funds that no longer exist or transactions without a fund.
+	rows := map[balanceKey]*FundBalance{}
+	row := func(id uuid.UUID, paymentMethod string) *FundBalance {
+		k := balanceKey{id, paymentMethod}
+		if rows[k] == nil {
+			rows[k] = &FundBalance{FundID: id, PaymentMethod: paymentMethod}
+		}
+		return rows[k]
+	}
+	names := map[uuid.UUID]string{}
+	for _, fund := range funds {
+		names[fund.ID] = fund.Name
+		if fundID == uuid.Nil || fund.ID == fundID {
+			row(fund.ID, "cash")
+			row(fund.ID, "bank")
+		}
+	}
+
+	for k, balance := range openings {
+		row(k.fundID, k.paymentMethod).OpeningBalance += balance
+	}
+	for _, total := range movements {
+		if fundID != uuid.Nil && total.FundID != fundID {
+			continue
+		}
+		r := row(total.FundID, total.PaymentMethod)
+		r.Income += total.Income
+		r.Expense += total.Expense
+		r.TransfersIn += total.TransferIn
+		r.TransfersOut += total.TransferOut
+	}
+
+	var totals FundBalance
+	balances := make([]FundBalance, 0, len(rows))
+	for _, r := range rows {
+		r.FundName = names[r.FundID]
+		r.ClosingBalance = r.OpeningBalance + r.Income - r.Expense + r.TransfersIn - r.TransfersOut
+		balances = append(balances, *r)
+
+		totals.OpeningBalance += r.OpeningBalance
+		totals.Income += r.Income
+		totals.Expense += r.Expense
+		totals.TransfersIn += r.TransfersIn
+		totals.TransfersOut += r.TransfersOut
+		totals.ClosingBalance += r.ClosingBalance
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		if balances[i].FundName != balances[j].FundName {
+			return balances[i].FundName < balances[j].FundName
+		}
+		return balances[i].PaymentMethod < balances[j].PaymentMethod
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": balances,
+		"totals": gin.H{
+			"openingBalance": totals.OpeningBalance,
+			"income":         totals.Income,
+			"expense":        totals.Expense,
+			"transfersIn":    totals.TransfersIn,
+			"transfersOut":   totals.TransfersOut,
+			"closingBalance": totals.ClosingBalance,
+		},
+	})
+}
+
+func (h *DashboardHandler) GetMonthlyData(c *gin.Context) {
+	fundID, ok := dashboardFundID(c)
+	if !ok {
+		return
+	}
+
 	var monthlyData []MonthlyData
 
 	// Get last 6 months data
@@ -79,19 +243,13 @@ func GetMonthlyData(c *gin.Context) {
 		monthStart := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
 		monthEnd := monthStart.AddDate(0, 1, -1)
 
-		var income, expense float64
-
-		config.DB.Model(&models.Transaction{}).
-			Where("type = ? AND status = ? AND date >= ? AND date <= ?", 
-				"income", "approved", monthStart, monthEnd).
-			Select("COALESCE(SUM(amount), 0)").
-			Scan(&income)
+		income, _ := h.repos.Transactions.Sum(repository.TransactionFilter{
+			Type: "income", Status: repository.StatusPosted, StartDate: monthStart, EndDate: monthEnd, FundID: fundID,
+		})
 
-		config.DB.Model(&models.Transaction{}).
-			Where("type = ? AND status = ? AND date >= ? AND date <= ?", 
-				"expense", "approved", monthStart, monthEnd).
-			Select("COALESCE(SUM(amount), 0)").
-			Scan(&expense)
+		expense, _ := h.repos.Transactions.Sum(repository.TransactionFilter{
+			Type: "expense", Status: repository.StatusPosted, StartDate: monthStart, EndDate: monthEnd, FundID: fundID,
+		})
 
 		monthlyData = append(monthlyData, MonthlyData{
 			Month:   month.Format("Jan 2006"),
@@ -103,7 +261,12 @@ func GetMonthlyData(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": monthlyData})
 }
 
-func GetCategoryData(c *gin.Context) {
+func (h *DashboardHandler) GetCategoryData(c *gin.Context) {
+	fundID, ok := dashboardFundID(c)
+	if !ok {
+		return
+	}
+
 	var categoryData []CategoryData
 
 	// Get type from query parameter (default: expense)
@@ -134,42 +297,38 @@ func GetCategoryData(c *gin.Context) {
 	}
 
 	// Check total count by type
-	var totalIncomeCount, totalExpenseCount int64
-	config.DB.Model(&models.Transaction{}).Where("status = ?", "approved").Where("type = ?", "income").Count(&totalIncomeCount)
-	config.DB.Model(&models.Transaction{}).Where("status = ?", "approved").Where("type = ?", "expense").Count(&totalExpenseCount)
+	totalIncomeCount, _ := h.repos.Transactions.Count(repository.TransactionFilter{Status: repository.StatusPosted, Type: "income"})
+	totalExpenseCount, _ := h.repos.Transactions.Count(repository.TransactionFilter{Status: repository.StatusPosted, Type: "expense"})
 	fmt.Printf("Total approved income count: %d\n", totalIncomeCount)
 	fmt.Printf("Total approved expense count: %d\n", totalExpenseCount)
 
-	// Build query
-	query := config.DB.Model(&models.Transaction{}).
-		Where("type = ? AND status = ?", transactionType, "approved")
-	
-	if !startDate.IsZero() {
-		query = query.Where("date >= ?", startDate)
+	filter := repository.TransactionFilter{
+		Type:      transactionType,
+		Status:    repository.StatusPosted,
+		StartDate: startDate,
+		FundID:    fundID,
 	}
 
 	// Get total for the period
-	var totalAmount float64
-	query.Select("COALESCE(SUM(amount), 0)").Scan(&totalAmount)
+	totalAmount, _ := h.repos.Transactions.Sum(filter)
 
-	fmt.Printf("Total %s amount for period: %f\n", transactionType, totalAmount)
+	fmt.Printf("Total %s amount for period: %s\n", transactionType, totalAmount)
 
 	// Get by category
-	type CategorySum struct {
-		Category string
-		Amount   float64
+	categorySums, err := h.repos.Transactions.SumByCategory(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch category data"})
+		return
 	}
 
-	var categorySums []CategorySum
-	categoryQuery := config.DB.Model(&models.Transaction{}).
-		Select("category, COALESCE(SUM(amount), 0) as amount").
-		Where("type = ? AND status = ?", transactionType, "approved")
-	
-	if !startDate.IsZero() {
-		categoryQuery = categoryQuery.Where("date >= ?", startDate)
+	if c.Query("rollup") == "true" {
+		categories, err := h.repos.Categories.List()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+			return
+		}
+		categorySums = rollUpCategories(categories, categorySums)
 	}
-	
-	categoryQuery.Group("category").Scan(&categorySums)
 
 	fmt.Printf("Found %d categories for %s\n", len(categorySums), transactionType)
 
@@ -177,12 +336,13 @@ func GetCategoryData(c *gin.Context) {
 	for _, cs := range categorySums {
 		percentage := 0.0
 		if totalAmount > 0 {
-			percentage = (cs.Amount / totalAmount) * 100
+			percentage = float64(cs.Amount) / float64(totalAmount) * 100
 		}
 
-		fmt.Printf("  Category: %s, Amount: %f, Percentage: %f%%\n", cs.Category, cs.Amount, percentage)
+		fmt.Printf("  Category: %s, Amount: %s, Percentage: %f%%\n", cs.Category, cs.Amount, percentage)
 
 		categoryData = append(categoryData, CategoryData{
+			CategoryID: cs.CategoryID,
 			Category:   cs.Category,
 			Amount:     cs.Amount,
 			Percentage: percentage,
@@ -191,3 +351,30 @@ func GetCategoryData(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"data": categoryData})
 }
+
+// rollUpCategories adds the totals of subcategories to their top-level
+// category. Totals without a category ID, or whose category no longer
+// exists, are kept as they are.
+func rollUpCategories(categories []models.Category, totals []repository.CategoryTotal) []repository.CategoryTotal {
+	var rolled []repository.CategoryTotal
+	index := map[uuid.UUID]int{}
+	for _, total := range totals {
+		var root *models.Category
+		if total.CategoryID != nil {
+			root = rootCategory(categories, *total.CategoryID)
+		}
+		if root == nil {
+			rolled = append(rolled, total)
+			continue
+		}
+		i, ok := index[root.ID]
+		if !ok {
+			i = len(rolled)
+			index[root.ID] = i
+			id := root.ID
+			rolled = append(rolled, repository.CategoryTotal{CategoryID: &id, Category: root.Name})
+		}
+		rolled[i].Amount += total.Amount
+	}
+	return rolled
+}
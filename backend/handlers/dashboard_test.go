@@ -0,0 +1,53 @@
+package handlers_test
+
+import (
+	"gkjw-finance-backend/models"
+	"net/http"
+	"testing"
+)
+
+func TestCategoryDataSplitsLines(t *testing.T) {
+	s := newTestServer(t)
+	worship := models.Category{Name: "Kegiatan Ibadah", Type: "expense"}
+	if err := s.repos.Categories.Create(&worship); err != nil {
+		t.Fatal(err)
+	}
+	building := models.Fund{Name: "Dana Pembangunan"}
+	if err := s.repos.Funds.Create(&building); err != nil {
+		t.Fatal(err)
+	}
+	s.approve(s.createTransaction(s.admin, map[string]interface{}{
+		"type": "expense", "amount": "150000",
+		"lines": []interface{}{
+			map[string]interface{}{"category": s.expense.Name, "amount": "100000", "fundId": s.fund.ID},
+			map[string]interface{}{"category": worship.Name, "amount": "50000", "fundId": building.ID},
+		},
+	}))
+	s.approve(s.createTransaction(s.admin, map[string]interface{}{"type": "expense", "category": s.expense.Name, "amount": "20000"}))
+
+	tests := []struct {
+		query string
+		want  map[string]float64
+	}{
+		{"", map[string]float64{s.expense.Name: 120000, worship.Name: 50000}},
+		{"&fundId=" + s.fund.ID.String(), map[string]float64{s.expense.Name: 120000}},
+		{"&fundId=" + building.ID.String(), map[string]float64{worship.Name: 50000}},
+	}
+	for _, tt := range tests {
+		got := map[string]float64{}
+		for _, item := range list(s.do(s.admin, http.MethodGet, "/api/dashboard/category?type=expense&period=all"+tt.query, nil, http.StatusOK)) {
+			row := item.(map[string]interface{})
+			got[row["category"].(string)] = row["amount"].(float64)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%q: categories %v, want %v", tt.query, got, tt.want)
+			continue
+		}
+		for category, amount := range tt.want {
+			if got[category] != amount {
+				t.Errorf("%q: categories %v, want %v", tt.query, got, tt.want)
+				break
+			}
+		}
+	}
+}
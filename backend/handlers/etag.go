@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Transactions, funds and categories are served with an ETag holding their
+// version. A client that sends it back in If-Match on PUT or DELETE gets
+// 412 Precondition Failed, with the current representation, when someone
+// else changed the record in the meantime.
+
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", etag(version))
+}
+
+// ifMatch reports whether the If-Match header of c, if any, names version.
+// Weak tags are compared by value.
+func ifMatch(c *gin.Context, version int) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// respondStale answers 412 with current, the record as it is now.
+func respondStale(c *gin.Context, current interface{}, version int) {
+	setETag(c, version)
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"error": "This record was changed by someone else; review the current version and try again",
+		"data":  current,
+	})
+}
@@ -0,0 +1,561 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// EventHandler manages events and their reports.
+type EventHandler struct {
+	repos *repository.Repositories
+}
+
+func NewEventHandler(repos *repository.Repositories) *EventHandler {
+	return &EventHandler{repos: repos}
+}
+
+// EventRequest describes an event. EndDate is left empty for a one-day
+// event; FundID is optional.
+type EventRequest struct {
+	Name          string       `json:"name" binding:"required"`
+	StartDate     string       `json:"startDate" binding:"required"`
+	EndDate       string       `json:"endDate"`
+	Commission    string       `json:"commission"`
+	FundID        string       `json:"fundId"`
+	BudgetIncome  models.Money `json:"budgetIncome" binding:"gte=0"`
+	BudgetExpense models.Money `json:"budgetExpense" binding:"gte=0"`
+	Description   string       `json:"description"`
+}
+
+// sameEventName reports whether two event names are the same once case and
+// surrounding spaces are ignored, as "Natal 2025" and " natal 2025".
+func sameEventName(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+// matchesEventName reports whether eventName is one of names.
+func matchesEventName(eventName string, names []string) bool {
+	for _, name := range names {
+		if sameEventName(eventName, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyEventRequest validates req and copies it onto event.
+func (h *EventHandler) applyEventRequest(req EventRequest, event *models.Event) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return errors.New("Event name is required")
+	}
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return errors.New("Invalid startDate format. Use YYYY-MM-DD")
+	}
+	var endDate *time.Time
+	if req.EndDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.EndDate)
+		if err != nil {
+			return errors.New("Invalid endDate format. Use YYYY-MM-DD")
+		}
+		if parsed.Before(startDate) {
+			return errors.New("endDate must not be before startDate")
+		}
+		endDate = &parsed
+	}
+	var fundID *uuid.UUID
+	if req.FundID != "" {
+		parsed, err := uuid.Parse(req.FundID)
+		if err != nil {
+			return errors.New("Invalid fundId")
+		}
+		if _, err := h.repos.Funds.FindByID(parsed); err != nil {
+			return errors.New("Fund not found")
+		}
+		fundID = &parsed
+	}
+
+	event.Name = name
+	event.StartDate = startDate
+	event.EndDate = endDate
+	event.Commission = strings.TrimSpace(req.Commission)
+	event.FundID = fundID
+	event.Fund = nil
+	event.BudgetIncome = req.BudgetIncome
+	event.BudgetExpense = req.BudgetExpense
+	event.Description = req.Description
+	return nil
+}
+
+// nameConflict returns the error message when another event than event
+// already has its name, or "" when the name is free.
+func (h *EventHandler) nameConflict(event *models.Event) (string, error) {
+	events, err := h.repos.Events.List()
+	if err != nil {
+		return "", err
+	}
+	for _, other := range events {
+		if other.ID != event.ID && sameEventName(other.Name, event.Name) {
+			return fmt.Sprintf("An event named %q already exists", other.Name), nil
+		}
+	}
+	return "", nil
+}
+
+// findEvent loads the event named by the id parameter, or answers 404 and
+// returns false.
+func (h *EventHandler) findEvent(c *gin.Context) (*models.Event, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return nil, false
+	}
+	event, err := h.repos.Events.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return nil, false
+	}
+	return event, true
+}
+
+func (h *EventHandler) GetEvents(c *gin.Context) {
+	events, err := h.repos.Events.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events"})
+		return
+	}
+	if events == nil {
+		events = []models.Event{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": events})
+}
+
+func (h *EventHandler) GetEvent(c *gin.Context) {
+	event, ok := h.findEvent(c)
+	if !ok {
+		return
+	}
+	setETag(c, event.Version)
+	c.JSON(http.StatusOK, gin.H{"data": event})
+}
+
+// CreateEvent adds an event. Names are unique ignoring case, so that
+// transactions are not split over near-identical events; the unique index
+// on LOWER(name) settles two requests racing for the same name.
+func (h *EventHandler) CreateEvent(c *gin.Context) {
+	var req EventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var event models.Event
+	if err := h.applyEventRequest(req, &event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	conflict, err := h.nameConflict(&event)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events"})
+		return
+	}
+	if conflict != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": conflict})
+		return
+	}
+
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.Events.Create(&event); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "event",
+			EntityID:   event.ID,
+			Action:     "create",
+			Summary:    "Created event: " + event.Name,
+			After:      event,
+		})
+	})
+	if errors.Is(err, repository.ErrDuplicate) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("An event named %q already exists", event.Name)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create event"})
+		return
+	}
+	setETag(c, event.Version)
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Event created successfully",
+		"data":    event,
+	})
+}
+
+// UpdateEvent replaces an event. A new name is also recorded as the event
+// name of its transactions, so it is refused while any of them is dated in
+// a closed accounting period.
+func (h *EventHandler) UpdateEvent(c *gin.Context) {
+	event, ok := h.findEvent(c)
+	if !ok {
+		return
+	}
+	if !ifMatch(c, event.Version) {
+		respondStale(c, event, event.Version)
+		return
+	}
+	var req EventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	before := *event
+	if err := h.applyEventRequest(req, event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	conflict, err := h.nameConflict(event)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events"})
+		return
+	}
+	if conflict != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": conflict})
+		return
+	}
+	var linked []models.Transaction
+	if event.Name != before.Name {
+		linked, err = h.repos.Transactions.FindAll(repository.TransactionFilter{EventID: event.ID})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+			return
+		}
+		dates := make([]time.Time, len(linked))
+		for i, tx := range linked {
+			dates[i] = tx.Date
+		}
+		if !checkPeriodsOpen(c, h.repos, dates...) {
+			return
+		}
+	}
+
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.Events.Update(event); err != nil {
+			return err
+		}
+		if len(linked) > 0 {
+			if err := repos.Events.Relabel(event.ID, event.Name); err != nil {
+				return err
+			}
+		}
+		for _, tx := range linked {
+			after := tx
+			after.EventName = event.Name
+			after.Version++
+			if err := recordAudit(c, repos, auditEntry{
+				EntityType: "transaction",
+				EntityID:   tx.ID,
+				Action:     "update",
+				Summary:    "Renamed event " + before.Name + " to " + event.Name + " on transaction",
+				Before:     tx,
+				After:      after,
+			}); err != nil {
+				return err
+			}
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "event",
+			EntityID:   event.ID,
+			Action:     "update",
+			Summary:    "Updated event: " + event.Name,
+			Before:     before,
+			After:      event,
+		})
+	})
+	if errors.Is(err, repository.ErrVersionConflict) {
+		if current, err := h.repos.Events.FindByID(event.ID); err == nil {
+			respondStale(c, current, current.Version)
+			return
+		}
+	}
+	if errors.Is(err, repository.ErrDuplicate) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("An event named %q already exists", event.Name)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update event"})
+		return
+	}
+	if updated, err := h.repos.Events.FindByID(event.ID); err == nil {
+		event = updated
+	}
+	setETag(c, event.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Event updated successfully",
+		"data":    event,
+	})
+}
+
+// DeleteEvent removes an event no transaction is linked to.
+func (h *EventHandler) DeleteEvent(c *gin.Context) {
+	event, ok := h.findEvent(c)
+	if !ok {
+		return
+	}
+	if !ifMatch(c, event.Version) {
+		respondStale(c, event, event.Version)
+		return
+	}
+	linked, err := h.repos.Transactions.Count(repository.TransactionFilter{EventID: event.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		return
+	}
+	if linked > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Event has %d linked transactions and cannot be deleted", linked)})
+		return
+	}
+
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.Events.Delete(event.ID); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "event",
+			EntityID:   event.ID,
+			Action:     "delete",
+			Summary:    "Deleted event: " + event.Name,
+			Before:     event,
+		})
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete event"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Event deleted successfully"})
+}
+
+// LinkEventRequest selects the transactions to link to an event: those not
+// yet linked to any event whose event name is one of EventNames, ignoring
+// case and surrounding spaces. EventNames defaults to the name of the
+// event. Without Apply the matches are only listed.
+type LinkEventRequest struct {
+	EventNames []string `json:"eventNames"`
+	StartDate  string   `json:"startDate"`
+	EndDate    string   `json:"endDate"`
+	Apply      bool     `json:"apply"`
+}
+
+// linkRefusal is a matching transaction LinkTransactions left alone, and
+// why.
+type linkRefusal struct {
+	TransactionID uuid.UUID `json:"transactionId"`
+	Date          time.Time `json:"date"`
+	EventName     string    `json:"eventName"`
+	Error         string    `json:"error"`
+}
+
+// LinkTransactions links transactions recorded with a free-text event name
+// to the event, renaming them after it. Transactions dated in a closed
+// accounting period are left alone and listed as refused.
+func (h *EventHandler) LinkTransactions(c *gin.Context) {
+	event, ok := h.findEvent(c)
+	if !ok {
+		return
+	}
+	var req LinkEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	names := req.EventNames
+	if len(names) == 0 {
+		names = []string{event.Name}
+	}
+	var filter repository.TransactionFilter
+	var err error
+	if req.StartDate != "" {
+		if filter.StartDate, err = time.Parse("2006-01-02", req.StartDate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid startDate format. Use YYYY-MM-DD"})
+			return
+		}
+	}
+	if req.EndDate != "" {
+		if filter.EndDate, err = time.Parse("2006-01-02", req.EndDate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid endDate format. Use YYYY-MM-DD"})
+			return
+		}
+	}
+
+	transactions, err := h.repos.Transactions.FindAll(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		return
+	}
+	matches := []models.Transaction{}
+	refused := []linkRefusal{}
+	for _, tx := range transactions {
+		if tx.EventID != nil || !matchesEventName(tx.EventName, names) {
+			continue
+		}
+		err := ensurePeriodsOpen(h.repos, tx.Date)
+		var closed *closedPeriodError
+		if errors.As(err, &closed) {
+			refused = append(refused, linkRefusal{TransactionID: tx.ID, Date: tx.Date, EventName: tx.EventName, Error: closed.Error()})
+			continue
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check accounting periods"})
+			return
+		}
+		matches = append(matches, tx)
+	}
+
+	if !req.Apply {
+		c.JSON(http.StatusOK, gin.H{
+			"message": fmt.Sprintf("%d transactions would be linked to %s", len(matches), event.Name),
+			"data":    matches,
+			"refused": refused,
+		})
+		return
+	}
+
+	ids := make([]uuid.UUID, len(matches))
+	for i, tx := range matches {
+		ids[i] = tx.ID
+	}
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.Events.Link(event, ids); err != nil {
+			return err
+		}
+		for i := range matches {
+			before := matches[i]
+			after := before
+			after.EventID = &event.ID
+			after.EventName = event.Name
+			after.Version++
+			matches[i] = after
+			if err := recordAudit(c, repos, auditEntry{
+				EntityType: "transaction",
+				EntityID:   after.ID,
+				Action:     "update",
+				Summary:    "Linked transaction " + before.EventName + " to event " + event.Name,
+				Before:     before,
+				After:      after,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link transactions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("%d transactions linked to %s", len(matches), event.Name),
+		"data":    matches,
+		"refused": refused,
+	})
+}
+
+// EventReport compares the posted income and expense of the transactions
+// linked to an event with its budget. IncomeVariance and ExpenseVariance
+// are positive when the event did better than budgeted: more income, or
+// less expense.
+type EventReport struct {
+	Event             *models.Event  `json:"event"`
+	BudgetIncome      models.Money   `json:"budgetIncome"`
+	ActualIncome      models.Money   `json:"actualIncome"`
+	IncomeVariance    models.Money   `json:"incomeVariance"`
+	BudgetExpense     models.Money   `json:"budgetExpense"`
+	ActualExpense     models.Money   `json:"actualExpense"`
+	ExpenseVariance   models.Money   `json:"expenseVariance"`
+	BudgetNet         models.Money   `json:"budgetNet"`
+	ActualNet         models.Money   `json:"actualNet"`
+	IncomeByCategory  []CategoryData `json:"incomeByCategory"`
+	ExpenseByCategory []CategoryData `json:"expenseByCategory"`
+	TransactionCount  int64          `json:"transactionCount"`
+}
+
+// eventCategoryData returns the posted totals per category of the given
+// type for the event, with their share of total.
+func eventCategoryData(repos *repository.Repositories, event *models.Event, transactionType string, total models.Money) ([]CategoryData, error) {
+	totals, err := repos.Transactions.SumByCategory(repository.TransactionFilter{
+		Status:  repository.StatusPosted,
+		Type:    transactionType,
+		EventID: event.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	data := []CategoryData{}
+	for _, ct := range totals {
+		percentage := 0.0
+		if total > 0 {
+			percentage = float64(ct.Amount) / float64(total) * 100
+		}
+		data = append(data, CategoryData{
+			CategoryID: ct.CategoryID,
+			Category:   ct.Category,
+			Amount:     ct.Amount,
+			Percentage: percentage,
+		})
+	}
+	return data, nil
+}
+
+// GetEventReport returns the budget against the actual income and expense
+// of an event. The same figures are exported with the transactions by the
+// report exports given eventId.
+func (h *EventHandler) GetEventReport(c *gin.Context) {
+	event, ok := h.findEvent(c)
+	if !ok {
+		return
+	}
+	filter := repository.TransactionFilter{Status: repository.StatusPosted, EventID: event.ID}
+	report := EventReport{
+		Event:         event,
+		BudgetIncome:  event.BudgetIncome,
+		BudgetExpense: event.BudgetExpense,
+		BudgetNet:     event.BudgetIncome - event.BudgetExpense,
+	}
+	var err error
+	if report.TransactionCount, err = h.repos.Transactions.Count(filter); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute event report"})
+		return
+	}
+	filter.Type = "income"
+	if report.ActualIncome, err = h.repos.Transactions.Sum(filter); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute event report"})
+		return
+	}
+	filter.Type = "expense"
+	if report.ActualExpense, err = h.repos.Transactions.Sum(filter); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute event report"})
+		return
+	}
+	report.IncomeVariance = report.ActualIncome - report.BudgetIncome
+	report.ExpenseVariance = report.BudgetExpense - report.ActualExpense
+	report.ActualNet = report.ActualIncome - report.ActualExpense
+	if report.IncomeByCategory, err = eventCategoryData(h.repos, event, "income", report.ActualIncome); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute event report"})
+		return
+	}
+	if report.ExpenseByCategory, err = eventCategoryData(h.repos, event, "expense", report.ActualExpense); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute event report"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
@@ -0,0 +1,57 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestLinkTransactionsRefusesClosedPeriods(t *testing.T) {
+	s := newTestServer(t)
+	closed := s.createTransaction(s.admin, map[string]interface{}{"eventName": "natal 2025 ", "date": "2025-01-05"})
+	open := s.createTransaction(s.admin, map[string]interface{}{"eventName": "Natal 2025", "date": "2025-02-05"})
+	s.createTransaction(s.admin, map[string]interface{}{"eventName": "Paskah 2025", "date": "2025-02-06"})
+	s.do(s.admin, http.MethodPost, "/api/periods/close", map[string]interface{}{"month": "2025-01"}, http.StatusOK)
+	event := data(s.do(s.admin, http.MethodPost, "/api/events", map[string]interface{}{"name": "Natal 2025", "startDate": "2025-12-25"}, http.StatusCreated))
+	path := "/api/events/" + event["id"].(string) + "/link"
+
+	for _, apply := range []bool{false, true} {
+		out := s.do(s.admin, http.MethodPost, path, map[string]interface{}{"apply": apply}, http.StatusOK)
+		linked, refused := list(out), out["refused"].([]interface{})
+		if len(linked) != 1 || linked[0].(map[string]interface{})["id"] != open {
+			t.Errorf("apply %v: linked %v, want only %s", apply, linked, open)
+		}
+		if len(refused) != 1 || refused[0].(map[string]interface{})["transactionId"] != closed {
+			t.Errorf("apply %v: refused %v, want only %s", apply, refused, closed)
+		}
+	}
+
+	got := data(s.do(s.admin, http.MethodGet, "/api/transactions/"+closed, nil, http.StatusOK))
+	if got["eventId"] != nil || got["eventName"] != "natal 2025 " {
+		t.Errorf("transaction in the closed period: event %v %q, want it unchanged", got["eventId"], got["eventName"])
+	}
+}
+
+func TestRenameEventUpdatesLinkedTransactions(t *testing.T) {
+	s := newTestServer(t)
+	event := data(s.do(s.admin, http.MethodPost, "/api/events", map[string]interface{}{"name": "Natal 2025", "startDate": "2025-01-05"}, http.StatusCreated))
+	eventPath := "/api/events/" + event["id"].(string)
+	id := s.createTransaction(s.admin, map[string]interface{}{"eventId": event["id"], "date": "2025-01-05"})
+	before := data(s.do(s.admin, http.MethodGet, "/api/transactions/"+id, nil, http.StatusOK))
+	period := data(s.do(s.admin, http.MethodPost, "/api/periods/close", map[string]interface{}{"month": "2025-01"}, http.StatusOK))
+
+	rename := map[string]interface{}{"name": "Perayaan Natal 2025", "startDate": "2025-01-05"}
+	s.do(s.admin, http.MethodPut, eventPath, rename, http.StatusConflict)
+	s.do(s.admin, http.MethodPut, eventPath, map[string]interface{}{"name": "Natal 2025", "startDate": "2025-01-05", "commission": "Komisi Remaja"}, http.StatusOK)
+
+	s.do(s.admin, http.MethodPost, "/api/periods/"+period["id"].(string)+"/reopen", map[string]interface{}{"reason": "Ganti nama kegiatan"}, http.StatusOK)
+	s.do(s.admin, http.MethodPut, eventPath, rename, http.StatusOK)
+	after := data(s.do(s.admin, http.MethodGet, "/api/transactions/"+id, nil, http.StatusOK))
+	if after["eventName"] != "Perayaan Natal 2025" || after["version"] != before["version"].(float64)+1 {
+		t.Errorf("transaction: event %v version %v, want Perayaan Natal 2025 at version %v", after["eventName"], after["version"], before["version"].(float64)+1)
+	}
+
+	logs := list(s.do(s.admin, http.MethodGet, "/api/logs?entityType=transaction&entityId="+id+"&action=update", nil, http.StatusOK))
+	if len(logs) != 1 {
+		t.Errorf("got %d audit entries for the rename, want 1", len(logs))
+	}
+}
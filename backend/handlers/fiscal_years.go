@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"errors"
+	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// FiscalYearHandler serves fiscal years, their year-end close and the
+// opening balances of funds.
+type FiscalYearHandler struct {
+	repos *repository.Repositories
+}
+
+func NewFiscalYearHandler(repos *repository.Repositories) *FiscalYearHandler {
+	return &FiscalYearHandler{repos: repos}
+}
+
+type CreateFiscalYearRequest struct {
+	Name      string `json:"name" binding:"required"`
+	StartDate string `json:"startDate" binding:"required"`
+	EndDate   string `json:"endDate" binding:"required"`
+}
+
+type SetOpeningBalanceRequest struct {
+	FundID        string       `json:"fundId" binding:"required"`
+	PaymentMethod string       `json:"paymentMethod" binding:"required,oneof=cash bank"`
+	AsOf          string       `json:"asOf" binding:"required"`
+	Amount        models.Money `json:"amount"`
+	Note          string       `json:"note"`
+}
+
+var errPendingInFiscalYear = errors.New("fiscal year has pending transactions")
+
+func (h *FiscalYearHandler) GetFiscalYears(c *gin.Context) {
+	years, err := h.repos.FiscalYears.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch fiscal years"})
+		return
+	}
+	if years == nil {
+		years = []models.FiscalYear{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": years})
+}
+
+// CreateFiscalYear opens a fiscal year. Fiscal years may not overlap.
+func (h *FiscalYearHandler) CreateFiscalYear(c *gin.Context) {
+	var req CreateFiscalYearRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid startDate. Use YYYY-MM-DD"})
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid endDate. Use YYYY-MM-DD"})
+		return
+	}
+	if !endDate.After(startDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "endDate must be after startDate"})
+		return
+	}
+
+	years, err := h.repos.FiscalYears.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch fiscal years"})
+		return
+	}
+	for _, year := range years {
+		if year.Name == req.Name {
+			c.JSON(http.StatusConflict, gin.H{"error": "Fiscal year name already exists"})
+			return
+		}
+		if !startDate.After(year.EndDate) && !endDate.Before(year.StartDate) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Fiscal year overlaps " + year.Name})
+			return
+		}
+	}
+
+	year := models.FiscalYear{
+		Name:      req.Name,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Status:    "open",
+	}
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.FiscalYears.Create(&year); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "fiscal_year",
+			EntityID:   year.ID,
+			Action:     "create",
+			Summary:    "Created fiscal year: " + year.Name,
+			After:      year,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create fiscal year"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Fiscal year created successfully",
+		"data":    year,
+	})
+}
+
+// CloseFiscalYear closes an open fiscal year. The closing balance of every
+// fund and payment method is carried forward as an opening balance dated
+// the day after EndDate, replacing any opening balance already there, and
+// the year's dates are closed as an accounting period.
+func (h *FiscalYearHandler) CloseFiscalYear(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Fiscal year not found"})
+		return
+	}
+	year, err := h.repos.FiscalYears.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Fiscal year not found"})
+		return
+	}
+	if year.Status != "open" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Fiscal year is already closed"})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	actor := userID.(uuid.UUID)
+	carried := 0
+
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		pending, err := repos.Transactions.Count(repository.TransactionFilter{
+			Status:    "pending",
+			StartDate: year.StartDate,
+			EndDate:   year.EndDate,
+		})
+		if err != nil {
+			return err
+		}
+		if pending > 0 {
+			return errPendingInFiscalYear
+		}
+
+		asOf := year.EndDate.AddDate(0, 0, 1)
+		balances, err := balancesBefore(repos, uuid.Nil, asOf)
+		if err != nil {
+			return err
+		}
+		funds, err := repos.Funds.List()
+		if err != nil {
+			return err
+		}
+		for _, fund := range funds {
+			for _, paymentMethod := range []string{"cash", "bank"} {
+				key := balanceKey{fund.ID, paymentMethod}
+				if _, ok := balances[key]; !ok {
+					balances[key] = 0
+				}
+			}
+		}
+
+		for key, amount := range balances {
+			// Transactions without a fund have nowhere to be carried to.
+			if key.fundID == uuid.Nil {
+				continue
+			}
+			note := "Carried forward from " + year.Name
+			opening, err := repos.OpeningBalances.FindByKey(key.fundID, key.paymentMethod, asOf)
+			switch {
+			case err == nil:
+				opening.Amount = amount
+				opening.FiscalYearID = &year.ID
+				opening.Note = note
+				err = repos.OpeningBalances.Update(opening)
+			case errors.Is(err, repository.ErrNotFound):
+				err = repos.OpeningBalances.Create(&models.OpeningBalance{
+					FundID:        key.fundID,
+					PaymentMethod: key.paymentMethod,
+					AsOf:          asOf,
+					Amount:        amount,
+					FiscalYearID:  &year.ID,
+					Note:          note,
+					CreatedBy:     actor,
+				})
+			}
+			if err != nil {
+				return err
+			}
+			carried++
+		}
+
+		if _, err := closePeriod(repos, year.Name, year.StartDate, year.EndDate, actor); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		before := *year
+		year.Status = "closed"
+		year.ClosedAt = &now
+		year.ClosedBy = &actor
+		if err := repos.FiscalYears.Update(year); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "fiscal_year",
+			EntityID:   year.ID,
+			Action:     "close",
+			Summary:    "Closed fiscal year: " + year.Name,
+			Before:     before,
+			After:      year,
+		})
+	})
+	if errors.Is(err, errPendingInFiscalYear) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Approve or reject the pending transactions of this fiscal year first"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to close fiscal year"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Fiscal year closed successfully",
+		"data":           year,
+		"carriedForward": carried,
+	})
+}
+
+func (h *FiscalYearHandler) GetOpeningBalances(c *gin.Context) {
+	fundID, ok := dashboardFundID(c)
+	if !ok {
+		return
+	}
+
+	balances, err := h.repos.OpeningBalances.List(repository.OpeningBalanceFilter{FundID: fundID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch opening balances"})
+		return
+	}
+	if balances == nil {
+		balances = []models.OpeningBalance{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": balances})
+}
+
+// SetOpeningBalance records the balance of a fund and payment method at the
+// start of asOf, replacing the one already recorded for that day. Approved
+// transactions of that fund and payment method before asOf no longer count
+// towards later balances.
+func (h *FiscalYearHandler) SetOpeningBalance(c *gin.Context) {
+	var req SetOpeningBalanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fundID, err := uuid.Parse(req.FundID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fundId"})
+		return
+	}
+	if _, err := h.repos.Funds.FindByID(fundID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fund not found"})
+		return
+	}
+	asOf, err := time.Parse("2006-01-02", req.AsOf)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid asOf. Use YYYY-MM-DD"})
+		return
+	}
+	if !checkPeriodsOpen(c, h.repos, asOf) {
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	status := http.StatusOK
+	message := "Opening balance updated successfully"
+
+	var opening *models.OpeningBalance
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		entry := auditEntry{
+			EntityType: "opening_balance",
+			Action:     "update",
+			Summary:    "Set opening balance " + req.PaymentMethod + " " + req.AsOf + ": " + req.Amount.String(),
+		}
+
+		var err error
+		opening, err = repos.OpeningBalances.FindByKey(fundID, req.PaymentMethod, asOf)
+		switch {
+		case err == nil:
+			entry.Before = *opening
+			opening.Amount = req.Amount
+			opening.Note = req.Note
+			err = repos.OpeningBalances.Update(opening)
+		case errors.Is(err, repository.ErrNotFound):
+			opening = &models.OpeningBalance{
+				FundID:        fundID,
+				PaymentMethod: req.PaymentMethod,
+				AsOf:          asOf,
+				Amount:        req.Amount,
+				Note:          req.Note,
+				CreatedBy:     userID.(uuid.UUID),
+			}
+			err = repos.OpeningBalances.Create(opening)
+			entry.Action = "create"
+			status = http.StatusCreated
+			message = "Opening balance created successfully"
+		}
+		if err != nil {
+			return err
+		}
+
+		entry.EntityID = opening.ID
+		entry.After = opening
+		return recordAudit(c, repos, entry)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save opening balance"})
+		return
+	}
+
+	c.JSON(status, gin.H{
+		"message": message,
+		"data":    opening,
+	})
+}
+
+func (h *FiscalYearHandler) DeleteOpeningBalance(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Opening balance not found"})
+		return
+	}
+	opening, err := h.repos.OpeningBalances.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Opening balance not found"})
+		return
+	}
+	if !checkPeriodsOpen(c, h.repos, opening.AsOf) {
+		return
+	}
+
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.OpeningBalances.Delete(id); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "opening_balance",
+			EntityID:   opening.ID,
+			Action:     "delete",
+			Summary:    "Deleted opening balance " + opening.PaymentMethod + " " + opening.AsOf.Format("2006-01-02"),
+			Before:     opening,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete opening balance"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Opening balance deleted successfully"})
+}
@@ -0,0 +1,51 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestFiscalYearCarriesBalancesForward(t *testing.T) {
+	s := newTestServer(t)
+	s.do(s.admin, http.MethodPost, "/api/opening-balances", map[string]interface{}{
+		"fundId": s.fund.ID, "paymentMethod": "cash", "asOf": "2025-01-01", "amount": "1000000",
+	}, http.StatusCreated)
+	// History before the opening balance is already part of it.
+	s.approve(s.createTransaction(s.admin, map[string]interface{}{"amount": "999", "date": "2024-12-31"}))
+	s.approve(s.createTransaction(s.admin, map[string]interface{}{"amount": "100000", "date": "2025-01-05"}))
+	s.approve(s.createTransaction(s.admin, map[string]interface{}{
+		"type": "expense", "category": s.expense.Name, "amount": "30000", "date": "2025-02-10",
+	}))
+
+	checkOpening := func(when string) {
+		t.Helper()
+		out := s.do(s.admin, http.MethodGet, "/api/dashboard/balances?startDate=2025-02-01&fundId="+s.fund.ID.String(), nil, http.StatusOK)
+		totals := out["totals"].(map[string]interface{})
+		if totals["openingBalance"] != 1100000.0 || totals["closingBalance"] != 1070000.0 {
+			t.Errorf("%s: opening %v, closing %v; want 1100000 and 1070000", when, totals["openingBalance"], totals["closingBalance"])
+		}
+	}
+	checkOpening("before the close")
+
+	year := data(s.do(s.admin, http.MethodPost, "/api/fiscal-years", map[string]interface{}{
+		"name": "Januari 2025", "startDate": "2025-01-01", "endDate": "2025-01-31",
+	}, http.StatusCreated))
+	s.do(s.admin, http.MethodPost, "/api/fiscal-years/"+year["id"].(string)+"/close", nil, http.StatusOK)
+	s.do(s.admin, http.MethodPost, "/api/fiscal-years/"+year["id"].(string)+"/close", nil, http.StatusConflict)
+
+	carried := map[string]interface{}{}
+	for _, item := range list(s.do(s.admin, http.MethodGet, "/api/opening-balances?fundId="+s.fund.ID.String(), nil, http.StatusOK)) {
+		opening := item.(map[string]interface{})
+		if opening["fiscalYearId"] == year["id"] {
+			carried[opening["paymentMethod"].(string)] = opening["amount"]
+		}
+	}
+	if carried["cash"] != 1100000.0 || carried["bank"] != 0.0 {
+		t.Errorf("carried forward %v, want 1100000 cash and 0 bank", carried)
+	}
+	checkOpening("after the close")
+
+	s.do(s.admin, http.MethodPost, "/api/transactions", map[string]interface{}{
+		"type": "income", "amount": "5000", "category": s.income.Name, "eventName": "Ibadah Minggu", "date": "2025-01-20", "fundId": s.fund.ID,
+	}, http.StatusConflict)
+}
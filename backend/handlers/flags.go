@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// TransactionFlagHandler lists the flags raised by the anomaly checks and
+// lets approvers dismiss them.
+type TransactionFlagHandler struct {
+	repos *repository.Repositories
+}
+
+func NewTransactionFlagHandler(repos *repository.Repositories) *TransactionFlagHandler {
+	return &TransactionFlagHandler{repos: repos}
+}
+
+type DismissFlagRequest struct {
+	Note string `json:"note"`
+}
+
+// canReviewFlags reports whether actor may dismiss the flags on
+// transaction: an admin, or whoever may sign its next approval step.
+// Nobody reviews their own transactions.
+func canReviewFlags(repos *repository.Repositories, transaction *models.Transaction, actor *models.User) (bool, error) {
+	if actor.Role == "admin" {
+		return true, nil
+	}
+	if transaction.CreatedBy == actor.ID || transaction.Status != "pending" {
+		return false, nil
+	}
+	state, _, err := loadApprovalState(repos, transaction)
+	if err != nil {
+		return false, err
+	}
+	return state.NextStep != nil && state.NextStep.Allows(actor), nil
+}
+
+// GetFlags lists flags for review, newest first. status is open (the
+// default), dismissed or all, and kind narrows the list to duplicate,
+// outlier, type_mismatch or suggestion. Admins see every flag; other users
+// see the flags on their own transactions and on those they may approve
+// next.
+func (h *TransactionFlagHandler) GetFlags(c *gin.Context) {
+	status := c.DefaultQuery("status", "open")
+	switch status {
+	case "open", "dismissed":
+	case "all":
+		status = ""
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be open, dismissed or all"})
+		return
+	}
+	kind := c.Query("kind")
+	switch kind {
+	case "", flagDuplicate, flagOutlier, flagTypeMismatch, flagSuggestion:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be duplicate, outlier, type_mismatch or suggestion"})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	actor, err := h.repos.Users.FindByID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	flags, err := h.repos.TransactionFlags.List(status, kind)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch flags"})
+		return
+	}
+	visible := []models.TransactionFlag{}
+	for _, flag := range flags {
+		if flag.Transaction == nil {
+			continue
+		}
+		if flag.Transaction.CreatedBy != actor.ID {
+			allowed, err := canReviewFlags(h.repos, flag.Transaction, actor)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch flags"})
+				return
+			}
+			if !allowed {
+				continue
+			}
+		}
+		visible = append(visible, flag)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": visible})
+}
+
+// DismissFlag marks a flag as checked, with an optional note on why the
+// transaction is fine. A dismissed flag is not raised again when the
+// transaction is edited.
+func (h *TransactionFlagHandler) DismissFlag(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Flag not found"})
+		return
+	}
+	flag, err := h.repos.TransactionFlags.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Flag not found"})
+		return
+	}
+	var req DismissFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if flag.Status != "open" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Flag has already been dismissed"})
+		return
+	}
+
+	transaction, err := h.repos.Transactions.FindByID(flag.TransactionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return
+	}
+	userID, _ := c.Get("userId")
+	actor, err := h.repos.Users.FindByID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+	allowed, err := canReviewFlags(h.repos, transaction, actor)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dismiss flag"})
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only an approver of this transaction can dismiss its flags"})
+		return
+	}
+
+	before := *flag
+	now := time.Now()
+	flag.Status = "dismissed"
+	flag.DismissedBy = &actor.ID
+	flag.DismissedAt = &now
+	flag.Note = req.Note
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.TransactionFlags.Update(flag); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "transaction",
+			EntityID:   transaction.ID,
+			Action:     "dismiss",
+			Summary:    "Dismissed " + flag.Kind + " flag on transaction: " + transaction.EventName,
+			Before:     before,
+			After:      flag,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dismiss flag"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Flag dismissed",
+		"data":    flag,
+	})
+}
@@ -0,0 +1,59 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+// flagKinds returns the kinds of the flags in a list response, keyed by
+// transaction ID.
+func flagKinds(out map[string]interface{}) map[string][]string {
+	kinds := map[string][]string{}
+	for _, item := range list(out) {
+		flag := item.(map[string]interface{})
+		id := flag["transactionId"].(string)
+		kinds[id] = append(kinds[id], flag["kind"].(string))
+	}
+	return kinds
+}
+
+func TestTransactionFlags(t *testing.T) {
+	s := newTestServer(t)
+	original := s.createTransaction(s.admin, map[string]interface{}{"eventName": "Persembahan Minggu", "description": "Kolekte ibadah pagi"})
+	duplicate := s.createTransaction(s.admin, map[string]interface{}{"eventName": "Persembahan Minggu", "description": "kolekte ibadah pagi"})
+	otherDay := s.createTransaction(s.admin, map[string]interface{}{"eventName": "Persembahan Minggu", "date": "2025-01-12"})
+	mismatch := s.createTransaction(s.admin, map[string]interface{}{"type": "expense", "category": s.expense.Name, "amount": "90000", "eventName": "Persembahan syukur"})
+	outlier := s.createTransaction(s.admin, map[string]interface{}{"amount": "50", "eventName": "Kolekte anak"})
+
+	got := flagKinds(s.do(s.admin, http.MethodGet, "/api/transaction-flags", nil, http.StatusOK))
+	want := map[string]string{duplicate: "duplicate", mismatch: "type_mismatch", outlier: "outlier"}
+	for id, kind := range want {
+		if len(got[id]) != 1 || got[id][0] != kind {
+			t.Errorf("flags on %s = %v, want [%s]", id, got[id], kind)
+		}
+	}
+	if len(got[original]) != 0 || len(got[otherDay]) != 0 {
+		t.Errorf("flags on the first and the next week's offering = %v, %v; want none", got[original], got[otherDay])
+	}
+
+	tx := data(s.do(s.admin, http.MethodGet, "/api/transactions/"+duplicate, nil, http.StatusOK))
+	flags, _ := tx["flags"].([]interface{})
+	if len(flags) != 1 || flags[0].(map[string]interface{})["relatedTransactionId"] != original {
+		t.Fatalf("flags on the transaction = %v, want one pointing at %s", tx["flags"], original)
+	}
+	flagID := flags[0].(map[string]interface{})["id"].(string)
+
+	s.do(s.member, http.MethodPost, "/api/transaction-flags/"+flagID+"/dismiss", map[string]interface{}{"note": "Dua kali ibadah"}, http.StatusForbidden)
+	s.do(s.reviewer, http.MethodPost, "/api/transaction-flags/"+flagID+"/dismiss", map[string]interface{}{"note": "Dua kali ibadah"}, http.StatusOK)
+	s.do(s.admin, http.MethodPut, "/api/transactions/"+duplicate, map[string]interface{}{
+		"type": "income", "amount": "100000", "category": s.income.Name, "eventName": "Persembahan Minggu",
+		"description": "Kolekte ibadah sore", "date": "2025-01-05", "fundId": s.fund.ID,
+	}, http.StatusOK)
+
+	if got := flagKinds(s.do(s.admin, http.MethodGet, "/api/transaction-flags", nil, http.StatusOK)); len(got[duplicate]) != 0 {
+		t.Errorf("open flags on %s after the dismissal = %v, want none", duplicate, got[duplicate])
+	}
+	if got := flagKinds(s.do(s.admin, http.MethodGet, "/api/transaction-flags?status=dismissed", nil, http.StatusOK)); len(got[duplicate]) != 1 {
+		t.Errorf("dismissed flags on %s = %v, want the duplicate", duplicate, got[duplicate])
+	}
+}
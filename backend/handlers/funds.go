@@ -1,28 +1,55 @@
 package handlers
 
 import (
-	"gkjw-finance-backend/config"
+	"errors"
 	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-// GetFunds mengambil semua dana
-func GetFunds(c *gin.Context) {
-	var funds []models.Fund
-	result := config.DB.Find(&funds)
+// FundHandler melayani endpoint dana
+type FundHandler struct {
+	repos *repository.Repositories
+}
 
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+func NewFundHandler(repos *repository.Repositories) *FundHandler {
+	return &FundHandler{repos: repos}
+}
+
+// GetFunds mengambil semua dana
+func (h *FundHandler) GetFunds(c *gin.Context) {
+	funds, err := h.repos.Funds.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": funds})
 }
 
+// GetFund mengambil satu dana beserta ETag versinya
+func (h *FundHandler) GetFund(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Fund not found"})
+		return
+	}
+
+	fund, err := h.repos.Funds.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Fund not found"})
+		return
+	}
+
+	setETag(c, fund.Version)
+	c.JSON(http.StatusOK, gin.H{"data": fund})
+}
+
 // CreateFund membuat dana baru (Admin only)
-func CreateFund(c *gin.Context) {
+func (h *FundHandler) CreateFund(c *gin.Context) {
 	var fund models.Fund
 	if err := c.ShouldBindJSON(&fund); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -34,9 +61,20 @@ func CreateFund(c *gin.Context) {
 		fund.Status = "active"
 	}
 
-	result := config.DB.Create(&fund)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	err := h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.Funds.Create(&fund); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "fund",
+			EntityID:   fund.ID,
+			Action:     "create",
+			Summary:    "Created fund: " + fund.Name,
+			After:      fund,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -44,38 +82,97 @@ func CreateFund(c *gin.Context) {
 }
 
 // UpdateFund memperbarui dana (Admin only)
-func UpdateFund(c *gin.Context) {
-	id := c.Param("id")
-	var fund models.Fund
+func (h *FundHandler) UpdateFund(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Fund not found"})
+		return
+	}
 
-	if err := config.DB.First(&fund, id).Error; err != nil {
+	fund, err := h.repos.Funds.FindByID(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Fund not found"})
 		return
 	}
 
-	if err := c.ShouldBindJSON(&fund); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !ifMatch(c, fund.Version) {
+		respondStale(c, fund, fund.Version)
 		return
 	}
 
-	config.DB.Save(&fund)
+	before := *fund
+	if err := c.ShouldBindJSON(fund); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	fund.ID = id
+	fund.Version = before.Version
+
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.Funds.Update(fund); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "fund",
+			EntityID:   fund.ID,
+			Action:     "update",
+			Summary:    "Updated fund: " + fund.Name,
+			Before:     before,
+			After:      fund,
+		})
+	})
+	if errors.Is(err, repository.ErrVersionConflict) {
+		if current, err := h.repos.Funds.FindByID(id); err == nil {
+			respondStale(c, current, current.Version)
+			return
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	setETag(c, fund.Version)
 	c.JSON(http.StatusOK, fund)
 }
 
 // DeleteFund menghapus dana (Admin only)
-func DeleteFund(c *gin.Context) {
-	id := c.Param("id")
-	result := config.DB.Delete(&models.Fund{}, id)
-
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+func (h *FundHandler) DeleteFund(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Fund not found"})
 		return
 	}
 
-	if result.RowsAffected == 0 {
+	fund, err := h.repos.Funds.FindByID(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Fund not found"})
 		return
 	}
+	if !ifMatch(c, fund.Version) {
+		respondStale(c, fund, fund.Version)
+		return
+	}
+
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.Funds.Delete(id); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "fund",
+			EntityID:   fund.ID,
+			Action:     "delete",
+			Summary:    "Deleted fund: " + fund.Name,
+			Before:     fund,
+		})
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Fund not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Fund deleted successfully"})
 }
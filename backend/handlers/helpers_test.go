@@ -0,0 +1,159 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
+	"gkjw-finance-backend/routes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const testJWTSecret = "test-secret"
+
+func TestMain(m *testing.M) {
+	os.Setenv("JWT_SECRET", testJWTSecret)
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// testServer is the API wired to in-memory repositories, with two admins
+// (so that one can review what the other submitted), a member, a fund and
+// a few categories.
+type testServer struct {
+	t      *testing.T
+	router *gin.Engine
+	repos  *repository.Repositories
+
+	admin, reviewer, member models.User
+	fund                    models.Fund
+	income, expense         models.Category
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	s := &testServer{
+		t:        t,
+		router:   gin.New(),
+		repos:    repository.NewMemoryRepositories(),
+		admin:    models.User{Name: "Admin", Email: "admin@example.com", Role: "admin"},
+		reviewer: models.User{Name: "Reviewer", Email: "reviewer@example.com", Role: "admin"},
+		member:   models.User{Name: "Member", Email: "member@example.com", Role: "member"},
+		fund:     models.Fund{Name: "Kas Umum"},
+		income:   models.Category{Name: "Persembahan", Type: "income"},
+		expense:  models.Category{Name: "Konsumsi", Type: "expense"},
+	}
+	for _, user := range []*models.User{&s.admin, &s.reviewer, &s.member} {
+		if err := s.repos.Users.Create(user); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.repos.Funds.Create(&s.fund); err != nil {
+		t.Fatal(err)
+	}
+	for _, category := range []*models.Category{&s.income, &s.expense} {
+		if err := s.repos.Categories.Create(category); err != nil {
+			t.Fatal(err)
+		}
+	}
+	routes.SetupRoutes(s.router, s.repos)
+	return s
+}
+
+// token returns a bearer token for user.
+func (s *testServer) token(user models.User) string {
+	s.t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"userId": user.ID,
+		"email":  user.Email,
+		"role":   user.Role,
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return token
+}
+
+// request sends body as JSON on behalf of user, or anonymously when user
+// has no ID, and returns the recorded response.
+func (s *testServer) request(user models.User, method, path string, body interface{}, headers ...string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if user.ID != uuid.Nil {
+		req.Header.Set("Authorization", "Bearer "+s.token(user))
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// do is request for calls expected to answer with status. It returns the
+// decoded response body.
+func (s *testServer) do(user models.User, method, path string, body interface{}, status int) map[string]interface{} {
+	s.t.Helper()
+	w := s.request(user, method, path, body)
+	if w.Code != status {
+		s.t.Fatalf("%s %s: status %d, want %d: %s", method, path, w.Code, status, w.Body.String())
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		s.t.Fatalf("%s %s: invalid JSON response: %v", method, path, err)
+	}
+	return out
+}
+
+// createTransaction creates a transaction as user from fields laid over
+// sensible defaults and returns its ID.
+func (s *testServer) createTransaction(user models.User, fields map[string]interface{}) string {
+	s.t.Helper()
+	body := map[string]interface{}{
+		"type":      "income",
+		"amount":    "100000",
+		"category":  s.income.Name,
+		"eventName": "Ibadah Minggu",
+		"date":      "2025-01-05",
+		"fundId":    s.fund.ID,
+	}
+	for key, value := range fields {
+		body[key] = value
+	}
+	out := s.do(user, http.MethodPost, "/api/transactions", body, http.StatusCreated)
+	return data(out)["id"].(string)
+}
+
+// approve approves transaction id as the reviewer.
+func (s *testServer) approve(id string) {
+	s.t.Helper()
+	s.do(s.reviewer, http.MethodPut, "/api/transactions/"+id+"/status", map[string]interface{}{"status": "approved"}, http.StatusOK)
+}
+
+// data returns the "data" object of a response.
+func data(out map[string]interface{}) map[string]interface{} {
+	object, _ := out["data"].(map[string]interface{})
+	return object
+}
+
+// list returns the "data" array of a response.
+func list(out map[string]interface{}) []interface{} {
+	items, _ := out["data"].([]interface{})
+	return items
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gkjw-finance-backend/importer"
+	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+)
+
+// maxImportSize is the largest file accepted for import.
+const maxImportSize = 10 * 1024 * 1024
+
+// ImportOptions controls ImportTransactions.
+type ImportOptions struct {
+	// DryRun checks every row without recording anything.
+	DryRun bool
+	// SkipInvalid records the valid rows even when others have errors;
+	// otherwise one bad row stops the whole import.
+	SkipInvalid bool
+	// CreatedBy is the user the transactions are recorded for.
+	CreatedBy uuid.UUID
+}
+
+// ImportResult reports how many rows were read, passed the checks and
+// were recorded, and what is wrong with the others.
+type ImportResult struct {
+	DryRun   bool                `json:"dryRun"`
+	Rows     int                 `json:"rows"`
+	Valid    int                 `json:"valid"`
+	Imported int                 `json:"imported"`
+	Errors   []importer.RowError `json:"errors"`
+}
+
+// ImportTransactions resolves the fund and category names of the rows in
+// table and checks every row as CreateTransaction would. Unless
+// options.DryRun is set, it then records the rows as pending transactions
+// in one database transaction, to be reviewed like any other. Problems are
+// recorded on the rows of table, for importer.ErrorReport. c may be nil
+// outside a request.
+func ImportTransactions(c *gin.Context, repos *repository.Repositories, table *importer.Table, options ImportOptions) (*ImportResult, error) {
+	funds, err := repos.Funds.List()
+	if err != nil {
+		return nil, err
+	}
+	categories, err := repos.Categories.List()
+	if err != nil {
+		return nil, err
+	}
+	fundIDs := map[string]uuid.UUID{}
+	for _, fund := range funds {
+		fundIDs[strings.ToLower(strings.TrimSpace(fund.Name))] = fund.ID
+		fundIDs[fund.ID.String()] = fund.ID
+	}
+
+	var transactions []models.Transaction
+	for i := range table.Rows {
+		row := &table.Rows[i]
+		fundID, found := fundIDs[strings.ToLower(row.Fund)]
+		if row.Fund != "" && !found {
+			row.Fail("Fund %q not found", row.Fund)
+		}
+		if len(row.Errors) > 0 {
+			continue
+		}
+
+		req := CreateTransactionRequest{
+			Type:          row.Type,
+			PaymentMethod: row.PaymentMethod,
+			Amount:        row.Amount,
+			Category:      row.Category,
+			Description:   row.Description,
+			EventName:     row.EventName,
+			Date:          row.Date.Format("2006-01-02"),
+			NoteURL:       row.NoteURL,
+			FundID:        fundID.String(),
+		}
+		if err := binding.Validator.ValidateStruct(req); err != nil {
+			row.Fail("%s", err.Error())
+			continue
+		}
+		transaction, err := newTransaction(req, options.CreatedBy)
+		if err != nil {
+			row.Fail("%s", err.Error())
+			continue
+		}
+		if err := resolveCategories(categories, &transaction); err != nil {
+			row.Fail("%s", err.Error())
+			continue
+		}
+		var closed *closedPeriodError
+		if err := ensurePeriodsOpen(repos, transaction.Date); errors.As(err, &closed) {
+			row.Fail("%s", closed.Error())
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	result := &ImportResult{
+		DryRun: options.DryRun,
+		Rows:   len(table.Rows),
+		Valid:  len(transactions),
+		Errors: table.Errors(),
+	}
+	if options.DryRun || len(transactions) == 0 || len(result.Errors) > 0 && !options.SkipInvalid {
+		return result, nil
+	}
+	err = repos.WithTx(func(repos *repository.Repositories) error {
+		for i := range transactions {
+			if err := saveNewTransaction(c, repos, &transactions[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Imported = len(transactions)
+	return result, nil
+}
+
+// ImportHandler imports transactions from CSV and Excel files.
+type ImportHandler struct {
+	repos *repository.Repositories
+}
+
+func NewImportHandler(repos *repository.Repositories) *ImportHandler {
+	return &ImportHandler{repos: repos}
+}
+
+// formBool reads a true/false form field, false when absent. It answers
+// 400 and returns false as ok when the value is not a boolean.
+func formBool(c *gin.Context, name string) (value bool, ok bool) {
+	raw := c.PostForm(name)
+	if raw == "" {
+		return false, true
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be true or false"})
+		return false, false
+	}
+	return value, true
+}
+
+// readUpload reads the uploaded file of an import request with its format
+// (csv or xlsx, guessed from the file name when left out) and mapping, as
+// JSON. It answers 400 and returns nil when the file cannot be read.
+func (h *ImportHandler) readUpload(c *gin.Context) *importer.Table {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file provided", "details": err.Error()})
+		return nil
+	}
+	if file.Size > maxImportSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File size exceeds 10MB limit"})
+		return nil
+	}
+	opened, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return nil
+	}
+	data, err := io.ReadAll(opened)
+	opened.Close()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return nil
+	}
+
+	format := strings.ToLower(c.PostForm("format"))
+	if format == "" {
+		format = importer.DetectFormat(file.Filename)
+	}
+	var mapping importer.Mapping
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mapping: " + err.Error()})
+			return nil
+		}
+	}
+
+	table, err := importer.Read(format, data, mapping)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file: " + err.Error()})
+		return nil
+	}
+	return table
+}
+
+// ImportTransactionFile imports the transactions in an uploaded CSV or
+// Excel file. With dryRun the rows are only checked. Unless skipInvalid is
+// set, nothing is recorded while any row has errors.
+func (h *ImportHandler) ImportTransactionFile(c *gin.Context) {
+	dryRun, ok := formBool(c, "dryRun")
+	if !ok {
+		return
+	}
+	skipInvalid, ok := formBool(c, "skipInvalid")
+	if !ok {
+		return
+	}
+	table := h.readUpload(c)
+	if table == nil {
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	result, err := ImportTransactions(c, h.repos, table, ImportOptions{
+		DryRun:      dryRun,
+		SkipInvalid: skipInvalid,
+		CreatedBy:   userID.(uuid.UUID),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import transactions"})
+		return
+	}
+
+	switch {
+	case dryRun:
+		c.JSON(http.StatusOK, gin.H{
+			"message": fmt.Sprintf("%d of %d rows are valid", result.Valid, result.Rows),
+			"data":    result,
+		})
+	case result.Imported == 0:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "No transactions were imported",
+			"data":  result,
+		})
+	default:
+		c.JSON(http.StatusCreated, gin.H{
+			"message": fmt.Sprintf("%d of %d rows imported", result.Imported, result.Rows),
+			"data":    result,
+		})
+	}
+}
+
+// GetImportErrorReport checks an uploaded file as a dry run would and
+// answers with its rows that have errors as a CSV file, to be fixed and
+// imported again.
+func (h *ImportHandler) GetImportErrorReport(c *gin.Context) {
+	table := h.readUpload(c)
+	if table == nil {
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	if _, err := ImportTransactions(c, h.repos, table, ImportOptions{DryRun: true, CreatedBy: userID.(uuid.UUID)}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check transactions"})
+		return
+	}
+	report, err := importer.ErrorReport(table)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate error report"})
+		return
+	}
+
+	filename := fmt.Sprintf("import_errors_%s.csv", time.Now().Format("20060102150405"))
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", report)
+}
@@ -0,0 +1,559 @@
+package handlers
+
+import (
+	"errors"
+	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// LedgerHandler serves the chart of accounts, journals and the ledger
+// reports built from them.
+type LedgerHandler struct {
+	repos *repository.Repositories
+}
+
+func NewLedgerHandler(repos *repository.Repositories) *LedgerHandler {
+	return &LedgerHandler{repos: repos}
+}
+
+type CreateAccountRequest struct {
+	Code string `json:"code" binding:"required"`
+	Name string `json:"name" binding:"required"`
+	Type string `json:"type" binding:"required,oneof=asset liability equity income expense"`
+}
+
+type UpdateAccountRequest struct {
+	Name   string `json:"name" binding:"required"`
+	Status string `json:"status" binding:"required,oneof=active archived"`
+}
+
+type JournalLineRequest struct {
+	AccountID string       `json:"accountId" binding:"required"`
+	FundID    string       `json:"fundId"`
+	Debit     models.Money `json:"debit"`
+	Credit    models.Money `json:"credit"`
+	Memo      string       `json:"memo"`
+}
+
+type CreateJournalRequest struct {
+	Date        string               `json:"date" binding:"required"`
+	Description string               `json:"description" binding:"required"`
+	Lines       []JournalLineRequest `json:"lines" binding:"required,dive"`
+}
+
+// TrialBalanceRow is one account of the trial balance. The net balance is
+// shown in the debit or the credit column.
+type TrialBalanceRow struct {
+	Account models.Account `json:"account"`
+	Debit   models.Money   `json:"debit"`
+	Credit  models.Money   `json:"credit"`
+}
+
+// GeneralLedgerLine is one posting to an account with the running balance
+// after it, signed by the account's normal side.
+type GeneralLedgerLine struct {
+	EntryID     uuid.UUID    `json:"entryId"`
+	Date        time.Time    `json:"date"`
+	Description string       `json:"description"`
+	Memo        string       `json:"memo,omitempty"`
+	FundID      *uuid.UUID   `json:"fundId,omitempty"`
+	Debit       models.Money `json:"debit"`
+	Credit      models.Money `json:"credit"`
+	Balance     models.Money `json:"balance"`
+}
+
+// ledgerFilterFromQuery reads accountId, fundId, startDate and endDate.
+func ledgerFilterFromQuery(c *gin.Context) (repository.LedgerFilter, error) {
+	var filter repository.LedgerFilter
+
+	if accountID := c.Query("accountId"); accountID != "" {
+		parsed, err := uuid.Parse(accountID)
+		if err != nil {
+			return filter, errors.New("Invalid accountId")
+		}
+		filter.AccountID = parsed
+	}
+	if fundID := c.Query("fundId"); fundID != "" && fundID != "all" {
+		parsed, err := uuid.Parse(fundID)
+		if err != nil {
+			return filter, errors.New("Invalid fundId")
+		}
+		filter.FundID = parsed
+	}
+	if startDate := c.Query("startDate"); startDate != "" {
+		parsed, err := time.Parse("2006-01-02", startDate)
+		if err != nil {
+			return filter, errors.New("Invalid startDate. Use YYYY-MM-DD")
+		}
+		filter.StartDate = parsed
+	}
+	if endDate := c.Query("endDate"); endDate != "" {
+		parsed, err := time.Parse("2006-01-02", endDate)
+		if err != nil {
+			return filter, errors.New("Invalid endDate. Use YYYY-MM-DD")
+		}
+		filter.EndDate = parsed
+	}
+
+	return filter, nil
+}
+
+// signedBalance returns debit minus credit for debit-normal accounts and
+// credit minus debit otherwise.
+func signedBalance(account *models.Account, debit, credit models.Money) models.Money {
+	if account.DebitNormal() {
+		return debit - credit
+	}
+	return credit - debit
+}
+
+func (h *LedgerHandler) GetAccounts(c *gin.Context) {
+	accounts, err := h.repos.Accounts.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": accounts})
+}
+
+func (h *LedgerHandler) CreateAccount(c *gin.Context) {
+	var req CreateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.repos.Accounts.FindByCode(req.Code); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Account code already exists"})
+		return
+	}
+
+	account := models.Account{
+		Code:   req.Code,
+		Name:   req.Name,
+		Type:   req.Type,
+		Status: "active",
+	}
+	err := h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.Accounts.Create(&account); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "account",
+			EntityID:   account.ID,
+			Action:     "create",
+			Summary:    "Created account: " + account.Code + " " + account.Name,
+			After:      account,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create account"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Account created successfully",
+		"data":    account,
+	})
+}
+
+// UpdateAccount renames or archives an account. Code and type are fixed
+// once created because posted journals depend on them.
+func (h *LedgerHandler) UpdateAccount(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+	account, err := h.repos.Accounts.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+
+	var req UpdateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	before := *account
+	account.Name = req.Name
+	account.Status = req.Status
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.Accounts.Update(account); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "account",
+			EntityID:   account.ID,
+			Action:     "update",
+			Summary:    "Updated account: " + account.Code + " " + account.Name,
+			Before:     before,
+			After:      account,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Account updated successfully",
+		"data":    account,
+	})
+}
+
+func (h *LedgerHandler) GetJournals(c *gin.Context) {
+	filter, err := ledgerFilterFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries, err := h.repos.Journals.List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch journals"})
+		return
+	}
+	if entries == nil {
+		entries = []models.JournalEntry{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
+
+func (h *LedgerHandler) GetJournalByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Journal not found"})
+		return
+	}
+	entry, err := h.repos.Journals.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Journal not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": entry})
+}
+
+// CreateJournal posts a manual journal, e.g. moving money from cash to
+// bank. The lines must balance and use active accounts.
+func (h *LedgerHandler) CreateJournal(c *gin.Context) {
+	var req CreateJournalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		return
+	}
+	if !checkPeriodsOpen(c, h.repos, date) {
+		return
+	}
+
+	lines := make([]models.JournalLine, 0, len(req.Lines))
+	for _, reqLine := range req.Lines {
+		accountID, err := uuid.Parse(reqLine.AccountID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid accountId"})
+			return
+		}
+		account, err := h.repos.Accounts.FindByID(accountID)
+		if err != nil || account.Status != "active" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown or archived account: " + reqLine.AccountID})
+			return
+		}
+
+		line := models.JournalLine{
+			AccountID: accountID,
+			Debit:     reqLine.Debit,
+			Credit:    reqLine.Credit,
+			Memo:      reqLine.Memo,
+		}
+		if reqLine.FundID != "" {
+			fundID, err := uuid.Parse(reqLine.FundID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fundId"})
+				return
+			}
+			if _, err := h.repos.Funds.FindByID(fundID); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Fund not found"})
+				return
+			}
+			line.FundID = &fundID
+		}
+		lines = append(lines, line)
+	}
+
+	if err := checkBalanced(lines); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	entry := models.JournalEntry{
+		Date:        date,
+		Description: req.Description,
+		Source:      "manual",
+		CreatedBy:   userID.(uuid.UUID),
+		Lines:       lines,
+	}
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.Journals.Create(&entry); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "journal",
+			EntityID:   entry.ID,
+			Action:     "create",
+			Summary:    "Posted journal: " + entry.Description,
+			After:      entry,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create journal"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Journal posted successfully",
+		"data":    entry,
+	})
+}
+
+// ReverseJournal undoes a manual journal by posting its mirror image.
+// Transaction postings are reversed through the transaction endpoints.
+func (h *LedgerHandler) ReverseJournal(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Journal not found"})
+		return
+	}
+	entry, err := h.repos.Journals.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Journal not found"})
+		return
+	}
+	if entry.Source != "manual" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only manual journals can be reversed here"})
+		return
+	}
+	if entry.ReversedByID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Journal is already reversed"})
+		return
+	}
+	if !checkPeriodsOpen(c, h.repos, entry.Date) {
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := reverseJournal(repos, entry, userID.(uuid.UUID), "Reversal: "+entry.Description); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "journal",
+			EntityID:   entry.ID,
+			Action:     "reverse",
+			Summary:    "Reversed journal: " + entry.Description,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reverse journal"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Journal reversed successfully"})
+}
+
+// GetGeneralLedger lists the postings to one account within the date range
+// with an opening balance and a running balance.
+func (h *LedgerHandler) GetGeneralLedger(c *gin.Context) {
+	filter, err := ledgerFilterFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.AccountID == uuid.Nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "accountId is required"})
+		return
+	}
+
+	account, err := h.repos.Accounts.FindByID(filter.AccountID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+
+	var opening models.Money
+	if !filter.StartDate.IsZero() {
+		before := filter
+		before.StartDate = time.Time{}
+		before.EndDate = filter.StartDate.AddDate(0, 0, -1)
+		balances, err := h.repos.Journals.Balances(before)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute opening balance"})
+			return
+		}
+		for _, b := range balances {
+			opening += signedBalance(account, b.Debit, b.Credit)
+		}
+	}
+
+	lines, err := h.repos.Journals.Lines(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ledger"})
+		return
+	}
+
+	balance := opening
+	var totalDebit, totalCredit models.Money
+	rows := make([]GeneralLedgerLine, 0, len(lines))
+	for _, line := range lines {
+		balance += signedBalance(account, line.Debit, line.Credit)
+		totalDebit += line.Debit
+		totalCredit += line.Credit
+
+		row := GeneralLedgerLine{
+			EntryID: line.EntryID,
+			Memo:    line.Memo,
+			FundID:  line.FundID,
+			Debit:   line.Debit,
+			Credit:  line.Credit,
+			Balance: balance,
+		}
+		if line.Entry != nil {
+			row.Date = line.Entry.Date
+			row.Description = line.Entry.Description
+		}
+		rows = append(rows, row)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"account":        account,
+			"openingBalance": opening,
+			"lines":          rows,
+			"totalDebit":     totalDebit,
+			"totalCredit":    totalCredit,
+			"closingBalance": balance,
+		},
+	})
+}
+
+// GetTrialBalance lists the balance of every account as of a date
+// (default today), optionally for one fund.
+func (h *LedgerHandler) GetTrialBalance(c *gin.Context) {
+	filter, err := ledgerFilterFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.AccountID = uuid.Nil
+	filter.StartDate = time.Time{}
+	filter.EndDate = time.Time{}
+	if asOf := c.Query("asOf"); asOf != "" {
+		parsed, err := time.Parse("2006-01-02", asOf)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid asOf. Use YYYY-MM-DD"})
+			return
+		}
+		filter.EndDate = parsed
+	}
+
+	accounts, err := h.repos.Accounts.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounts"})
+		return
+	}
+	balances, err := h.repos.Journals.Balances(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute trial balance"})
+		return
+	}
+
+	byAccount := make(map[uuid.UUID]repository.AccountBalance, len(balances))
+	for _, b := range balances {
+		byAccount[b.AccountID] = b
+	}
+
+	var totalDebit, totalCredit models.Money
+	rows := make([]TrialBalanceRow, 0, len(accounts))
+	for _, account := range accounts {
+		b, ok := byAccount[account.ID]
+		if !ok {
+			continue
+		}
+		row := TrialBalanceRow{Account: account}
+		if net := b.Debit - b.Credit; net >= 0 {
+			row.Debit = net
+		} else {
+			row.Credit = -net
+		}
+		totalDebit += row.Debit
+		totalCredit += row.Credit
+		rows = append(rows, row)
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Account.Code < rows[j].Account.Code })
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": rows,
+		"totals": gin.H{
+			"debit":    totalDebit,
+			"credit":   totalCredit,
+			"balanced": totalDebit == totalCredit,
+		},
+	})
+}
+
+// PostApprovedTransactions posts journals for approved transactions that
+// have none yet, e.g. those approved before the ledger existed. It is safe
+// to run more than once.
+func (h *LedgerHandler) PostApprovedTransactions(c *gin.Context) {
+	transactions, err := h.repos.Transactions.FindAll(repository.TransactionFilter{Status: repository.StatusPosted})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	posted := 0
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		for i := range transactions {
+			transaction := &transactions[i]
+			_, err := repos.Journals.FindActiveByTransaction(transaction.ID)
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, repository.ErrNotFound) {
+				return err
+			}
+			if err := syncTransactionPosting(repos, transaction, userID.(uuid.UUID)); err != nil {
+				return err
+			}
+			posted++
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Approved transactions posted to the ledger",
+		"data":    gin.H{"posted": posted},
+	})
+}
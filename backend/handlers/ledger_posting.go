@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// errUnbalancedJournal is returned for journals whose debits and credits
+// differ or that have fewer than two lines.
+var errUnbalancedJournal = errors.New("Journal must have at least two lines and total debit must equal total credit")
+
+// paymentAccountCode returns the asset account that holds money paid or
+// received with the given payment method.
+func paymentAccountCode(paymentMethod string) string {
+	if paymentMethod == "bank" {
+		return models.AccountCodeBank
+	}
+	return models.AccountCodeCash
+}
+
+// transactionJournalLines returns the lines an approved transaction posts:
+// income debits cash or bank and credits income, expense debits expense and
+// credits cash or bank, a pair for each line of the transaction carrying
+// its fund and category. A transfer debits the destination and credits the
+// source; between funds it also moves Saldo Dana so that each fund stays
+// balanced on its own. A reversal, whose amount is negative, posts the same
+// lines with debits and credits swapped.
+func transactionJournalLines(repos *repository.Repositories, transaction *models.Transaction) ([]models.JournalLine, error) {
+	debitCode, creditCode := paymentAccountCode(transaction.PaymentMethod), models.AccountCodeIncome
+	switch transaction.Type {
+	case "expense":
+		debitCode, creditCode = models.AccountCodeExpense, paymentAccountCode(transaction.PaymentMethod)
+	case "transfer":
+		debitCode, creditCode = paymentAccountCode(transaction.ToPaymentMethod), paymentAccountCode(transaction.PaymentMethod)
+	}
+
+	debit, err := repos.Accounts.FindByCode(debitCode)
+	if err != nil {
+		return nil, fmt.Errorf("account %s: %w", debitCode, err)
+	}
+	credit, err := repos.Accounts.FindByCode(creditCode)
+	if err != nil {
+		return nil, fmt.Errorf("account %s: %w", creditCode, err)
+	}
+
+	var lines []models.JournalLine
+	for _, split := range transaction.Splits() {
+		var fundID *uuid.UUID
+		if split.FundID != uuid.Nil {
+			id := split.FundID
+			fundID = &id
+		}
+
+		debitFundID := fundID
+		if transaction.Type == "transfer" && transaction.ToFundID != nil {
+			id := *transaction.ToFundID
+			debitFundID = &id
+		}
+
+		amount := split.Amount
+		if amount < 0 {
+			amount = -amount
+		}
+		lines = append(lines,
+			models.JournalLine{AccountID: debit.ID, FundID: debitFundID, Debit: amount, Memo: split.Category},
+			models.JournalLine{AccountID: credit.ID, FundID: fundID, Credit: amount, Memo: split.Category},
+		)
+		if debitFundID != nil && fundID != nil && *debitFundID != *fundID {
+			equity, err := repos.Accounts.FindByCode(models.AccountCodeFundBalance)
+			if err != nil {
+				return nil, fmt.Errorf("account %s: %w", models.AccountCodeFundBalance, err)
+			}
+			lines = append(lines,
+				models.JournalLine{AccountID: equity.ID, FundID: debitFundID, Credit: amount, Memo: split.Category},
+				models.JournalLine{AccountID: equity.ID, FundID: fundID, Debit: amount, Memo: split.Category},
+			)
+		}
+	}
+	if transaction.Amount < 0 {
+		for i := range lines {
+			lines[i].Debit, lines[i].Credit = lines[i].Credit, lines[i].Debit
+		}
+	}
+	return lines, nil
+}
+
+// checkBalanced validates journal lines: at least two, each either a debit
+// or a credit but not both, and debits equal to credits.
+func checkBalanced(lines []models.JournalLine) error {
+	if len(lines) < 2 {
+		return errUnbalancedJournal
+	}
+	var debit, credit models.Money
+	for _, line := range lines {
+		if line.Debit < 0 || line.Credit < 0 || (line.Debit == 0) == (line.Credit == 0) {
+			return errors.New("Each journal line needs either a positive debit or a positive credit")
+		}
+		debit += line.Debit
+		credit += line.Credit
+	}
+	if debit != credit {
+		return errUnbalancedJournal
+	}
+	return nil
+}
+
+// samePosting reports whether entry already posts exactly lines on date.
+func samePosting(entry *models.JournalEntry, date time.Time, lines []models.JournalLine) bool {
+	if !entry.Date.Equal(date) || len(entry.Lines) != len(lines) {
+		return false
+	}
+	for i, line := range lines {
+		posted := entry.Lines[i]
+		if posted.AccountID != line.AccountID || posted.Debit != line.Debit || posted.Credit != line.Credit ||
+			(posted.FundID == nil) != (line.FundID == nil) ||
+			(posted.FundID != nil && *posted.FundID != *line.FundID) {
+			return false
+		}
+	}
+	return true
+}
+
+// reverseJournal posts an entry that mirrors entry with debits and credits
+// swapped and links the two. The reversal keeps the original date so that
+// balances as of any date match the transactions approved at that date.
+func reverseJournal(repos *repository.Repositories, entry *models.JournalEntry, actor uuid.UUID, description string) error {
+	lines := make([]models.JournalLine, len(entry.Lines))
+	for i, line := range entry.Lines {
+		lines[i] = models.JournalLine{
+			AccountID: line.AccountID,
+			FundID:    line.FundID,
+			Debit:     line.Credit,
+			Credit:    line.Debit,
+			Memo:      line.Memo,
+		}
+	}
+
+	reversal := models.JournalEntry{
+		Date:          entry.Date,
+		Description:   description,
+		Source:        "reversal",
+		TransactionID: entry.TransactionID,
+		ReversesID:    &entry.ID,
+		CreatedBy:     actor,
+		Lines:         lines,
+	}
+	if err := repos.Journals.Create(&reversal); err != nil {
+		return err
+	}
+	return repos.Journals.MarkReversed(entry.ID, reversal.ID)
+}
+
+// syncTransactionPosting brings the ledger in line with transaction. A
+// posted (approved or voided) transaction has exactly one active posting
+// matching its current amount, date, fund and payment method; any other
+// transaction has none.
+// Stale postings are reversed rather than changed. It must run inside
+// WithTx together with the change to the transaction.
+func syncTransactionPosting(repos *repository.Repositories, transaction *models.Transaction, actor uuid.UUID) error {
+	active, err := repos.Journals.FindActiveByTransaction(transaction.ID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	var lines []models.JournalLine
+	if transaction.Posted() {
+		if lines, err = transactionJournalLines(repos, transaction); err != nil {
+			return err
+		}
+		if active != nil && samePosting(active, transaction.Date, lines) {
+			return nil
+		}
+	}
+
+	if active != nil {
+		if err := reverseJournal(repos, active, actor, "Reversal: "+transaction.EventName); err != nil {
+			return err
+		}
+	}
+	if lines == nil {
+		return nil
+	}
+
+	transactionID := transaction.ID
+	entry := models.JournalEntry{
+		Date:          transaction.Date,
+		Description:   transaction.EventName,
+		Source:        "transaction",
+		TransactionID: &transactionID,
+		CreatedBy:     actor,
+		Lines:         lines,
+	}
+	return repos.Journals.Create(&entry)
+}
+
+// unpostTransaction reverses the active posting of a transaction that is
+// about to be deleted.
+func unpostTransaction(repos *repository.Repositories, transaction *models.Transaction, actor uuid.UUID) error {
+	active, err := repos.Journals.FindActiveByTransaction(transaction.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return reverseJournal(repos, active, actor, "Reversal: "+transaction.EventName+" (deleted)")
+}
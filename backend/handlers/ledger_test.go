@@ -0,0 +1,189 @@
+package handlers_test
+
+import (
+	"gkjw-finance-backend/models"
+	"net/http"
+	"testing"
+)
+
+// trialBalance returns the net balance, debit minus credit, of each account
+// in the trial balance for query, keyed by account code. It fails the test
+// when the debits and credits differ.
+func trialBalance(s *testServer, query string) map[string]float64 {
+	s.t.Helper()
+	out := s.do(s.admin, http.MethodGet, "/api/ledger/trial-balance?"+query, nil, http.StatusOK)
+	totals := out["totals"].(map[string]interface{})
+	if totals["balanced"] != true || totals["debit"] != totals["credit"] {
+		s.t.Fatalf("trial balance %s: debit %v, credit %v", query, totals["debit"], totals["credit"])
+	}
+	balances := make(map[string]float64)
+	for _, item := range list(out) {
+		row := item.(map[string]interface{})
+		code := row["account"].(map[string]interface{})["code"].(string)
+		if net := row["debit"].(float64) - row["credit"].(float64); net != 0 {
+			balances[code] = net
+		}
+	}
+	return balances
+}
+
+// checkBalances compares the trial balance for query with want, which
+// leaves out accounts with a zero balance.
+func checkBalances(t *testing.T, s *testServer, query string, want map[string]float64) {
+	t.Helper()
+	got := trialBalance(s, query)
+	if len(got) != len(want) {
+		t.Errorf("trial balance %s = %v, want %v", query, got, want)
+		return
+	}
+	for code, balance := range want {
+		if got[code] != balance {
+			t.Errorf("trial balance %s = %v, want %v", query, got, want)
+			return
+		}
+	}
+}
+
+func TestLedgerPostsApprovedTransactions(t *testing.T) {
+	s := newTestServer(t)
+	income := s.createTransaction(s.admin, map[string]interface{}{"amount": "250000"})
+	expense := s.createTransaction(s.admin, map[string]interface{}{
+		"type": "expense", "category": s.expense.Name, "amount": "75000", "paymentMethod": "bank", "date": "2025-01-10",
+	})
+	checkBalances(t, s, "", map[string]float64{})
+
+	s.approve(income)
+	s.approve(expense)
+	checkBalances(t, s, "", map[string]float64{
+		models.AccountCodeCash:    250000,
+		models.AccountCodeBank:    -75000,
+		models.AccountCodeIncome:  -250000,
+		models.AccountCodeExpense: 75000,
+	})
+	checkBalances(t, s, "asOf=2025-01-09", map[string]float64{
+		models.AccountCodeCash:   250000,
+		models.AccountCodeIncome: -250000,
+	})
+
+	s.do(s.admin, http.MethodPost, "/api/ledger/post-approved", nil, http.StatusOK)
+	if journals := list(s.do(s.admin, http.MethodGet, "/api/ledger/journals", nil, http.StatusOK)); len(journals) != 2 {
+		t.Errorf("%d journals after posting again, want 2", len(journals))
+	}
+}
+
+func TestLedgerPostsSplitLines(t *testing.T) {
+	s := newTestServer(t)
+	tithe := models.Category{Name: "Perpuluhan", Type: "income"}
+	if err := s.repos.Categories.Create(&tithe); err != nil {
+		t.Fatal(err)
+	}
+	id := s.createTransaction(s.admin, map[string]interface{}{
+		"amount": "150000",
+		"lines": []interface{}{
+			map[string]interface{}{"category": s.income.Name, "amount": "100000"},
+			map[string]interface{}{"category": tithe.Name, "amount": "50000"},
+		},
+	})
+	s.approve(id)
+
+	journals := list(s.do(s.admin, http.MethodGet, "/api/ledger/journals", nil, http.StatusOK))
+	if len(journals) != 1 {
+		t.Fatalf("%d journals, want 1", len(journals))
+	}
+	credits := make(map[string]float64)
+	for _, item := range journals[0].(map[string]interface{})["lines"].([]interface{}) {
+		line := item.(map[string]interface{})
+		credits[line["memo"].(string)] += line["credit"].(float64)
+	}
+	if credits[s.income.Name] != 100000 || credits[tithe.Name] != 50000 {
+		t.Errorf("credits per category = %v, want 100000 to %s and 50000 to %s", credits, s.income.Name, tithe.Name)
+	}
+	checkBalances(t, s, "", map[string]float64{
+		models.AccountCodeCash:   150000,
+		models.AccountCodeIncome: -150000,
+	})
+}
+
+func TestLedgerBalancesTransfersPerFund(t *testing.T) {
+	s := newTestServer(t)
+	building := models.Fund{Name: "Dana Pembangunan"}
+	if err := s.repos.Funds.Create(&building); err != nil {
+		t.Fatal(err)
+	}
+	s.approve(s.createTransaction(s.admin, map[string]interface{}{"amount": "500000"}))
+	transfer := s.createTransaction(s.admin, map[string]interface{}{
+		"type": "transfer", "amount": "200000", "toFundId": building.ID, "toPaymentMethod": "bank",
+	})
+	s.approve(transfer)
+
+	checkBalances(t, s, "", map[string]float64{
+		models.AccountCodeCash:   300000,
+		models.AccountCodeBank:   200000,
+		models.AccountCodeIncome: -500000,
+	})
+	checkBalances(t, s, "fundId="+s.fund.ID.String(), map[string]float64{
+		models.AccountCodeCash:        300000,
+		models.AccountCodeFundBalance: 200000,
+		models.AccountCodeIncome:      -500000,
+	})
+	checkBalances(t, s, "fundId="+building.ID.String(), map[string]float64{
+		models.AccountCodeBank:        200000,
+		models.AccountCodeFundBalance: -200000,
+	})
+
+	// The reversal of a void flips every leg, Saldo Dana included.
+	s.do(s.admin, http.MethodPost, "/api/transactions/"+transfer+"/void", map[string]interface{}{"reason": "Salah dana", "date": "2025-01-06"}, http.StatusOK)
+	checkBalances(t, s, "fundId="+building.ID.String(), map[string]float64{})
+	checkBalances(t, s, "fundId="+s.fund.ID.String(), map[string]float64{
+		models.AccountCodeCash:   500000,
+		models.AccountCodeIncome: -500000,
+	})
+}
+
+func TestLedgerFollowsTransactionChanges(t *testing.T) {
+	s := newTestServer(t)
+	approved := s.createTransaction(s.admin, map[string]interface{}{"amount": "100000"})
+	s.approve(approved)
+	want := map[string]float64{
+		models.AccountCodeCash:   100000,
+		models.AccountCodeIncome: -100000,
+	}
+
+	// An edit before approval posts nothing; the approval posts the edit.
+	id := s.createTransaction(s.admin, map[string]interface{}{"type": "expense", "category": s.expense.Name, "amount": "40000"})
+	s.do(s.admin, http.MethodPut, "/api/transactions/"+id, map[string]interface{}{
+		"type": "expense", "category": s.expense.Name, "amount": "30000", "eventName": "Rapat Majelis", "date": "2025-01-06", "fundId": s.fund.ID,
+	}, http.StatusOK)
+	checkBalances(t, s, "", want)
+	s.approve(id)
+	want[models.AccountCodeCash] -= 30000
+	want[models.AccountCodeExpense] = 30000
+	checkBalances(t, s, "", want)
+
+	// An approved transaction is corrected by voiding it, not by editing.
+	s.do(s.admin, http.MethodPut, "/api/transactions/"+approved, map[string]interface{}{
+		"type": "income", "category": s.income.Name, "amount": "1", "eventName": "Ibadah Minggu", "date": "2025-01-05", "fundId": s.fund.ID,
+	}, http.StatusConflict)
+	checkBalances(t, s, "", want)
+
+	// Drafts and rejected transactions never posted, so deleting them
+	// leaves the ledger alone.
+	draft := s.createTransaction(s.admin, map[string]interface{}{"draft": true, "amount": "5000"})
+	rejected := s.createTransaction(s.admin, map[string]interface{}{"amount": "7000"})
+	s.do(s.reviewer, http.MethodPut, "/api/transactions/"+rejected+"/status", map[string]interface{}{
+		"status": "rejected", "rejectionReason": "Nota tidak terbaca",
+	}, http.StatusOK)
+	s.do(s.admin, http.MethodDelete, "/api/transactions/"+draft, nil, http.StatusOK)
+	s.do(s.admin, http.MethodDelete, "/api/transactions/"+rejected, nil, http.StatusOK)
+	checkBalances(t, s, "", want)
+
+	// A void leaves the balances before its date alone and nets the
+	// transaction to zero from then on.
+	s.do(s.admin, http.MethodPost, "/api/transactions/"+approved+"/void", map[string]interface{}{
+		"reason": "Salah catat", "date": "2025-02-01",
+	}, http.StatusOK)
+	checkBalances(t, s, "asOf=2025-01-31", want)
+	delete(want, models.AccountCodeIncome)
+	want[models.AccountCodeCash] -= 100000
+	checkBalances(t, s, "", want)
+}
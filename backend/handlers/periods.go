@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"errors"
+	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PeriodHandler closes and reopens accounting periods.
+type PeriodHandler struct {
+	repos *repository.Repositories
+}
+
+func NewPeriodHandler(repos *repository.Repositories) *PeriodHandler {
+	return &PeriodHandler{repos: repos}
+}
+
+// ClosePeriodRequest names the period to close: either a month
+// (YYYY-MM) or a calendar year (YYYY).
+type ClosePeriodRequest struct {
+	Month string `json:"month"`
+	Year  string `json:"year"`
+}
+
+type ReopenPeriodRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// closedPeriodError is returned when a change touches a date inside a
+// closed accounting period.
+type closedPeriodError struct {
+	period *models.AccountingPeriod
+	date   time.Time
+}
+
+func (e *closedPeriodError) Error() string {
+	return "Accounting period " + e.period.Name + " is closed; nothing dated " +
+		e.date.Format("2006-01-02") + " can be changed until an admin reopens it"
+}
+
+// ensurePeriodsOpen returns a *closedPeriodError when any of dates falls
+// inside a closed accounting period.
+func ensurePeriodsOpen(repos *repository.Repositories, dates ...time.Time) error {
+	for _, date := range dates {
+		period, err := repos.Periods.FindClosedOn(date)
+		if err == nil {
+			return &closedPeriodError{period: period, date: date}
+		}
+		if !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkPeriodsOpen is ensurePeriodsOpen for handlers. It writes a 409
+// response when a date is in a closed period and returns false.
+func checkPeriodsOpen(c *gin.Context, repos *repository.Repositories, dates ...time.Time) bool {
+	err := ensurePeriodsOpen(repos, dates...)
+	var closed *closedPeriodError
+	switch {
+	case errors.As(err, &closed):
+		c.JSON(http.StatusConflict, gin.H{"error": closed.Error(), "period": closed.period})
+		return false
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check accounting periods"})
+		return false
+	}
+	return true
+}
+
+// closePeriod closes the period called name covering startDate..endDate,
+// creating it or closing it again when it was reopened.
+func closePeriod(repos *repository.Repositories, name string, startDate, endDate time.Time, actor uuid.UUID) (*models.AccountingPeriod, error) {
+	now := time.Now()
+	period, err := repos.Periods.FindByName(name)
+	if errors.Is(err, repository.ErrNotFound) {
+		period = &models.AccountingPeriod{
+			Name:      name,
+			StartDate: startDate,
+			EndDate:   endDate,
+			Status:    "closed",
+			ClosedAt:  &now,
+			ClosedBy:  &actor,
+		}
+		return period, repos.Periods.Create(period)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	period.StartDate = startDate
+	period.EndDate = endDate
+	period.Status = "closed"
+	period.ClosedAt = &now
+	period.ClosedBy = &actor
+	return period, repos.Periods.Update(period)
+}
+
+func (h *PeriodHandler) GetPeriods(c *gin.Context) {
+	periods, err := h.repos.Periods.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounting periods"})
+		return
+	}
+	if periods == nil {
+		periods = []models.AccountingPeriod{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": periods})
+}
+
+// ClosePeriod closes a month or a calendar year.
+func (h *PeriodHandler) ClosePeriod(c *gin.Context) {
+	var req ClosePeriodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var name string
+	var startDate, endDate time.Time
+	switch {
+	case req.Month != "" && req.Year == "":
+		parsed, err := time.Parse("2006-01", req.Month)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid month. Use YYYY-MM"})
+			return
+		}
+		name, startDate, endDate = req.Month, parsed, parsed.AddDate(0, 1, -1)
+	case req.Year != "" && req.Month == "":
+		parsed, err := time.Parse("2006", req.Year)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year. Use YYYY"})
+			return
+		}
+		name, startDate, endDate = req.Year, parsed, parsed.AddDate(1, 0, -1)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide either month or year"})
+		return
+	}
+
+	if existing, err := h.repos.Periods.FindByName(name); err == nil && existing.Status == "closed" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Accounting period " + name + " is already closed"})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	var period *models.AccountingPeriod
+	err := h.repos.WithTx(func(repos *repository.Repositories) error {
+		var err error
+		period, err = closePeriod(repos, name, startDate, endDate, userID.(uuid.UUID))
+		if err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "period",
+			EntityID:   period.ID,
+			Action:     "close",
+			Summary:    "Closed accounting period: " + period.Name,
+			After:      period,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to close accounting period"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Accounting period closed successfully",
+		"data":    period,
+	})
+}
+
+// ReopenPeriod reopens a closed period. The reason is required and written
+// to the activity log.
+func (h *PeriodHandler) ReopenPeriod(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Accounting period not found"})
+		return
+	}
+	period, err := h.repos.Periods.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Accounting period not found"})
+		return
+	}
+
+	var req ReopenPeriodRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Reason) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A reason is required to reopen a period"})
+		return
+	}
+	if period.Status != "closed" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Accounting period " + period.Name + " is not closed"})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	actor := userID.(uuid.UUID)
+	now := time.Now()
+	before := *period
+	period.Status = "open"
+	period.ReopenedAt = &now
+	period.ReopenedBy = &actor
+	period.ReopenReason = strings.TrimSpace(req.Reason)
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.Periods.Update(period); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "period",
+			EntityID:   period.ID,
+			Action:     "reopen",
+			Summary:    "Reopened accounting period " + period.Name + ": " + period.ReopenReason,
+			Before:     before,
+			After:      period,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reopen accounting period"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Accounting period reopened successfully",
+		"data":    period,
+	})
+}
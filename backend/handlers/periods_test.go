@@ -0,0 +1,56 @@
+package handlers_test
+
+import (
+	"gkjw-finance-backend/models"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestClosedPeriodRefusesChanges(t *testing.T) {
+	s := newTestServer(t)
+	pending := s.createTransaction(s.admin, map[string]interface{}{"date": "2025-01-05"})
+	draft := s.createTransaction(s.admin, map[string]interface{}{"draft": true, "date": "2025-01-06"})
+	february := s.createTransaction(s.admin, map[string]interface{}{"date": "2025-02-03"})
+	period := data(s.do(s.admin, http.MethodPost, "/api/periods/close", map[string]interface{}{"month": "2025-01"}, http.StatusOK))
+
+	edit := func(date string) map[string]interface{} {
+		return map[string]interface{}{
+			"type": "income", "amount": "120000", "category": s.income.Name, "eventName": "Ibadah Minggu", "date": date, "fundId": s.fund.ID,
+		}
+	}
+	tests := []struct {
+		name   string
+		user   models.User
+		method string
+		path   string
+		body   interface{}
+	}{
+		{"create", s.admin, http.MethodPost, "/api/transactions", edit("2025-01-31")},
+		{"edit", s.admin, http.MethodPut, "/api/transactions/" + pending, edit("2025-01-05")},
+		{"move into the period", s.admin, http.MethodPut, "/api/transactions/" + february, edit("2025-01-20")},
+		{"approve", s.reviewer, http.MethodPut, "/api/transactions/" + pending + "/status", map[string]interface{}{"status": "approved"}},
+		{"submit", s.admin, http.MethodPut, "/api/transactions/" + draft + "/status", map[string]interface{}{"status": "pending"}},
+		{"delete", s.admin, http.MethodDelete, "/api/transactions/" + draft, nil},
+	}
+	for _, tt := range tests {
+		w := s.request(tt.user, tt.method, tt.path, tt.body)
+		if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "closed") {
+			t.Errorf("%s: status %d, want 409 naming the closed period: %s", tt.name, w.Code, w.Body.String())
+		}
+	}
+	if got := data(s.do(s.admin, http.MethodGet, "/api/transactions/"+pending, nil, http.StatusOK)); got["status"] != "pending" || got["amount"] != 100000.0 {
+		t.Errorf("transaction in the closed period: status %v, amount %v; want it unchanged", got["status"], got["amount"])
+	}
+
+	reopen := "/api/periods/" + period["id"].(string) + "/reopen"
+	s.do(s.admin, http.MethodPost, reopen, map[string]interface{}{"reason": " "}, http.StatusBadRequest)
+	s.do(s.member, http.MethodPost, reopen, map[string]interface{}{"reason": "Koreksi nota"}, http.StatusForbidden)
+	s.do(s.admin, http.MethodPost, reopen, map[string]interface{}{"reason": "Koreksi nota"}, http.StatusOK)
+
+	logs := list(s.do(s.admin, http.MethodGet, "/api/logs?entityType=period&action=reopen", nil, http.StatusOK))
+	if len(logs) != 1 || !strings.Contains(logs[0].(map[string]interface{})["summary"].(string), "Koreksi nota") {
+		t.Errorf("reopen logs %v, want one naming the reason", logs)
+	}
+	s.approve(pending)
+}
@@ -0,0 +1,555 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// defaultRecurringInterval is how often the scheduler looks for due
+// templates unless RECURRING_INTERVAL (a Go duration such as "15m") says
+// otherwise.
+const defaultRecurringInterval = time.Hour
+
+// recurringLockKey identifies the scheduler lock among PostgreSQL advisory
+// locks, next to the migration lock, so that only one server records
+// occurrences at a time.
+const recurringLockKey = 72_011_002
+
+// RecurringHandler manages the recurring templates.
+type RecurringHandler struct {
+	repos *repository.Repositories
+}
+
+func NewRecurringHandler(repos *repository.Repositories) *RecurringHandler {
+	return &RecurringHandler{repos: repos}
+}
+
+// RecurringTemplateRequest describes a template. DayOfMonth defaults to
+// the day of StartDate and MonthOfYear to its month; Weekday defaults to
+// Sunday.
+type RecurringTemplateRequest struct {
+	Name          string       `json:"name" binding:"required"`
+	Type          string       `json:"type" binding:"required,oneof=income expense"`
+	Amount        models.Money `json:"amount" binding:"required,gt=0"`
+	FundID        string       `json:"fundId" binding:"required"`
+	Category      string       `json:"category" binding:"required"`
+	PaymentMethod string       `json:"paymentMethod" binding:"omitempty,oneof=cash bank"`
+	EventName     string       `json:"eventName"`
+	Description   string       `json:"description"`
+	Frequency     string       `json:"frequency" binding:"required,oneof=weekly monthly yearly"`
+	DayOfMonth    int          `json:"dayOfMonth" binding:"omitempty,min=1,max=31"`
+	Weekday       int          `json:"weekday" binding:"min=0,max=6"`
+	MonthOfYear   int          `json:"monthOfYear" binding:"omitempty,min=1,max=12"`
+	StartDate     string       `json:"startDate" binding:"required"`
+	EndDate       string       `json:"endDate"`
+}
+
+// today returns the current date at midnight UTC, as dates are stored.
+func today() time.Time {
+	date, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	return date
+}
+
+// firstUnrecorded returns the first date from which template may still
+// record occurrences: its start date, or the day after the last occurrence
+// it recorded.
+func firstUnrecorded(template *models.RecurringTemplate) time.Time {
+	if template.LastRunDate != nil && !template.LastRunDate.Before(template.StartDate) {
+		return template.LastRunDate.AddDate(0, 0, 1)
+	}
+	return template.StartDate
+}
+
+// applyTemplateRequest validates req and copies it onto template, then
+// moves its next run to the first occurrence of the new schedule from the
+// current next run on. A new startDate restarts the series from that date
+// instead, after any occurrence already recorded.
+func (h *RecurringHandler) applyTemplateRequest(req RecurringTemplateRequest, template *models.RecurringTemplate) error {
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return errors.New("Invalid startDate format. Use YYYY-MM-DD")
+	}
+	var endDate *time.Time
+	if req.EndDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.EndDate)
+		if err != nil {
+			return errors.New("Invalid endDate format. Use YYYY-MM-DD")
+		}
+		if parsed.Before(startDate) {
+			return errors.New("endDate must not be before startDate")
+		}
+		endDate = &parsed
+	}
+	fundID, err := uuid.Parse(req.FundID)
+	if err != nil {
+		return errors.New("Invalid fundId")
+	}
+	if _, err := h.repos.Funds.FindByID(fundID); err != nil {
+		return errors.New("Fund not found")
+	}
+	categories, err := h.repos.Categories.List()
+	if err != nil {
+		return err
+	}
+	category := findCategory(categories, req.Category, req.Type)
+	if category == nil {
+		return fmt.Errorf("No %s category named %q", req.Type, req.Category)
+	}
+	if !category.Allows(req.Type) {
+		return fmt.Errorf("Category %q is for %s transactions", category.Name, category.Type)
+	}
+
+	template.Name = req.Name
+	template.Type = req.Type
+	template.Amount = req.Amount
+	template.FundID = fundID
+	template.Fund = nil
+	template.Category = category.Name
+	template.PaymentMethod = req.PaymentMethod
+	if template.PaymentMethod == "" {
+		template.PaymentMethod = "cash"
+	}
+	template.EventName = req.EventName
+	if template.EventName == "" {
+		template.EventName = req.Name
+	}
+	template.Description = req.Description
+	template.Frequency = req.Frequency
+	template.DayOfMonth = req.DayOfMonth
+	if template.DayOfMonth == 0 {
+		template.DayOfMonth = startDate.Day()
+	}
+	template.Weekday = req.Weekday
+	template.MonthOfYear = req.MonthOfYear
+	if template.MonthOfYear == 0 {
+		template.MonthOfYear = int(startDate.Month())
+	}
+	from := template.NextRunDate
+	if !startDate.Equal(template.StartDate) || template.ID == uuid.Nil {
+		from = time.Time{}
+	}
+	template.StartDate = startDate
+	template.EndDate = endDate
+
+	if first := firstUnrecorded(template); first.After(from) {
+		from = first
+	}
+	template.NextRunDate = template.FirstOn(from)
+	if endDate != nil && template.NextRunDate.After(*endDate) {
+		return errors.New("The series has no occurrence left before its endDate")
+	}
+	return nil
+}
+
+// findTemplate loads the template named by the id parameter, or answers
+// 404 and returns false.
+func (h *RecurringHandler) findTemplate(c *gin.Context) (*models.RecurringTemplate, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring template not found"})
+		return nil, false
+	}
+	template, err := h.repos.RecurringTemplates.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring template not found"})
+		return nil, false
+	}
+	if !ifMatch(c, template.Version) {
+		respondStale(c, template, template.Version)
+		return nil, false
+	}
+	return template, true
+}
+
+// saveTemplate stores a changed template with its audit entry and answers
+// with it.
+func (h *RecurringHandler) saveTemplate(c *gin.Context, template *models.RecurringTemplate, before models.RecurringTemplate, action, message string) {
+	err := h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.RecurringTemplates.Update(template); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "recurring_template",
+			EntityID:   template.ID,
+			Action:     action,
+			Summary:    message + ": " + template.Name,
+			Before:     before,
+			After:      template,
+		})
+	})
+	if errors.Is(err, repository.ErrVersionConflict) {
+		if current, err := h.repos.RecurringTemplates.FindByID(template.ID); err == nil {
+			respondStale(c, current, current.Version)
+			return
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update recurring template"})
+		return
+	}
+
+	setETag(c, template.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    template,
+	})
+}
+
+func (h *RecurringHandler) GetTemplates(c *gin.Context) {
+	templates, err := h.repos.RecurringTemplates.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recurring templates"})
+		return
+	}
+	if templates == nil {
+		templates = []models.RecurringTemplate{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": templates})
+}
+
+func (h *RecurringHandler) GetTemplate(c *gin.Context) {
+	template, ok := h.findTemplate(c)
+	if !ok {
+		return
+	}
+
+	setETag(c, template.Version)
+	c.JSON(http.StatusOK, gin.H{"data": template})
+}
+
+// CreateTemplate adds an active template. Occurrences between its start
+// date and today are recorded by the next scheduler run.
+func (h *RecurringHandler) CreateTemplate(c *gin.Context) {
+	var req RecurringTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	template := models.RecurringTemplate{
+		Status:    "active",
+		CreatedBy: userID.(uuid.UUID),
+	}
+	if err := h.applyTemplateRequest(req, &template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.RecurringTemplates.Create(&template); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "recurring_template",
+			EntityID:   template.ID,
+			Action:     "create",
+			Summary:    "Created recurring template: " + template.Name,
+			After:      template,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recurring template"})
+		return
+	}
+
+	setETag(c, template.Version)
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Recurring template created successfully",
+		"data":    template,
+	})
+}
+
+// UpdateTemplate replaces a template. Occurrences already recorded are
+// kept; the next run moves to the first occurrence of the new schedule
+// after them.
+func (h *RecurringHandler) UpdateTemplate(c *gin.Context) {
+	template, ok := h.findTemplate(c)
+	if !ok {
+		return
+	}
+	if template.Status == "ended" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Recurring template has ended and cannot be changed"})
+		return
+	}
+
+	var req RecurringTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	before := *template
+	if err := h.applyTemplateRequest(req, template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.saveTemplate(c, template, before, "update", "Recurring template updated successfully")
+}
+
+// PauseTemplate stops a template from recording occurrences until it is
+// resumed.
+func (h *RecurringHandler) PauseTemplate(c *gin.Context) {
+	template, ok := h.findTemplate(c)
+	if !ok {
+		return
+	}
+	if template.Status != "active" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only active recurring templates can be paused"})
+		return
+	}
+
+	before := *template
+	template.Status = "paused"
+	h.saveTemplate(c, template, before, "pause", "Recurring template paused")
+}
+
+// ResumeTemplate restarts a paused template from today. Occurrences that
+// fell while it was paused are not recorded.
+func (h *RecurringHandler) ResumeTemplate(c *gin.Context) {
+	template, ok := h.findTemplate(c)
+	if !ok {
+		return
+	}
+	if template.Status != "paused" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only paused recurring templates can be resumed"})
+		return
+	}
+
+	before := *template
+	from := firstUnrecorded(template)
+	if now := today(); now.After(from) {
+		from = now
+	}
+	template.Status = "active"
+	template.NextRunDate = template.FirstOn(from)
+	if template.EndDate != nil && template.NextRunDate.After(*template.EndDate) {
+		template.Status = "ended"
+	}
+	h.saveTemplate(c, template, before, "resume", "Recurring template resumed")
+}
+
+// EndTemplate ends a series for good. Transactions it recorded are kept.
+func (h *RecurringHandler) EndTemplate(c *gin.Context) {
+	template, ok := h.findTemplate(c)
+	if !ok {
+		return
+	}
+	if template.Status == "ended" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Recurring template has already ended"})
+		return
+	}
+
+	before := *template
+	template.Status = "ended"
+	h.saveTemplate(c, template, before, "end", "Recurring template ended")
+}
+
+// RunTemplates records the occurrences due today without waiting for the
+// scheduler.
+func (h *RecurringHandler) RunTemplates(c *gin.Context) {
+	created, err := GenerateRecurring(h.repos, today())
+	if err != nil {
+		log.Printf("Recurring transactions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to record some recurring transactions",
+			"created": created,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": strconv.Itoa(created) + " recurring transactions recorded",
+		"created": created,
+	})
+}
+
+// GenerateRecurring records a pending transaction for every occurrence of
+// an active template due on or before date, oldest first, and returns how
+// many it created. Each occurrence is recorded in one database transaction
+// together with the template's next run date, so a run that stops halfway
+// or races another never records an occurrence twice, and the next run
+// catches up with whatever was missed.
+func GenerateRecurring(repos *repository.Repositories, date time.Time) (int, error) {
+	templates, err := repos.RecurringTemplates.ListDue(date)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	var errs []error
+	for i := range templates {
+		count, err := generateOccurrences(repos, &templates[i], date)
+		created += count
+		if err != nil {
+			errs = append(errs, fmt.Errorf("template %q: %w", templates[i].Name, err))
+		}
+	}
+	return created, errors.Join(errs...)
+}
+
+// generateOccurrences records the occurrences of template due on or before
+// date.
+func generateOccurrences(repos *repository.Repositories, template *models.RecurringTemplate, date time.Time) (int, error) {
+	created := 0
+	for template.Status == "active" && !template.NextRunDate.After(date) {
+		next := *template
+		var recorded bool
+		err := repos.WithTx(func(repos *repository.Repositories) error {
+			var err error
+			recorded, err = recordOccurrence(repos, &next)
+			return err
+		})
+		if errors.Is(err, repository.ErrVersionConflict) {
+			// The template was changed, or another run got here first;
+			// the next run starts from the stored template.
+			return created, nil
+		}
+		if err != nil {
+			return created, err
+		}
+		*template = next
+		if recorded {
+			created++
+		}
+	}
+	return created, nil
+}
+
+// recordOccurrence records the next occurrence of template as a pending
+// transaction by its creator and moves the template on to the following
+// one. An occurrence falling in a closed accounting period is skipped and
+// the skip is written to the audit trail. It reports whether a transaction
+// was created.
+func recordOccurrence(repos *repository.Repositories, template *models.RecurringTemplate) (bool, error) {
+	before := *template
+	occurrence := template.NextRunDate
+
+	err := ensurePeriodsOpen(repos, occurrence)
+	var closed *closedPeriodError
+	if err != nil && !errors.As(err, &closed) {
+		return false, err
+	}
+	if closed == nil {
+		transaction, err := newTransaction(CreateTransactionRequest{
+			Type:          template.Type,
+			PaymentMethod: template.PaymentMethod,
+			Amount:        template.Amount,
+			Category:      template.Category,
+			Description:   template.Description,
+			EventName:     template.EventName,
+			Date:          occurrence.Format("2006-01-02"),
+			FundID:        template.FundID.String(),
+		}, template.CreatedBy)
+		if err != nil {
+			return false, err
+		}
+		categories, err := repos.Categories.List()
+		if err != nil {
+			return false, err
+		}
+		if err := resolveCategories(categories, &transaction); err != nil {
+			return false, err
+		}
+		transaction.RecurringTemplateID = &template.ID
+		transaction.OccurrenceDate = &occurrence
+		if err := saveNewTransaction(nil, repos, &transaction); err != nil {
+			return false, err
+		}
+	}
+
+	template.LastRunDate = &occurrence
+	template.NextRunDate = template.After(occurrence)
+	if template.EndDate != nil && template.NextRunDate.After(*template.EndDate) {
+		template.Status = "ended"
+	}
+	if err := repos.RecurringTemplates.Update(template); err != nil {
+		return false, err
+	}
+
+	entry := auditEntry{
+		EntityType: "recurring_template",
+		EntityID:   template.ID,
+		Before:     before,
+		After:      template,
+		Actor:      template.CreatedBy,
+	}
+	switch {
+	case closed != nil:
+		entry.Action = "skip"
+		entry.Summary = "Skipped the " + occurrence.Format("2006-01-02") + " occurrence of recurring template " + template.Name + ": " + closed.Error()
+	case template.Status == "ended":
+		entry.Action = "end"
+		entry.Summary = "Recurring template ended after its last occurrence: " + template.Name
+	default:
+		return true, nil
+	}
+	return closed == nil, recordAudit(nil, repos, entry)
+}
+
+// StartRecurringScheduler records the due occurrences now, catching up on
+// any missed while the server was down, and again every
+// RECURRING_INTERVAL in the background until ctx is done. A run is
+// skipped while another server holds the scheduler lock. The returned
+// channel is closed once the scheduler has stopped, after the run in
+// progress, if any, has finished.
+func StartRecurringScheduler(ctx context.Context, repos *repository.Repositories) <-chan struct{} {
+	interval := recurringInterval()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			runRecurringScheduler(repos)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return done
+}
+
+// runRecurringScheduler is one run of the scheduler.
+func runRecurringScheduler(repos *repository.Repositories) {
+	var created int
+	locked, err := repos.TryLock(recurringLockKey, func() error {
+		var err error
+		created, err = GenerateRecurring(repos, today())
+		return err
+	})
+	if err != nil {
+		log.Printf("Recurring transactions: %v", err)
+	}
+	if !locked {
+		log.Printf("Recurring transactions: another server is recording them")
+	}
+	if created > 0 {
+		log.Printf("Recorded %d recurring transactions", created)
+	}
+}
+
+func recurringInterval() time.Duration {
+	value := os.Getenv("RECURRING_INTERVAL")
+	if value == "" {
+		return defaultRecurringInterval
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		log.Printf("Invalid RECURRING_INTERVAL %q, using %s", value, defaultRecurringInterval)
+		return defaultRecurringInterval
+	}
+	return interval
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
+	"testing"
+	"time"
+)
+
+// dueTemplate stores a monthly template whose first two occurrences have
+// fallen due.
+func dueTemplate(t *testing.T, repos *repository.Repositories) {
+	t.Helper()
+	user := models.User{Name: "Bendahara", Email: "bendahara@example.com", Role: "admin"}
+	fund := models.Fund{Name: "Kas Umum"}
+	category := models.Category{Name: "Listrik & Air", Type: "expense"}
+	if err := repos.Users.Create(&user); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Funds.Create(&fund); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Categories.Create(&category); err != nil {
+		t.Fatal(err)
+	}
+	start := today().AddDate(0, 0, -40)
+	err := repos.RecurringTemplates.Create(&models.RecurringTemplate{
+		Name:          "Tagihan listrik",
+		Type:          "expense",
+		Amount:        models.Rupiah(350000),
+		FundID:        fund.ID,
+		Category:      category.Name,
+		PaymentMethod: "bank",
+		EventName:     "Tagihan listrik",
+		Frequency:     "monthly",
+		DayOfMonth:    start.Day(),
+		StartDate:     start,
+		NextRunDate:   start,
+		Status:        "active",
+		CreatedBy:     user.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func countTransactions(t *testing.T, repos *repository.Repositories) int64 {
+	t.Helper()
+	count, err := repos.Transactions.Count(repository.TransactionFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestRecurringSchedulerSkipsRunWhileLocked(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	dueTemplate(t, repos)
+
+	locked, err := repos.TryLock(recurringLockKey, func() error {
+		runRecurringScheduler(repos)
+		return nil
+	})
+	if err != nil || !locked {
+		t.Fatalf("TryLock = %v, %v", locked, err)
+	}
+	if n := countTransactions(t, repos); n != 0 {
+		t.Fatalf("recorded %d transactions while another run held the lock", n)
+	}
+
+	runRecurringScheduler(repos)
+	if n := countTransactions(t, repos); n < 2 {
+		t.Errorf("recorded %d transactions, want the 2 due occurrences", n)
+	}
+}
+
+func TestRecurringSchedulerStops(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	dueTemplate(t, repos)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := StartRecurringScheduler(ctx, repos)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the scheduler did not stop")
+	}
+	if n := countTransactions(t, repos); n < 2 {
+		t.Errorf("recorded %d transactions before stopping, want the 2 due occurrences", n)
+	}
+}
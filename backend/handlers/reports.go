@@ -2,107 +2,222 @@ package handlers
 
 import (
 	"fmt"
-	"gkjw-finance-backend/config"
 	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/jung-kurt/gofpdf"
 	"github.com/xuri/excelize/v2"
 )
 
-func GetReports(c *gin.Context) {
-	var transactions []models.Transaction
+// ReportHandler serves the report listing and its PDF/Excel exports.
+type ReportHandler struct {
+	repos *repository.Repositories
+}
 
-	query := config.DB.Preload("CreatedByUser").Preload("Fund").Where("status = ?", "approved")
+func NewReportHandler(repos *repository.Repositories) *ReportHandler {
+	return &ReportHandler{repos: repos}
+}
 
-	// Filter by date range
-	if startDate := c.Query("startDate"); startDate != "" {
-		query = query.Where("date >= ?", startDate)
-	}
-	if endDate := c.Query("endDate"); endDate != "" {
-		query = query.Where("date <= ?", endDate)
+// reportTransactions loads the posted transactions selected by the report
+// query parameters, ordered by date, the filter that selected them and the
+// summary of the report. Without a startDate the report starts at the
+// earliest opening balance. The report of an event, given by eventId, has
+// no opening balance and carries the event for its budget. It writes an
+// error response and returns false on failure.
+func (h *ReportHandler) reportTransactions(c *gin.Context) ([]models.Transaction, repository.TransactionFilter, reportSummary, bool) {
+	var summary reportSummary
+	filter, err := transactionFilterFromQuery(c, h.repos)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, filter, summary, false
 	}
+	filter.Status = repository.StatusPosted
 
-	// Filter by type
-	if txType := c.Query("type"); txType != "" {
-		query = query.Where("type = ?", txType)
+	if filter.EventID != uuid.Nil {
+		if summary.Event, err = h.repos.Events.FindByID(filter.EventID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Event not found"})
+			return nil, filter, summary, false
+		}
+	} else if filter.StartDate.IsZero() {
+		if filter.StartDate, err = defaultStartDate(h.repos, filter.FundID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute opening balance"})
+			return nil, filter, summary, false
+		}
 	}
-
-	// Filter by category
-	if category := c.Query("category"); category != "" {
-		query = query.Where("category = ?", category)
+	if !filter.StartDate.IsZero() && summary.Event == nil {
+		balances, err := balancesBefore(h.repos, filter.FundID, filter.StartDate)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute opening balance"})
+			return nil, filter, summary, false
+		}
+		summary.OpeningBalance = selectedBalance(filter, balances)
 	}
 
-	// Filter by fund
-	if fundID := c.Query("fundId"); fundID != "" && fundID != "all" {
-		query = query.Where("fund_id = ?", fundID)
+	transactions, err := h.repos.Transactions.FindAll(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		return nil, filter, summary, false
 	}
+	summary.add(filter, transactions)
+	return transactions, filter, summary, true
+}
 
-	query = query.Order("date ASC")
+// reportSummary holds the totals of a report. Transfers are not income or
+// expense; NetTransfer is what they moved into (positive) or out of
+// (negative) the funds and payment methods in the report. OpeningBalance
+// is their balance at the start of the period and does not depend on the
+// type or category filter. Event is the event of an event report.
+type reportSummary struct {
+	Event          *models.Event
+	OpeningBalance models.Money
+	TotalIncome    models.Money
+	TotalExpense   models.Money
+	NetTransfer    models.Money
+}
 
-	if err := query.Find(&transactions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
-		return
-	}
+// Balance is the change in balance over the period.
+func (s reportSummary) Balance() models.Money {
+	return s.TotalIncome - s.TotalExpense + s.NetTransfer
+}
 
-	// Calculate summary
-	var totalIncome, totalExpense float64
-	for _, t := range transactions {
-		if t.Type == "income" {
-			totalIncome += t.Amount
-		} else {
-			totalExpense += t.Amount
+func (s reportSummary) ClosingBalance() models.Money {
+	return s.OpeningBalance + s.Balance()
+}
+
+// add adds transactions to the totals as seen from the selection of filter.
+func (s *reportSummary) add(filter repository.TransactionFilter, transactions []models.Transaction) {
+	for _, tx := range transactions {
+		switch tx.Type {
+		case "income":
+			s.TotalIncome += filter.Amount(tx)
+		case "expense":
+			s.TotalExpense += filter.Amount(tx)
+		case "transfer":
+			s.NetTransfer += filter.BalanceEffect(tx)
 		}
 	}
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": transactions,
-		"summary": gin.H{
-			"totalIncome":  totalIncome,
-			"totalExpense": totalExpense,
-			"balance":      totalIncome - totalExpense,
-			"count":        len(transactions),
-		},
-	})
+// reportRow is one row of an export.
+type reportRow struct {
+	Label       string
+	Category    string
+	Description string
+	Income      models.Money
+	Expense     models.Money
 }
 
-func ExportPDF(c *gin.Context) {
-	// Fetch transactions
-	var transactions []models.Transaction
-	query := config.DB.Preload("CreatedByUser").Preload("Fund").Where("status = ?", "approved")
+// reportRows returns the rows of tx in an export: one for each line of an
+// income or expense that the filter selects, and one for a transfer.
+// Income and expense stay in their own column, so that a reversal shows as
+// a negative amount under its original and the columns add up to the
+// totals; transfers go by their effect on the selection.
+func reportRows(filter repository.TransactionFilter, tx models.Transaction) []reportRow {
+	label := tx.EventName
+	switch {
+	case tx.ReversalOfID != nil:
+		label = "Pembatalan: " + label
+	case tx.Status == "voided":
+		label += " (dibatalkan)"
+	}
+
+	if tx.Type == "transfer" {
+		row := reportRow{Label: label, Category: tx.Category, Description: tx.Description}
+		if effect := filter.BalanceEffect(tx); effect > 0 {
+			row.Income = effect
+		} else {
+			row.Expense = -effect
+		}
+		return []reportRow{row}
+	}
 
-	if startDate := c.Query("startDate"); startDate != "" {
-		query = query.Where("date >= ?", startDate)
+	var rows []reportRow
+	for _, line := range filter.Lines(tx) {
+		row := reportRow{Label: label, Category: line.Category, Description: line.Description}
+		if row.Description == "" {
+			row.Description = tx.Description
+		}
+		if tx.Type == "income" {
+			row.Income = line.Amount
+		} else {
+			row.Expense = line.Amount
+		}
+		rows = append(rows, row)
 	}
-	if endDate := c.Query("endDate"); endDate != "" {
-		query = query.Where("date <= ?", endDate)
+	return rows
+}
+
+// reportTitle is the title of the exports of a report.
+func reportTitle(summary reportSummary) string {
+	if summary.Event != nil {
+		return "LAPORAN KEGIATAN: " + strings.ToUpper(summary.Event.Name)
 	}
-	if txType := c.Query("type"); txType != "" && txType != "all" {
-		query = query.Where("type = ?", txType)
+	return "LAPORAN KEUANGAN GKJW KARANGPILANG"
+}
+
+// reportPeriod describes the period of a report for the export headers:
+// that of the filter, or the dates of the event of an event report.
+func reportPeriod(filter repository.TransactionFilter, summary reportSummary) string {
+	if event := summary.Event; event != nil {
+		periodText := "Tanggal Kegiatan: " + event.StartDate.Format("2006-01-02")
+		if event.EndDate != nil && !event.EndDate.Equal(event.StartDate) {
+			periodText += " s/d " + event.EndDate.Format("2006-01-02")
+		}
+		if event.Commission != "" {
+			periodText += " | Penanggung Jawab: " + event.Commission
+		}
+		return periodText
 	}
-	if category := c.Query("category"); category != "" && category != "all" {
-		query = query.Where("category = ?", category)
+	periodText := "Periode: "
+	if !filter.StartDate.IsZero() {
+		periodText += filter.StartDate.Format("2006-01-02")
+	} else {
+		periodText += "Awal"
 	}
-	if fundID := c.Query("fundId"); fundID != "" && fundID != "all" {
-		query = query.Where("fund_id = ?", fundID)
+	periodText += " s/d "
+	if !filter.EndDate.IsZero() {
+		periodText += filter.EndDate.Format("2006-01-02")
+	} else {
+		periodText += "Sekarang"
 	}
+	return periodText
+}
 
-	if err := query.Order("date ASC").Find(&transactions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+func (h *ReportHandler) GetReports(c *gin.Context) {
+	transactions, _, summary, ok := h.reportTransactions(c)
+	if !ok {
 		return
 	}
 
-	// Calculate summary
-	var totalIncome, totalExpense float64
-	for _, tx := range transactions {
-		if tx.Type == "income" {
-			totalIncome += tx.Amount
-		} else {
-			totalExpense += tx.Amount
-		}
+	summaryData := gin.H{
+		"openingBalance": summary.OpeningBalance,
+		"totalIncome":    summary.TotalIncome,
+		"totalExpense":   summary.TotalExpense,
+		"netTransfer":    summary.NetTransfer,
+		"balance":        summary.Balance(),
+		"closingBalance": summary.ClosingBalance(),
+		"count":          len(transactions),
+	}
+	if summary.Event != nil {
+		summaryData["event"] = summary.Event
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":    transactions,
+		"summary": summaryData,
+	})
+}
+
+func (h *ReportHandler) ExportPDF(c *gin.Context) {
+	// Fetch transactions and summary
+	transactions, filter, summary, ok := h.reportTransactions(c)
+	if !ok {
+		return
 	}
 
 	// Create PDF
@@ -111,32 +226,32 @@ func ExportPDF(c *gin.Context) {
 
 	// Use built-in font for better compatibility
 	pdf.SetFont("Helvetica", "B", 16)
-	pdf.Cell(0, 10, "LAPORAN KEUANGAN GKJW KARANGPILANG")
+	pdf.Cell(0, 10, reportTitle(summary))
 	pdf.Ln(8)
 
 	// Period
 	pdf.SetFont("Helvetica", "", 10)
-	periodText := "Periode: "
-	if c.Query("startDate") != "" {
-		periodText += c.Query("startDate")
-	} else {
-		periodText += "Awal"
-	}
-	periodText += " s/d "
-	if c.Query("endDate") != "" {
-		periodText += c.Query("endDate")
-	} else {
-		periodText += "Sekarang"
-	}
-	pdf.Cell(0, 6, periodText)
+	pdf.Cell(0, 6, reportPeriod(filter, summary))
 	pdf.Ln(8)
 
 	// Summary
 	pdf.SetFont("Helvetica", "B", 11)
-	pdf.Cell(70, 7, fmt.Sprintf("Total Pemasukan: Rp %.0f", totalIncome))
-	pdf.Cell(70, 7, fmt.Sprintf("Total Pengeluaran: Rp %.0f", totalExpense))
-	pdf.Cell(70, 7, fmt.Sprintf("Saldo: Rp %.0f", totalIncome-totalExpense))
-	pdf.Ln(10)
+	pdf.Cell(70, 7, "Saldo Awal: "+summary.OpeningBalance.Format())
+	pdf.Cell(70, 7, "Total Pemasukan: "+summary.TotalIncome.Format())
+	pdf.Cell(70, 7, "Total Pengeluaran: "+summary.TotalExpense.Format())
+	pdf.Ln(7)
+	if summary.NetTransfer != 0 {
+		pdf.Cell(70, 7, "Transfer Bersih: "+summary.NetTransfer.Format())
+	}
+	pdf.Cell(70, 7, "Saldo Akhir: "+summary.ClosingBalance().Format())
+	pdf.Ln(7)
+	if event := summary.Event; event != nil {
+		pdf.Cell(70, 7, "Anggaran Pemasukan: "+event.BudgetIncome.Format())
+		pdf.Cell(70, 7, "Anggaran Pengeluaran: "+event.BudgetExpense.Format())
+		pdf.Cell(70, 7, "Sisa Anggaran: "+(event.BudgetExpense-summary.TotalExpense).Format())
+		pdf.Ln(7)
+	}
+	pdf.Ln(3)
 
 	// Table Header
 	pdf.SetFont("Helvetica", "B", 9)
@@ -154,38 +269,44 @@ func ExportPDF(c *gin.Context) {
 	pdf.SetFont("Helvetica", "", 8)
 	pdf.SetTextColor(0, 0, 0)
 	fill := false
-	runningBalance := 0.0
-	
-	for _, tx := range transactions {
-		if fill {
-			pdf.SetFillColor(240, 240, 240)
-		} else {
-			pdf.SetFillColor(255, 255, 255)
-		}
+	runningBalance := summary.OpeningBalance
 
-		// Update running balance
-		if tx.Type == "income" {
-			runningBalance += tx.Amount
-		} else {
-			runningBalance -= tx.Amount
-		}
+	// Opening balance row
+	pdf.CellFormat(145, 7, "Saldo Awal", "1", 0, "L", fill, 0, "")
+	pdf.CellFormat(28, 7, "", "1", 0, "R", fill, 0, "")
+	pdf.CellFormat(28, 7, "", "1", 0, "R", fill, 0, "")
+	pdf.CellFormat(35, 7, runningBalance.Format(), "1", 1, "R", fill, 0, "")
+	fill = !fill
 
-		incomeStr := "-"
-		expenseStr := "-"
-		if tx.Type == "income" {
-			incomeStr = fmt.Sprintf("Rp %.0f", tx.Amount)
-		} else {
-			expenseStr = fmt.Sprintf("Rp %.0f", tx.Amount)
+	for _, tx := range transactions {
+		for _, r := range reportRows(filter, tx) {
+			if fill {
+				pdf.SetFillColor(240, 240, 240)
+			} else {
+				pdf.SetFillColor(255, 255, 255)
+			}
+
+			// Update running balance; transfers within the report net to zero
+			runningBalance += r.Income - r.Expense
+
+			incomeStr := "-"
+			expenseStr := "-"
+			if r.Income != 0 {
+				incomeStr = r.Income.Format()
+			}
+			if r.Expense != 0 {
+				expenseStr = r.Expense.Format()
+			}
+
+			pdf.CellFormat(25, 7, tx.Date.Format("02/01/2006"), "1", 0, "C", fill, 0, "")
+			pdf.CellFormat(50, 7, truncateString(r.Label, 30), "1", 0, "L", fill, 0, "")
+			pdf.CellFormat(25, 7, truncateString(r.Category, 12), "1", 0, "C", fill, 0, "")
+			pdf.CellFormat(45, 7, truncateString(r.Description, 25), "1", 0, "L", fill, 0, "")
+			pdf.CellFormat(28, 7, incomeStr, "1", 0, "R", fill, 0, "")
+			pdf.CellFormat(28, 7, expenseStr, "1", 0, "R", fill, 0, "")
+			pdf.CellFormat(35, 7, runningBalance.Format(), "1", 1, "R", fill, 0, "")
+			fill = !fill
 		}
-
-		pdf.CellFormat(25, 7, tx.Date.Format("02/01/2006"), "1", 0, "C", fill, 0, "")
-		pdf.CellFormat(50, 7, truncateString(tx.EventName, 30), "1", 0, "L", fill, 0, "")
-		pdf.CellFormat(25, 7, truncateString(tx.Category, 12), "1", 0, "C", fill, 0, "")
-		pdf.CellFormat(45, 7, truncateString(tx.Description, 25), "1", 0, "L", fill, 0, "")
-		pdf.CellFormat(28, 7, incomeStr, "1", 0, "R", fill, 0, "")
-		pdf.CellFormat(28, 7, expenseStr, "1", 0, "R", fill, 0, "")
-		pdf.CellFormat(35, 7, fmt.Sprintf("Rp %.0f", runningBalance), "1", 1, "R", fill, 0, "")
-		fill = !fill
 	}
 
 	// Total Row
@@ -193,9 +314,9 @@ func ExportPDF(c *gin.Context) {
 	pdf.SetFillColor(59, 130, 246)
 	pdf.SetTextColor(255, 255, 255)
 	pdf.CellFormat(145, 8, "TOTAL", "1", 0, "R", true, 0, "")
-	pdf.CellFormat(28, 8, fmt.Sprintf("Rp %.0f", totalIncome), "1", 0, "R", true, 0, "")
-	pdf.CellFormat(28, 8, fmt.Sprintf("Rp %.0f", totalExpense), "1", 0, "R", true, 0, "")
-	pdf.CellFormat(35, 8, fmt.Sprintf("Rp %.0f", totalIncome-totalExpense), "1", 1, "R", true, 0, "")
+	pdf.CellFormat(28, 8, summary.TotalIncome.Format(), "1", 0, "R", true, 0, "")
+	pdf.CellFormat(28, 8, summary.TotalExpense.Format(), "1", 0, "R", true, 0, "")
+	pdf.CellFormat(35, 8, summary.ClosingBalance().Format(), "1", 1, "R", true, 0, "")
 
 	// Output PDF
 	filename := fmt.Sprintf("laporan_keuangan_%s.pdf", time.Now().Format("20060102150405"))
@@ -223,39 +344,13 @@ func truncateString(s string, maxLen int) string {
 	return s[:maxLen-3] + "..."
 }
 
-func ExportExcel(c *gin.Context) {
-	// Fetch transactions
-	var transactions []models.Transaction
-	query := config.DB.Preload("CreatedByUser").Where("status = ?", "approved")
-
-	if startDate := c.Query("startDate"); startDate != "" {
-		query = query.Where("date >= ?", startDate)
-	}
-	if endDate := c.Query("endDate"); endDate != "" {
-		query = query.Where("date <= ?", endDate)
-	}
-	if txType := c.Query("type"); txType != "" && txType != "all" {
-		query = query.Where("type = ?", txType)
-	}
-	if category := c.Query("category"); category != "" && category != "all" {
-		query = query.Where("category = ?", category)
-	}
-
-	if err := query.Order("date ASC").Find(&transactions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+func (h *ReportHandler) ExportExcel(c *gin.Context) {
+	// Fetch transactions and summary
+	transactions, filter, summary, ok := h.reportTransactions(c)
+	if !ok {
 		return
 	}
 
-	// Calculate summary
-	var totalIncome, totalExpense float64
-	for _, tx := range transactions {
-		if tx.Type == "income" {
-			totalIncome += tx.Amount
-		} else {
-			totalExpense += tx.Amount
-		}
-	}
-
 	// Create Excel file
 	f := excelize.NewFile()
 	sheetName := "Laporan Keuangan"
@@ -264,7 +359,7 @@ func ExportExcel(c *gin.Context) {
 	f.DeleteSheet("Sheet1")
 
 	// Title
-	f.SetCellValue(sheetName, "A1", "LAPORAN KEUANGAN GKJW KARANGPILANG")
+	f.SetCellValue(sheetName, "A1", reportTitle(summary))
 	titleStyle, _ := f.NewStyle(&excelize.Style{
 		Font: &excelize.Font{Bold: true, Size: 14},
 		Alignment: &excelize.Alignment{
@@ -277,29 +372,31 @@ func ExportExcel(c *gin.Context) {
 	f.SetRowHeight(sheetName, 1, 25)
 
 	// Period
-	periodText := "Periode: "
-	if c.Query("startDate") != "" {
-		periodText += c.Query("startDate")
-	} else {
-		periodText += "Awal"
-	}
-	periodText += " s/d "
-	if c.Query("endDate") != "" {
-		periodText += c.Query("endDate")
-	} else {
-		periodText += "Sekarang"
-	}
-	f.SetCellValue(sheetName, "A2", periodText)
+	f.SetCellValue(sheetName, "A2", reportPeriod(filter, summary))
 	f.MergeCell(sheetName, "A2", "G2")
 
 	// Summary
 	f.SetCellValue(sheetName, "A4", "RINGKASAN")
 	f.SetCellValue(sheetName, "A5", "Total Pemasukan:")
-	f.SetCellValue(sheetName, "B5", totalIncome)
+	f.SetCellValue(sheetName, "B5", summary.TotalIncome.Float64())
 	f.SetCellValue(sheetName, "A6", "Total Pengeluaran:")
-	f.SetCellValue(sheetName, "B6", totalExpense)
-	f.SetCellValue(sheetName, "A7", "Saldo:")
-	f.SetCellValue(sheetName, "B7", totalIncome-totalExpense)
+	f.SetCellValue(sheetName, "B6", summary.TotalExpense.Float64())
+	f.SetCellValue(sheetName, "A7", "Saldo Akhir:")
+	f.SetCellValue(sheetName, "B7", summary.ClosingBalance().Float64())
+	f.SetCellValue(sheetName, "C5", "Saldo Awal:")
+	f.SetCellValue(sheetName, "D5", summary.OpeningBalance.Float64())
+	if summary.NetTransfer != 0 {
+		f.SetCellValue(sheetName, "C7", "Transfer Bersih:")
+		f.SetCellValue(sheetName, "D7", summary.NetTransfer.Float64())
+	}
+	if event := summary.Event; event != nil {
+		f.SetCellValue(sheetName, "E5", "Anggaran Pemasukan:")
+		f.SetCellValue(sheetName, "F5", event.BudgetIncome.Float64())
+		f.SetCellValue(sheetName, "E6", "Anggaran Pengeluaran:")
+		f.SetCellValue(sheetName, "F6", event.BudgetExpense.Float64())
+		f.SetCellValue(sheetName, "E7", "Sisa Anggaran:")
+		f.SetCellValue(sheetName, "F7", (event.BudgetExpense - summary.TotalExpense).Float64())
+	}
 
 	summaryStyle, _ := f.NewStyle(&excelize.Style{
 		Font: &excelize.Font{Bold: true},
@@ -310,6 +407,10 @@ func ExportExcel(c *gin.Context) {
 		NumFmt: 3, // #,##0
 	})
 	f.SetCellStyle(sheetName, "B5", "B7", numberStyle)
+	f.SetCellStyle(sheetName, "D5", "D7", numberStyle)
+	if summary.Event != nil {
+		f.SetCellStyle(sheetName, "F5", "F7", numberStyle)
+	}
 
 	// Table Headers - Cashflow format with Description
 	row := 9
@@ -335,62 +436,64 @@ func ExportExcel(c *gin.Context) {
 	})
 	f.SetCellStyle(sheetName, "A9", "G9", headerStyle)
 
-	// Data Rows - Cashflow format
+	// Opening balance row
 	row = 10
-	runningBalance := 0.0
-	for _, tx := range transactions {
-		// Update running balance
-		if tx.Type == "income" {
-			runningBalance += tx.Amount
-		} else {
-			runningBalance -= tx.Amount
-		}
+	runningBalance := summary.OpeningBalance
+	f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), "Saldo Awal")
+	f.MergeCell(sheetName, fmt.Sprintf("A%d", row), fmt.Sprintf("F%d", row))
+	f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), runningBalance.Float64())
+	row++
 
-		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), tx.Date.Format("02/01/2006"))
-		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), tx.EventName)
-		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), tx.Description)
-		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), tx.Category)
-		
-		// Pemasukan column
-		if tx.Type == "income" {
-			f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), tx.Amount)
-		} else {
-			f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), "-")
-		}
-		
-		// Pengeluaran column
-		if tx.Type == "expense" {
-			f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), tx.Amount)
-		} else {
-			f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), "-")
+	// Data Rows - Cashflow format
+	for _, tx := range transactions {
+		for _, r := range reportRows(filter, tx) {
+			// Update running balance; transfers within the report net to zero
+			runningBalance += r.Income - r.Expense
+
+			f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), tx.Date.Format("02/01/2006"))
+			f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), r.Label)
+			f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), r.Description)
+			f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), r.Category)
+
+			// Pemasukan column
+			if r.Income != 0 {
+				f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), r.Income.Float64())
+			} else {
+				f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), "-")
+			}
+
+			// Pengeluaran column
+			if r.Expense != 0 {
+				f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), r.Expense.Float64())
+			} else {
+				f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), "-")
+			}
+
+			// Saldo column
+			f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), runningBalance.Float64())
+			row++
 		}
-		
-		// Saldo column
-		f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), runningBalance)
-		row++
 	}
 
 	// Apply number format to amount columns
-	if len(transactions) > 0 {
-		f.SetCellStyle(sheetName, "E10", fmt.Sprintf("E%d", row-1), numberStyle)
-		f.SetCellStyle(sheetName, "F10", fmt.Sprintf("F%d", row-1), numberStyle)
-		f.SetCellStyle(sheetName, "G10", fmt.Sprintf("G%d", row-1), numberStyle)
-	}
+	f.SetCellStyle(sheetName, "E10", fmt.Sprintf("E%d", row-1), numberStyle)
+	f.SetCellStyle(sheetName, "F10", fmt.Sprintf("F%d", row-1), numberStyle)
+	f.SetCellStyle(sheetName, "G10", fmt.Sprintf("G%d", row-1), numberStyle)
 
 	// Total Row
 	totalRow := row
 	totalStyle, _ := f.NewStyle(&excelize.Style{
-		Font: &excelize.Font{Bold: true, Color: "FFFFFF"},
-		Fill: excelize.Fill{Type: "pattern", Color: []string{"3B82F6"}, Pattern: 1},
+		Font:      &excelize.Font{Bold: true, Color: "FFFFFF"},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"3B82F6"}, Pattern: 1},
 		Alignment: &excelize.Alignment{Horizontal: "right"},
-		NumFmt: 3,
+		NumFmt:    3,
 	})
-	
+
 	f.SetCellValue(sheetName, fmt.Sprintf("A%d", totalRow), "TOTAL")
 	f.MergeCell(sheetName, fmt.Sprintf("A%d", totalRow), fmt.Sprintf("D%d", totalRow))
-	f.SetCellValue(sheetName, fmt.Sprintf("E%d", totalRow), totalIncome)
-	f.SetCellValue(sheetName, fmt.Sprintf("F%d", totalRow), totalExpense)
-	f.SetCellValue(sheetName, fmt.Sprintf("G%d", totalRow), totalIncome-totalExpense)
+	f.SetCellValue(sheetName, fmt.Sprintf("E%d", totalRow), summary.TotalIncome.Float64())
+	f.SetCellValue(sheetName, fmt.Sprintf("F%d", totalRow), summary.TotalExpense.Float64())
+	f.SetCellValue(sheetName, fmt.Sprintf("G%d", totalRow), summary.ClosingBalance().Float64())
 	f.SetCellStyle(sheetName, fmt.Sprintf("A%d", totalRow), fmt.Sprintf("G%d", totalRow), totalStyle)
 
 	// Set column widths
@@ -439,7 +542,7 @@ func UploadFile(c *gin.Context) {
 
 	// Generate unique filename
 	filename := time.Now().Format("20060102150405") + "_" + file.Filename
-	
+
 	// Save file to uploads directory
 	uploadPath := "./uploads/" + filename
 	if err := c.SaveUploadedFile(file, uploadPath); err != nil {
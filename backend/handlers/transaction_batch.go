@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"errors"
+	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+)
+
+// maxBatchSize is the most transactions one batch request may name.
+const maxBatchSize = 200
+
+// errBatchRejected rolls back a batch in which some row failed; the rows
+// and their errors are reported separately.
+var errBatchRejected = errors.New("batch rejected")
+
+// BatchCreateTransactionsRequest creates many transactions at once. Each
+// item follows the rules of CreateTransactionRequest. With Atomic, one bad
+// item rejects the whole batch and all items are saved in one database
+// transaction; otherwise each valid item is saved on its own, and the
+// items that are invalid or fail to save are reported.
+type BatchCreateTransactionsRequest struct {
+	Items  []CreateTransactionRequest `json:"items" binding:"required,min=1"`
+	Atomic bool                       `json:"atomic"`
+}
+
+// BatchUpdateStatusRequest approves or rejects many pending transactions
+// together. Either every transaction is reviewed or none is.
+type BatchUpdateStatusRequest struct {
+	IDs             []string `json:"ids" binding:"required,min=1"`
+	Status          string   `json:"status" binding:"required,oneof=approved rejected"`
+	RejectionReason string   `json:"rejectionReason"`
+}
+
+// batchRowError is the reason one row of a batch was refused. Index is the
+// position of the row in the request and ID the transaction it names, if
+// any.
+type batchRowError struct {
+	Index int    `json:"index"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error"`
+}
+
+// BatchCreateTransactions creates the transactions in a batch, as
+// CreateTransaction does for one, and answers with the created
+// transactions and an error for each row that was refused.
+func (h *TransactionHandler) BatchCreateTransactions(c *gin.Context) {
+	var req BatchCreateTransactionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Items) > maxBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A batch may hold at most " + strconv.Itoa(maxBatchSize) + " transactions"})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	var rowErrors []batchRowError
+	var transactions []models.Transaction
+	var indexes []int // position in req.Items of each transaction
+	funds := map[uuid.UUID]error{}
+	categories, err := h.repos.Categories.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		return
+	}
+	for i, item := range req.Items {
+		transaction, err := h.validateBatchItem(item, userID.(uuid.UUID), funds, categories)
+		if err != nil {
+			rowErrors = append(rowErrors, batchRowError{Index: i, Error: err.Error()})
+			continue
+		}
+		transactions = append(transactions, transaction)
+		indexes = append(indexes, i)
+	}
+	if len(transactions) == 0 || req.Atomic && len(rowErrors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":  "No transactions were created",
+			"errors": rowErrors,
+		})
+		return
+	}
+
+	if req.Atomic {
+		err = h.repos.WithTx(func(repos *repository.Repositories) error {
+			for i := range transactions {
+				if err := saveNewTransaction(c, repos, &transactions[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transactions"})
+			return
+		}
+	} else {
+		created := make([]models.Transaction, 0, len(transactions))
+		for i := range transactions {
+			err := h.repos.WithTx(func(repos *repository.Repositories) error {
+				return saveNewTransaction(c, repos, &transactions[i])
+			})
+			if err != nil {
+				rowErrors = append(rowErrors, batchRowError{Index: indexes[i], Error: "Failed to create transaction"})
+				continue
+			}
+			created = append(created, transactions[i])
+		}
+		transactions = created
+		sort.Slice(rowErrors, func(i, j int) bool { return rowErrors[i].Index < rowErrors[j].Index })
+		if len(transactions) == 0 {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":  "Failed to create transactions",
+				"errors": rowErrors,
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": strconv.Itoa(len(transactions)) + " of " + strconv.Itoa(len(req.Items)) + " transactions created",
+		"data":    transactions,
+		"errors":  rowErrors,
+	})
+}
+
+// validateBatchItem checks item as binding would for a single request and
+// builds its transaction. funds remembers which funds were already looked
+// up.
+func (h *TransactionHandler) validateBatchItem(item CreateTransactionRequest, createdBy uuid.UUID, funds map[uuid.UUID]error, categories []models.Category) (models.Transaction, error) {
+	if err := binding.Validator.ValidateStruct(item); err != nil {
+		return models.Transaction{}, err
+	}
+	transaction, err := newTransaction(item, createdBy)
+	if err != nil {
+		return models.Transaction{}, err
+	}
+	if err := resolveCategories(categories, &transaction); err != nil {
+		return models.Transaction{}, err
+	}
+	if err := resolveEvent(h.repos, &transaction); err != nil {
+		return models.Transaction{}, err
+	}
+
+	var fundIDs []uuid.UUID
+	for _, line := range transaction.Splits() {
+		fundIDs = append(fundIDs, line.FundID)
+	}
+	if transaction.ToFundID != nil {
+		fundIDs = append(fundIDs, *transaction.ToFundID)
+	}
+	for _, id := range fundIDs {
+		lookup, seen := funds[id]
+		if !seen {
+			_, lookup = h.repos.Funds.FindByID(id)
+			funds[id] = lookup
+		}
+		if errors.Is(lookup, repository.ErrNotFound) {
+			return models.Transaction{}, errors.New("Fund " + id.String() + " not found")
+		}
+		if lookup != nil {
+			return models.Transaction{}, lookup
+		}
+	}
+
+	if err := ensurePeriodsOpen(h.repos, transaction.Date); err != nil {
+		return models.Transaction{}, err
+	}
+	return transaction, nil
+}
+
+// BatchUpdateTransactionStatus approves or rejects many pending
+// transactions in one database transaction. Every row is checked as
+// UpdateTransactionStatus would check it; if any is refused, nothing is
+// changed and every refused row is reported.
+func (h *TransactionHandler) BatchUpdateTransactionStatus(c *gin.Context) {
+	var req BatchUpdateStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.IDs) > maxBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A batch may hold at most " + strconv.Itoa(maxBatchSize) + " transactions"})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	userRole, _ := c.Get("userRole")
+	role, _ := userRole.(string)
+	actor, err := h.repos.Users.FindByID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	var rowErrors []batchRowError
+	var transactions []*models.Transaction
+	seen := map[uuid.UUID]bool{}
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		for i, raw := range req.IDs {
+			refuse := func(message string) {
+				rowErrors = append(rowErrors, batchRowError{Index: i, ID: raw, Error: message})
+			}
+
+			id, err := uuid.Parse(raw)
+			if err != nil {
+				refuse("Invalid transaction ID")
+				continue
+			}
+			if seen[id] {
+				refuse("Transaction is listed more than once")
+				continue
+			}
+			seen[id] = true
+
+			transaction, err := repos.Transactions.FindByID(id)
+			if errors.Is(err, repository.ErrNotFound) {
+				refuse("Transaction not found")
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			var refused *transitionError
+			if err := checkTransition(transaction, req.Status, actor.ID, role); errors.As(err, &refused) {
+				refuse(refused.message)
+				continue
+			}
+			var closed *closedPeriodError
+			if err := ensurePeriodsOpen(repos, transaction.Date); errors.As(err, &closed) {
+				refuse(closed.Error())
+				continue
+			} else if err != nil {
+				return err
+			}
+			if len(rowErrors) > 0 {
+				// The batch is lost already; only look for more
+				// refused rows.
+				continue
+			}
+
+			before := *transaction
+			if err := reviewTransaction(repos, transaction, req.Status, actor, req.RejectionReason); errors.As(err, &refused) {
+				refuse(refused.message)
+				continue
+			} else if err != nil {
+				return err
+			}
+			if err := repos.Transactions.Update(transaction); err != nil {
+				return err
+			}
+			if err := syncTransactionPosting(repos, transaction, actor.ID); err != nil {
+				return err
+			}
+
+			entry := auditEntry{
+				EntityType: "transaction",
+				EntityID:   transaction.ID,
+				Action:     statusActions[req.Status],
+				Summary:    "Updated transaction status to " + req.Status + ": " + transaction.EventName,
+				Before:     before,
+				After:      transaction,
+			}
+			if transaction.Status != req.Status {
+				entry.Action = "sign"
+				entry.Summary = "Signed approval of transaction: " + transaction.EventName
+			}
+			if err := recordAudit(c, repos, entry); err != nil {
+				return err
+			}
+			transactions = append(transactions, transaction)
+		}
+		if len(rowErrors) > 0 {
+			return errBatchRejected
+		}
+		return nil
+	})
+	if errors.Is(err, errBatchRejected) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":  "No transactions were updated",
+			"errors": rowErrors,
+		})
+		return
+	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A transaction was changed by someone else during the batch; nothing was updated, try again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": strconv.Itoa(len(transactions)) + " transactions updated",
+		"data":    transactions,
+	})
+}
@@ -0,0 +1,66 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestBatchCreateTransactions(t *testing.T) {
+	item := func(fields map[string]interface{}) map[string]interface{} {
+		body := map[string]interface{}{
+			"type":      "income",
+			"amount":    "50000",
+			"category":  "Persembahan",
+			"eventName": "Ibadah Minggu",
+			"date":      "2025-01-05",
+		}
+		for key, value := range fields {
+			body[key] = value
+		}
+		return body
+	}
+
+	tests := []struct {
+		name         string
+		atomic       bool
+		status       int
+		created      int
+		errorIndexes []float64
+	}{
+		{"partial", false, http.StatusCreated, 2, []float64{1, 3}},
+		{"atomic", true, http.StatusUnprocessableEntity, 0, []float64{1, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			fund := s.fund.ID.String()
+			out := s.do(s.admin, http.MethodPost, "/api/transactions/batch", map[string]interface{}{
+				"atomic": tt.atomic,
+				"items": []interface{}{
+					item(map[string]interface{}{"fundId": fund}),
+					item(map[string]interface{}{"fundId": fund, "date": "05/01/2025"}),
+					item(map[string]interface{}{"fundId": fund, "amount": "75000"}),
+					item(map[string]interface{}{"fundId": "00000000-0000-0000-0000-000000000001"}),
+				},
+			}, tt.status)
+
+			if got := len(list(out)); got != tt.created {
+				t.Errorf("created %d transactions, want %d", got, tt.created)
+			}
+			rowErrors, _ := out["errors"].([]interface{})
+			if len(rowErrors) != len(tt.errorIndexes) {
+				t.Fatalf("errors = %v, want rows %v", rowErrors, tt.errorIndexes)
+			}
+			for i, rowError := range rowErrors {
+				if index := rowError.(map[string]interface{})["index"]; index != tt.errorIndexes[i] {
+					t.Errorf("error %d is for row %v, want %v", i, index, tt.errorIndexes[i])
+				}
+			}
+
+			stored := s.do(s.admin, http.MethodGet, "/api/transactions", nil, http.StatusOK)
+			if got := len(list(stored)); got != tt.created {
+				t.Errorf("%d transactions stored, want %d", got, tt.created)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"gkjw-finance-backend/models"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// A transaction moves through draft -> pending -> approved/rejected, and an
+// approved transaction may be voided by a reversal (see VoidTransaction).
+// Editing a rejected transaction sends it back to pending (see
+// UpdateTransaction); every other change must be listed here.
+
+type statusTransition struct {
+	from, to string
+}
+
+// transitionRule reports why actor may not make a transition, or "" when
+// they may.
+type transitionRule func(transaction *models.Transaction, actor uuid.UUID, role string) string
+
+// creatorOrAdmin lets the creator of a transaction or an admin act on it.
+func creatorOrAdmin(transaction *models.Transaction, actor uuid.UUID, role string) string {
+	if role == "admin" || transaction.CreatedBy == actor {
+		return ""
+	}
+	return "Only the creator or an admin can submit this transaction"
+}
+
+// reviewer lets anyone but the creator approve or reject; who may sign
+// each step is decided by the approval policy (see reviewTransaction).
+func reviewer(transaction *models.Transaction, actor uuid.UUID, role string) string {
+	if transaction.CreatedBy == actor {
+		return "You cannot review a transaction you created"
+	}
+	return ""
+}
+
+func adminOnly(transaction *models.Transaction, actor uuid.UUID, role string) string {
+	if role != "admin" {
+		return "Only an admin can void transactions"
+	}
+	return ""
+}
+
+var transactionTransitions = map[statusTransition]transitionRule{
+	{"draft", "pending"}:    creatorOrAdmin,
+	{"pending", "approved"}: reviewer,
+	{"pending", "rejected"}: reviewer,
+	{"approved", "voided"}:  adminOnly,
+}
+
+// statusActions names the audit log action of each status a transaction
+// can be moved to through UpdateTransactionStatus.
+var statusActions = map[string]string{
+	"pending":  "submit",
+	"approved": "approve",
+	"rejected": "reject",
+}
+
+// transitionError is a refused status change and the HTTP status to
+// answer it with.
+type transitionError struct {
+	status  int
+	message string
+}
+
+func (e *transitionError) Error() string {
+	return e.message
+}
+
+// checkTransition returns a *transitionError when actor, with role, may not
+// move transaction to status.
+func checkTransition(transaction *models.Transaction, status string, actor uuid.UUID, role string) error {
+	rule, ok := transactionTransitions[statusTransition{transaction.Status, status}]
+	if !ok {
+		return &transitionError{
+			status:  http.StatusConflict,
+			message: "Cannot change status from " + transaction.Status + " to " + status,
+		}
+	}
+	if reason := rule(transaction, actor, role); reason != "" {
+		return &transitionError{status: http.StatusForbidden, message: reason}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"gkjw-finance-backend/models"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCheckTransition(t *testing.T) {
+	creator, other := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name   string
+		from   string
+		to     string
+		actor  uuid.UUID
+		role   string
+		status int // 0 when the transition is allowed
+	}{
+		{"creator submits draft", "draft", "pending", creator, "member", 0},
+		{"admin submits draft", "draft", "pending", other, "admin", 0},
+		{"member submits someone else's draft", "draft", "pending", other, "member", http.StatusForbidden},
+		{"reviewer approves", "pending", "approved", other, "admin", 0},
+		{"reviewer rejects", "pending", "rejected", other, "member", 0},
+		{"creator approves own", "pending", "approved", creator, "admin", http.StatusForbidden},
+		{"creator rejects own", "pending", "rejected", creator, "admin", http.StatusForbidden},
+		{"admin voids approved", "approved", "voided", other, "admin", 0},
+		{"member voids approved", "approved", "voided", creator, "member", http.StatusForbidden},
+		{"draft approved directly", "draft", "approved", other, "admin", http.StatusConflict},
+		{"approved back to pending", "approved", "pending", other, "admin", http.StatusConflict},
+		{"rejected approved", "rejected", "approved", other, "admin", http.StatusConflict},
+		{"pending voided", "pending", "voided", other, "admin", http.StatusConflict},
+		{"voided reapproved", "voided", "approved", other, "admin", http.StatusConflict},
+		{"pending to pending", "pending", "pending", creator, "admin", http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transaction := &models.Transaction{Status: tt.from, CreatedBy: creator}
+			err := checkTransition(transaction, tt.to, tt.actor, tt.role)
+
+			var refused *transitionError
+			switch {
+			case tt.status == 0 && err != nil:
+				t.Errorf("refused: %v", err)
+			case tt.status != 0 && !errors.As(err, &refused):
+				t.Errorf("err = %v, want a transition error", err)
+			case tt.status != 0 && refused.status != tt.status:
+				t.Errorf("status %d, want %d (%s)", refused.status, tt.status, refused.message)
+			}
+		})
+	}
+}
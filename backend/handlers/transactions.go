@@ -1,132 +1,413 @@
 package handlers
 
 import (
-	"gkjw-finance-backend/config"
+	"errors"
+	"fmt"
 	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+const (
+	defaultTransactionPageSize = 50
+	maxTransactionPageSize     = 200
+)
+
+// TransactionHandler serves the transaction endpoints.
+type TransactionHandler struct {
+	repos *repository.Repositories
+}
+
+func NewTransactionHandler(repos *repository.Repositories) *TransactionHandler {
+	return &TransactionHandler{repos: repos}
+}
+
+// CreateTransactionRequest is also used for updates. For a transfer,
+// FundID and PaymentMethod are the source and ToFundID and ToPaymentMethod
+// the destination; category and event name may be left empty. Draft saves
+// a new transaction without submitting it for review and is ignored on
+// update.
+//
+// An income or expense may be split into Lines, which must add up to
+// Amount; FundID then is the default fund of the lines and Category may be
+// left empty. Without lines, the transaction gets a single line holding
+// FundID, Category and Amount. A category is named by CategoryID or by
+// Category, its name; see resolveCategories.
+//
+// EventID links the transaction to an event, whose name then replaces
+// EventName; see resolveEvent.
 type CreateTransactionRequest struct {
-	Type        string  `json:"type" binding:"required,oneof=income expense"`
-	PaymentMethod string `json:"paymentMethod" binding:"omitempty,oneof=cash bank"`
-	Amount      float64 `json:"amount" binding:"required,gt=0"`
-	Category    string  `json:"category" binding:"required"`
-	Description string  `json:"description"`
-	EventName   string  `json:"eventName" binding:"required"`
-	Date        string  `json:"date" binding:"required"`
-	NoteURL     string  `json:"noteUrl"`
-	FundID      string  `json:"fundId" binding:"required"`
+	Draft           bool                     `json:"draft"`
+	Type            string                   `json:"type" binding:"required,oneof=income expense transfer"`
+	PaymentMethod   string                   `json:"paymentMethod" binding:"omitempty,oneof=cash bank"`
+	Amount          models.Money             `json:"amount" binding:"required,gt=0"`
+	CategoryID      string                   `json:"categoryId"`
+	Category        string                   `json:"category"`
+	Lines           []TransactionLineRequest `json:"lines"`
+	Description     string                   `json:"description"`
+	EventID         string                   `json:"eventId"`
+	EventName       string                   `json:"eventName"`
+	Date            string                   `json:"date" binding:"required"`
+	NoteURL         string                   `json:"noteUrl"`
+	FundID          string                   `json:"fundId"`
+	ToFundID        string                   `json:"toFundId"`
+	ToPaymentMethod string                   `json:"toPaymentMethod" binding:"omitempty,oneof=cash bank"`
+}
+
+// TransactionLineRequest is one line of a split transaction. FundID
+// defaults to the fund of the transaction.
+type TransactionLineRequest struct {
+	FundID      string       `json:"fundId"`
+	CategoryID  string       `json:"categoryId"`
+	Category    string       `json:"category"`
+	Amount      models.Money `json:"amount"`
+	Description string       `json:"description"`
+}
+
+// applyLines sets the fund, category and lines of transaction from req.
+// Lines already stored keep their IDs, position by position, so that an
+// unchanged line does not show up in the audit trail.
+func applyLines(req CreateTransactionRequest, transaction *models.Transaction) error {
+	var fundID uuid.UUID
+	if req.FundID != "" || len(req.Lines) == 0 {
+		parsed, err := uuid.Parse(req.FundID)
+		if err != nil {
+			return errors.New("Invalid fundId")
+		}
+		fundID = parsed
+	}
+	existing := transaction.Lines
+	transaction.FundID = fundID
+	transaction.CategoryID = nil
+	transaction.Category = strings.TrimSpace(req.Category)
+	transaction.Lines = nil
+
+	if req.Type == "transfer" {
+		if len(req.Lines) > 0 {
+			return errors.New("Transfers cannot be split into lines")
+		}
+		return nil
+	}
+
+	requested := req.Lines
+	if len(requested) == 0 {
+		if transaction.Category == "" && req.CategoryID == "" {
+			return errors.New("Category is required")
+		}
+		requested = []TransactionLineRequest{{CategoryID: req.CategoryID, Category: transaction.Category, Amount: req.Amount}}
+	}
+
+	var total models.Money
+	for i, reqLine := range requested {
+		line := models.TransactionLine{
+			FundID:      fundID,
+			Category:    strings.TrimSpace(reqLine.Category),
+			Amount:      reqLine.Amount,
+			Description: reqLine.Description,
+		}
+		if reqLine.FundID != "" {
+			parsed, err := uuid.Parse(reqLine.FundID)
+			if err != nil {
+				return fmt.Errorf("Line %d: invalid fundId", i+1)
+			}
+			line.FundID = parsed
+		}
+		if reqLine.CategoryID != "" {
+			parsed, err := uuid.Parse(reqLine.CategoryID)
+			if err != nil {
+				return fmt.Errorf("Line %d: invalid categoryId", i+1)
+			}
+			line.CategoryID = &parsed
+		}
+		switch {
+		case line.FundID == uuid.Nil:
+			return fmt.Errorf("Line %d: fundId is required", i+1)
+		case line.Category == "" && line.CategoryID == nil:
+			return fmt.Errorf("Line %d: category is required", i+1)
+		case line.Amount <= 0:
+			return fmt.Errorf("Line %d: amount must be greater than 0", i+1)
+		}
+		if i < len(existing) {
+			line.ID = existing[i].ID
+		}
+		transaction.Lines = append(transaction.Lines, line)
+		total += line.Amount
+	}
+	if total != req.Amount {
+		return fmt.Errorf("The lines add up to %s, not to the amount of %s", total, req.Amount)
+	}
+
+	transaction.FundID = transaction.Lines[0].FundID
+	transaction.CategoryID = transaction.Lines[0].CategoryID
+	transaction.Category = transaction.Lines[0].Category
+	return nil
+}
+
+// applyTransfer sets the destination of a transfer on transaction from req
+// and clears it for other types. An unset destination fund or payment
+// method defaults to the source, but at least one of them must differ.
+func applyTransfer(req CreateTransactionRequest, transaction *models.Transaction) error {
+	if req.Type != "transfer" {
+		transaction.ToFundID = nil
+		transaction.ToPaymentMethod = ""
+		return nil
+	}
+
+	toFund := transaction.FundID
+	if req.ToFundID != "" {
+		parsed, err := uuid.Parse(req.ToFundID)
+		if err != nil {
+			return errors.New("Invalid toFundId")
+		}
+		toFund = parsed
+	}
+	toPaymentMethod := req.ToPaymentMethod
+	if toPaymentMethod == "" {
+		toPaymentMethod = transaction.PaymentMethod
+	}
+	if toFund == transaction.FundID && toPaymentMethod == transaction.PaymentMethod {
+		return errors.New("Transfer source and destination must differ")
+	}
+
+	transaction.ToFundID = &toFund
+	transaction.ToPaymentMethod = toPaymentMethod
+	if transaction.Category == "" {
+		transaction.Category = "Transfer"
+	}
+	if transaction.EventName == "" {
+		transaction.EventName = "Transfer"
+	}
+	return nil
 }
 
 type UpdateTransactionStatusRequest struct {
-	Status          string `json:"status" binding:"required,oneof=approved rejected"`
+	Status          string `json:"status" binding:"required,oneof=pending approved rejected"`
 	RejectionReason string `json:"rejectionReason"`
 }
 
-// GetApprovedTransactions returns only approved transactions (public endpoint for guests)
-func GetApprovedTransactions(c *gin.Context) {
-	var transactions []models.Transaction
-
-	query := config.DB.Preload("CreatedByUser").Preload("Fund").
-		Where("status = ?", "approved").
-		Order("created_at DESC")
+// VoidTransactionRequest cancels an approved transaction. Date is the date
+// of the reversal and defaults to today.
+type VoidTransactionRequest struct {
+	Reason string `json:"reason" binding:"required"`
+	Date   string `json:"date"`
+}
 
-	// Filter by type
-	if txType := c.Query("type"); txType != "" {
-		query = query.Where("type = ?", txType)
+// transactionFilterFromQuery builds a filter from the query parameters
+// shared by the transaction, dashboard and report endpoints: status, type,
+// paymentMethod, category, categoryId, startDate, endDate, fundId, eventId
+// and q. A categoryId also selects its subcategories. The value "all" is
+// treated as no filter.
+func transactionFilterFromQuery(c *gin.Context, repos *repository.Repositories) (repository.TransactionFilter, error) {
+	query := func(key string) string {
+		if value := c.Query(key); value != "all" {
+			return value
+		}
+		return ""
 	}
 
-	// Filter by payment method
-	if pm := c.Query("paymentMethod"); pm != "" {
-		query = query.Where("payment_method = ?", pm)
+	filter := repository.TransactionFilter{
+		Status:        query("status"),
+		Type:          query("type"),
+		PaymentMethod: query("paymentMethod"),
+		Category:      query("category"),
+		Query:         c.Query("q"),
 	}
 
-	// Filter by date range
 	if startDate := c.Query("startDate"); startDate != "" {
-		query = query.Where("date >= ?", startDate)
+		parsed, err := time.Parse("2006-01-02", startDate)
+		if err != nil {
+			return filter, errors.New("Invalid startDate. Use YYYY-MM-DD")
+		}
+		filter.StartDate = parsed
 	}
 	if endDate := c.Query("endDate"); endDate != "" {
-		query = query.Where("date <= ?", endDate)
+		parsed, err := time.Parse("2006-01-02", endDate)
+		if err != nil {
+			return filter, errors.New("Invalid endDate. Use YYYY-MM-DD")
+		}
+		filter.EndDate = parsed
 	}
 
-	// Filter by fund
-	if fundID := c.Query("fundId"); fundID != "" {
-		query = query.Where("fund_id = ?", fundID)
+	if fundID := query("fundId"); fundID != "" {
+		parsed, err := uuid.Parse(fundID)
+		if err != nil {
+			return filter, errors.New("Invalid fundId")
+		}
+		filter.FundID = parsed
 	}
 
-	if err := query.Find(&transactions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
-		return
+	if categoryID := query("categoryId"); categoryID != "" {
+		parsed, err := uuid.Parse(categoryID)
+		if err != nil {
+			return filter, errors.New("Invalid categoryId")
+		}
+		categories, err := repos.Categories.List()
+		if err != nil {
+			return filter, errors.New("Failed to fetch categories")
+		}
+		filter.CategoryIDs = categoryTree(categories, parsed)
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": transactions})
-}
+	if eventID := query("eventId"); eventID != "" {
+		parsed, err := uuid.Parse(eventID)
+		if err != nil {
+			return filter, errors.New("Invalid eventId")
+		}
+		filter.EventID = parsed
+	}
 
-func GetTransactions(c *gin.Context) {
-	var transactions []models.Transaction
+	return filter, nil
+}
 
-	query := config.DB.Preload("CreatedByUser").Preload("Fund").Order("created_at DESC")
+// listTransactions writes one page of the transactions selected by filter,
+// together with the total row count and income/expense sums of the whole
+// selection. Pages are keyset-paginated on the requested sort column.
+func (h *TransactionHandler) listTransactions(c *gin.Context, filter repository.TransactionFilter) {
+	page := repository.PageRequest{
+		Sort:   c.DefaultQuery("sort", "createdAt"),
+		Limit:  defaultTransactionPageSize,
+		Cursor: c.Query("cursor"),
+	}
+	if !repository.ValidTransactionSort(page.Sort) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort. Use date, amount or createdAt"})
+		return
+	}
 
-	// Filter by status
-	if status := c.Query("status"); status != "" {
-		query = query.Where("status = ?", status)
+	switch strings.ToLower(c.DefaultQuery("order", "desc")) {
+	case "asc":
+	case "desc":
+		page.Desc = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order. Use asc or desc"})
+		return
 	}
 
-	// Filter by type
-	if txType := c.Query("type"); txType != "" {
-		query = query.Where("type = ?", txType)
+	if l := c.Query("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		page.Limit = parsed
+	}
+	if page.Limit > maxTransactionPageSize {
+		page.Limit = maxTransactionPageSize
 	}
 
-	// Filter by payment method
-	if pm := c.Query("paymentMethod"); pm != "" {
-		query = query.Where("payment_method = ?", pm)
+	result, err := h.repos.Transactions.List(filter, page)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		return
 	}
 
-	// Filter by date range
-	if startDate := c.Query("startDate"); startDate != "" {
-		query = query.Where("date >= ?", startDate)
+	items := result.Items
+	if items == nil {
+		items = []models.Transaction{}
 	}
-	if endDate := c.Query("endDate"); endDate != "" {
-		query = query.Where("date <= ?", endDate)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": items,
+		"pagination": gin.H{
+			"limit":      page.Limit,
+			"total":      result.Total,
+			"hasMore":    result.HasMore,
+			"nextCursor": result.NextCursor,
+		},
+		"summary": gin.H{
+			"totalIncome":  result.TotalIncome,
+			"totalExpense": result.TotalExpense,
+		},
+	})
+}
+
+// GetApprovedTransactions returns only posted transactions, approved or voided (public endpoint for guests)
+func (h *TransactionHandler) GetApprovedTransactions(c *gin.Context) {
+	filter, err := transactionFilterFromQuery(c, h.repos)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	filter.Status = repository.StatusPosted
+
+	h.listTransactions(c, filter)
+}
 
-	// Filter by fund
-	if fundID := c.Query("fundId"); fundID != "" {
-		query = query.Where("fund_id = ?", fundID)
+func (h *TransactionHandler) GetTransactions(c *gin.Context) {
+	filter, err := transactionFilterFromQuery(c, h.repos)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	// For non-admin users, show only their own transactions
 	userRole, _ := c.Get("userRole")
 	userID, _ := c.Get("userId")
 	if userRole != "admin" {
-		query = query.Where("created_by = ?", userID)
+		filter.CreatedBy = userID.(uuid.UUID)
 	}
 
-	if err := query.Find(&transactions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
-		return
+	h.listTransactions(c, filter)
+}
+
+// findTransaction loads the transaction named by the :id path parameter,
+// writing a 404 response when it does not exist.
+func (h *TransactionHandler) findTransaction(c *gin.Context) (*models.Transaction, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return nil, false
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": transactions})
+	transaction, err := h.repos.Transactions.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return nil, false
+	}
+	return transaction, true
 }
 
-func GetTransactionByID(c *gin.Context) {
-	id := c.Param("id")
-	
-	var transaction models.Transaction
-	if err := config.DB.Preload("CreatedByUser").Preload("Fund").Where("id = ?", id).First(&transaction).Error; err != nil {
+// checkIfMatch answers 412 and returns false when the If-Match header of c
+// names another version of transaction.
+func (h *TransactionHandler) checkIfMatch(c *gin.Context, transaction *models.Transaction) bool {
+	if ifMatch(c, transaction.Version) {
+		return true
+	}
+	respondStale(c, transaction, transaction.Version)
+	return false
+}
+
+// respondStale answers 412 with the transaction as it is now, after an
+// update lost the race against another one.
+func (h *TransactionHandler) respondStale(c *gin.Context, id uuid.UUID) {
+	current, err := h.repos.Transactions.FindByID(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
 		return
 	}
+	respondStale(c, current, current.Version)
+}
+
+func (h *TransactionHandler) GetTransactionByID(c *gin.Context) {
+	transaction, ok := h.findTransaction(c)
+	if !ok {
+		return
+	}
 
+	setETag(c, transaction.Version)
 	c.JSON(http.StatusOK, gin.H{"data": transaction})
 }
 
-func CreateTransaction(c *gin.Context) {
+func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 	var req CreateTransactionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -134,65 +415,185 @@ func CreateTransaction(c *gin.Context) {
 	}
 
 	userID, _ := c.Get("userId")
-	userRole, _ := c.Get("userRole")
-
-	// Parse date string to time.Time
-	parsedDate, err := time.Parse("2006-01-02", req.Date)
+	transaction, err := newTransaction(req, userID.(uuid.UUID))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkCategories(c, &transaction) || !h.checkEvent(c, &transaction) {
 		return
 	}
 
-	parsedFund, err := uuid.Parse(req.FundID)
+	if !checkPeriodsOpen(c, h.repos, transaction.Date) {
+		return
+	}
+
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		return saveNewTransaction(c, repos, &transaction)
+	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fundId"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transaction"})
 		return
 	}
 
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Transaction created successfully",
+		"data":    transaction,
+	})
+}
+
+// checkCategories is resolveCategories for handlers. It writes a 400 or 500
+// response and returns false when the categories of transaction cannot be
+// used.
+func (h *TransactionHandler) checkCategories(c *gin.Context, transaction *models.Transaction) bool {
+	categories, err := h.repos.Categories.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		return false
+	}
+	if err := resolveCategories(categories, transaction); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// applyEvent sets the event of transaction from req. An event given by ID
+// is not yet looked up; see resolveEvent.
+func applyEvent(req CreateTransactionRequest, transaction *models.Transaction) error {
+	transaction.EventID = nil
+	transaction.EventName = req.EventName
+	if req.EventID != "" {
+		parsed, err := uuid.Parse(req.EventID)
+		if err != nil {
+			return errors.New("Invalid eventId")
+		}
+		transaction.EventID = &parsed
+	} else if req.EventName == "" && req.Type != "transfer" {
+		return errors.New("Event name is required")
+	}
+	return nil
+}
+
+var errEventNotFound = errors.New("Event not found")
+
+// resolveEvent looks up the event transaction is linked to, if any, and
+// names the transaction after it.
+func resolveEvent(repos *repository.Repositories, transaction *models.Transaction) error {
+	if transaction.EventID == nil {
+		return nil
+	}
+	event, err := repos.Events.FindByID(*transaction.EventID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return errEventNotFound
+	}
+	if err != nil {
+		return err
+	}
+	transaction.EventName = event.Name
+	return nil
+}
+
+// checkEvent is resolveEvent for handlers. It writes a 400 or 500 response
+// and returns false when the event of transaction cannot be used.
+func (h *TransactionHandler) checkEvent(c *gin.Context, transaction *models.Transaction) bool {
+	err := resolveEvent(h.repos, transaction)
+	if errors.Is(err, errEventNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch event"})
+		return false
+	}
+	return true
+}
+
+// newTransaction builds the transaction described by req, created by
+// createdBy. The error, if any, explains what is wrong with req. The
+// categories and event are named but not yet looked up; see
+// resolveCategories and resolveEvent.
+func newTransaction(req CreateTransactionRequest, createdBy uuid.UUID) (models.Transaction, error) {
+	// Parse date string to time.Time
+	parsedDate, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return models.Transaction{}, errors.New("Invalid date format. Use YYYY-MM-DD")
+	}
+
 	paymentMethod := req.PaymentMethod
 	if paymentMethod == "" {
 		paymentMethod = "cash"
 	}
 
 	transaction := models.Transaction{
-		FundID:      parsedFund,
-		Type:        req.Type,
+		Type:          req.Type,
 		PaymentMethod: paymentMethod,
-		Amount:      req.Amount,
-		Category:    req.Category,
-		Description: req.Description,
-		EventName:   req.EventName,
-		Date:        parsedDate,
-		CreatedBy:   userID.(uuid.UUID),
-		NoteURL:     req.NoteURL,
-		Status:      "pending",
+		Amount:        req.Amount,
+		Description:   req.Description,
+		Date:          parsedDate,
+		CreatedBy:     createdBy,
+		NoteURL:       req.NoteURL,
+		Status:        "pending",
 	}
-
-	// Auto-approve for income transactions created by admin
-	if req.Type == "income" && userRole == "admin" {
-		transaction.Status = "approved"
+	if req.Draft {
+		transaction.Status = "draft"
 	}
-
-	if err := config.DB.Create(&transaction).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transaction"})
-		return
+	if err := applyEvent(req, &transaction); err != nil {
+		return models.Transaction{}, err
 	}
+	if err := applyLines(req, &transaction); err != nil {
+		return models.Transaction{}, err
+	}
+	if err := applyTransfer(req, &transaction); err != nil {
+		return models.Transaction{}, err
+	}
+	return transaction, nil
+}
 
-	// Log activity
-	logActivity(userID.(uuid.UUID), "Created transaction: "+req.EventName)
-
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Transaction created successfully",
-		"data":    transaction,
+// saveNewTransaction applies the categorization rules to transaction,
+// stores it, posts it to the ledger when it is approved, flags anything
+// suspicious about it and records it in the audit trail. Call it inside
+// WithTx.
+func saveNewTransaction(c *gin.Context, repos *repository.Repositories, transaction *models.Transaction) error {
+	rule, err := categorizeTransaction(repos, transaction)
+	if err != nil {
+		return err
+	}
+	if err := repos.Transactions.Create(transaction); err != nil {
+		return err
+	}
+	if err := syncTransactionPosting(repos, transaction, transaction.CreatedBy); err != nil {
+		return err
+	}
+	if err := flagTransaction(repos, transaction); err != nil {
+		return err
+	}
+	summary := "Created transaction: " + transaction.EventName
+	if rule != nil {
+		summary += " (categorized by rule " + rule.Name + ")"
+	}
+	return recordAudit(c, repos, auditEntry{
+		EntityType: "transaction",
+		EntityID:   transaction.ID,
+		Action:     "create",
+		Summary:    summary,
+		After:      transaction,
+		Actor:      transaction.CreatedBy,
 	})
 }
 
-func UpdateTransaction(c *gin.Context) {
-	id := c.Param("id")
-	
-	var transaction models.Transaction
-	if err := config.DB.Where("id = ?", id).First(&transaction).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+// UpdateTransaction edits a transaction that has not been approved. A
+// rejected transaction goes back to pending and needs a new review, and
+// signatures already given no longer count. Approved and voided
+// transactions cannot be edited; an approved one is corrected by voiding
+// it and recording it again. The anomaly checks run again on the change.
+func (h *TransactionHandler) UpdateTransaction(c *gin.Context) {
+	transaction, ok := h.findTransaction(c)
+	if !ok || !h.checkIfMatch(c, transaction) {
+		return
+	}
+	if transaction.Posted() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Approved transactions cannot be edited; void it and record a new one"})
 		return
 	}
 
@@ -209,12 +610,11 @@ func UpdateTransaction(c *gin.Context) {
 		return
 	}
 
-	parsedFund, err := uuid.Parse(req.FundID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fundId"})
+	if !checkPeriodsOpen(c, h.repos, transaction.Date, parsedDate) {
 		return
 	}
 
+	before := *transaction
 	paymentMethod := req.PaymentMethod
 	if paymentMethod == "" {
 		paymentMethod = transaction.PaymentMethod
@@ -223,36 +623,82 @@ func UpdateTransaction(c *gin.Context) {
 		}
 	}
 
-	transaction.FundID = parsedFund
+	transaction.Fund = nil
 	transaction.Type = req.Type
 	transaction.PaymentMethod = paymentMethod
 	transaction.Amount = req.Amount
-	transaction.Category = req.Category
 	transaction.Description = req.Description
-	transaction.EventName = req.EventName
 	transaction.Date = parsedDate
 	transaction.NoteURL = req.NoteURL
-
-	if err := config.DB.Save(&transaction).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction"})
+	transaction.ToFund = nil
+	if err := applyEvent(req, transaction); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := applyLines(req, transaction); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := applyTransfer(req, transaction); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkCategories(c, transaction) || !h.checkEvent(c, transaction) {
+		return
+	}
+	if transaction.Status == "rejected" {
+		transaction.Status = "pending"
+		transaction.RejectionReason = ""
+		transaction.ReviewedBy = nil
+		transaction.ReviewedAt = nil
+	}
 
 	userID, _ := c.Get("userId")
-	logActivity(userID.(uuid.UUID), "Updated transaction: "+transaction.EventName)
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.Transactions.Update(transaction); err != nil {
+			return err
+		}
+		if err := repos.Approvals.Supersede(transaction.ID); err != nil {
+			return err
+		}
+		if err := syncTransactionPosting(repos, transaction, userID.(uuid.UUID)); err != nil {
+			return err
+		}
+		if err := reflagTransaction(repos, transaction); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "transaction",
+			EntityID:   transaction.ID,
+			Action:     "update",
+			Summary:    "Updated transaction: " + transaction.EventName,
+			Before:     before,
+			After:      transaction,
+		})
+	})
+	if errors.Is(err, repository.ErrVersionConflict) {
+		h.respondStale(c, transaction.ID)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction"})
+		return
+	}
 
+	setETag(c, transaction.Version)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Transaction updated successfully",
 		"data":    transaction,
 	})
 }
 
-func UpdateTransactionStatus(c *gin.Context) {
-	id := c.Param("id")
-	
-	var transaction models.Transaction
-	if err := config.DB.Where("id = ?", id).First(&transaction).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+// UpdateTransactionStatus moves a transaction along its lifecycle. Only
+// the transitions in transactionTransitions are allowed: the creator or an
+// admin submits a draft, the approvers named by its approval policy
+// approve or reject, and an admin voids.
+func (h *TransactionHandler) UpdateTransactionStatus(c *gin.Context) {
+	transaction, ok := h.findTransaction(c)
+	if !ok || !h.checkIfMatch(c, transaction) {
 		return
 	}
 
@@ -262,18 +708,82 @@ func UpdateTransactionStatus(c *gin.Context) {
 		return
 	}
 
-	transaction.Status = req.Status
-	if req.Status == "rejected" {
-		transaction.RejectionReason = req.RejectionReason
+	userID, _ := c.Get("userId")
+	userRole, _ := c.Get("userRole")
+	role, _ := userRole.(string)
+	var refused *transitionError
+	if err := checkTransition(transaction, req.Status, userID.(uuid.UUID), role); errors.As(err, &refused) {
+		c.JSON(refused.status, gin.H{"error": refused.message})
+		return
+	}
+
+	if !checkPeriodsOpen(c, h.repos, transaction.Date) {
+		return
+	}
+
+	actor, err := h.repos.Users.FindByID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
 	}
 
-	if err := config.DB.Save(&transaction).Error; err != nil {
+	before := *transaction
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if req.Status == "approved" || req.Status == "rejected" {
+			if err := reviewTransaction(repos, transaction, req.Status, actor, req.RejectionReason); err != nil {
+				return err
+			}
+		} else {
+			transaction.Status = req.Status
+		}
+		if err := repos.Transactions.Update(transaction); err != nil {
+			return err
+		}
+		if err := syncTransactionPosting(repos, transaction, userID.(uuid.UUID)); err != nil {
+			return err
+		}
+
+		entry := auditEntry{
+			EntityType: "transaction",
+			EntityID:   transaction.ID,
+			Action:     statusActions[req.Status],
+			Summary:    "Updated transaction status to " + req.Status + ": " + transaction.EventName,
+			Before:     before,
+			After:      transaction,
+		}
+		if transaction.Status != req.Status {
+			entry.Action = "sign"
+			entry.Summary = "Signed approval of transaction: " + transaction.EventName
+		}
+		return recordAudit(c, repos, entry)
+	})
+	if errors.As(err, &refused) {
+		c.JSON(refused.status, gin.H{"error": refused.message})
+		return
+	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		h.respondStale(c, transaction.ID)
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction status"})
 		return
 	}
 
-	userID, _ := c.Get("userId")
-	logActivity(userID.(uuid.UUID), "Updated transaction status to "+req.Status+": "+transaction.EventName)
+	setETag(c, transaction.Version)
+	if transaction.Status != req.Status {
+		// A policy step was signed but more signatures are needed.
+		state, _, err := loadApprovalState(h.repos, transaction)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch approvals"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": fmt.Sprintf("Approval recorded; %d more required", len(state.Policy.Steps)-len(state.Signed)),
+			"data":    transaction,
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Transaction status updated successfully",
@@ -281,22 +791,177 @@ func UpdateTransactionStatus(c *gin.Context) {
 	})
 }
 
-func DeleteTransaction(c *gin.Context) {
-	id := c.Param("id")
-	
-	var transaction models.Transaction
-	if err := config.DB.Where("id = ?", id).First(&transaction).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+// VoidTransaction cancels an approved transaction. The original is kept,
+// marked voided, and an approved reversal with the opposite amount is
+// recorded on the reversal date, so reports up to that date are unchanged
+// and balances from then on are as if the transaction never happened. The
+// original may lie in a closed period; the reversal date may not.
+func (h *TransactionHandler) VoidTransaction(c *gin.Context) {
+	transaction, ok := h.findTransaction(c)
+	if !ok {
 		return
 	}
 
-	if err := config.DB.Delete(&transaction).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete transaction"})
+	var req VoidTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A reason is required"})
+		return
+	}
+
+	switch {
+	case transaction.ReversalOfID != nil:
+		c.JSON(http.StatusConflict, gin.H{"error": "A reversal cannot be voided"})
+		return
+	case transaction.Status == "voided":
+		c.JSON(http.StatusConflict, gin.H{"error": "Transaction is already voided"})
+		return
+	}
+	userID, _ := c.Get("userId")
+	userRole, _ := c.Get("userRole")
+	role, _ := userRole.(string)
+	actor := userID.(uuid.UUID)
+	var refused *transitionError
+	if err := checkTransition(transaction, "voided", actor, role); errors.As(err, &refused) {
+		c.JSON(refused.status, gin.H{"error": refused.message})
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	if req.Date != "" {
+		date, err = time.Parse("2006-01-02", req.Date)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		return
+	}
+	if date.Before(transaction.Date) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The reversal cannot be dated before the transaction"})
+		return
+	}
+	if !checkPeriodsOpen(c, h.repos, date) {
+		return
+	}
+
+	now := time.Now()
+	reversal := models.Transaction{
+		FundID:          transaction.FundID,
+		Type:            transaction.Type,
+		PaymentMethod:   transaction.PaymentMethod,
+		ToFundID:        transaction.ToFundID,
+		ToPaymentMethod: transaction.ToPaymentMethod,
+		Amount:          -transaction.Amount,
+		CategoryID:      transaction.CategoryID,
+		Category:        transaction.Category,
+		Description:     reason,
+		EventID:         transaction.EventID,
+		EventName:       transaction.EventName,
+		Date:            date,
+		CreatedBy:       actor,
+		Status:          "approved",
+		ReviewedBy:      &actor,
+		ReviewedAt:      &now,
+		ReversalOfID:    &transaction.ID,
+		VoidReason:      reason,
+	}
+	for _, line := range transaction.Lines {
+		reversal.Lines = append(reversal.Lines, models.TransactionLine{
+			FundID:      line.FundID,
+			CategoryID:  line.CategoryID,
+			Category:    line.Category,
+			Amount:      -line.Amount,
+			Description: line.Description,
+		})
+	}
+	before := *transaction
+	transaction.Status = "voided"
+	transaction.VoidReason = reason
+
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.Transactions.Update(transaction); err != nil {
+			return err
+		}
+		if err := repos.Transactions.Create(&reversal); err != nil {
+			return err
+		}
+		if err := syncTransactionPosting(repos, &reversal, actor); err != nil {
+			return err
+		}
+		if err := recordAudit(c, repos, auditEntry{
+			EntityType: "transaction",
+			EntityID:   transaction.ID,
+			Action:     "void",
+			Summary:    "Voided transaction: " + transaction.EventName + " (" + reason + ")",
+			Before:     before,
+			After:      transaction,
+		}); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "transaction",
+			EntityID:   reversal.ID,
+			Action:     "create",
+			Summary:    "Created reversal of transaction: " + transaction.EventName,
+			After:      reversal,
+		})
+	})
+	if errors.Is(err, repository.ErrVersionConflict) {
+		h.respondStale(c, transaction.ID)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to void transaction"})
+		return
+	}
+
+	setETag(c, transaction.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Transaction voided successfully",
+		"data":     transaction,
+		"reversal": reversal,
+	})
+}
+
+// DeleteTransaction removes a draft or rejected transaction. Anything that
+// was approved stays on record and is cancelled with VoidTransaction.
+func (h *TransactionHandler) DeleteTransaction(c *gin.Context) {
+	transaction, ok := h.findTransaction(c)
+	if !ok || !h.checkIfMatch(c, transaction) {
+		return
+	}
+	if transaction.Status != "draft" && transaction.Status != "rejected" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only draft and rejected transactions can be deleted; void approved ones instead"})
+		return
+	}
+
+	if !checkPeriodsOpen(c, h.repos, transaction.Date) {
 		return
 	}
 
 	userID, _ := c.Get("userId")
-	logActivity(userID.(uuid.UUID), "Deleted transaction: "+transaction.EventName)
+	err := h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := unpostTransaction(repos, transaction, userID.(uuid.UUID)); err != nil {
+			return err
+		}
+		if err := repos.Transactions.Delete(transaction.ID); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "transaction",
+			EntityID:   transaction.ID,
+			Action:     "delete",
+			Summary:    "Deleted transaction: " + transaction.EventName,
+			Before:     transaction,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete transaction"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Transaction deleted successfully"})
 }
@@ -0,0 +1,186 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestGetTransactionsFollowsCursor(t *testing.T) {
+	s := newTestServer(t)
+	for _, amount := range []string{"300", "100", "500", "200", "400"} {
+		s.createTransaction(s.admin, map[string]interface{}{"amount": amount})
+	}
+
+	var amounts []string
+	path := "/api/transactions?limit=2&sort=amount&order=asc"
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("the cursor never ran out")
+		}
+		out := s.do(s.admin, http.MethodGet, path, nil, http.StatusOK)
+		for _, item := range list(out) {
+			amounts = append(amounts, fmt.Sprint(item.(map[string]interface{})["amount"]))
+		}
+		pagination := out["pagination"].(map[string]interface{})
+		if total := pagination["total"].(float64); total != 5 {
+			t.Fatalf("total = %v, want 5", total)
+		}
+		cursor, _ := pagination["nextCursor"].(string)
+		if cursor == "" {
+			break
+		}
+		path = "/api/transactions?limit=2&sort=amount&order=asc&cursor=" + cursor
+	}
+
+	if got, want := fmt.Sprint(amounts), "[100 200 300 400 500]"; got != want {
+		t.Errorf("amounts = %s, want %s", got, want)
+	}
+}
+
+func TestGetTransactionsRejectsInvalidQuery(t *testing.T) {
+	s := newTestServer(t)
+	for _, query := range []string{
+		"sort=name",
+		"order=up",
+		"limit=0",
+		"limit=abc",
+		"cursor=not-a-cursor",
+		"startDate=05-01-2025",
+		"fundId=42",
+	} {
+		t.Run(query, func(t *testing.T) {
+			if w := s.request(s.admin, http.MethodGet, "/api/transactions?"+query, nil); w.Code != http.StatusBadRequest {
+				t.Errorf("status %d, want 400: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetTransactionsFilters(t *testing.T) {
+	s := newTestServer(t)
+	s.createTransaction(s.admin, map[string]interface{}{"eventName": "Ibadah Natal", "date": "2025-12-25"})
+	s.createTransaction(s.admin, map[string]interface{}{"type": "expense", "category": s.expense.Name, "eventName": "Rapat Majelis"})
+	s.createTransaction(s.member, map[string]interface{}{"eventName": "Persekutuan Doa"})
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", 3},
+		{"type=expense", 1},
+		{"type=all", 3},
+		{"q=natal", 1},
+		{"startDate=2025-06-01", 1},
+		{"endDate=2025-06-01", 2},
+		{"category=" + s.expense.Name, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			out := s.do(s.admin, http.MethodGet, "/api/transactions?"+tt.query, nil, http.StatusOK)
+			if got := len(list(out)); got != tt.want {
+				t.Errorf("got %d transactions, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetTransactionsShowsMembersTheirOwn(t *testing.T) {
+	s := newTestServer(t)
+	s.createTransaction(s.admin, nil)
+	own := s.createTransaction(s.member, nil)
+
+	items := list(s.do(s.member, http.MethodGet, "/api/transactions", nil, http.StatusOK))
+	if len(items) != 1 || items[0].(map[string]interface{})["id"] != own {
+		t.Errorf("member sees %v, want only %s", items, own)
+	}
+}
+
+func TestGetTransactionByID(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createTransaction(s.admin, map[string]interface{}{"amount": "1234.5"})
+
+	out := s.do(s.admin, http.MethodGet, "/api/transactions/"+id, nil, http.StatusOK)
+	if amount := data(out)["amount"]; amount != 1234.5 {
+		t.Errorf("amount = %v, want 1234.5", amount)
+	}
+	s.do(s.admin, http.MethodGet, "/api/transactions/00000000-0000-0000-0000-000000000001", nil, http.StatusNotFound)
+	s.do(s.admin, http.MethodGet, "/api/transactions/not-an-id", nil, http.StatusNotFound)
+}
+
+func TestTransactionsRequireAuthentication(t *testing.T) {
+	s := newTestServer(t)
+	s.do(s.admin, http.MethodGet, "/api/transactions", nil, http.StatusOK)
+	w := s.request(s.admin, http.MethodGet, "/api/transactions", nil, "Authorization", "Bearer invalid")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status %d with an invalid token, want 401", w.Code)
+	}
+}
+
+func TestTransactionReviewLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createTransaction(s.member, map[string]interface{}{"draft": true})
+	path := "/api/transactions/" + id + "/status"
+	status := func(status string) map[string]interface{} {
+		return map[string]interface{}{"status": status}
+	}
+
+	if got := data(s.do(s.member, http.MethodGet, "/api/transactions/"+id, nil, http.StatusOK))["status"]; got != "draft" {
+		t.Fatalf("status = %v, want draft", got)
+	}
+	s.do(s.reviewer, http.MethodPut, path, status("approved"), http.StatusConflict)
+	s.do(s.member, http.MethodPut, path, status("pending"), http.StatusOK)
+	s.do(s.member, http.MethodPut, path, status("approved"), http.StatusForbidden)
+
+	out := s.do(s.reviewer, http.MethodPut, path, status("approved"), http.StatusOK)
+	if got := data(out); got["status"] != "approved" || got["reviewedBy"] != s.reviewer.ID.String() {
+		t.Errorf("after approval: status %v reviewed by %v, want approved by %s", got["status"], got["reviewedBy"], s.reviewer.ID)
+	}
+	s.do(s.reviewer, http.MethodPut, path, status("pending"), http.StatusConflict)
+}
+
+func TestTransactionCannotBeSelfApproved(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createTransaction(s.admin, nil)
+	path := "/api/transactions/" + id + "/status"
+
+	s.do(s.admin, http.MethodPut, path, map[string]interface{}{"status": "approved"}, http.StatusForbidden)
+	out := s.do(s.reviewer, http.MethodPut, path, map[string]interface{}{"status": "rejected", "rejectionReason": "Nota tidak lengkap"}, http.StatusOK)
+	if got := data(out); got["status"] != "rejected" || got["rejectionReason"] != "Nota tidak lengkap" {
+		t.Errorf("after rejection: %v", got)
+	}
+}
+
+func TestVoidTransactionNetsToZero(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createTransaction(s.admin, map[string]interface{}{"type": "expense", "category": s.expense.Name, "amount": "80000"})
+	s.do(s.admin, http.MethodPost, "/api/transactions/"+id+"/void", map[string]interface{}{"reason": "Belum disetujui"}, http.StatusConflict)
+	s.approve(id)
+	s.do(s.admin, http.MethodDelete, "/api/transactions/"+id, nil, http.StatusConflict)
+	s.do(s.admin, http.MethodPost, "/api/transactions/"+id+"/void", map[string]interface{}{"reason": " "}, http.StatusBadRequest)
+
+	out := s.do(s.admin, http.MethodPost, "/api/transactions/"+id+"/void", map[string]interface{}{"reason": "Nota ganda", "date": "2025-02-01"}, http.StatusOK)
+	reversal := out["reversal"].(map[string]interface{})
+	if got := data(out); got["status"] != "voided" || got["voidReason"] != "Nota ganda" {
+		t.Errorf("original: status %v, reason %v; want voided for Nota ganda", got["status"], got["voidReason"])
+	}
+	if reversal["reversalOfId"] != id || reversal["amount"] != -80000.0 || reversal["status"] != "approved" {
+		t.Errorf("reversal %v, want an approved -80000 linked to %s", reversal, id)
+	}
+	s.do(s.admin, http.MethodPost, "/api/transactions/"+id+"/void", map[string]interface{}{"reason": "Lagi"}, http.StatusConflict)
+	s.do(s.admin, http.MethodPost, "/api/transactions/"+reversal["id"].(string)+"/void", map[string]interface{}{"reason": "Lagi"}, http.StatusConflict)
+
+	stats := data(s.do(s.admin, http.MethodGet, "/api/dashboard/stats", nil, http.StatusOK))
+	if stats["totalExpense"] != 0.0 || stats["currentBalance"] != 0.0 {
+		t.Errorf("after the void: expense %v, balance %v; want both 0", stats["totalExpense"], stats["currentBalance"])
+	}
+	balances := func(query string) map[string]interface{} {
+		return s.do(s.admin, http.MethodGet, "/api/dashboard/balances?"+query, nil, http.StatusOK)["totals"].(map[string]interface{})
+	}
+	if got := balances("endDate=2025-01-31"); got["closingBalance"] != -80000.0 {
+		t.Errorf("balance before the reversal date = %v, want -80000", got["closingBalance"])
+	}
+	if got := balances("startDate=2025-02-01"); got["openingBalance"] != -80000.0 || got["closingBalance"] != 0.0 {
+		t.Errorf("from the reversal date: opening %v, closing %v; want -80000 and 0", got["openingBalance"], got["closingBalance"])
+	}
+}
@@ -1,17 +1,34 @@
 package handlers
 
 import (
-	"gkjw-finance-backend/config"
+	"errors"
 	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-func GetUsers(c *gin.Context) {
-	var users []models.User
-	
-	if err := config.DB.Order("created_at DESC").Find(&users).Error; err != nil {
+const (
+	defaultActivityLogPageSize = 50
+	maxActivityLogPageSize     = 200
+)
+
+// UserHandler serves user management and activity logs.
+type UserHandler struct {
+	repos *repository.Repositories
+}
+
+func NewUserHandler(repos *repository.Repositories) *UserHandler {
+	return &UserHandler{repos: repos}
+}
+
+func (h *UserHandler) GetUsers(c *gin.Context) {
+	users, err := h.repos.Users.List()
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
 		return
 	}
@@ -19,11 +36,15 @@ func GetUsers(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": users})
 }
 
-func GetUserByID(c *gin.Context) {
-	id := c.Param("id")
-	
-	var user models.User
-	if err := config.DB.Where("id = ?", id).First(&user).Error; err != nil {
+func (h *UserHandler) GetUserByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	user, err := h.repos.Users.FindByID(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -31,16 +52,20 @@ func GetUserByID(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": user})
 }
 
-func CreateUser(c *gin.Context) {
+func (h *UserHandler) CreateUser(c *gin.Context) {
 	// Uses the same logic as Register
-	Register(c)
+	registerUser(c, h.repos)
 }
 
-func UpdateUser(c *gin.Context) {
-	id := c.Param("id")
-	
-	var user models.User
-	if err := config.DB.Where("id = ?", id).First(&user).Error; err != nil {
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	user, err := h.repos.Users.FindByID(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -57,6 +82,7 @@ func UpdateUser(c *gin.Context) {
 		return
 	}
 
+	before := *user
 	if req.Name != "" {
 		user.Name = req.Name
 	}
@@ -67,7 +93,20 @@ func UpdateUser(c *gin.Context) {
 		user.Role = req.Role
 	}
 
-	if err := config.DB.Save(&user).Error; err != nil {
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.Users.Update(user); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "user",
+			EntityID:   user.ID,
+			Action:     "update",
+			Summary:    "Updated user: " + user.Email,
+			Before:     before,
+			After:      user,
+		})
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
 		return
 	}
@@ -78,16 +117,36 @@ func UpdateUser(c *gin.Context) {
 	})
 }
 
-func DeleteUser(c *gin.Context) {
-	id := c.Param("id")
-	
-	var user models.User
-	if err := config.DB.Where("id = ?", id).First(&user).Error; err != nil {
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
-	if err := config.DB.Delete(&user).Error; err != nil {
+	user, err := h.repos.Users.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	err = h.repos.WithTx(func(repos *repository.Repositories) error {
+		if err := repos.Users.Delete(id); err != nil {
+			return err
+		}
+		return recordAudit(c, repos, auditEntry{
+			EntityType: "user",
+			EntityID:   user.ID,
+			Action:     "delete",
+			Summary:    "Deleted user: " + user.Email,
+			Before:     user,
+		})
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
 		return
 	}
@@ -95,13 +154,82 @@ func DeleteUser(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
 
-func GetActivityLogs(c *gin.Context) {
-	var logs []models.ActivityLog
-	
-	if err := config.DB.Preload("User").Order("timestamp DESC").Limit(100).Find(&logs).Error; err != nil {
+// GetActivityLogs lists audit log entries, newest first, filtered by
+// entityType, entityId, userId, action, startDate and endDate. Pages are
+// keyset-paginated; pass nextCursor back as cursor for the next page.
+func (h *UserHandler) GetActivityLogs(c *gin.Context) {
+	filter := repository.ActivityLogFilter{
+		EntityType: c.Query("entityType"),
+		Action:     c.Query("action"),
+	}
+	if entityID := c.Query("entityId"); entityID != "" {
+		parsed, err := uuid.Parse(entityID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entityId"})
+			return
+		}
+		filter.EntityID = parsed
+	}
+	if userID := c.Query("userId"); userID != "" {
+		parsed, err := uuid.Parse(userID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userId"})
+			return
+		}
+		filter.UserID = parsed
+	}
+	if startDate := c.Query("startDate"); startDate != "" {
+		parsed, err := time.Parse("2006-01-02", startDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid startDate. Use YYYY-MM-DD"})
+			return
+		}
+		filter.StartDate = parsed
+	}
+	if endDate := c.Query("endDate"); endDate != "" {
+		parsed, err := time.Parse("2006-01-02", endDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid endDate. Use YYYY-MM-DD"})
+			return
+		}
+		filter.EndDate = parsed
+	}
+
+	limit := defaultActivityLogPageSize
+	if l := c.Query("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+	if limit > maxActivityLogPageSize {
+		limit = maxActivityLogPageSize
+	}
+
+	page, err := h.repos.ActivityLogs.List(filter, limit, c.Query("cursor"))
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch activity logs"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": logs})
+	items := page.Items
+	if items == nil {
+		items = []models.ActivityLog{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": items,
+		"pagination": gin.H{
+			"limit":      limit,
+			"total":      page.Total,
+			"hasMore":    page.HasMore,
+			"nextCursor": page.NextCursor,
+		},
+	})
 }
@@ -0,0 +1,408 @@
+// Package importer reads transactions from CSV and Excel files, such as
+// the spreadsheets the church kept before this system, so that they can be
+// checked and recorded.
+//
+// Read turns every row into a Row with the fields of a transaction; a row
+// that cannot be read carries its errors instead of failing the file, so
+// that a dry run reports every problem at once. Fund and category names are
+// left for the caller to resolve.
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"gkjw-finance-backend/bankstatement"
+	"gkjw-finance-backend/models"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Formats lists the file formats Read understands.
+var Formats = []string{"csv", "xlsx"}
+
+// Mapping tells Read where to find each field. Columns are named by their
+// header, case-insensitively, or by their 1-based number. A field left
+// empty is looked up under its usual headers, in English or Indonesian,
+// such as "Tanggal" for Date or "Kategori" for Category.
+//
+// The amount is either one Amount column, negative for expenses, or a pair
+// of Income and Expense columns as in the exported reports. Type, when
+// mapped, holds income or expense (pemasukan or pengeluaran); rows that do
+// not tell get DefaultType. DefaultFund and DefaultPaymentMethod fill empty
+// cells likewise.
+type Mapping struct {
+	Sheet                string `json:"sheet"`
+	Delimiter            string `json:"delimiter"`
+	NoHeader             bool   `json:"noHeader"`
+	SkipRows             int    `json:"skipRows"`
+	Date                 string `json:"date"`
+	DateFormat           string `json:"dateFormat"`
+	Type                 string `json:"type"`
+	Amount               string `json:"amount"`
+	Income               string `json:"income"`
+	Expense              string `json:"expense"`
+	DecimalComma         bool   `json:"decimalComma"`
+	Fund                 string `json:"fund"`
+	Category             string `json:"category"`
+	PaymentMethod        string `json:"paymentMethod"`
+	EventName            string `json:"eventName"`
+	Description          string `json:"description"`
+	NoteURL              string `json:"noteUrl"`
+	DefaultType          string `json:"defaultType"`
+	DefaultFund          string `json:"defaultFund"`
+	DefaultPaymentMethod string `json:"defaultPaymentMethod"`
+}
+
+// headerNames are the headers a field is looked up under when the mapping
+// leaves it empty.
+var headerNames = map[string][]string{
+	"date":          {"date", "tanggal", "tgl"},
+	"type":          {"type", "jenis", "tipe"},
+	"amount":        {"amount", "jumlah", "nominal"},
+	"income":        {"income", "pemasukan", "masuk"},
+	"expense":       {"expense", "pengeluaran", "keluar"},
+	"fund":          {"fund", "dana"},
+	"category":      {"category", "kategori"},
+	"paymentMethod": {"payment method", "paymentmethod", "metode", "metode pembayaran"},
+	"eventName":     {"event name", "eventname", "event", "kegiatan", "nama kegiatan"},
+	"description":   {"description", "deskripsi", "keterangan"},
+	"noteUrl":       {"note url", "noteurl", "nota", "bukti"},
+}
+
+// typeNames maps the words used for a transaction type to the type.
+var typeNames = map[string]string{
+	"income":      "income",
+	"pemasukan":   "income",
+	"masuk":       "income",
+	"expense":     "expense",
+	"pengeluaran": "expense",
+	"keluar":      "expense",
+}
+
+// paymentMethodNames maps the words used for a payment method to the method.
+var paymentMethodNames = map[string]string{
+	"cash":     "cash",
+	"tunai":    "cash",
+	"kas":      "cash",
+	"bank":     "bank",
+	"transfer": "bank",
+}
+
+// Table is a file read by Read. Header holds the column titles, or is
+// empty when the file has none.
+type Table struct {
+	Header []string
+	Rows   []Row
+}
+
+// Row is one transaction read from a file. Number is its row in the file,
+// counting from 1, and Cells its original content. Errors lists what is
+// wrong with it; a row with errors must not be recorded.
+type Row struct {
+	Number        int
+	Cells         []string
+	Date          time.Time
+	Type          string
+	Amount        models.Money
+	Fund          string
+	Category      string
+	PaymentMethod string
+	EventName     string
+	Description   string
+	NoteURL       string
+	Errors        []string
+}
+
+// Fail records a problem with the row.
+func (r *Row) Fail(format string, args ...interface{}) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+}
+
+// RowError lists the problems of one row.
+type RowError struct {
+	Row    int      `json:"row"`
+	Errors []string `json:"errors"`
+}
+
+// Errors returns the problems of every row that has any.
+func (t *Table) Errors() []RowError {
+	rowErrors := []RowError{}
+	for _, row := range t.Rows {
+		if len(row.Errors) > 0 {
+			rowErrors = append(rowErrors, RowError{Row: row.Number, Errors: row.Errors})
+		}
+	}
+	return rowErrors
+}
+
+// DetectFormat guesses the format of a file from its name, or returns "".
+func DetectFormat(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv", ".txt":
+		return "csv"
+	case ".xlsx", ".xlsm":
+		return "xlsx"
+	}
+	return ""
+}
+
+// Read reads the transactions in data. It fails only when the file or the
+// mapping cannot be used at all; problems with single rows are recorded on
+// the rows.
+func Read(format string, data []byte, mapping Mapping) (*Table, error) {
+	var records [][]string
+	var err error
+	switch format {
+	case "csv":
+		records, err = readCSV(data, mapping.Delimiter)
+	case "xlsx":
+		records, err = readXLSX(data, mapping.Sheet)
+	default:
+		return nil, fmt.Errorf("unknown file format %q; use one of %s", format, strings.Join(Formats, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	table := &Table{}
+	first := mapping.SkipRows
+	if first > len(records) {
+		first = len(records)
+	}
+	if !mapping.NoHeader && first < len(records) {
+		table.Header = records[first]
+		first++
+	}
+	columns, err := mapping.columns(table.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := first; i < len(records); i++ {
+		if strings.TrimSpace(strings.Join(records[i], "")) == "" {
+			continue
+		}
+		row := Row{Number: i + 1, Cells: records[i]}
+		mapping.read(&row, columns)
+		table.Rows = append(table.Rows, row)
+	}
+	if len(table.Rows) == 0 {
+		return nil, errors.New("the file has no rows to import")
+	}
+	return table, nil
+}
+
+func readCSV(data []byte, delimiter string) ([][]string, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if delimiter != "" {
+		runes := []rune(delimiter)
+		if len(runes) != 1 {
+			return nil, errors.New("the CSV delimiter must be a single character")
+		}
+		reader.Comma = runes[0]
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	return records, nil
+}
+
+// readXLSX reads the rows of sheet, or of the first sheet. Cells are read
+// unformatted, so that amounts keep their digits and dates come as serial
+// numbers.
+func readXLSX(data []byte, sheet string) ([][]string, error) {
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Excel file: %w", err)
+	}
+	defer f.Close()
+	if sheet == "" {
+		sheet = f.GetSheetName(0)
+	}
+	rows, err := f.GetRows(sheet, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, fmt.Errorf("sheet %q: %w", sheet, err)
+	}
+	return rows, nil
+}
+
+// columns holds the index of each field in a row, or -1.
+type columns map[string]int
+
+// columns finds the column of every field in header.
+func (m Mapping) columns(header []string) (columns, error) {
+	mapped := map[string]string{
+		"date": m.Date, "type": m.Type, "amount": m.Amount, "income": m.Income, "expense": m.Expense,
+		"fund": m.Fund, "category": m.Category, "paymentMethod": m.PaymentMethod,
+		"eventName": m.EventName, "description": m.Description, "noteUrl": m.NoteURL,
+	}
+	result := columns{}
+	for field, name := range mapped {
+		result[field] = -1
+		if name == "" {
+			result[field] = findColumn(header, headerNames[field]...)
+			continue
+		}
+		if number, err := strconv.Atoi(name); err == nil && number > 0 {
+			result[field] = number - 1
+			continue
+		}
+		if result[field] = findColumn(header, name); result[field] < 0 {
+			return nil, fmt.Errorf("column %q not found in the header", name)
+		}
+	}
+
+	// A mapped amount column wins over the usual income and expense headers.
+	if m.Amount != "" && m.Income == "" && m.Expense == "" {
+		result["income"], result["expense"] = -1, -1
+	}
+	switch {
+	case result["date"] < 0:
+		return nil, errors.New("the mapping needs a date column")
+	case result["amount"] < 0 && result["income"] < 0 && result["expense"] < 0:
+		return nil, errors.New("the mapping needs an amount column or income and expense columns")
+	case result["fund"] < 0 && m.DefaultFund == "":
+		return nil, errors.New("the mapping needs a fund column or a default fund")
+	case result["category"] < 0:
+		return nil, errors.New("the mapping needs a category column")
+	case result["eventName"] < 0:
+		return nil, errors.New("the mapping needs an event name column")
+	}
+	if m.DefaultType != "" && typeNames[strings.ToLower(m.DefaultType)] == "" {
+		return nil, fmt.Errorf("unknown default type %q", m.DefaultType)
+	}
+	if m.DefaultPaymentMethod != "" && paymentMethodNames[strings.ToLower(m.DefaultPaymentMethod)] == "" {
+		return nil, fmt.Errorf("unknown default payment method %q", m.DefaultPaymentMethod)
+	}
+	return result, nil
+}
+
+func findColumn(header []string, names ...string) int {
+	for _, name := range names {
+		for i, title := range header {
+			if strings.EqualFold(strings.TrimSpace(title), strings.TrimSpace(name)) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// read fills row from its cells.
+func (m Mapping) read(row *Row, columns columns) {
+	cell := func(field string) string {
+		index := columns[field]
+		if index < 0 || index >= len(row.Cells) {
+			return ""
+		}
+		return strings.TrimSpace(row.Cells[index])
+	}
+
+	if value := cell("date"); value == "" {
+		row.Fail("Date is required")
+	} else if date, err := parseDate(value, m.DateFormat); err != nil {
+		row.Fail("Invalid date %q", value)
+	} else {
+		row.Date = date
+	}
+
+	// kind is the type the amount columns tell.
+	var kind string
+	failures := len(row.Errors)
+	amount := func(field string) models.Money {
+		value, err := bankstatement.ParseAmount(cell(field), m.DecimalComma)
+		if err != nil {
+			row.Fail("Invalid %s %q", field, cell(field))
+		}
+		return value
+	}
+	if columns["amount"] >= 0 {
+		row.Amount = amount("amount")
+	} else {
+		income, expense := amount("income"), amount("expense")
+		switch {
+		case income != 0 && expense != 0:
+			row.Fail("A row cannot hold both income and expense")
+			kind = "both"
+		case income != 0:
+			row.Amount, kind = income, "income"
+		case expense != 0:
+			row.Amount, kind = expense, "expense"
+		}
+	}
+	if row.Amount < 0 {
+		row.Amount, kind = -row.Amount, "expense"
+	}
+	if row.Amount == 0 && len(row.Errors) == failures {
+		row.Fail("Amount must be greater than 0")
+	}
+
+	value := cell("type")
+	named := typeNames[strings.ToLower(value)]
+	switch {
+	case value != "" && named == "":
+		row.Fail("Unknown type %q; use income or expense", value)
+	case kind == "both":
+		// Refused above; the type cannot be told.
+	case named != "" && kind != "" && named != kind:
+		row.Fail("Type %s does not agree with the amount, which is an %s", value, kind)
+	case named != "":
+		row.Type = named
+	case kind != "":
+		row.Type = kind
+	default:
+		if row.Type = typeNames[strings.ToLower(m.DefaultType)]; row.Type == "" {
+			row.Fail("Type is required")
+		}
+	}
+
+	row.PaymentMethod = paymentMethodNames[strings.ToLower(m.DefaultPaymentMethod)]
+	if value := cell("paymentMethod"); value != "" {
+		if row.PaymentMethod = paymentMethodNames[strings.ToLower(value)]; row.PaymentMethod == "" {
+			row.Fail("Unknown payment method %q; use cash or bank", value)
+		}
+	}
+
+	row.Fund = cell("fund")
+	if row.Fund == "" {
+		row.Fund = m.DefaultFund
+	}
+	if row.Fund == "" {
+		row.Fail("Fund is required")
+	}
+	if row.Category = cell("category"); row.Category == "" {
+		row.Fail("Category is required")
+	}
+	if row.EventName = cell("eventName"); row.EventName == "" {
+		row.Fail("Event name is required")
+	}
+	row.Description = cell("description")
+	row.NoteURL = cell("noteUrl")
+}
+
+// parseDate reads a date as ParseDate of the bankstatement package does,
+// or as the serial number of an unformatted Excel date.
+func parseDate(value, layout string) (time.Time, error) {
+	date, err := bankstatement.ParseDate(value, layout)
+	if err == nil {
+		return date, nil
+	}
+	serial, serialErr := strconv.ParseFloat(value, 64)
+	if serialErr != nil || serial < 1 || serial >= 2958466 {
+		return time.Time{}, err
+	}
+	excelDate, serialErr := excelize.ExcelDateToTime(serial, false)
+	if serialErr != nil {
+		return time.Time{}, err
+	}
+	return time.Date(excelDate.Year(), excelDate.Month(), excelDate.Day(), 0, 0, 0, 0, time.UTC), nil
+}
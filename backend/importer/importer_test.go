@@ -0,0 +1,209 @@
+package importer
+
+import (
+	"gkjw-finance-backend/models"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestReadRows(t *testing.T) {
+	data := "\xef\xbb\xbfTanggal,Jenis,Jumlah,Dana,Kategori,Metode,Kegiatan,Keterangan\n" +
+		"05/01/2025,pemasukan,\"1,500,000\",Kas Umum,Persembahan,tunai,Ibadah Minggu,Kolekte\n" +
+		",,,,,,,\n" +
+		"2025-01-06,,-250000,,Konsumsi,transfer,Rapat Majelis,\n" +
+		"besok,income,0,Kas Umum,,cek,,\n" +
+		"2025-01-07,income,-5000,Kas Umum,Persembahan,,Ibadah,\n" +
+		"2025-01-08,hadiah,5000,Kas Umum,Persembahan,,Ibadah,\n"
+
+	table, err := Read("csv", []byte(data), Mapping{DefaultFund: "Kas Pembangunan", DefaultPaymentMethod: "cash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		number int
+		want   Row
+		errors []string
+	}{
+		{
+			number: 2,
+			want: Row{Date: day("2025-01-05"), Type: "income", Amount: models.Rupiah(1500000), Fund: "Kas Umum",
+				Category: "Persembahan", PaymentMethod: "cash", EventName: "Ibadah Minggu", Description: "Kolekte"},
+		},
+		{
+			number: 4,
+			want: Row{Date: day("2025-01-06"), Type: "expense", Amount: models.Rupiah(250000), Fund: "Kas Pembangunan",
+				Category: "Konsumsi", PaymentMethod: "bank", EventName: "Rapat Majelis"},
+		},
+		{
+			number: 5,
+			errors: []string{`Invalid date "besok"`, "Amount must be greater than 0", `Unknown payment method "cek"; use cash or bank`,
+				"Category is required", "Event name is required"},
+		},
+		{
+			number: 6,
+			errors: []string{"Type income does not agree with the amount, which is an expense"},
+		},
+		{
+			number: 7,
+			errors: []string{`Unknown type "hadiah"; use income or expense`},
+		},
+	}
+	if len(table.Rows) != len(tests) {
+		t.Fatalf("read %d rows, want %d (blank rows skipped)", len(table.Rows), len(tests))
+	}
+	for i, tt := range tests {
+		row := table.Rows[i]
+		if row.Number != tt.number {
+			t.Errorf("row %d has number %d, want %d", i, row.Number, tt.number)
+		}
+		if !reflect.DeepEqual(row.Errors, tt.errors) {
+			t.Errorf("row %d: errors %q, want %q", tt.number, row.Errors, tt.errors)
+		}
+		if tt.errors != nil {
+			continue
+		}
+		row.Number, row.Cells = 0, nil
+		if !reflect.DeepEqual(row, tt.want) {
+			t.Errorf("row %d = %+v, want %+v", tt.number, row, tt.want)
+		}
+	}
+
+	if got := len(table.Errors()); got != 3 {
+		t.Errorf("Errors() lists %d rows, want 3", got)
+	}
+}
+
+func TestReadIncomeAndExpenseColumns(t *testing.T) {
+	data := "No;Tanggal;Kategori;Kegiatan;Pemasukan;Pengeluaran\n" +
+		"1;05-01-2025;Persembahan;Ibadah;1.000.000,00;\n" +
+		"2;06-01-2025;Konsumsi;Rapat;;250.000,50\n" +
+		"3;07-01-2025;Lain-lain;Koreksi;10,00;10,00\n"
+
+	table, err := Read("csv", []byte(data), Mapping{Delimiter: ";", DecimalComma: true, DefaultFund: "Kas Umum"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		kind   string
+		amount string
+		errors int
+	}{
+		{"income", "1000000", 0},
+		{"expense", "250000.50", 0},
+		{"", "0", 1},
+	}
+	for i, tt := range tests {
+		row := table.Rows[i]
+		amount, _ := models.ParseMoney(tt.amount)
+		if row.Type != tt.kind || row.Amount != amount || len(row.Errors) != tt.errors {
+			t.Errorf("row %d: %s %s with errors %q, want %s %s with %d errors",
+				row.Number, row.Type, row.Amount, row.Errors, tt.kind, tt.amount, tt.errors)
+		}
+	}
+}
+
+func TestReadMappingErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		data    string
+		mapping Mapping
+		want    string
+	}{
+		{"unknown format", "ods", "", Mapping{}, "unknown file format"},
+		{"no rows", "csv", "Tanggal,Jumlah,Dana,Kategori,Kegiatan\n", Mapping{}, "no rows to import"},
+		{"no date", "csv", "Jumlah,Dana,Kategori,Kegiatan\n1,A,B,C\n", Mapping{}, "needs a date column"},
+		{"no amount", "csv", "Tanggal,Dana,Kategori,Kegiatan\n2025-01-01,A,B,C\n", Mapping{}, "needs an amount column"},
+		{"no fund", "csv", "Tanggal,Jumlah,Kategori,Kegiatan\n2025-01-01,1,B,C\n", Mapping{}, "needs a fund column or a default fund"},
+		{"unknown column", "csv", "Tanggal,Jumlah\n2025-01-01,1\n", Mapping{Amount: "Nominal"}, `column "Nominal" not found`},
+		{"bad default type", "csv", "Tanggal,Jumlah,Dana,Kategori,Kegiatan\n2025-01-01,1,A,B,C\n", Mapping{DefaultType: "hadiah"}, "unknown default type"},
+		{"bad delimiter", "csv", "a", Mapping{Delimiter: "||"}, "single character"},
+		{"not an Excel file", "xlsx", "plain text", Mapping{}, "invalid Excel file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(tt.format, []byte(tt.data), tt.mapping)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadXLSX(t *testing.T) {
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := f.GetSheetName(0)
+	for i, row := range [][]interface{}{
+		{"Laporan Keuangan 2025"},
+		{"Tanggal", "Kategori", "Kegiatan", "Dana", "Jumlah"},
+		{time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC), "Persembahan", "Ibadah Minggu", "Kas Umum", 1250000.5},
+	} {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table, err := Read("xlsx", buf.Bytes(), Mapping{SkipRows: 1, DefaultType: "income"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := table.Rows[0]
+	if len(row.Errors) > 0 {
+		t.Fatalf("errors: %q", row.Errors)
+	}
+	if row.Number != 3 || !row.Date.Equal(day("2025-01-05")) || row.Amount != 125000050 || row.Type != "income" {
+		t.Errorf("row %d: %s %s %s, want row 3: 2025-01-05 income 1250000.50",
+			row.Number, row.Date.Format("2006-01-02"), row.Type, row.Amount)
+	}
+}
+
+func TestErrorReport(t *testing.T) {
+	table := &Table{
+		Header: []string{"Tanggal", ""},
+		Rows: []Row{
+			{Number: 2, Cells: []string{"2025-01-05", "1000"}},
+			{Number: 3, Cells: []string{"besok", "0", "extra"}, Errors: []string{`Invalid date "besok"`, "Amount must be greater than 0"}},
+		},
+	}
+	report, err := ErrorReport(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Row,Tanggal,Column 2,Column 3,Errors\n" +
+		"3,besok,0,extra,\"Invalid date \"\"besok\"\"; Amount must be greater than 0\"\n"
+	if string(report) != want {
+		t.Errorf("report:\n%s\nwant:\n%s", report, want)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	for name, want := range map[string]string{
+		"laporan.xlsx": "xlsx",
+		"laporan.XLSM": "xlsx",
+		"laporan.csv":  "csv",
+		"laporan.xls":  "",
+	} {
+		if got := DetectFormat(name); got != want {
+			t.Errorf("DetectFormat(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func day(value string) time.Time {
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return date
+}
@@ -0,0 +1,46 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"strings"
+)
+
+// ErrorReport writes the rows of t that have errors as CSV: the row
+// number, the original cells and the errors. Once fixed, the rows can be
+// imported again; columns mapped by number move one to the right.
+func ErrorReport(t *Table) ([]byte, error) {
+	width := len(t.Header)
+	for _, row := range t.Rows {
+		if len(row.Cells) > width {
+			width = len(row.Cells)
+		}
+	}
+
+	header := []string{"Row"}
+	for i := 0; i < width; i++ {
+		if i < len(t.Header) && strings.TrimSpace(t.Header[i]) != "" {
+			header = append(header, t.Header[i])
+		} else {
+			header = append(header, "Column "+strconv.Itoa(i+1))
+		}
+	}
+	header = append(header, "Errors")
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write(header)
+	for _, row := range t.Rows {
+		if len(row.Errors) == 0 {
+			continue
+		}
+		record := append([]string{strconv.Itoa(row.Number)}, row.Cells...)
+		for len(record) < width+1 {
+			record = append(record, "")
+		}
+		writer.Write(append(record, strings.Join(row.Errors, "; ")))
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
@@ -1,10 +1,19 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"gkjw-finance-backend/config"
+	"gkjw-finance-backend/handlers"
+	"gkjw-finance-backend/migrations"
+	"gkjw-finance-backend/repository"
 	"gkjw-finance-backend/routes"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -13,12 +22,24 @@ import (
 func main() {
 	// Load environment variables from .env file if exists (for local development)
 	// For production (Leapcell), environment variables are set directly
-	godotenv.Load()              // Load .env
-	godotenv.Load(".env.local")  // Override with .env.local if exists (for local development)
+	godotenv.Load()             // Load .env
+	godotenv.Load(".env.local") // Override with .env.local if exists (for local development)
 
 	// Initialize database connection (reads from environment variables)
 	config.InitDB()
 
+	// SQLite is only used for local development, so bring its schema up to
+	// date on start. PostgreSQL is migrated by cmd/dbmigrate during deploys.
+	if config.Driver == "sqlite" {
+		runner, err := migrations.NewRunner(config.DB, config.Driver)
+		if err != nil {
+			log.Fatal("Failed to load migrations:", err)
+		}
+		if _, err := runner.Up(); err != nil {
+			log.Fatal("Failed to migrate SQLite database:", err)
+		}
+	}
+
 	// Create uploads directory if it doesn't exist
 	if err := os.MkdirAll("./uploads", 0755); err != nil {
 		log.Printf("Warning: Failed to create uploads directory: %v", err)
@@ -31,7 +52,8 @@ func main() {
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID, If-Match, Idempotency-Key")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, ETag, Idempotent-Replayed")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
@@ -48,7 +70,15 @@ func main() {
 	})
 
 	// Setup routes
-	routes.SetupRoutes(router)
+	repos := repository.NewGormRepositories(config.DB)
+	routes.SetupRoutes(router, repos)
+
+	// Stop on SIGINT or SIGTERM, as sent by the platform on redeploys
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Record recurring transactions that fell due, now and from time to time
+	schedulerDone := handlers.StartRecurringScheduler(ctx, repos)
 
 	// Get port from environment or use default
 	port := os.Getenv("PORT")
@@ -57,8 +87,21 @@ func main() {
 	}
 
 	// Start server
-	log.Printf("Server starting on port %s", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	server := &http.Server{Addr: ":" + port, Handler: router}
+	go func() {
+		log.Printf("Server starting on port %s", port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
+
+	// Finish the requests and the scheduler run in progress, then exit
+	<-ctx.Done()
+	log.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Failed to shut down the server: %v", err)
 	}
+	<-schedulerDone
 }
@@ -0,0 +1,246 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// IdempotencyKeyHeader lets a client retry a request safely. The first
+// successful response to a key is stored and replayed for every retry with
+// the same key, method, path and body until the key expires.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// defaultIdempotencyKeyTTL is how long keys are kept unless
+// IDEMPOTENCY_KEY_TTL (a Go duration such as "48h") says otherwise.
+const defaultIdempotencyKeyTTL = 24 * time.Hour
+
+// idempotencyKeyLease is how long a claimed key is held for its request.
+// A retry after that takes the key over, so that a key is not stuck when
+// the server stopped before storing the response.
+const idempotencyKeyLease = time.Minute
+
+// maxIdempotentBodySize bounds the request bodies read into memory for
+// hashing: the largest import file (10MB) and its form fields.
+const maxIdempotentBodySize = 11 * 1024 * 1024
+
+// Idempotency answers a request carrying an Idempotency-Key header that was
+// already answered with the stored response, marked with an
+// Idempotent-Replayed header. Only 2xx JSON responses are stored; after
+// any other, or a panic, the key is released so that the request can be
+// corrected and retried. The response is held back until it is stored,
+// and answered with 500 when that fails. It must run after
+// AuthMiddleware, as keys belong to a user.
+func Idempotency(repos *repository.Repositories) gin.HandlerFunc {
+	ttl := idempotencyKeyTTL()
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBodySize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body is too large"})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		userID, _ := c.Get("userId")
+		now := time.Now()
+		record := &models.IdempotencyKey{
+			UserID:      userID.(uuid.UUID),
+			Key:         key,
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			RequestHash: requestHash(c.Request, body),
+			CreatedAt:   now,
+			LockedUntil: now.Add(idempotencyKeyLease),
+			ExpiresAt:   now.Add(ttl),
+		}
+
+		claimed, err := repos.IdempotencyKeys.Claim(record)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check Idempotency-Key"})
+			c.Abort()
+			return
+		}
+		if !claimed {
+			replay(c, repos, record)
+			return
+		}
+
+		release := func() {
+			if err := repos.IdempotencyKeys.Release(record.UserID, key); err != nil {
+				log.Printf("Failed to release idempotency key %q: %v", key, err)
+			}
+		}
+		headers := c.Writer.Header().Clone()
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		// A panicking handler answers nothing worth replaying; release the
+		// key before the panic reaches the recovery middleware, which
+		// writes its response directly.
+		defer func() {
+			if p := recover(); p != nil {
+				c.Writer = recorder.ResponseWriter
+				release()
+				panic(p)
+			}
+		}()
+
+		c.Writer = recorder
+		c.Next()
+		c.Writer = recorder.ResponseWriter
+
+		status := recorder.Status()
+		contentType := recorder.Header().Get("Content-Type")
+		if status < 200 || status > 299 || !strings.HasPrefix(contentType, "application/json") {
+			release()
+			recorder.flush()
+			return
+		}
+		record.StatusCode = status
+		record.ResponseBody = recorder.body.String()
+		if err := repos.IdempotencyKeys.Complete(record); err != nil {
+			// The request took effect, but a retry cannot be answered
+			// with its response; the key stays claimed until its lease
+			// runs out.
+			log.Printf("Failed to store response for idempotency key %q: %v", key, err)
+			for name := range recorder.Header() {
+				delete(recorder.Header(), name)
+			}
+			for name, values := range headers {
+				recorder.Header()[name] = values
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "The request was processed, but its response could not be stored for the Idempotency-Key; check the result before retrying"})
+			return
+		}
+		recorder.flush()
+	}
+}
+
+// replay answers a request whose key was already claimed.
+func replay(c *gin.Context, repos *repository.Repositories, record *models.IdempotencyKey) {
+	defer c.Abort()
+
+	stored, err := repos.IdempotencyKeys.Find(record.UserID, record.Key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check Idempotency-Key"})
+		return
+	}
+	if stored.RequestHash != record.RequestHash {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+		return
+	}
+	if stored.StatusCode == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+		return
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(stored.StatusCode, "application/json; charset=utf-8", []byte(stored.ResponseBody))
+}
+
+// requestHash identifies the request a key was used for. A multipart form
+// is hashed by its fields and file contents rather than by its bytes, as
+// clients choose a new boundary for every attempt.
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if form, err := multipartDigest(body, params["boundary"]); err == nil {
+			body = form
+		}
+	}
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// multipartDigest lists the parts of a multipart body by name, file name
+// and content hash, in a fixed order.
+func multipartDigest(body []byte, boundary string) ([]byte, error) {
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	var parts []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		content := sha256.New()
+		if _, err := io.Copy(content, part); err != nil {
+			return nil, err
+		}
+		parts = append(parts, fmt.Sprintf("%q %q %x", part.FormName(), part.FileName(), content.Sum(nil)))
+	}
+	sort.Strings(parts)
+	return []byte(strings.Join(parts, "\n")), nil
+}
+
+func idempotencyKeyTTL() time.Duration {
+	value := os.Getenv("IDEMPOTENCY_KEY_TTL")
+	if value == "" {
+		return defaultIdempotencyKeyTTL
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		log.Printf("Invalid IDEMPOTENCY_KEY_TTL %q, using %s", value, defaultIdempotencyKeyTTL)
+		return defaultIdempotencyKeyTTL
+	}
+	return ttl
+}
+
+// responseRecorder holds back the response body until flush, so that the
+// response can still be replaced when it cannot be stored.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// flush writes the status and the held back body.
+func (w *responseRecorder) flush() {
+	w.ResponseWriter.WriteHeaderNow()
+	if _, err := w.ResponseWriter.Write(w.body.Bytes()); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
@@ -0,0 +1,263 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"gkjw-finance-backend/models"
+	"gkjw-finance-backend/repository"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// testUserID is the user every test request is sent for.
+var testUserID = uuid.New()
+
+// idempotentRouter serves POST /items through Idempotency for testUserID.
+// handler answers each call with the status it returns, or panics when
+// that is 0, and counts its calls.
+func idempotentRouter(statuses ...int) (*gin.Engine, *int) {
+	return idempotentRouterFor(repository.NewMemoryRepositories(), statuses...)
+}
+
+// idempotentRouterFor is idempotentRouter keeping its keys in repos.
+func idempotentRouterFor(repos *repository.Repositories, statuses ...int) (*gin.Engine, *int) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	router := gin.New()
+	router.Use(gin.CustomRecovery(func(c *gin.Context, _ interface{}) {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "panic"})
+	}))
+	router.Use(func(c *gin.Context) { c.Set("userId", testUserID) })
+	router.POST("/items", Idempotency(repos), func(c *gin.Context) {
+		status := statuses[calls]
+		calls++
+		if status == 0 {
+			panic("handler failed")
+		}
+		c.JSON(status, gin.H{"call": calls})
+	})
+	return router, &calls
+}
+
+func post(router *gin.Engine, key, body string, contentType ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(body))
+	req.Header.Set(IdempotencyKeyHeader, key)
+	if len(contentType) > 0 {
+		req.Header.Set("Content-Type", contentType[0])
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int // answered by the handler on each call; 0 panics
+		bodies    []string
+		wantCodes []int
+		wantCalls int
+	}{
+		{
+			name:      "success is replayed",
+			statuses:  []int{http.StatusCreated},
+			bodies:    []string{`{"a":1}`, `{"a":1}`},
+			wantCodes: []int{http.StatusCreated, http.StatusCreated},
+			wantCalls: 1,
+		},
+		{
+			name:      "other body is refused",
+			statuses:  []int{http.StatusCreated},
+			bodies:    []string{`{"a":1}`, `{"a":2}`},
+			wantCodes: []int{http.StatusCreated, http.StatusUnprocessableEntity},
+			wantCalls: 1,
+		},
+		{
+			name:      "error releases the key",
+			statuses:  []int{http.StatusBadRequest, http.StatusCreated},
+			bodies:    []string{`{"a":1}`, `{"a":1}`, `{"a":1}`},
+			wantCodes: []int{http.StatusBadRequest, http.StatusCreated, http.StatusCreated},
+			wantCalls: 2,
+		},
+		{
+			name:      "panic releases the key",
+			statuses:  []int{0, http.StatusCreated},
+			bodies:    []string{`{"a":1}`, `{"a":1}`, `{"a":1}`},
+			wantCodes: []int{http.StatusInternalServerError, http.StatusCreated, http.StatusCreated},
+			wantCalls: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, calls := idempotentRouter(tt.statuses...)
+			for i, body := range tt.bodies {
+				w := post(router, "key-1", body)
+				if w.Code != tt.wantCodes[i] {
+					t.Errorf("request %d: status %d, want %d: %s", i+1, w.Code, tt.wantCodes[i], w.Body.String())
+				}
+			}
+			if *calls != tt.wantCalls {
+				t.Errorf("handler ran %d times, want %d", *calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyMarksReplays(t *testing.T) {
+	router, _ := idempotentRouter(http.StatusCreated)
+	first := post(router, "key-1", "{}")
+	second := post(router, "key-1", "{}")
+	if first.Header().Get("Idempotent-Replayed") != "" || second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("only the retry should carry Idempotent-Replayed")
+	}
+	if first.Body.String() != second.Body.String() {
+		t.Errorf("replayed %s, want %s", second.Body.String(), first.Body.String())
+	}
+}
+
+// form encodes a multipart form with one field and one file under boundary.
+func form(t *testing.T, boundary, apply, file string) (string, string) {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.SetBoundary(boundary); err != nil {
+		t.Fatal(err)
+	}
+	writer.WriteField("apply", apply)
+	part, err := writer.CreateFormFile("file", "mutasi.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(file))
+	writer.Close()
+	return body.String(), writer.FormDataContentType()
+}
+
+func TestIdempotencyMultipartForms(t *testing.T) {
+	tests := []struct {
+		name      string
+		apply     string
+		file      string
+		wantCode  int
+		wantCalls int
+	}{
+		{"same form, new boundary", "true", "date,amount\n2025-01-05,1000\n", http.StatusCreated, 1},
+		{"other field", "false", "date,amount\n2025-01-05,1000\n", http.StatusUnprocessableEntity, 1},
+		{"other file", "true", "date,amount\n2025-01-05,2000\n", http.StatusUnprocessableEntity, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, calls := idempotentRouter(http.StatusCreated)
+			body, contentType := form(t, "first-boundary", "true", "date,amount\n2025-01-05,1000\n")
+			if w := post(router, "import-1", body, contentType); w.Code != http.StatusCreated {
+				t.Fatalf("first attempt: status %d", w.Code)
+			}
+
+			body, contentType = form(t, "second-boundary", tt.apply, tt.file)
+			if w := post(router, "import-1", body, contentType); w.Code != tt.wantCode {
+				t.Errorf("retry: status %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			if *calls != tt.wantCalls {
+				t.Errorf("handler ran %d times, want %d", *calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyStoresOnlyJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	userID := uuid.New()
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("userId", userID) })
+	router.POST("/items", Idempotency(repository.NewMemoryRepositories()), func(c *gin.Context) {
+		calls++
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", []byte("row,error\n"))
+	})
+
+	for i := 0; i < 2; i++ {
+		if w := post(router, "report-1", "{}"); w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/csv; charset=utf-8" {
+			t.Errorf("attempt %d: status %d, content type %q", i+1, w.Code, w.Header().Get("Content-Type"))
+		}
+	}
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want 2: a CSV response must not be replayed as JSON", calls)
+	}
+}
+
+// failingCompleteRepo stores keys in memory but cannot store responses.
+type failingCompleteRepo struct {
+	repository.IdempotencyKeyRepo
+}
+
+func (failingCompleteRepo) Complete(*models.IdempotencyKey) error {
+	return errors.New("database is gone")
+}
+
+func TestIdempotencyReportsUnstoredResponse(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	repos.IdempotencyKeys = failingCompleteRepo{repos.IdempotencyKeys}
+	router, calls := idempotentRouterFor(repos, http.StatusCreated)
+
+	w := post(router, "key-1", "{}")
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), `"call"`) {
+		t.Errorf("status %d: %s; want 500 without the handler's response", w.Code, w.Body.String())
+	}
+	if w := post(router, "key-1", "{}"); w.Code != http.StatusConflict || *calls != 1 {
+		t.Errorf("retry within the lease: status %d after %d calls, want 409 after 1", w.Code, *calls)
+	}
+}
+
+func TestIdempotencyTakesOverExpiredLease(t *testing.T) {
+	tests := []struct {
+		name        string
+		lockedUntil time.Duration
+		wantCode    int
+		wantCalls   int
+	}{
+		{"lease running", time.Minute, http.StatusConflict, 0},
+		{"lease over", -time.Second, http.StatusCreated, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := repository.NewMemoryRepositories()
+			router, calls := idempotentRouterFor(repos, http.StatusCreated)
+			claim := &models.IdempotencyKey{
+				UserID:      testUserID,
+				Key:         "key-1",
+				Method:      http.MethodPost,
+				Path:        "/items",
+				RequestHash: requestHash(httptest.NewRequest(http.MethodPost, "/items", nil), []byte("{}")),
+				LockedUntil: time.Now().Add(tt.lockedUntil),
+				ExpiresAt:   time.Now().Add(time.Hour),
+			}
+			if claimed, err := repos.IdempotencyKeys.Claim(claim); !claimed || err != nil {
+				t.Fatalf("Claim = %v, %v", claimed, err)
+			}
+
+			if w := post(router, "key-1", "{}"); w.Code != tt.wantCode {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			if *calls != tt.wantCalls {
+				t.Errorf("handler ran %d times, want %d", *calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyLimitsBodySize(t *testing.T) {
+	router, calls := idempotentRouter(http.StatusCreated)
+	if w := post(router, "key-1", strings.Repeat("x", maxIdempotentBodySize+1)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status %d, want 413", w.Code)
+	}
+	if *calls != 0 {
+		t.Errorf("handler ran %d times, want 0", *calls)
+	}
+}
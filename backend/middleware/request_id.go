@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the id of a request. A client may send its own;
+// otherwise one is generated. It is echoed in the response and recorded
+// with every audit log entry the request writes.
+const RequestIDHeader = "X-Request-ID"
+
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > 100 {
+			requestID = uuid.New().String()
+		}
+
+		c.Set("requestId", requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS activity_logs;
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS funds;
+DROP TABLE IF EXISTS users;
@@ -54,13 +54,13 @@ CREATE TABLE IF NOT EXISTS activity_logs (
 );
 
 -- Create indexes for better performance
-CREATE INDEX idx_categories_type_name ON categories(type, name);
-CREATE INDEX idx_transactions_created_by ON transactions(created_by);
-CREATE INDEX idx_transactions_status ON transactions(status);
-CREATE INDEX idx_transactions_date ON transactions(date);
-CREATE INDEX idx_transactions_type ON transactions(type);
-CREATE INDEX idx_activity_logs_user_id ON activity_logs(user_id);
-CREATE INDEX idx_activity_logs_timestamp ON activity_logs(timestamp);
+CREATE INDEX IF NOT EXISTS idx_categories_type_name ON categories(type, name);
+CREATE INDEX IF NOT EXISTS idx_transactions_created_by ON transactions(created_by);
+CREATE INDEX IF NOT EXISTS idx_transactions_status ON transactions(status);
+CREATE INDEX IF NOT EXISTS idx_transactions_date ON transactions(date);
+CREATE INDEX IF NOT EXISTS idx_transactions_type ON transactions(type);
+CREATE INDEX IF NOT EXISTS idx_activity_logs_user_id ON activity_logs(user_id);
+CREATE INDEX IF NOT EXISTS idx_activity_logs_timestamp ON activity_logs(timestamp);
 
 -- Insert default admin user (password: admin123)
 -- Password hash generated using bcrypt
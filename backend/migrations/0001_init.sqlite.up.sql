@@ -0,0 +1,86 @@
+-- SQLite variant of the initial schema. IDs are generated by the
+-- application, and enum-like columns are validated in the handlers.
+
+-- Create users table
+CREATE TABLE IF NOT EXISTS users (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) UNIQUE NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    role VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create transactions table
+CREATE TABLE IF NOT EXISTS transactions (
+    id UUID PRIMARY KEY,
+    type VARCHAR(50) NOT NULL,
+    amount DECIMAL(15, 2) NOT NULL,
+    category VARCHAR(100) NOT NULL,
+    description TEXT,
+    event_name VARCHAR(255) NOT NULL,
+    date DATE NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(id),
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    note_url TEXT,
+    rejection_reason TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create funds table
+CREATE TABLE IF NOT EXISTS funds (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) UNIQUE NOT NULL,
+    description TEXT,
+    status VARCHAR(50) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create categories table
+CREATE TABLE IF NOT EXISTS categories (
+    id UUID PRIMARY KEY,
+    type VARCHAR(50) NOT NULL DEFAULT 'general',
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create activity_logs table
+CREATE TABLE IF NOT EXISTS activity_logs (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    action TEXT NOT NULL,
+    timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_categories_type_name ON categories(type, name);
+CREATE INDEX IF NOT EXISTS idx_transactions_created_by ON transactions(created_by);
+CREATE INDEX IF NOT EXISTS idx_transactions_status ON transactions(status);
+CREATE INDEX IF NOT EXISTS idx_transactions_date ON transactions(date);
+CREATE INDEX IF NOT EXISTS idx_transactions_type ON transactions(type);
+CREATE INDEX IF NOT EXISTS idx_activity_logs_user_id ON activity_logs(user_id);
+CREATE INDEX IF NOT EXISTS idx_activity_logs_timestamp ON activity_logs(timestamp);
+
+-- Insert default admin user (password: admin123)
+INSERT INTO users (id, name, email, password_hash, role)
+VALUES (
+    '00000000-0000-0000-0000-000000000001',
+    'Administrator',
+    'admin@gkjw.com',
+    '$2a$10$YQk7vYjB0LQcJx9hxVN0vOzN.qwYZ3H5vQWkN5m4qHKF8JxZZQY7e',
+    'admin'
+) ON CONFLICT (email) DO NOTHING;
+
+-- Insert sample member user (password: member123)
+INSERT INTO users (id, name, email, password_hash, role)
+VALUES (
+    '00000000-0000-0000-0000-000000000002',
+    'Member Perkap',
+    'member@gkjw.com',
+    '$2a$10$YQk7vYjB0LQcJx9hxVN0vOzN.qwYZ3H5vQWkN5m4qHKF8JxZZQY7e',
+    'member'
+) ON CONFLICT (email) DO NOTHING;
@@ -0,0 +1,7 @@
+-- models.Transaction has carried fund_id and payment_method since funds were
+-- introduced, but 001_init.sql never created them. Databases that already
+-- added them by hand are left untouched.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fund_id UUID REFERENCES funds(id);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS payment_method VARCHAR(20) NOT NULL DEFAULT 'cash';
+
+CREATE INDEX IF NOT EXISTS idx_transactions_fund_id ON transactions(fund_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transactions_fund_id;
+ALTER TABLE transactions DROP COLUMN payment_method;
+ALTER TABLE transactions DROP COLUMN fund_id;
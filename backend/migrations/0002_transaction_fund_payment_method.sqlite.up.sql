@@ -0,0 +1,6 @@
+-- SQLite cannot drop a column that is part of a foreign key, so fund_id is
+-- added without one to keep this migration reversible.
+ALTER TABLE transactions ADD COLUMN fund_id UUID;
+ALTER TABLE transactions ADD COLUMN payment_method VARCHAR(20) NOT NULL DEFAULT 'cash';
+
+CREATE INDEX IF NOT EXISTS idx_transactions_fund_id ON transactions(fund_id);
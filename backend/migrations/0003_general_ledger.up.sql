@@ -0,0 +1,49 @@
+-- Double-entry general ledger. Every approved transaction posts a balanced
+-- journal; postings are never edited, only reversed.
+
+CREATE TABLE IF NOT EXISTS accounts (
+    id UUID PRIMARY KEY,
+    code VARCHAR(20) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+    id UUID PRIMARY KEY,
+    date DATE NOT NULL,
+    description TEXT,
+    source VARCHAR(20) NOT NULL,
+    transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL,
+    reverses_id UUID REFERENCES journal_entries(id),
+    reversed_by_id UUID REFERENCES journal_entries(id),
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS journal_lines (
+    id UUID PRIMARY KEY,
+    entry_id UUID NOT NULL REFERENCES journal_entries(id) ON DELETE CASCADE,
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    fund_id UUID REFERENCES funds(id),
+    debit DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    credit DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    memo TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_journal_entries_date ON journal_entries(date);
+CREATE INDEX IF NOT EXISTS idx_journal_entries_transaction_id ON journal_entries(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_journal_lines_entry_id ON journal_lines(entry_id);
+CREATE INDEX IF NOT EXISTS idx_journal_lines_account_id ON journal_lines(account_id);
+CREATE INDEX IF NOT EXISTS idx_journal_lines_fund_id ON journal_lines(fund_id);
+
+-- System accounts used by transaction postings (see models.AccountCode*).
+INSERT INTO accounts (id, code, name, type) VALUES
+    ('00000000-0000-0000-0000-000000001101', '1101', 'Kas', 'asset'),
+    ('00000000-0000-0000-0000-000000001102', '1102', 'Bank', 'asset'),
+    ('00000000-0000-0000-0000-000000003100', '3100', 'Saldo Dana', 'equity'),
+    ('00000000-0000-0000-0000-000000004100', '4100', 'Pendapatan', 'income'),
+    ('00000000-0000-0000-0000-000000005100', '5100', 'Beban', 'expense')
+ON CONFLICT (code) DO NOTHING;
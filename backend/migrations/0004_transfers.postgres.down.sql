@@ -0,0 +1,7 @@
+-- Fails while transfer rows exist; remove or convert them first.
+DROP INDEX IF EXISTS idx_transactions_to_fund_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS to_payment_method;
+ALTER TABLE transactions DROP COLUMN IF EXISTS to_fund_id;
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check CHECK (type IN ('income', 'expense'));
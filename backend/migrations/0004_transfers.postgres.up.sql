@@ -0,0 +1,9 @@
+-- Transfers move money between funds and/or payment methods. The source is
+-- fund_id/payment_method and the destination to_fund_id/to_payment_method.
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check CHECK (type IN ('income', 'expense', 'transfer'));
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS to_fund_id UUID REFERENCES funds(id);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS to_payment_method VARCHAR(20) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_transactions_to_fund_id ON transactions(to_fund_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transactions_to_fund_id;
+ALTER TABLE transactions DROP COLUMN to_payment_method;
+ALTER TABLE transactions DROP COLUMN to_fund_id;
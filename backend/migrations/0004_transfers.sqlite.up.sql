@@ -0,0 +1,7 @@
+-- Transfers move money between funds and/or payment methods. The source is
+-- fund_id/payment_method and the destination to_fund_id/to_payment_method.
+-- As in 0002, to_fund_id has no foreign key so the column can be dropped.
+ALTER TABLE transactions ADD COLUMN to_fund_id UUID;
+ALTER TABLE transactions ADD COLUMN to_payment_method VARCHAR(20) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_transactions_to_fund_id ON transactions(to_fund_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS opening_balances;
+DROP TABLE IF EXISTS fiscal_years;
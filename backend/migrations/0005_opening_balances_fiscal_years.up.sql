@@ -0,0 +1,31 @@
+-- Fiscal years and per-fund opening balances. Closing a fiscal year writes
+-- the closing balance of every fund and payment method as an opening
+-- balance dated the day after the year ends.
+
+CREATE TABLE IF NOT EXISTS fiscal_years (
+    id UUID PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    closed_at TIMESTAMP,
+    closed_by UUID REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS opening_balances (
+    id UUID PRIMARY KEY,
+    fund_id UUID NOT NULL REFERENCES funds(id) ON DELETE CASCADE,
+    payment_method VARCHAR(20) NOT NULL,
+    as_of DATE NOT NULL,
+    amount DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    fiscal_year_id UUID REFERENCES fiscal_years(id) ON DELETE SET NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (fund_id, payment_method, as_of)
+);
+
+CREATE INDEX IF NOT EXISTS idx_opening_balances_as_of ON opening_balances(as_of);
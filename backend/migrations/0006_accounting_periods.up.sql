@@ -0,0 +1,19 @@
+-- Closed accounting periods. Transactions and journals dated inside a
+-- closed period are read-only until an admin reopens it.
+
+CREATE TABLE IF NOT EXISTS accounting_periods (
+    id UUID PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'closed',
+    closed_at TIMESTAMP,
+    closed_by UUID REFERENCES users(id),
+    reopened_at TIMESTAMP,
+    reopened_by UUID REFERENCES users(id),
+    reopen_reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_accounting_periods_dates ON accounting_periods(start_date, end_date);
@@ -0,0 +1,7 @@
+-- Fails while draft or voided rows exist; move them to another status first.
+ALTER TABLE transactions DROP COLUMN IF EXISTS reviewed_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS reviewed_by;
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_status_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_status_check
+    CHECK (status IN ('pending', 'approved', 'rejected'));
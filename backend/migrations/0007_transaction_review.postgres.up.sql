@@ -0,0 +1,8 @@
+-- Transaction lifecycle: draft -> pending -> approved/rejected -> voided.
+-- reviewed_by/reviewed_at record who approved or rejected a transaction.
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_status_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_status_check
+    CHECK (status IN ('draft', 'pending', 'approved', 'rejected', 'voided'));
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reviewed_by UUID REFERENCES users(id);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP;
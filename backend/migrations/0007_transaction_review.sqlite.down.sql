@@ -0,0 +1,2 @@
+ALTER TABLE transactions DROP COLUMN reviewed_at;
+ALTER TABLE transactions DROP COLUMN reviewed_by;
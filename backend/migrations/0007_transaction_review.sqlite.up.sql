@@ -0,0 +1,4 @@
+-- reviewed_by/reviewed_at record who approved or rejected a transaction.
+-- As in 0002, reviewed_by has no foreign key so the column can be dropped.
+ALTER TABLE transactions ADD COLUMN reviewed_by UUID;
+ALTER TABLE transactions ADD COLUMN reviewed_at TIMESTAMP;
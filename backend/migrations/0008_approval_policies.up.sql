@@ -0,0 +1,39 @@
+-- Approval policies: which signatures a pending transaction needs before it
+-- is approved, and the signatures given.
+
+CREATE TABLE IF NOT EXISTS approval_policies (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 100,
+    type VARCHAR(20) NOT NULL DEFAULT '',
+    fund_id UUID REFERENCES funds(id) ON DELETE CASCADE,
+    category VARCHAR(100) NOT NULL DEFAULT '',
+    min_amount DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    max_amount DECIMAL(15, 2),
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS approval_steps (
+    id UUID PRIMARY KEY,
+    policy_id UUID NOT NULL REFERENCES approval_policies(id) ON DELETE CASCADE,
+    step_order INTEGER NOT NULL,
+    role VARCHAR(50) NOT NULL DEFAULT '',
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE (policy_id, step_order)
+);
+
+CREATE TABLE IF NOT EXISTS approvals (
+    id UUID PRIMARY KEY,
+    transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    policy_id UUID REFERENCES approval_policies(id) ON DELETE SET NULL,
+    step_order INTEGER NOT NULL,
+    approver_id UUID NOT NULL REFERENCES users(id),
+    decision VARCHAR(20) NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    superseded BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_approvals_transaction_id ON approvals(transaction_id);
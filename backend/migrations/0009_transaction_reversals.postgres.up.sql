@@ -0,0 +1,7 @@
+-- Voiding an approved transaction adds a reversing transaction with the
+-- opposite amount; reversal_of_id links it to the original. void_reason is
+-- kept on both.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reversal_of_id UUID REFERENCES transactions(id);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS void_reason TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_reversal_of_id ON transactions(reversal_of_id);
@@ -0,0 +1,6 @@
+-- reversal_of_id links a reversing transaction to the voided original.
+-- As in 0002, it has no foreign key so the column can be dropped.
+ALTER TABLE transactions ADD COLUMN reversal_of_id UUID;
+ALTER TABLE transactions ADD COLUMN void_reason TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_reversal_of_id ON transactions(reversal_of_id);
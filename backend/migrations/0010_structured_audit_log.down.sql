@@ -0,0 +1,10 @@
+UPDATE activity_logs SET action = summary WHERE summary <> '';
+
+DROP INDEX IF EXISTS idx_activity_logs_entity;
+ALTER TABLE activity_logs DROP COLUMN request_id;
+ALTER TABLE activity_logs DROP COLUMN user_agent;
+ALTER TABLE activity_logs DROP COLUMN ip_address;
+ALTER TABLE activity_logs DROP COLUMN changes;
+ALTER TABLE activity_logs DROP COLUMN summary;
+ALTER TABLE activity_logs DROP COLUMN entity_id;
+ALTER TABLE activity_logs DROP COLUMN entity_type;
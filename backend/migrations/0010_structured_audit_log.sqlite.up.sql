@@ -0,0 +1,14 @@
+-- Audit log entries record what changed, on which entity, and from which
+-- request. The old free-text action becomes the summary; action now holds
+-- a verb such as create, update or approve.
+ALTER TABLE activity_logs ADD COLUMN entity_type VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE activity_logs ADD COLUMN entity_id UUID;
+ALTER TABLE activity_logs ADD COLUMN summary TEXT NOT NULL DEFAULT '';
+ALTER TABLE activity_logs ADD COLUMN changes TEXT NOT NULL DEFAULT '';
+ALTER TABLE activity_logs ADD COLUMN ip_address VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE activity_logs ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE activity_logs ADD COLUMN request_id VARCHAR(100) NOT NULL DEFAULT '';
+
+UPDATE activity_logs SET summary = action, action = '';
+
+CREATE INDEX IF NOT EXISTS idx_activity_logs_entity ON activity_logs(entity_type, entity_id);
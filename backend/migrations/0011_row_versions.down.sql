@@ -0,0 +1,3 @@
+ALTER TABLE categories DROP COLUMN version;
+ALTER TABLE funds DROP COLUMN version;
+ALTER TABLE transactions DROP COLUMN version;
@@ -0,0 +1,5 @@
+-- version is raised by every update of a row; an update made against an
+-- older version is refused (optimistic concurrency control).
+ALTER TABLE transactions ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE funds ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE categories ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,24 @@
+-- Split transactions: each income or expense is booked to one or more
+-- categories and funds. Existing transactions get a single line holding
+-- their whole amount; the line reuses the transaction's id.
+
+CREATE TABLE IF NOT EXISTS transaction_lines (
+    id UUID PRIMARY KEY,
+    transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL DEFAULT 0,
+    fund_id UUID NOT NULL REFERENCES funds(id),
+    category VARCHAR(255) NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    description TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_lines_transaction_id ON transaction_lines(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_lines_fund_id ON transaction_lines(fund_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_lines_category ON transaction_lines(category);
+
+INSERT INTO transaction_lines (id, transaction_id, position, fund_id, category, amount, description)
+SELECT id, id, 0, fund_id, category, amount, description
+FROM transactions
+WHERE type IN ('income', 'expense')
+  AND fund_id IS NOT NULL
+  AND id NOT IN (SELECT transaction_id FROM transaction_lines);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_transactions_recurring_occurrence;
+ALTER TABLE transactions DROP COLUMN IF EXISTS occurrence_date;
+ALTER TABLE transactions DROP COLUMN IF EXISTS recurring_template_id;
+DROP TABLE IF EXISTS recurring_templates;
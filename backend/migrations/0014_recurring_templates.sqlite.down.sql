@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_transactions_recurring_occurrence;
+ALTER TABLE transactions DROP COLUMN occurrence_date;
+ALTER TABLE transactions DROP COLUMN recurring_template_id;
+DROP TABLE IF EXISTS recurring_templates;
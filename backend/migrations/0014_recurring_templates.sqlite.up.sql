@@ -0,0 +1,36 @@
+-- Recurring templates describe incomes and expenses that repeat; the
+-- scheduler records a pending transaction for each occurrence and links it
+-- back through recurring_template_id and occurrence_date. As in 0002, the
+-- link has no foreign key so the column can be dropped.
+
+CREATE TABLE IF NOT EXISTS recurring_templates (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    amount DECIMAL(15, 2) NOT NULL,
+    fund_id UUID NOT NULL REFERENCES funds(id),
+    category VARCHAR(100) NOT NULL,
+    payment_method VARCHAR(20) NOT NULL DEFAULT 'cash',
+    event_name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    frequency VARCHAR(20) NOT NULL,
+    day_of_month INTEGER NOT NULL DEFAULT 1,
+    weekday INTEGER NOT NULL DEFAULT 0,
+    month_of_year INTEGER NOT NULL DEFAULT 1,
+    start_date DATE NOT NULL,
+    end_date DATE,
+    next_run_date DATE NOT NULL,
+    last_run_date DATE,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_by UUID NOT NULL REFERENCES users(id),
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_recurring_templates_next_run_date ON recurring_templates(status, next_run_date);
+
+ALTER TABLE transactions ADD COLUMN recurring_template_id UUID;
+ALTER TABLE transactions ADD COLUMN occurrence_date DATE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_recurring_occurrence ON transactions(recurring_template_id, occurrence_date);
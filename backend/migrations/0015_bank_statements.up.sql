@@ -0,0 +1,36 @@
+-- Bank statements imported for reconciliation, and their lines. A line is
+-- matched to at most one transaction and a transaction to at most one line.
+
+CREATE TABLE IF NOT EXISTS bank_statements (
+    id UUID PRIMARY KEY,
+    fund_id UUID REFERENCES funds(id),
+    format VARCHAR(20) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    account_number VARCHAR(100) NOT NULL DEFAULT '',
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    opening_balance DECIMAL(15, 2),
+    closing_balance DECIMAL(15, 2),
+    checksum VARCHAR(64) NOT NULL UNIQUE,
+    imported_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS bank_statement_lines (
+    id UUID PRIMARY KEY,
+    statement_id UUID NOT NULL REFERENCES bank_statements(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    date DATE NOT NULL,
+    amount DECIMAL(15, 2) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    reference VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'unmatched',
+    transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL,
+    matched_by UUID REFERENCES users(id),
+    matched_at TIMESTAMP,
+    note TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_bank_statement_lines_statement_id ON bank_statement_lines(statement_id);
+CREATE INDEX IF NOT EXISTS idx_bank_statement_lines_status ON bank_statement_lines(status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bank_statement_lines_transaction_id ON bank_statement_lines(transaction_id);
@@ -0,0 +1,18 @@
+-- Checks a transaction failed when it was recorded or changed: probable
+-- duplicates, outlying amounts and descriptions that contradict the type.
+
+CREATE TABLE IF NOT EXISTS transaction_flags (
+    id UUID PRIMARY KEY,
+    transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    message TEXT NOT NULL,
+    related_transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    dismissed_by UUID REFERENCES users(id),
+    dismissed_at TIMESTAMP,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_flags_transaction_id ON transaction_flags(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_flags_status ON transaction_flags(status);
@@ -0,0 +1,26 @@
+-- Admin-managed rules that set or suggest the type, category and fund of
+-- transactions by their description, amount and payment method. The seed
+-- rule carries the income keywords cmd/fix_income used to hard-code.
+
+CREATE TABLE IF NOT EXISTS categorization_rules (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 100,
+    pattern TEXT NOT NULL,
+    field VARCHAR(20) NOT NULL DEFAULT 'any',
+    min_amount DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    max_amount DECIMAL(15, 2),
+    payment_method VARCHAR(20) NOT NULL DEFAULT '',
+    set_type VARCHAR(20) NOT NULL DEFAULT '',
+    set_category VARCHAR(255) NOT NULL DEFAULT '',
+    set_fund_id UUID REFERENCES funds(id),
+    mode VARCHAR(20) NOT NULL DEFAULT 'suggest',
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO categorization_rules (id, name, priority, pattern, set_type, mode) VALUES
+    ('00000000-0000-0000-0000-00000000c001', 'Kata kunci pemasukan', 100,
+     '\b(sumbangan|donasi|setor|masuk|pemasukan|usaha|penjualan|pigora|sisa dana|transfer hadiah|pengembalian|reimburse)',
+     'income', 'suggest');
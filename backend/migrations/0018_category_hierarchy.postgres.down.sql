@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_transaction_lines_category_id;
+DROP INDEX IF EXISTS idx_transactions_category_id;
+DROP INDEX IF EXISTS idx_categories_parent_id;
+ALTER TABLE transaction_lines DROP COLUMN IF EXISTS category_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS category_id;
+ALTER TABLE categories DROP COLUMN IF EXISTS parent_id;
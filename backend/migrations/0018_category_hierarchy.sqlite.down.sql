@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_transaction_lines_category_id;
+DROP INDEX IF EXISTS idx_transactions_category_id;
+DROP INDEX IF EXISTS idx_categories_parent_id;
+ALTER TABLE transaction_lines DROP COLUMN category_id;
+ALTER TABLE transactions DROP COLUMN category_id;
+ALTER TABLE categories DROP COLUMN parent_id;
@@ -0,0 +1,41 @@
+-- Transactions and their lines reference their category by id; the name
+-- is kept alongside as a label. Categories nest under parent_id. As in
+-- 0002, the columns have no foreign key so they can be dropped.
+ALTER TABLE categories ADD COLUMN parent_id UUID;
+ALTER TABLE transactions ADD COLUMN category_id UUID;
+ALTER TABLE transaction_lines ADD COLUMN category_id UUID;
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories(parent_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_category_id ON transactions(category_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_lines_category_id ON transaction_lines(category_id);
+
+-- Names in use without a matching category become categories of the type
+-- of the transactions using them.
+INSERT INTO categories (id, type, name)
+SELECT lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' ||
+       substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))),
+       t.type, l.category
+FROM transaction_lines l
+JOIN transactions t ON t.id = l.transaction_id
+WHERE NOT EXISTS (
+    SELECT 1 FROM categories c
+    WHERE LOWER(c.name) = LOWER(l.category) AND c.type IN (t.type, 'general')
+)
+GROUP BY t.type, l.category;
+
+-- A category of the transaction's own type wins over a general one.
+UPDATE transaction_lines SET category_id = (
+    SELECT c.id
+    FROM categories c
+    JOIN transactions t ON t.id = transaction_lines.transaction_id
+    WHERE LOWER(c.name) = LOWER(transaction_lines.category) AND c.type IN (t.type, 'general')
+    ORDER BY CASE WHEN c.type = t.type THEN 0 ELSE 1 END, c.created_at
+    LIMIT 1
+)
+WHERE category_id IS NULL;
+
+UPDATE transactions SET category_id = (
+    SELECT l.category_id FROM transaction_lines l
+    WHERE l.transaction_id = transactions.id AND l.position = 0
+)
+WHERE type IN ('income', 'expense') AND category_id IS NULL;
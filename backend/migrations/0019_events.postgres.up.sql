@@ -0,0 +1,28 @@
+-- Events are activities with their own budget, run by a commission.
+-- Transactions link to them through event_id; event_name stays as the
+-- label, and is what older transactions are linked by.
+
+CREATE TABLE IF NOT EXISTS events (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE,
+    commission VARCHAR(255) NOT NULL DEFAULT '',
+    fund_id UUID REFERENCES funds(id),
+    budget_income DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    budget_expense DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    description TEXT NOT NULL DEFAULT '',
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_start_date ON events(start_date);
+
+-- Names are unique ignoring case, so that transactions are not split over
+-- near-identical events.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_events_name ON events (LOWER(name));
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS event_id UUID REFERENCES events(id);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_event_id ON transactions(event_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_transactions_event_id;
+ALTER TABLE transactions DROP COLUMN event_id;
+DROP INDEX IF EXISTS idx_events_start_date;
+DROP INDEX IF EXISTS idx_events_name;
+DROP TABLE IF EXISTS events;
@@ -0,0 +1,110 @@
+// Package migrations holds the versioned SQL schema and the runner that
+// applies it.
+//
+// Files are named <version>_<name>[.<dialect>].<up|down>.sql. A file with
+// a dialect ("postgres" or "sqlite") takes precedence over the shared file
+// of the same version and direction, so most migrations are written once
+// and only the non-portable ones are split.
+package migrations
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+?)(?:\.(postgres|sqlite))?\.(up|down)\.sql$`)
+
+// Migration is one schema version resolved for a dialect.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+	// Checksum and DownChecksum are the SHA-256 of Up and Down, used to
+	// detect migrations that were edited after being applied.
+	Checksum     string
+	DownChecksum string
+}
+
+// Load returns the migrations for dialect ordered by version.
+func Load(dialect string) ([]Migration, error) {
+	return load(files, dialect)
+}
+
+func load(fsys fs.FS, dialect string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	type source struct {
+		sql      string
+		specific bool
+	}
+	byVersion := map[int64]*Migration{}
+	sources := map[string]source{}
+
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		fileDialect := match[3]
+		if fileDialect != "" && fileDialect != dialect {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, match[2])
+		}
+
+		key := match[1] + "." + match[4]
+		if existing, ok := sources[key]; ok && existing.specific {
+			continue
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		sources[key] = source{sql: string(content), specific: fileDialect != ""}
+		if match[4] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file for %s", m.Version, m.Name, dialect)
+		}
+		m.Checksum = checksum(m.Up)
+		m.DownChecksum = checksum(m.Down)
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+func checksum(sql string) string {
+	sum := sha256.Sum256([]byte(sql))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,147 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func file(content string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(content)}
+}
+
+func TestLoadPicksDialectFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0001_init.up.sql":                 file("CREATE TABLE a (id INT);"),
+		"0001_init.down.sql":               file("DROP TABLE a;"),
+		"0002_index.postgres.up.sql":       file("CREATE INDEX CONCURRENTLY i ON a (id);"),
+		"0002_index.up.sql":                file("CREATE INDEX i ON a (id);"),
+		"0002_index.down.sql":              file("DROP INDEX i;"),
+		"0010_later.sqlite.up.sql":         file("SELECT 10;"),
+		"0010_later.postgres.up.sql":       file("SELECT 'ten';"),
+		"README.md":                        file("not a migration"),
+		"0003_skipped.mysql.up.sql":        file("SELECT 3;"),
+		"0002_index.postgres.down.sql.bak": file("ignored"),
+	}
+
+	tests := []struct {
+		dialect string
+		want    []string
+	}{
+		{"postgres", []string{"CREATE TABLE a (id INT);", "CREATE INDEX CONCURRENTLY i ON a (id);", "SELECT 'ten';"}},
+		{"sqlite", []string{"CREATE TABLE a (id INT);", "CREATE INDEX i ON a (id);", "SELECT 10;"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			migrations, err := load(fsys, tt.dialect)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(migrations) != len(tt.want) {
+				t.Fatalf("got %d migrations, want %d", len(migrations), len(tt.want))
+			}
+			for i, m := range migrations {
+				if m.Up != tt.want[i] {
+					t.Errorf("migration %d: up = %q, want %q", m.Version, m.Up, tt.want[i])
+				}
+			}
+			if v := []int64{migrations[0].Version, migrations[1].Version, migrations[2].Version}; v[0] != 1 || v[1] != 2 || v[2] != 10 {
+				t.Errorf("versions = %v, want [1 2 10]", v)
+			}
+			if migrations[1].Down != "DROP INDEX i;" {
+				t.Errorf("down = %q, want the shared file", migrations[1].Down)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		want string
+	}{
+		{
+			name: "no up file",
+			fsys: fstest.MapFS{"0001_init.down.sql": file("DROP TABLE a;")},
+			want: "has no up file",
+		},
+		{
+			name: "up file for another dialect only",
+			fsys: fstest.MapFS{"0001_init.postgres.up.sql": file("SELECT 1;"), "0001_init.down.sql": file("SELECT 1;")},
+			want: "has no up file for sqlite",
+		},
+		{
+			name: "two names",
+			fsys: fstest.MapFS{"0001_init.up.sql": file("SELECT 1;"), "0001_start.down.sql": file("SELECT 1;")},
+			want: "has two names",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := load(tt.fsys, "sqlite")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadChecksums(t *testing.T) {
+	base := fstest.MapFS{
+		"0001_init.up.sql":   file("CREATE TABLE a (id INT);"),
+		"0001_init.down.sql": file("DROP TABLE a;"),
+	}
+	original, err := load(base, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name             string
+		up, down         string
+		upSame, downSame bool
+	}{
+		{"unchanged", "CREATE TABLE a (id INT);", "DROP TABLE a;", true, true},
+		{"up edited", "CREATE TABLE a (id BIGINT);", "DROP TABLE a;", false, true},
+		{"down edited", "CREATE TABLE a (id INT);", "DROP TABLE IF EXISTS a;", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edited, err := load(fstest.MapFS{
+				"0001_init.up.sql":   file(tt.up),
+				"0001_init.down.sql": file(tt.down),
+			}, "sqlite")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if same := edited[0].Checksum == original[0].Checksum; same != tt.upSame {
+				t.Errorf("up checksum unchanged = %v, want %v", same, tt.upSame)
+			}
+			if same := edited[0].DownChecksum == original[0].DownChecksum; same != tt.downSame {
+				t.Errorf("down checksum unchanged = %v, want %v", same, tt.downSame)
+			}
+		})
+	}
+}
+
+// TestEmbeddedMigrations checks that the shipped files load for both
+// dialects with consecutive versions and a down file each.
+func TestEmbeddedMigrations(t *testing.T) {
+	for _, dialect := range []string{"postgres", "sqlite"} {
+		t.Run(dialect, func(t *testing.T) {
+			migrations, err := Load(dialect)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, m := range migrations {
+				if m.Version != int64(i+1) {
+					t.Errorf("migration %s has version %d, want %d", m.Name, m.Version, i+1)
+				}
+				if strings.TrimSpace(m.Down) == "" {
+					t.Errorf("migration %d_%s has no down file", m.Version, m.Name)
+				}
+			}
+		})
+	}
+}
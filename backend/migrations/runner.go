@@ -0,0 +1,230 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// advisoryLockKey identifies the migration lock among PostgreSQL advisory
+// locks. It is an arbitrary constant shared by every deploy.
+const advisoryLockKey = 72_011_001
+
+// AppliedMigration is a row of the schema_migrations table.
+type AppliedMigration struct {
+	Version      int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name         string    `gorm:"not null"`
+	Checksum     string    `gorm:"not null"`
+	DownChecksum string    `gorm:"not null"`
+	AppliedAt    time.Time `gorm:"not null"`
+}
+
+func (AppliedMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status describes one migration for the status command.
+type Status struct {
+	Version   int64
+	Name      string
+	State     string // applied, pending, modified or missing
+	AppliedAt *time.Time
+}
+
+// Runner applies and rolls back migrations on one database.
+type Runner struct {
+	db          *gorm.DB
+	dialect     string
+	migrations  []Migration
+	LockTimeout time.Duration
+}
+
+// NewRunner loads the migrations for dialect and returns a runner for db.
+func NewRunner(db *gorm.DB, dialect string) (*Runner, error) {
+	migrations, err := Load(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Runner{db: db, dialect: dialect, migrations: migrations, LockTimeout: time.Minute}, nil
+}
+
+// Up applies every pending migration in version order, each in its own
+// transaction. It refuses to run when an applied migration was edited.
+func (r *Runner) Up() ([]Migration, error) {
+	var applied []Migration
+	err := r.locked(func(db *gorm.DB) error {
+		records, err := r.applied(db)
+		if err != nil {
+			return err
+		}
+		if err := r.verify(records); err != nil {
+			return err
+		}
+
+		for _, m := range r.migrations {
+			if _, ok := records[m.Version]; ok {
+				continue
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(m.Up).Error; err != nil {
+					return err
+				}
+				return tx.Create(&AppliedMigration{
+					Version:      m.Version,
+					Name:         m.Name,
+					Checksum:     m.Checksum,
+					DownChecksum: m.DownChecksum,
+					AppliedAt:    time.Now(),
+				}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+			}
+			applied = append(applied, m)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the most recently applied steps migrations.
+func (r *Runner) Down(steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := r.locked(func(db *gorm.DB) error {
+		records, err := r.applied(db)
+		if err != nil {
+			return err
+		}
+		if err := r.verify(records); err != nil {
+			return err
+		}
+
+		for i := len(r.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			m := r.migrations[i]
+			if _, ok := records[m.Version]; !ok {
+				continue
+			}
+			if m.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", m.Version, m.Name)
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(m.Down).Error; err != nil {
+					return err
+				}
+				return tx.Where("version = ?", m.Version).Delete(&AppliedMigration{}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("rollback %d_%s: %w", m.Version, m.Name, err)
+			}
+			reverted = append(reverted, m)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status reports every known migration and every applied version that no
+// longer has a file.
+func (r *Runner) Status() ([]Status, error) {
+	if err := r.db.AutoMigrate(&AppliedMigration{}); err != nil {
+		return nil, err
+	}
+	records, err := r.applied(r.db)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	known := map[int64]bool{}
+	for _, m := range r.migrations {
+		known[m.Version] = true
+		status := Status{Version: m.Version, Name: m.Name, State: "pending"}
+		if record, ok := records[m.Version]; ok {
+			status.AppliedAt = &record.AppliedAt
+			status.State = "applied"
+			if edited(record, m) {
+				status.State = "modified"
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	for version, record := range records {
+		if !known[version] {
+			appliedAt := record.AppliedAt
+			statuses = append(statuses, Status{Version: version, Name: record.Name, State: "missing", AppliedAt: &appliedAt})
+		}
+	}
+	return statuses, nil
+}
+
+func (r *Runner) applied(db *gorm.DB) (map[int64]AppliedMigration, error) {
+	var rows []AppliedMigration
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	records := make(map[int64]AppliedMigration, len(rows))
+	for _, row := range rows {
+		records[row.Version] = row
+	}
+	return records, nil
+}
+
+// edited reports whether the up or down file of m changed since record
+// was written.
+func edited(record AppliedMigration, m Migration) bool {
+	return record.Checksum != m.Checksum || record.DownChecksum != m.DownChecksum
+}
+
+// verify fails when an applied migration's file changed since it ran.
+func (r *Runner) verify(records map[int64]AppliedMigration) error {
+	var modified []string
+	for _, m := range r.migrations {
+		if record, ok := records[m.Version]; ok && edited(record, m) {
+			modified = append(modified, fmt.Sprintf("%d_%s", m.Version, m.Name))
+		}
+	}
+	if len(modified) > 0 {
+		return fmt.Errorf("applied migrations were edited: %v; add a new migration instead", modified)
+	}
+	return nil
+}
+
+// locked runs fn on a single connection while holding the migration lock,
+// so two deploys cannot migrate at the same time. On PostgreSQL this is a
+// session advisory lock. SQLite databases are local files and every
+// migration already takes the database write lock, so no extra lock is
+// taken there.
+func (r *Runner) locked(fn func(db *gorm.DB) error) error {
+	return r.db.Connection(func(conn *gorm.DB) error {
+		if r.dialect == "postgres" {
+			if err := r.acquireAdvisoryLock(conn); err != nil {
+				return err
+			}
+			defer conn.Exec("SELECT pg_advisory_unlock(?)", advisoryLockKey)
+		}
+
+		if err := conn.AutoMigrate(&AppliedMigration{}); err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+func (r *Runner) acquireAdvisoryLock(conn *gorm.DB) error {
+	deadline := time.Now().Add(r.LockTimeout)
+	for {
+		var acquired bool
+		if err := conn.Raw("SELECT pg_try_advisory_lock(?)", advisoryLockKey).Scan(&acquired).Error; err != nil {
+			return err
+		}
+		if acquired {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.New("another migration holds the lock; try again later")
+		}
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,106 @@
+package migrations
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func testRunner(t *testing.T, db *gorm.DB, fsys fstest.MapFS) *Runner {
+	t.Helper()
+	migrations, err := load(fsys, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Runner{db: db, dialect: "sqlite", migrations: migrations}
+}
+
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+var testMigrations = fstest.MapFS{
+	"0001_funds.up.sql":     file("CREATE TABLE funds (id INTEGER PRIMARY KEY);"),
+	"0001_funds.down.sql":   file("DROP TABLE funds;"),
+	"0002_budgets.up.sql":   file("CREATE TABLE budgets (id INTEGER PRIMARY KEY);"),
+	"0002_budgets.down.sql": file("DROP TABLE budgets;"),
+}
+
+func TestRunnerUpAndDown(t *testing.T) {
+	db := testDB(t)
+	runner := testRunner(t, db, testMigrations)
+
+	applied, err := runner.Up()
+	if err != nil || len(applied) != 2 {
+		t.Fatalf("Up applied %d migrations, err %v; want 2", len(applied), err)
+	}
+	if applied, err := runner.Up(); err != nil || len(applied) != 0 {
+		t.Fatalf("second Up applied %d migrations, err %v; want none", len(applied), err)
+	}
+
+	reverted, err := runner.Down(1)
+	if err != nil || len(reverted) != 1 || reverted[0].Version != 2 {
+		t.Fatalf("Down(1) reverted %v, err %v; want version 2", reverted, err)
+	}
+	if db.Migrator().HasTable("budgets") || !db.Migrator().HasTable("funds") {
+		t.Error("Down(1) did not drop exactly the budgets table")
+	}
+
+	statuses, err := runner.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statuses[0].State != "applied" || statuses[1].State != "pending" {
+		t.Errorf("states = %s, %s; want applied, pending", statuses[0].State, statuses[1].State)
+	}
+}
+
+func TestRunnerRefusesEditedMigrations(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		sql  string
+	}{
+		{"up edited", "0001_funds.up.sql", "CREATE TABLE funds (id BIGINT PRIMARY KEY);"},
+		{"down edited", "0001_funds.down.sql", "DROP TABLE IF EXISTS funds;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDB(t)
+			if _, err := testRunner(t, db, testMigrations).Up(); err != nil {
+				t.Fatal(err)
+			}
+
+			edited := fstest.MapFS{}
+			for name, f := range testMigrations {
+				edited[name] = f
+			}
+			edited[tt.file] = file(tt.sql)
+			runner := testRunner(t, db, edited)
+
+			if _, err := runner.Up(); err == nil || !strings.Contains(err.Error(), "1_funds") {
+				t.Errorf("Up: err = %v, want one naming 1_funds", err)
+			}
+			if _, err := runner.Down(2); err == nil {
+				t.Error("Down ran an edited migration")
+			}
+			statuses, err := runner.Status()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if statuses[0].State != "modified" {
+				t.Errorf("state = %s, want modified", statuses[0].State)
+			}
+		})
+	}
+}
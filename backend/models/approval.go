@@ -0,0 +1,104 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ApprovalPolicy decides who must approve a pending transaction. A policy
+// matches on type, fund, category and amount range; empty criteria match
+// everything. The active policy with the lowest Priority that matches is
+// used, and its Steps must all be signed, in order, before the transaction
+// is approved.
+type ApprovalPolicy struct {
+	ID        uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
+	Name      string         `gorm:"not null" json:"name"`
+	Priority  int            `gorm:"not null;default:100" json:"priority"`
+	Type      string         `json:"type,omitempty"` // income, expense, transfer; empty matches all
+	FundID    *uuid.UUID     `gorm:"type:uuid" json:"fundId,omitempty"`
+	Fund      *Fund          `gorm:"foreignKey:FundID" json:"fund,omitempty"`
+	Category  string         `json:"category,omitempty"`
+	MinAmount Money          `gorm:"type:decimal(15,2);not null;default:0" json:"minAmount"`
+	MaxAmount *Money         `gorm:"type:decimal(15,2)" json:"maxAmount,omitempty"` // inclusive; nil means no limit
+	Status    string         `gorm:"not null;default:'active'" json:"status"`       // active, archived
+	Steps     []ApprovalStep `gorm:"foreignKey:PolicyID" json:"steps"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+}
+
+func (p *ApprovalPolicy) BeforeCreate(tx *gorm.DB) error {
+	assignID(&p.ID)
+	return nil
+}
+
+// Matches reports whether the policy applies to transaction. A policy for
+// a fund or category applies when any line of the transaction is booked to
+// it; the amount compared is that of the whole transaction.
+func (p *ApprovalPolicy) Matches(transaction *Transaction) bool {
+	if p.Type != "" && p.Type != transaction.Type {
+		return false
+	}
+	if p.FundID != nil || p.Category != "" {
+		matched := false
+		for _, line := range transaction.Splits() {
+			if (p.FundID == nil || *p.FundID == line.FundID) && (p.Category == "" || p.Category == line.Category) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if transaction.Amount < p.MinAmount {
+		return false
+	}
+	return p.MaxAmount == nil || transaction.Amount <= *p.MaxAmount
+}
+
+// ApprovalStep is one required signature of a policy. It names either a
+// user role or a specific user.
+type ApprovalStep struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	PolicyID  uuid.UUID  `gorm:"type:uuid;not null" json:"policyId"`
+	StepOrder int        `gorm:"not null" json:"stepOrder"`
+	Role      string     `json:"role,omitempty"`
+	UserID    *uuid.UUID `gorm:"type:uuid" json:"userId,omitempty"`
+	User      *User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+func (s *ApprovalStep) BeforeCreate(tx *gorm.DB) error {
+	assignID(&s.ID)
+	return nil
+}
+
+// Allows reports whether user may sign the step.
+func (s *ApprovalStep) Allows(user *User) bool {
+	if s.UserID != nil {
+		return *s.UserID == user.ID
+	}
+	return s.Role == user.Role
+}
+
+// Approval is one signature on a transaction: an approval of a policy step
+// or a rejection. Signatures are kept when an edited transaction goes back
+// to pending, but are marked Superseded and no longer count.
+type Approval struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	TransactionID uuid.UUID  `gorm:"type:uuid;not null" json:"transactionId"`
+	PolicyID      *uuid.UUID `gorm:"type:uuid" json:"policyId,omitempty"`
+	StepOrder     int        `gorm:"not null" json:"stepOrder"`
+	ApproverID    uuid.UUID  `gorm:"type:uuid;not null" json:"approverId"`
+	Approver      *User      `gorm:"foreignKey:ApproverID" json:"approver,omitempty"`
+	Decision      string     `gorm:"not null" json:"decision"` // approved, rejected
+	Note          string     `json:"note,omitempty"`
+	Superseded    bool       `gorm:"not null;default:false" json:"superseded"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+func (a *Approval) BeforeCreate(tx *gorm.DB) error {
+	assignID(&a.ID)
+	return nil
+}
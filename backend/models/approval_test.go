@@ -0,0 +1,80 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestApprovalPolicyMatches(t *testing.T) {
+	kas, bank := uuid.New(), uuid.New()
+	maxAmount := Rupiah(5000000)
+	transaction := &Transaction{
+		Type:     "expense",
+		FundID:   kas,
+		Category: "Konsumsi",
+		Amount:   Rupiah(1000000),
+	}
+	split := &Transaction{
+		Type:   "expense",
+		Amount: Rupiah(1000000),
+		Lines: []TransactionLine{
+			{FundID: kas, Category: "Konsumsi", Amount: Rupiah(400000)},
+			{FundID: bank, Category: "Transport", Amount: Rupiah(600000)},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		policy      ApprovalPolicy
+		transaction *Transaction
+		want        bool
+	}{
+		{"empty criteria", ApprovalPolicy{}, transaction, true},
+		{"same type", ApprovalPolicy{Type: "expense"}, transaction, true},
+		{"other type", ApprovalPolicy{Type: "income"}, transaction, false},
+		{"same fund", ApprovalPolicy{FundID: &kas}, transaction, true},
+		{"other fund", ApprovalPolicy{FundID: &bank}, transaction, false},
+		{"same category", ApprovalPolicy{Category: "Konsumsi"}, transaction, true},
+		{"other category", ApprovalPolicy{Category: "Gaji"}, transaction, false},
+		{"at minimum", ApprovalPolicy{MinAmount: Rupiah(1000000)}, transaction, true},
+		{"below minimum", ApprovalPolicy{MinAmount: Rupiah(1000001)}, transaction, false},
+		{"within maximum", ApprovalPolicy{MaxAmount: &maxAmount}, transaction, true},
+		{"above maximum", ApprovalPolicy{MinAmount: Rupiah(2000000), MaxAmount: &maxAmount}, transaction, false},
+		{"fund of a line", ApprovalPolicy{FundID: &bank}, split, true},
+		{"category of a line", ApprovalPolicy{Category: "Transport"}, split, true},
+		{"fund and category on different lines", ApprovalPolicy{FundID: &bank, Category: "Konsumsi"}, split, false},
+		{"whole amount of a split", ApprovalPolicy{Category: "Konsumsi", MinAmount: Rupiah(500000)}, split, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Matches(tt.transaction); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApprovalStepAllows(t *testing.T) {
+	treasurer := &User{ID: uuid.New(), Role: "admin"}
+	member := &User{ID: uuid.New(), Role: "member"}
+
+	tests := []struct {
+		name string
+		step ApprovalStep
+		user *User
+		want bool
+	}{
+		{"role matches", ApprovalStep{Role: "admin"}, treasurer, true},
+		{"role differs", ApprovalStep{Role: "admin"}, member, false},
+		{"named user", ApprovalStep{UserID: &member.ID}, member, true},
+		{"another user with the role", ApprovalStep{UserID: &treasurer.ID, Role: "admin"}, &User{ID: uuid.New(), Role: "admin"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.step.Allows(tt.user); got != tt.want {
+				t.Errorf("Allows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
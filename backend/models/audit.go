@@ -0,0 +1,50 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// FieldChange is the value of one field before and after a change. Before
+// is nil for created entities and After is nil for deleted ones.
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// FieldChanges maps JSON field names to their change. It is stored as a
+// JSON text column.
+type FieldChanges map[string]FieldChange
+
+// Value stores the changes as JSON text, or an empty string when there are
+// none.
+func (c FieldChanges) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return "", nil
+	}
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
+// Scan reads the JSON text written by Value.
+func (c *FieldChanges) Scan(src interface{}) error {
+	var raw []byte
+	switch v := src.(type) {
+	case nil:
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return fmt.Errorf("cannot scan %T into FieldChanges", src)
+	}
+	if len(raw) == 0 {
+		*c = nil
+		return nil
+	}
+	return json.Unmarshal(raw, c)
+}
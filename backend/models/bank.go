@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BankStatement is a statement of the church's bank account, imported to
+// reconcile the books with the bank. FundID limits it to the bank money of
+// one fund; without it the statement covers the bank money of every fund.
+type BankStatement struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	FundID         *uuid.UUID `gorm:"type:uuid" json:"fundId,omitempty"`
+	Fund           *Fund      `gorm:"foreignKey:FundID" json:"fund,omitempty"`
+	Format         string     `gorm:"not null" json:"format"` // csv, ofx, mt940
+	FileName       string     `gorm:"not null" json:"fileName"`
+	AccountNumber  string     `json:"accountNumber"`
+	StartDate      time.Time  `gorm:"not null" json:"startDate"`
+	EndDate        time.Time  `gorm:"not null" json:"endDate"`
+	OpeningBalance *Money     `gorm:"type:decimal(15,2)" json:"openingBalance,omitempty"`
+	ClosingBalance *Money     `gorm:"type:decimal(15,2)" json:"closingBalance,omitempty"`
+	// Checksum is the SHA-256 of the file, so that a statement is not
+	// imported twice.
+	Checksum   string              `gorm:"not null" json:"-"`
+	ImportedBy uuid.UUID           `gorm:"type:uuid;not null" json:"importedBy"`
+	Lines      []BankStatementLine `gorm:"foreignKey:StatementID" json:"lines,omitempty"`
+	CreatedAt  time.Time           `json:"createdAt"`
+}
+
+func (s *BankStatement) BeforeCreate(tx *gorm.DB) error {
+	assignID(&s.ID)
+	return nil
+}
+
+// BankStatementLine is one entry on a bank statement. Amount is positive
+// for money coming into the account. A matched line names the transaction
+// it was reconciled with; MatchedBy is empty when it was matched
+// automatically. Lines that will never have a transaction, such as bank
+// charges booked later in bulk, can be ignored.
+type BankStatementLine struct {
+	ID            uuid.UUID    `gorm:"type:uuid;primary_key" json:"id"`
+	StatementID   uuid.UUID    `gorm:"type:uuid;not null" json:"statementId"`
+	Position      int          `gorm:"not null" json:"position"`
+	Date          time.Time    `gorm:"not null" json:"date"`
+	Amount        Money        `gorm:"type:decimal(15,2);not null" json:"amount"`
+	Description   string       `json:"description"`
+	Reference     string       `json:"reference"`
+	Status        string       `gorm:"not null;default:'unmatched'" json:"status"` // unmatched, matched, ignored
+	TransactionID *uuid.UUID   `gorm:"type:uuid" json:"transactionId,omitempty"`
+	Transaction   *Transaction `gorm:"foreignKey:TransactionID" json:"transaction,omitempty"`
+	MatchedBy     *uuid.UUID   `gorm:"type:uuid" json:"matchedBy,omitempty"`
+	MatchedAt     *time.Time   `json:"matchedAt,omitempty"`
+	Note          string       `json:"note,omitempty"`
+}
+
+func (l *BankStatementLine) BeforeCreate(tx *gorm.DB) error {
+	assignID(&l.ID)
+	return nil
+}
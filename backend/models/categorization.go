@@ -0,0 +1,67 @@
+package models
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CategorizationRule recognises incomes and expenses by their event name
+// or description, amount and payment method, and sets or suggests their
+// type, category and fund. Active rules are tried in priority order, lowest
+// first, and the first that matches decides. A "set" rule changes a new
+// transaction before it is recorded; a "suggest" rule only flags it (see
+// TransactionFlag).
+type CategorizationRule struct {
+	ID       uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Name     string    `gorm:"not null" json:"name"`
+	Priority int       `gorm:"not null;default:100" json:"priority"`
+	// Pattern is a regular expression matched, ignoring case, against the
+	// event name, the description or both, as Field says.
+	Pattern       string     `gorm:"not null" json:"pattern"`
+	Field         string     `gorm:"not null;default:'any'" json:"field"` // any, eventName, description
+	MinAmount     Money      `gorm:"type:decimal(15,2);not null;default:0" json:"minAmount"`
+	MaxAmount     *Money     `gorm:"type:decimal(15,2)" json:"maxAmount,omitempty"` // inclusive; nil means no limit
+	PaymentMethod string     `json:"paymentMethod,omitempty"`                       // cash, bank; empty matches both
+	SetType       string     `json:"setType,omitempty"`                             // income, expense
+	SetCategory   string     `json:"setCategory,omitempty"`
+	SetFundID     *uuid.UUID `gorm:"type:uuid" json:"setFundId,omitempty"`
+	SetFund       *Fund      `gorm:"foreignKey:SetFundID" json:"setFund,omitempty"`
+	Mode          string     `gorm:"not null;default:'suggest'" json:"mode"`  // set, suggest
+	Status        string     `gorm:"not null;default:'active'" json:"status"` // active, archived
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+func (r *CategorizationRule) BeforeCreate(tx *gorm.DB) error {
+	assignID(&r.ID)
+	return nil
+}
+
+// Matches reports whether the rule recognises transaction. Transfers are
+// never matched, and neither is anything when Pattern is not a valid
+// regular expression.
+func (r *CategorizationRule) Matches(transaction *Transaction) bool {
+	if transaction.Type != "income" && transaction.Type != "expense" {
+		return false
+	}
+	if r.PaymentMethod != "" && r.PaymentMethod != transaction.PaymentMethod {
+		return false
+	}
+	if transaction.Amount < r.MinAmount || r.MaxAmount != nil && transaction.Amount > *r.MaxAmount {
+		return false
+	}
+	pattern, err := regexp.Compile("(?i)" + r.Pattern)
+	if err != nil {
+		return false
+	}
+	switch r.Field {
+	case "eventName":
+		return pattern.MatchString(transaction.EventName)
+	case "description":
+		return pattern.MatchString(transaction.Description)
+	}
+	return pattern.MatchString(transaction.EventName) || pattern.MatchString(transaction.Description)
+}
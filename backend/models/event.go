@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Event is an activity such as a Christmas celebration or a retreat, run
+// by a commission with its own budget. Transactions link to it with
+// EventID, and its report compares the posted income and expense of those
+// transactions with BudgetIncome and BudgetExpense.
+type Event struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	Name       string     `gorm:"not null" json:"name"`
+	StartDate  time.Time  `gorm:"not null" json:"startDate"`
+	EndDate    *time.Time `json:"endDate,omitempty"`                     // nil for a one-day event
+	Commission string     `gorm:"not null;default:''" json:"commission"` // the commission responsible, e.g. Komisi Remaja
+	// FundID is the fund the event is paid from, if it has its own.
+	FundID        *uuid.UUID `gorm:"type:uuid" json:"fundId,omitempty"`
+	Fund          *Fund      `gorm:"foreignKey:FundID" json:"fund,omitempty"`
+	BudgetIncome  Money      `gorm:"type:decimal(15,2);not null;default:0" json:"budgetIncome"`
+	BudgetExpense Money      `gorm:"type:decimal(15,2);not null;default:0" json:"budgetExpense"`
+	Description   string     `json:"description"`
+	Version       int        `gorm:"not null;default:1" json:"version"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+func (e *Event) BeforeCreate(tx *gorm.DB) error {
+	assignID(&e.ID)
+	e.Version = 1
+	return nil
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FiscalYear is a bookkeeping year. Closing it carries the closing balance
+// of every fund and payment method forward as opening balances of the day
+// after EndDate.
+type FiscalYear struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	Name      string     `gorm:"unique;not null" json:"name"`
+	StartDate time.Time  `gorm:"not null" json:"startDate"`
+	EndDate   time.Time  `gorm:"not null" json:"endDate"`
+	Status    string     `gorm:"not null;default:'open'" json:"status"` // open, closed
+	ClosedAt  *time.Time `json:"closedAt,omitempty"`
+	ClosedBy  *uuid.UUID `gorm:"type:uuid" json:"closedBy,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+func (f *FiscalYear) BeforeCreate(tx *gorm.DB) error {
+	assignID(&f.ID)
+	return nil
+}
+
+// OpeningBalance is the balance of one fund and payment method at the
+// start of AsOf. Balances on later dates are computed from the latest
+// opening balance plus the approved transactions since.
+type OpeningBalance struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	FundID        uuid.UUID  `gorm:"type:uuid;not null" json:"fundId"`
+	Fund          *Fund      `gorm:"foreignKey:FundID" json:"fund,omitempty"`
+	PaymentMethod string     `gorm:"not null" json:"paymentMethod"` // cash, bank
+	AsOf          time.Time  `gorm:"not null" json:"asOf"`
+	Amount        Money      `gorm:"type:decimal(15,2);not null" json:"amount"`
+	FiscalYearID  *uuid.UUID `gorm:"type:uuid" json:"fiscalYearId,omitempty"` // set when carried forward by a year-end close
+	Note          string     `json:"note,omitempty"`
+	CreatedBy     uuid.UUID  `gorm:"type:uuid;not null" json:"createdBy"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+func (o *OpeningBalance) BeforeCreate(tx *gorm.DB) error {
+	assignID(&o.ID)
+	return nil
+}
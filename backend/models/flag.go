@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TransactionFlag marks a transaction that looks wrong and should be
+// checked by an approver: a probable duplicate of RelatedTransactionID, an
+// amount far outside what its category usually sees, a description that
+// reads like the other transaction type, or the change a categorization
+// rule suggests (see CategorizationRule). Flags are raised when a
+// transaction is recorded or changed, and stay open until dismissed.
+type TransactionFlag struct {
+	ID                   uuid.UUID    `gorm:"type:uuid;primary_key" json:"id"`
+	TransactionID        uuid.UUID    `gorm:"type:uuid;not null;index" json:"transactionId"`
+	Transaction          *Transaction `gorm:"foreignKey:TransactionID" json:"transaction,omitempty"`
+	Kind                 string       `gorm:"not null" json:"kind"` // duplicate, outlier, type_mismatch, suggestion
+	Message              string       `gorm:"not null" json:"message"`
+	RelatedTransactionID *uuid.UUID   `gorm:"type:uuid" json:"relatedTransactionId,omitempty"`
+	Status               string       `gorm:"not null;default:'open'" json:"status"` // open, dismissed
+	DismissedBy          *uuid.UUID   `gorm:"type:uuid" json:"dismissedBy,omitempty"`
+	DismissedAt          *time.Time   `json:"dismissedAt,omitempty"`
+	Note                 string       `json:"note,omitempty"`
+	CreatedAt            time.Time    `json:"createdAt"`
+}
+
+func (f *TransactionFlag) BeforeCreate(tx *gorm.DB) error {
+	assignID(&f.ID)
+	return nil
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyKey remembers the response to a request sent with an
+// Idempotency-Key header, so that a retry of the same request gets the
+// same response instead of creating a second record. Keys belong to the
+// user who sent them. StatusCode is 0 while the first request is running;
+// once LockedUntil has passed, a retry may take the key over.
+type IdempotencyKey struct {
+	UserID       uuid.UUID `gorm:"type:uuid;primaryKey" json:"userId"`
+	Key          string    `gorm:"column:idempotency_key;primaryKey" json:"key"`
+	Method       string    `gorm:"not null" json:"method"`
+	Path         string    `gorm:"not null" json:"path"`
+	RequestHash  string    `gorm:"not null" json:"requestHash"` // SHA-256 of method, path and body
+	StatusCode   int       `gorm:"not null;default:0" json:"statusCode"`
+	ResponseBody string    `gorm:"type:text;not null;default:''" json:"responseBody"`
+	CreatedAt    time.Time `json:"createdAt"`
+	LockedUntil  time.Time `gorm:"not null" json:"lockedUntil"`
+	ExpiresAt    time.Time `gorm:"not null" json:"expiresAt"`
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Account types in the chart of accounts.
+const (
+	AccountAsset     = "asset"
+	AccountLiability = "liability"
+	AccountEquity    = "equity"
+	AccountIncome    = "income"
+	AccountExpense   = "expense"
+)
+
+// Codes of the system accounts seeded by the general ledger migration.
+// Transactions post against these.
+const (
+	AccountCodeCash        = "1101"
+	AccountCodeBank        = "1102"
+	AccountCodeFundBalance = "3100"
+	AccountCodeIncome      = "4100"
+	AccountCodeExpense     = "5100"
+)
+
+// Account is one entry of the chart of accounts.
+type Account struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Code      string    `gorm:"unique;not null" json:"code"`
+	Name      string    `gorm:"not null" json:"name"`
+	Type      string    `gorm:"not null" json:"type"`                    // asset, liability, equity, income, expense
+	Status    string    `gorm:"not null;default:'active'" json:"status"` // active, archived
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (a *Account) BeforeCreate(tx *gorm.DB) error {
+	assignID(&a.ID)
+	return nil
+}
+
+// DebitNormal reports whether the account's balance grows with debits.
+func (a *Account) DebitNormal() bool {
+	return a.Type == AccountAsset || a.Type == AccountExpense
+}
+
+// JournalEntry is a balanced set of debit and credit lines posted on one
+// date. Entries are never edited; a posting is undone by a reversing entry.
+type JournalEntry struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Date        time.Time `gorm:"not null" json:"date"`
+	Description string    `json:"description"`
+	Source      string    `gorm:"not null" json:"source"` // transaction, manual, reversal
+	// TransactionID links entries posted for a transaction.
+	TransactionID *uuid.UUID `gorm:"type:uuid" json:"transactionId,omitempty"`
+	// ReversesID and ReversedByID link an entry and its reversal.
+	ReversesID   *uuid.UUID    `gorm:"type:uuid" json:"reversesId,omitempty"`
+	ReversedByID *uuid.UUID    `gorm:"type:uuid" json:"reversedById,omitempty"`
+	CreatedBy    uuid.UUID     `gorm:"type:uuid;not null" json:"createdBy"`
+	Lines        []JournalLine `gorm:"foreignKey:EntryID" json:"lines,omitempty"`
+	CreatedAt    time.Time     `json:"createdAt"`
+}
+
+func (e *JournalEntry) BeforeCreate(tx *gorm.DB) error {
+	assignID(&e.ID)
+	return nil
+}
+
+// JournalLine debits or credits one account, optionally for one fund.
+// Exactly one of Debit and Credit is non-zero.
+type JournalLine struct {
+	ID        uuid.UUID     `gorm:"type:uuid;primary_key" json:"id"`
+	EntryID   uuid.UUID     `gorm:"type:uuid;not null" json:"entryId"`
+	Entry     *JournalEntry `gorm:"foreignKey:EntryID" json:"entry,omitempty"`
+	AccountID uuid.UUID     `gorm:"type:uuid;not null" json:"accountId"`
+	Account   *Account      `gorm:"foreignKey:AccountID" json:"account,omitempty"`
+	FundID    *uuid.UUID    `gorm:"type:uuid" json:"fundId,omitempty"`
+	Debit     Money         `gorm:"type:decimal(15,2);not null;default:0" json:"debit"`
+	Credit    Money         `gorm:"type:decimal(15,2);not null;default:0" json:"credit"`
+	Memo      string        `json:"memo,omitempty"`
+}
+
+func (l *JournalLine) BeforeCreate(tx *gorm.DB) error {
+	assignID(&l.ID)
+	return nil
+}
@@ -4,10 +4,19 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// IDs are generated in Go rather than with gen_random_uuid() so that the
+// models work the same on PostgreSQL and SQLite.
+func assignID(id *uuid.UUID) {
+	if *id == uuid.Nil {
+		*id = uuid.New()
+	}
+}
+
 type User struct {
-	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ID           uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
 	Name         string    `gorm:"not null" json:"name"`
 	Email        string    `gorm:"unique;not null" json:"email"`
 	PasswordHash string    `gorm:"not null" json:"-"`
@@ -16,55 +25,179 @@ type User struct {
 	UpdatedAt    time.Time `json:"updatedAt"`
 }
 
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	assignID(&u.ID)
+	return nil
+}
+
 type Transaction struct {
-	ID              uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	FundID          uuid.UUID `gorm:"type:uuid" json:"fundId"`
-	Fund            *Fund     `gorm:"foreignKey:FundID" json:"fund,omitempty"`
-	Type            string    `gorm:"not null" json:"type"`                         // income, expense
-	PaymentMethod   string    `gorm:"not null;default:'cash'" json:"paymentMethod"` // cash, bank
-	Amount          float64   `gorm:"not null" json:"amount"`
-	Category        string    `gorm:"not null" json:"category"`
-	Description     string    `json:"description"`
-	EventName       string    `gorm:"not null" json:"eventName"`
-	Date            time.Time `gorm:"not null" json:"date"`
-	CreatedBy       uuid.UUID `gorm:"type:uuid;not null" json:"createdBy"`
-	CreatedByUser   *User     `gorm:"foreignKey:CreatedBy" json:"createdByUser,omitempty"`
-	Status          string    `gorm:"not null;default:'pending'" json:"status"` // pending, approved, rejected
-	NoteURL         string    `json:"noteUrl,omitempty"`
-	RejectionReason string    `json:"rejectionReason,omitempty"`
-	CreatedAt       time.Time `json:"createdAt"`
-	UpdatedAt       time.Time `json:"updatedAt"`
+	ID            uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	FundID        uuid.UUID `gorm:"type:uuid" json:"fundId"`
+	Fund          *Fund     `gorm:"foreignKey:FundID" json:"fund,omitempty"`
+	Type          string    `gorm:"not null" json:"type"`                         // income, expense, transfer
+	PaymentMethod string    `gorm:"not null;default:'cash'" json:"paymentMethod"` // cash, bank
+	// ToFundID and ToPaymentMethod are the destination of a transfer; the
+	// source is FundID and PaymentMethod. Both are empty for other types.
+	ToFundID        *uuid.UUID `gorm:"type:uuid" json:"toFundId,omitempty"`
+	ToFund          *Fund      `gorm:"foreignKey:ToFundID" json:"toFund,omitempty"`
+	ToPaymentMethod string     `json:"toPaymentMethod,omitempty"`
+	Amount          Money      `gorm:"type:decimal(15,2);not null" json:"amount"`
+	// CategoryID is the category of an income or expense; Category is its
+	// name, kept up to date when the category is renamed. Transfers have no
+	// category ID and are labelled "Transfer".
+	CategoryID *uuid.UUID `gorm:"type:uuid" json:"categoryId,omitempty"`
+	Category   string     `gorm:"not null" json:"category"`
+	// Lines split an income or expense over categories and funds; their
+	// amounts add up to Amount, and FundID and Category are those of the
+	// first line. Transfers have no lines.
+	Lines       []TransactionLine `gorm:"foreignKey:TransactionID" json:"lines,omitempty"`
+	Description string            `json:"description"`
+	// EventID links the transaction to an Event; EventName is then the
+	// name of the event, kept up to date when it is renamed.
+	EventID         *uuid.UUID `gorm:"type:uuid" json:"eventId,omitempty"`
+	EventName       string     `gorm:"not null" json:"eventName"`
+	Date            time.Time  `gorm:"not null" json:"date"`
+	CreatedBy       uuid.UUID  `gorm:"type:uuid;not null" json:"createdBy"`
+	CreatedByUser   *User      `gorm:"foreignKey:CreatedBy" json:"createdByUser,omitempty"`
+	Status          string     `gorm:"not null;default:'pending'" json:"status"` // draft, pending, approved, rejected, voided
+	NoteURL         string     `json:"noteUrl,omitempty"`
+	RejectionReason string     `json:"rejectionReason,omitempty"`
+	// ReviewedBy and ReviewedAt record who approved or rejected the
+	// transaction and when. They are cleared when it goes back to pending.
+	ReviewedBy *uuid.UUID `gorm:"type:uuid" json:"reviewedBy,omitempty"`
+	ReviewedAt *time.Time `json:"reviewedAt,omitempty"`
+	// An approved transaction is cancelled by voiding it, which adds an
+	// approved reversal with the opposite amount. ReversalOfID links the
+	// reversal to the original; both carry the VoidReason.
+	ReversalOfID *uuid.UUID `gorm:"type:uuid" json:"reversalOfId,omitempty"`
+	VoidReason   string     `json:"voidReason,omitempty"`
+	// RecurringTemplateID and OccurrenceDate link a transaction recorded by
+	// the scheduler to its template; each occurrence is recorded once.
+	RecurringTemplateID *uuid.UUID `gorm:"type:uuid" json:"recurringTemplateId,omitempty"`
+	OccurrenceDate      *time.Time `json:"occurrenceDate,omitempty"`
+	// Flags are the checks the transaction failed when it was recorded or
+	// last changed; see TransactionFlag.
+	Flags []TransactionFlag `gorm:"foreignKey:TransactionID" json:"flags,omitempty"`
+	// Version starts at 1 and is raised by every update. Funds and
+	// categories have one too; see repository.ErrVersionConflict.
+	Version   int       `gorm:"not null;default:1" json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
 
-type ActivityLog struct {
-	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	UserID    uuid.UUID `gorm:"type:uuid;not null" json:"userId"`
-	User      *User     `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	Action    string    `gorm:"not null" json:"action"`
-	Timestamp time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"timestamp"`
+func (t *Transaction) BeforeCreate(tx *gorm.DB) error {
+	assignID(&t.ID)
+	t.Version = 1
+	return nil
+}
+
+// Posted reports whether the transaction counts towards balances. A voided
+// transaction still counts; its reversal cancels it out from the reversal
+// date on.
+func (t *Transaction) Posted() bool {
+	return t.Status == "approved" || t.Status == "voided"
+}
+
+// Splits returns the lines of the transaction, or, for a transaction
+// without lines such as a transfer, a single line holding its fund,
+// category and amount.
+func (t *Transaction) Splits() []TransactionLine {
+	if len(t.Lines) > 0 {
+		return t.Lines
+	}
+	return []TransactionLine{{
+		TransactionID: t.ID,
+		FundID:        t.FundID,
+		CategoryID:    t.CategoryID,
+		Category:      t.Category,
+		Amount:        t.Amount,
+		Description:   t.Description,
+	}}
+}
+
+// TransactionLine is the part of a transaction booked to one category and
+// fund. Position keeps the lines in the order they were entered.
+type TransactionLine struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	TransactionID uuid.UUID  `gorm:"type:uuid;not null" json:"transactionId"`
+	Position      int        `gorm:"not null" json:"position"`
+	FundID        uuid.UUID  `gorm:"type:uuid;not null" json:"fundId"`
+	CategoryID    *uuid.UUID `gorm:"type:uuid" json:"categoryId,omitempty"`
+	Category      string     `gorm:"not null" json:"category"`
+	Amount        Money      `gorm:"type:decimal(15,2);not null" json:"amount"`
+	Description   string     `json:"description,omitempty"`
+}
+
+func (l *TransactionLine) BeforeCreate(tx *gorm.DB) error {
+	assignID(&l.ID)
+	return nil
 }
 
+// ActivityLog is one entry of the audit trail: who (UserID) did what
+// (Action) to which entity, the fields it changed, and the request it came
+// from. Summary is a human-readable description of the change.
+type ActivityLog struct {
+	ID         uuid.UUID    `gorm:"type:uuid;primary_key" json:"id"`
+	UserID     uuid.UUID    `gorm:"type:uuid;not null" json:"userId"`
+	User       *User        `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	EntityType string       `gorm:"not null;default:''" json:"entityType"` // transaction, fund, category, user, ...
+	EntityID   *uuid.UUID   `gorm:"type:uuid" json:"entityId,omitempty"`
+	Action     string       `gorm:"not null" json:"action"` // create, update, delete, submit, approve, void, login, ...
+	Summary    string       `json:"summary"`
+	Changes    FieldChanges `gorm:"type:text" json:"changes,omitempty"`
+	IPAddress  string       `json:"ipAddress,omitempty"`
+	UserAgent  string       `json:"userAgent,omitempty"`
+	RequestID  string       `json:"requestId,omitempty"`
+	Timestamp  time.Time    `gorm:"default:CURRENT_TIMESTAMP" json:"timestamp"`
+}
 
+func (l *ActivityLog) BeforeCreate(tx *gorm.DB) error {
+	assignID(&l.ID)
+	if l.Timestamp.IsZero() {
+		l.Timestamp = time.Now()
+	}
+	return nil
+}
 
 // Fund represents a project / program fund envelope
 type Fund struct {
-	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ID          uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
 	Name        string    `gorm:"unique;not null" json:"name"`
 	Description string    `json:"description"`
 	Status      string    `gorm:"not null;default:'active'" json:"status"` // active, archived
+	Version     int       `gorm:"not null;default:1" json:"version"`
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
 }
 
+func (f *Fund) BeforeCreate(tx *gorm.DB) error {
+	assignID(&f.ID)
+	f.Version = 1
+	return nil
+}
 
-
-// Category represents transaction category label (can be used for both income and expense)
+// Category represents transaction category label (can be used for both income and expense).
+// Categories nest under a ParentID, and reports can roll subcategories up
+// into their top-level category. A subcategory has the type of its parent
+// unless the parent is general.
 type Category struct {
-	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	Type      string    `gorm:"not null;default:'general';index:idx_categories_type_name" json:"type"` // income, expense, or general
-	Name      string    `gorm:"not null;index:idx_categories_type_name" json:"name"`                   // category name
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	Type      string     `gorm:"not null;default:'general';index:idx_categories_type_name" json:"type"` // income, expense, or general
+	Name      string     `gorm:"not null;index:idx_categories_type_name" json:"name"`                   // category name
+	ParentID  *uuid.UUID `gorm:"type:uuid" json:"parentId,omitempty"`
+	Version   int        `gorm:"not null;default:1" json:"version"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
 }
 
+func (c *Category) BeforeCreate(tx *gorm.DB) error {
+	assignID(&c.ID)
+	c.Version = 1
+	return nil
+}
 
+// Allows reports whether transactions or subcategories of transactionType
+// may use the category.
+func (c *Category) Allows(transactionType string) bool {
+	return c.Type == "general" || c.Type == transactionType
+}
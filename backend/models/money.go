@@ -0,0 +1,171 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact rupiah amount stored as a whole number of sen
+// (1/100 rupiah), matching the DECIMAL(15,2) amount columns.
+//
+// Rounding rules: values with more than two decimals are rounded half away
+// from zero to the nearest sen, both when parsing (ParseMoney, JSON) and
+// when scanning floating-point values returned by the database. Sums and
+// differences of Money values are exact.
+type Money int64
+
+const senPerRupiah = 100
+
+var errMoneyRange = errors.New("amount out of range")
+
+// decimalPattern is the notation ParseMoney accepts. big.Rat would also
+// take fractions such as "1/3" and base prefixes such as "0x10".
+var decimalPattern = regexp.MustCompile(`^[+-]?\d+(\.\d+)?([eE][+-]?\d{1,3})?$`)
+
+// Rupiah returns a Money of whole rupiah.
+func Rupiah(rupiah int64) Money {
+	return Money(rupiah * senPerRupiah)
+}
+
+// ParseMoney parses a decimal string such as "150000", "1234.5" or "1e6".
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if !decimalPattern.MatchString(s) {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return moneyFromRat(r)
+}
+
+func moneyFromRat(r *big.Rat) (Money, error) {
+	scaled := new(big.Rat).Mul(r, big.NewRat(senPerRupiah, 1))
+	quotient, remainder := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+
+	// Round half away from zero.
+	remainder.Abs(remainder).Mul(remainder, big.NewInt(2))
+	if remainder.Cmp(scaled.Denom()) >= 0 {
+		if scaled.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+
+	if !quotient.IsInt64() {
+		return 0, errMoneyRange
+	}
+	return Money(quotient.Int64()), nil
+}
+
+// moneyFromFloat converts a float to Money, rounding half away from zero.
+func moneyFromFloat(f float64) (Money, error) {
+	sen := math.Round(f * senPerRupiah)
+	if math.IsNaN(sen) || sen > math.MaxInt64 || sen < math.MinInt64 {
+		return 0, errMoneyRange
+	}
+	return Money(sen), nil
+}
+
+// String returns the amount with exactly two decimals, e.g. "1234.50".
+func (m Money) String() string {
+	sign := ""
+	sen := int64(m)
+	if sen < 0 {
+		sign = "-"
+		sen = -sen
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, sen/senPerRupiah, sen%senPerRupiah)
+}
+
+// Format returns the amount in Indonesian notation, e.g. "Rp 1.234.567" or
+// "Rp 1.234,50" when there are sen.
+func (m Money) Format() string {
+	sign := ""
+	sen := int64(m)
+	if sen < 0 {
+		sign = "-"
+		sen = -sen
+	}
+
+	digits := strconv.FormatInt(sen/senPerRupiah, 10)
+	var grouped strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteByte('.')
+		}
+		grouped.WriteRune(d)
+	}
+
+	if cents := sen % senPerRupiah; cents != 0 {
+		return fmt.Sprintf("%sRp %s,%02d", sign, grouped.String(), cents)
+	}
+	return sign + "Rp " + grouped.String()
+}
+
+// Float64 returns the amount in rupiah as a float. Use it only at display
+// edges such as chart percentages and spreadsheet cells, never for sums.
+func (m Money) Float64() float64 {
+	return float64(m) / senPerRupiah
+}
+
+// MarshalJSON writes the amount as a JSON number with no float artifacts:
+// 150000, 1234.5, 0.05.
+func (m Money) MarshalJSON() ([]byte, error) {
+	s := m.String()
+	s = strings.TrimSuffix(s, ".00")
+	if strings.Contains(s, ".") {
+		s = strings.TrimSuffix(s, "0")
+	}
+	return []byte(s), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a decimal string.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value stores the amount as an exact decimal string.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan reads DECIMAL values returned as strings, integers or floats.
+func (m *Money) Scan(src interface{}) error {
+	var err error
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+	case []byte:
+		*m, err = ParseMoney(string(v))
+	case string:
+		*m, err = ParseMoney(v)
+	case int64:
+		*m = Rupiah(v)
+	case float64:
+		*m, err = moneyFromFloat(v)
+	default:
+		err = fmt.Errorf("cannot scan %T into Money", src)
+	}
+	return err
+}
@@ -0,0 +1,159 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{"150000", Rupiah(150000), false},
+		{"1234.5", 123450, false},
+		{" 0.05 ", 5, false},
+		{"1e6", Rupiah(1000000), false},
+		{"-75000", Rupiah(-75000), false},
+		{"0.005", 1, false},
+		{"0.004", 0, false},
+		{"-0.005", -1, false},
+		{"0.1", 10, false},
+		{"", 0, true},
+		{"abc", 0, true},
+		{"1.234,56", 0, true},
+		{"1e30", 0, true},
+		{"1/3", 0, true},
+		{"0x10/1", 0, true},
+		{"010/1", 0, true},
+		{"0x10", 0, true},
+		{"0b101", 0, true},
+		{"1_000", 0, true},
+		{".5", 0, true},
+		{"5.", 0, true},
+		{"1e", 0, true},
+		{"1e10000", 0, true},
+		{"+1500.25", 150025, false},
+		{"1.5E3", Rupiah(1500), false},
+		{"2e-2", 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseMoney(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMoney(%q) = %d sen, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyFormatting(t *testing.T) {
+	tests := []struct {
+		m           Money
+		str, format string
+		json        string
+	}{
+		{0, "0.00", "Rp 0", "0"},
+		{5, "0.05", "Rp 0,05", "0.05"},
+		{123450, "1234.50", "Rp 1.234,50", "1234.5"},
+		{Rupiah(150000), "150000.00", "Rp 150.000", "150000"},
+		{Rupiah(1234567), "1234567.00", "Rp 1.234.567", "1234567"},
+		{-Rupiah(250000), "-250000.00", "-Rp 250.000", "-250000"},
+		{-1, "-0.01", "-Rp 0,01", "-0.01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			if got := tt.m.String(); got != tt.str {
+				t.Errorf("String() = %q, want %q", got, tt.str)
+			}
+			if got := tt.m.Format(); got != tt.format {
+				t.Errorf("Format() = %q, want %q", got, tt.format)
+			}
+			got, err := json.Marshal(tt.m)
+			if err != nil || string(got) != tt.json {
+				t.Errorf("MarshalJSON() = %s, %v; want %s", got, err, tt.json)
+			}
+		})
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{`150000`, Rupiah(150000), false},
+		{`0.1`, 10, false},
+		{`"1234.56"`, 123456, false},
+		{`null`, 0, false},
+		{`"Rp 1.000"`, 0, true},
+		{`true`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			var got Money
+			err := json.Unmarshal([]byte(tt.in), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %d sen, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMoneySumsAreExact is the reason Money exists: ten offerings of
+// Rp 0,10 add up to exactly Rp 1, which float64 does not.
+func TestMoneySumsAreExact(t *testing.T) {
+	var total Money
+	for i := 0; i < 10; i++ {
+		m, err := ParseMoney("0.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += m
+	}
+	if total != Rupiah(1) {
+		t.Errorf("total = %s, want 1.00", total)
+	}
+}
+
+func TestMoneyScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     interface{}
+		want    Money
+		wantErr bool
+	}{
+		{"nil", nil, 0, false},
+		{"bytes", []byte("1234.50"), 123450, false},
+		{"string", "99.99", 9999, false},
+		{"int64", int64(5000), Rupiah(5000), false},
+		{"float64", 0.1 + 0.2, 30, false},
+		{"float64 half", 2.675, 268, false},
+		{"bool", true, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Money
+			err := got.Scan(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Scan(%v) = %d sen, want %d", tt.src, got, tt.want)
+			}
+		})
+	}
+
+	value, err := Money(123450).Value()
+	if err != nil || value != "1234.50" {
+		t.Errorf("Value() = %v, %v; want \"1234.50\"", value, err)
+	}
+}
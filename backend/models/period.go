@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AccountingPeriod is a month, year or fiscal year closed for bookkeeping.
+// While it is closed, transactions and journals dated inside it cannot be
+// created, edited, reviewed or deleted.
+type AccountingPeriod struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	Name         string     `gorm:"unique;not null" json:"name"` // e.g. 2025-01, 2025
+	StartDate    time.Time  `gorm:"not null" json:"startDate"`
+	EndDate      time.Time  `gorm:"not null" json:"endDate"`
+	Status       string     `gorm:"not null;default:'closed'" json:"status"` // closed, open
+	ClosedAt     *time.Time `json:"closedAt,omitempty"`
+	ClosedBy     *uuid.UUID `gorm:"type:uuid" json:"closedBy,omitempty"`
+	ReopenedAt   *time.Time `json:"reopenedAt,omitempty"`
+	ReopenedBy   *uuid.UUID `gorm:"type:uuid" json:"reopenedBy,omitempty"`
+	ReopenReason string     `json:"reopenReason,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+func (p *AccountingPeriod) BeforeCreate(tx *gorm.DB) error {
+	assignID(&p.ID)
+	return nil
+}
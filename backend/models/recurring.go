@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RecurringTemplate describes an income or expense that repeats, such as a
+// monthly salary or utility bill. The scheduler records a pending
+// transaction for every occurrence from StartDate on, up to EndDate if set.
+//
+// A monthly template falls on DayOfMonth, or on the last day of shorter
+// months; a yearly one on DayOfMonth of MonthOfYear; a weekly one on
+// Weekday (0 is Sunday). NextRunDate is the first occurrence that has not
+// been recorded yet and LastRunDate the latest one that was.
+type RecurringTemplate struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	Name          string     `gorm:"not null" json:"name"`
+	Type          string     `gorm:"not null" json:"type"` // income, expense
+	Amount        Money      `gorm:"type:decimal(15,2);not null" json:"amount"`
+	FundID        uuid.UUID  `gorm:"type:uuid;not null" json:"fundId"`
+	Fund          *Fund      `gorm:"foreignKey:FundID" json:"fund,omitempty"`
+	Category      string     `gorm:"not null" json:"category"`
+	PaymentMethod string     `gorm:"not null;default:'cash'" json:"paymentMethod"`
+	EventName     string     `gorm:"not null" json:"eventName"`
+	Description   string     `json:"description"`
+	Frequency     string     `gorm:"not null" json:"frequency"` // weekly, monthly, yearly
+	DayOfMonth    int        `gorm:"not null;default:1" json:"dayOfMonth"`
+	Weekday       int        `gorm:"not null;default:0" json:"weekday"`
+	MonthOfYear   int        `gorm:"not null;default:1" json:"monthOfYear"`
+	StartDate     time.Time  `gorm:"not null" json:"startDate"`
+	EndDate       *time.Time `json:"endDate,omitempty"`
+	NextRunDate   time.Time  `gorm:"not null" json:"nextRunDate"`
+	LastRunDate   *time.Time `json:"lastRunDate,omitempty"`
+	Status        string     `gorm:"not null;default:'active'" json:"status"` // active, paused, ended
+	CreatedBy     uuid.UUID  `gorm:"type:uuid;not null" json:"createdBy"`
+	// Version is raised by every update, so that two schedulers cannot
+	// record the same occurrence.
+	Version   int       `gorm:"not null;default:1" json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (t *RecurringTemplate) BeforeCreate(tx *gorm.DB) error {
+	assignID(&t.ID)
+	t.Version = 1
+	return nil
+}
+
+// FirstOn returns the first occurrence of the template on or after date,
+// which must be a date at midnight UTC.
+func (t *RecurringTemplate) FirstOn(date time.Time) time.Time {
+	switch t.Frequency {
+	case "weekly":
+		return date.AddDate(0, 0, (t.Weekday-int(date.Weekday())+7)%7)
+	case "yearly":
+		occurrence := dayInMonth(date.Year(), time.Month(t.MonthOfYear), t.DayOfMonth)
+		if occurrence.Before(date) {
+			occurrence = dayInMonth(date.Year()+1, time.Month(t.MonthOfYear), t.DayOfMonth)
+		}
+		return occurrence
+	default:
+		occurrence := dayInMonth(date.Year(), date.Month(), t.DayOfMonth)
+		if occurrence.Before(date) {
+			occurrence = dayInMonth(date.Year(), date.Month()+1, t.DayOfMonth)
+		}
+		return occurrence
+	}
+}
+
+// After returns the occurrence that follows occurrence.
+func (t *RecurringTemplate) After(occurrence time.Time) time.Time {
+	return t.FirstOn(occurrence.AddDate(0, 0, 1))
+}
+
+// dayInMonth returns day of the given month, or its last day when the month
+// is shorter. month may overflow into the next year.
+func dayInMonth(year int, month time.Month, day int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1).Day()
+	if day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func day(value string) time.Time {
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return date
+}
+
+func TestRecurringTemplateFirstOn(t *testing.T) {
+	tests := []struct {
+		name     string
+		template RecurringTemplate
+		date     string
+		want     string
+	}{
+		{"monthly later this month", RecurringTemplate{Frequency: "monthly", DayOfMonth: 25}, "2025-01-10", "2025-01-25"},
+		{"monthly on the day", RecurringTemplate{Frequency: "monthly", DayOfMonth: 10}, "2025-01-10", "2025-01-10"},
+		{"monthly next month", RecurringTemplate{Frequency: "monthly", DayOfMonth: 5}, "2025-01-10", "2025-02-05"},
+		{"monthly 31st in February", RecurringTemplate{Frequency: "monthly", DayOfMonth: 31}, "2025-02-01", "2025-02-28"},
+		{"monthly 31st in a leap February", RecurringTemplate{Frequency: "monthly", DayOfMonth: 31}, "2024-02-01", "2024-02-29"},
+		{"monthly 31st in April", RecurringTemplate{Frequency: "monthly", DayOfMonth: 31}, "2025-04-01", "2025-04-30"},
+		{"monthly across the year end", RecurringTemplate{Frequency: "monthly", DayOfMonth: 1}, "2025-12-02", "2026-01-01"},
+		{"weekly same weekday", RecurringTemplate{Frequency: "weekly", Weekday: 0}, "2025-01-05", "2025-01-05"},
+		{"weekly later this week", RecurringTemplate{Frequency: "weekly", Weekday: 5}, "2025-01-06", "2025-01-10"},
+		{"weekly next week", RecurringTemplate{Frequency: "weekly", Weekday: 1}, "2025-01-07", "2025-01-13"},
+		{"yearly this year", RecurringTemplate{Frequency: "yearly", MonthOfYear: 12, DayOfMonth: 25}, "2025-01-01", "2025-12-25"},
+		{"yearly next year", RecurringTemplate{Frequency: "yearly", MonthOfYear: 1, DayOfMonth: 15}, "2025-01-16", "2026-01-15"},
+		{"yearly 29 February", RecurringTemplate{Frequency: "yearly", MonthOfYear: 2, DayOfMonth: 29}, "2025-01-01", "2025-02-28"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.template.FirstOn(day(tt.date)); !got.Equal(day(tt.want)) {
+				t.Errorf("FirstOn(%s) = %s, want %s", tt.date, got.Format("2006-01-02"), tt.want)
+			}
+		})
+	}
+}
+
+func TestRecurringTemplateAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		template RecurringTemplate
+		from     string
+		want     []string
+	}{
+		{
+			name:     "monthly on the 31st keeps its day",
+			template: RecurringTemplate{Frequency: "monthly", DayOfMonth: 31},
+			from:     "2025-01-31",
+			want:     []string{"2025-02-28", "2025-03-31", "2025-04-30", "2025-05-31"},
+		},
+		{
+			name:     "weekly",
+			template: RecurringTemplate{Frequency: "weekly", Weekday: 0},
+			from:     "2024-12-29",
+			want:     []string{"2025-01-05", "2025-01-12"},
+		},
+		{
+			name:     "yearly",
+			template: RecurringTemplate{Frequency: "yearly", MonthOfYear: 2, DayOfMonth: 29},
+			from:     "2024-02-29",
+			want:     []string{"2025-02-28", "2026-02-28", "2027-02-28", "2028-02-29"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			occurrence := day(tt.from)
+			for _, want := range tt.want {
+				occurrence = tt.template.After(occurrence)
+				if !occurrence.Equal(day(want)) {
+					t.Fatalf("got %s, want %s", occurrence.Format("2006-01-02"), want)
+				}
+			}
+		})
+	}
+}
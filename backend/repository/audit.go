@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"gkjw-finance-backend/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ActivityLogFilter selects audit log entries. Zero values mean "no
+// filter". StartDate and EndDate are inclusive days.
+type ActivityLogFilter struct {
+	EntityType string
+	EntityID   uuid.UUID
+	UserID     uuid.UUID
+	Action     string
+	StartDate  time.Time
+	EndDate    time.Time
+}
+
+// ActivityLogPage is one page of audit log entries, newest first, plus the
+// number of entries in the whole selection.
+type ActivityLogPage struct {
+	Items      []models.ActivityLog
+	Total      int64
+	HasMore    bool
+	NextCursor string
+}
+
+// activityLogCursor is the keyset position of the last entry on a page.
+type activityLogCursor struct {
+	Timestamp time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+}
+
+func encodeActivityLogCursor(log models.ActivityLog) string {
+	raw, _ := json.Marshal(activityLogCursor{Timestamp: log.Timestamp, ID: log.ID})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeActivityLogCursor(encoded string) (activityLogCursor, error) {
+	var cursor activityLogCursor
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID == uuid.Nil {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// activityLogPage trims items, fetched with one extra row, to limit and
+// fills in the paging fields.
+func activityLogPage(items []models.ActivityLog, total int64, limit int) *ActivityLogPage {
+	page := &ActivityLogPage{Items: items, Total: total}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		page.HasMore = true
+		page.NextCursor = encodeActivityLogCursor(page.Items[limit-1])
+	}
+	return page
+}
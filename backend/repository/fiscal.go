@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OpeningBalanceFilter narrows opening balance queries. Zero values mean
+// "no filter"; Until is inclusive.
+type OpeningBalanceFilter struct {
+	FundID uuid.UUID
+	Until  time.Time
+}
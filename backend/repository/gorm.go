@@ -0,0 +1,337 @@
+package repository
+
+import (
+	"errors"
+	"gkjw-finance-backend/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NewGormRepositories returns repositories backed by db.
+func NewGormRepositories(db *gorm.DB) *Repositories {
+	return &Repositories{
+		Transactions: &gormTransactionRepo{db: db},
+		Funds:        &gormFundRepo{db: db},
+		Categories:   &gormCategoryRepo{db: db},
+		Users:        &gormUserRepo{db: db},
+		ActivityLogs: &gormActivityLogRepo{db: db},
+		Accounts:     &gormAccountRepo{db: db},
+		Journals:     &gormJournalRepo{db: db},
+
+		FiscalYears:     &gormFiscalYearRepo{db: db},
+		OpeningBalances: &gormOpeningBalanceRepo{db: db},
+		Periods:         &gormAccountingPeriodRepo{db: db},
+
+		ApprovalPolicies: &gormApprovalPolicyRepo{db: db},
+		Approvals:        &gormApprovalRepo{db: db},
+
+		RecurringTemplates: &gormRecurringTemplateRepo{db: db},
+		BankStatements:     &gormBankStatementRepo{db: db},
+		TransactionFlags:   &gormTransactionFlagRepo{db: db},
+
+		CategorizationRules: &gormCategorizationRuleRepo{db: db},
+		Events:              &gormEventRepo{db: db},
+
+		IdempotencyKeys: &gormIdempotencyKeyRepo{db: db},
+
+		transaction: func(fn func(repos *Repositories) error) error {
+			return db.Transaction(func(tx *gorm.DB) error {
+				return fn(NewGormRepositories(tx))
+			})
+		},
+		tryLock: func(key int64, fn func() error) (bool, error) {
+			return gormTryLock(db, key, fn)
+		},
+	}
+}
+
+// gormTryLock takes a session advisory lock on PostgreSQL, on a connection
+// of its own that is kept until fn returns. SQLite databases are local
+// files served by a single process, so fn simply runs there.
+func gormTryLock(db *gorm.DB, key int64, fn func() error) (bool, error) {
+	if db.Dialector.Name() != "postgres" {
+		return true, fn()
+	}
+	acquired := false
+	err := db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Raw("SELECT pg_try_advisory_lock(?)", key).Scan(&acquired).Error; err != nil {
+			return err
+		}
+		if !acquired {
+			return nil
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", key)
+		return fn()
+	})
+	return acquired, err
+}
+
+// translateError maps GORM errors to repository errors.
+func translateError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// translateDuplicate returns ErrDuplicate when the driver reports err as a
+// unique violation, and err otherwise. It asks the dialector directly, so
+// that other repositories keep the driver's own errors.
+func translateDuplicate(db *gorm.DB, err error) error {
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok && errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+// deleteByID deletes the row of model with the given ID and returns
+// ErrNotFound when nothing was deleted.
+func deleteByID(db *gorm.DB, model interface{}, id uuid.UUID) error {
+	result := db.Where("id = ?", id).Delete(model)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// updateVersioned saves every column of model, the row with the given id,
+// if the stored row is still at *version, and raises *version.
+func updateVersioned(db *gorm.DB, model interface{}, id uuid.UUID, version *int) error {
+	current := *version
+	*version = current + 1
+	result := db.Model(model).Where("id = ? AND version = ?", id, current).
+		Select("*").Omit(clause.Associations).Updates(model)
+	if result.Error == nil && result.RowsAffected == 1 {
+		return nil
+	}
+	*version = current
+	if result.Error != nil {
+		return result.Error
+	}
+
+	var count int64
+	if err := db.Model(model).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return ErrVersionConflict
+}
+
+type gormFundRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormFundRepo) List() ([]models.Fund, error) {
+	var funds []models.Fund
+	err := r.db.Find(&funds).Error
+	return funds, err
+}
+
+func (r *gormFundRepo) FindByID(id uuid.UUID) (*models.Fund, error) {
+	var fund models.Fund
+	if err := r.db.Where("id = ?", id).First(&fund).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &fund, nil
+}
+
+func (r *gormFundRepo) Create(fund *models.Fund) error {
+	return r.db.Create(fund).Error
+}
+
+func (r *gormFundRepo) Update(fund *models.Fund) error {
+	return updateVersioned(r.db, fund, fund.ID, &fund.Version)
+}
+
+func (r *gormFundRepo) Delete(id uuid.UUID) error {
+	return deleteByID(r.db, &models.Fund{}, id)
+}
+
+type gormCategoryRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormCategoryRepo) List() ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.Find(&categories).Error
+	return categories, err
+}
+
+func (r *gormCategoryRepo) FindByID(id uuid.UUID) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.Where("id = ?", id).First(&category).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &category, nil
+}
+
+func (r *gormCategoryRepo) Create(category *models.Category) error {
+	return r.db.Create(category).Error
+}
+
+func (r *gormCategoryRepo) Update(category *models.Category) error {
+	return updateVersioned(r.db, category, category.ID, &category.Version)
+}
+
+func (r *gormCategoryRepo) Delete(id uuid.UUID) error {
+	return deleteByID(r.db, &models.Category{}, id)
+}
+
+func (r *gormCategoryRepo) CountTransactions(id uuid.UUID) (map[string]int, error) {
+	var rows []struct {
+		Type  string
+		Count int
+	}
+	lines := r.db.Model(&models.TransactionLine{}).Select("1").
+		Where("transaction_lines.transaction_id = transactions.id AND transaction_lines.category_id = ?", id)
+	err := r.db.Model(&models.Transaction{}).
+		Select("type, COUNT(*) as count").
+		Where("category_id = ? OR EXISTS (?)", id, lines).
+		Group("type").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := map[string]int{}
+	for _, row := range rows {
+		counts[row.Type] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *gormCategoryRepo) TransactionDates(id uuid.UUID) ([]time.Time, error) {
+	var dates []time.Time
+	lines := r.db.Model(&models.TransactionLine{}).Select("1").
+		Where("transaction_lines.transaction_id = transactions.id AND transaction_lines.category_id = ?", id)
+	err := r.db.Model(&models.Transaction{}).
+		Where("category_id = ? OR EXISTS (?)", id, lines).
+		Distinct().Pluck("date", &dates).Error
+	return dates, err
+}
+
+// Relabel and Merge leave the version of the transactions alone: the
+// category name is a label, not an edit of the transaction.
+func (r *gormCategoryRepo) Relabel(id uuid.UUID, name string) error {
+	for _, model := range []interface{}{&models.Transaction{}, &models.TransactionLine{}} {
+		if err := r.db.Model(model).Where("category_id = ?", id).UpdateColumn("category", name).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *gormCategoryRepo) Merge(from uuid.UUID, into *models.Category) error {
+	for _, model := range []interface{}{&models.Transaction{}, &models.TransactionLine{}} {
+		err := r.db.Model(model).Where("category_id = ?", from).
+			UpdateColumns(map[string]interface{}{"category_id": into.ID, "category": into.Name}).Error
+		if err != nil {
+			return err
+		}
+	}
+	err := r.db.Model(&models.Category{}).Where("parent_id = ?", from).
+		UpdateColumns(map[string]interface{}{"parent_id": into.ID, "version": gorm.Expr("version + 1")}).Error
+	if err != nil {
+		return err
+	}
+	return deleteByID(r.db, &models.Category{}, from)
+}
+
+type gormUserRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormUserRepo) List() ([]models.User, error) {
+	var users []models.User
+	err := r.db.Order("created_at DESC").Find(&users).Error
+	return users, err
+}
+
+func (r *gormUserRepo) FindByID(id uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := r.db.Where("id = ?", id).First(&user).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepo) FindByEmail(email string) (*models.User, error) {
+	var user models.User
+	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepo) Create(user *models.User) error {
+	return r.db.Create(user).Error
+}
+
+func (r *gormUserRepo) Update(user *models.User) error {
+	return r.db.Save(user).Error
+}
+
+func (r *gormUserRepo) Delete(id uuid.UUID) error {
+	return deleteByID(r.db, &models.User{}, id)
+}
+
+type gormActivityLogRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormActivityLogRepo) Create(log *models.ActivityLog) error {
+	return r.db.Create(log).Error
+}
+
+func (r *gormActivityLogRepo) filtered(filter ActivityLogFilter) *gorm.DB {
+	query := r.db.Model(&models.ActivityLog{})
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != uuid.Nil {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.UserID != uuid.Nil {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if !filter.StartDate.IsZero() {
+		query = query.Where("timestamp >= ?", filter.StartDate)
+	}
+	if !filter.EndDate.IsZero() {
+		query = query.Where("timestamp < ?", filter.EndDate.AddDate(0, 0, 1))
+	}
+	return query
+}
+
+func (r *gormActivityLogRepo) List(filter ActivityLogFilter, limit int, cursor string) (*ActivityLogPage, error) {
+	var total int64
+	if err := r.filtered(filter).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	query := r.filtered(filter).Preload("User").Order("timestamp DESC").Order("id DESC")
+	if cursor != "" {
+		position, err := decodeActivityLogCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("(timestamp < ? OR (timestamp = ? AND id < ?))",
+			position.Timestamp, position.Timestamp, position.ID)
+	}
+
+	var logs []models.ActivityLog
+	if err := query.Limit(limit + 1).Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	return activityLogPage(logs, total, limit), nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"gkjw-finance-backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormApprovalPolicyRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormApprovalPolicyRepo) withSteps() *gorm.DB {
+	return r.db.Preload("Fund").
+		Preload("Steps", func(db *gorm.DB) *gorm.DB { return db.Order("step_order ASC") }).
+		Preload("Steps.User")
+}
+
+func (r *gormApprovalPolicyRepo) List() ([]models.ApprovalPolicy, error) {
+	var policies []models.ApprovalPolicy
+	err := r.withSteps().Order("priority ASC, created_at ASC").Find(&policies).Error
+	return policies, err
+}
+
+func (r *gormApprovalPolicyRepo) FindByID(id uuid.UUID) (*models.ApprovalPolicy, error) {
+	var policy models.ApprovalPolicy
+	if err := r.withSteps().Where("id = ?", id).First(&policy).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &policy, nil
+}
+
+func (r *gormApprovalPolicyRepo) createSteps(policy *models.ApprovalPolicy) error {
+	for i := range policy.Steps {
+		policy.Steps[i].PolicyID = policy.ID
+		if err := r.db.Omit(clause.Associations).Create(&policy.Steps[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *gormApprovalPolicyRepo) Create(policy *models.ApprovalPolicy) error {
+	if err := r.db.Omit(clause.Associations).Create(policy).Error; err != nil {
+		return err
+	}
+	return r.createSteps(policy)
+}
+
+func (r *gormApprovalPolicyRepo) Update(policy *models.ApprovalPolicy) error {
+	if err := r.db.Omit(clause.Associations).Save(policy).Error; err != nil {
+		return err
+	}
+	if err := r.db.Where("policy_id = ?", policy.ID).Delete(&models.ApprovalStep{}).Error; err != nil {
+		return err
+	}
+	for i := range policy.Steps {
+		policy.Steps[i].ID = uuid.Nil
+	}
+	return r.createSteps(policy)
+}
+
+func (r *gormApprovalPolicyRepo) Delete(id uuid.UUID) error {
+	if err := r.db.Where("policy_id = ?", id).Delete(&models.ApprovalStep{}).Error; err != nil {
+		return err
+	}
+	// Signatures outlive their policy.
+	err := r.db.Model(&models.Approval{}).Where("policy_id = ?", id).Update("policy_id", nil).Error
+	if err != nil {
+		return err
+	}
+	return deleteByID(r.db, &models.ApprovalPolicy{}, id)
+}
+
+type gormApprovalRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormApprovalRepo) ListByTransaction(transactionID uuid.UUID) ([]models.Approval, error) {
+	var approvals []models.Approval
+	err := r.db.Preload("Approver").
+		Where("transaction_id = ?", transactionID).
+		Order("created_at ASC, step_order ASC").
+		Find(&approvals).Error
+	return approvals, err
+}
+
+func (r *gormApprovalRepo) Create(approval *models.Approval) error {
+	return r.db.Omit(clause.Associations).Create(approval).Error
+}
+
+func (r *gormApprovalRepo) Supersede(transactionID uuid.UUID) error {
+	return r.db.Model(&models.Approval{}).
+		Where("transaction_id = ? AND superseded = ?", transactionID, false).
+		Update("superseded", true).Error
+}
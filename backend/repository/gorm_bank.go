@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"gkjw-finance-backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormBankStatementRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormBankStatementRepo) List() ([]models.BankStatement, error) {
+	var statements []models.BankStatement
+	err := r.db.Preload("Fund").Order("end_date DESC, created_at DESC").Find(&statements).Error
+	return statements, err
+}
+
+func (r *gormBankStatementRepo) FindByID(id uuid.UUID) (*models.BankStatement, error) {
+	var statement models.BankStatement
+	err := r.db.Preload("Fund").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Where("id = ?", id).
+		First(&statement).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &statement, nil
+}
+
+func (r *gormBankStatementRepo) FindByChecksum(checksum string) (*models.BankStatement, error) {
+	var statement models.BankStatement
+	if err := r.db.Where("checksum = ?", checksum).First(&statement).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &statement, nil
+}
+
+func (r *gormBankStatementRepo) Create(statement *models.BankStatement) error {
+	if err := r.db.Omit(clause.Associations).Create(statement).Error; err != nil {
+		return err
+	}
+	for i := range statement.Lines {
+		statement.Lines[i].StatementID = statement.ID
+		statement.Lines[i].Position = i
+		if err := r.db.Omit(clause.Associations).Create(&statement.Lines[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *gormBankStatementRepo) Delete(id uuid.UUID) error {
+	if err := r.db.Where("statement_id = ?", id).Delete(&models.BankStatementLine{}).Error; err != nil {
+		return err
+	}
+	return deleteByID(r.db, &models.BankStatement{}, id)
+}
+
+func (r *gormBankStatementRepo) ListLines(status string) ([]models.BankStatementLine, error) {
+	query := r.db.Model(&models.BankStatementLine{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var lines []models.BankStatementLine
+	err := query.Order("date ASC, statement_id ASC, position ASC").Find(&lines).Error
+	return lines, err
+}
+
+func (r *gormBankStatementRepo) UpdateLine(line *models.BankStatementLine) error {
+	return r.db.Omit(clause.Associations).Save(line).Error
+}
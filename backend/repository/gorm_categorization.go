@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"gkjw-finance-backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormCategorizationRuleRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormCategorizationRuleRepo) List() ([]models.CategorizationRule, error) {
+	var rules []models.CategorizationRule
+	err := r.db.Preload("SetFund").Order("priority ASC, created_at ASC").Find(&rules).Error
+	return rules, err
+}
+
+func (r *gormCategorizationRuleRepo) FindByID(id uuid.UUID) (*models.CategorizationRule, error) {
+	var rule models.CategorizationRule
+	if err := r.db.Preload("SetFund").Where("id = ?", id).First(&rule).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &rule, nil
+}
+
+func (r *gormCategorizationRuleRepo) Create(rule *models.CategorizationRule) error {
+	return r.db.Omit(clause.Associations).Create(rule).Error
+}
+
+func (r *gormCategorizationRuleRepo) Update(rule *models.CategorizationRule) error {
+	return r.db.Omit(clause.Associations).Save(rule).Error
+}
+
+func (r *gormCategorizationRuleRepo) Delete(id uuid.UUID) error {
+	return deleteByID(r.db, &models.CategorizationRule{}, id)
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"gkjw-finance-backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type gormEventRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormEventRepo) List() ([]models.Event, error) {
+	var events []models.Event
+	err := r.db.Preload("Fund").Order("start_date DESC, created_at DESC").Find(&events).Error
+	return events, err
+}
+
+func (r *gormEventRepo) FindByID(id uuid.UUID) (*models.Event, error) {
+	var event models.Event
+	if err := r.db.Preload("Fund").Where("id = ?", id).First(&event).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &event, nil
+}
+
+func (r *gormEventRepo) Create(event *models.Event) error {
+	return translateDuplicate(r.db, r.db.Omit("Fund").Create(event).Error)
+}
+
+func (r *gormEventRepo) Update(event *models.Event) error {
+	return translateDuplicate(r.db, updateVersioned(r.db, event, event.ID, &event.Version))
+}
+
+func (r *gormEventRepo) Delete(id uuid.UUID) error {
+	return deleteByID(r.db, &models.Event{}, id)
+}
+
+func (r *gormEventRepo) Relabel(id uuid.UUID, name string) error {
+	return r.db.Model(&models.Transaction{}).Where("event_id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"event_name": name,
+			"version":    gorm.Expr("version + 1"),
+		}).Error
+}
+
+func (r *gormEventRepo) Link(event *models.Event, transactionIDs []uuid.UUID) error {
+	if len(transactionIDs) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.Transaction{}).Where("id IN ?", transactionIDs).
+		UpdateColumns(map[string]interface{}{
+			"event_id":   event.ID,
+			"event_name": event.Name,
+			"version":    gorm.Expr("version + 1"),
+		}).Error
+}
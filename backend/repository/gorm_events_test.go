@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"errors"
+	"gkjw-finance-backend/migrations"
+	"gkjw-finance-backend/models"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// TestGormEventNamesAreUnique runs the migrations on SQLite and checks that
+// the unique index on event names surfaces as ErrDuplicate.
+func TestGormEventNamesAreUnique(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner, err := migrations.NewRunner(db, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(); err != nil {
+		t.Fatal(err)
+	}
+	events := NewGormRepositories(db).Events
+
+	natal := models.Event{Name: "Natal 2025", StartDate: time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC)}
+	paskah := models.Event{Name: "Paskah 2025", StartDate: time.Date(2025, 4, 20, 0, 0, 0, 0, time.UTC)}
+	for _, event := range []*models.Event{&natal, &paskah} {
+		if err := events.Create(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	duplicate := models.Event{Name: "NATAL 2025", StartDate: natal.StartDate}
+	if err := events.Create(&duplicate); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Create: err = %v, want ErrDuplicate", err)
+	}
+	paskah.Name = "natal 2025"
+	if err := events.Update(&paskah); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Update: err = %v, want ErrDuplicate", err)
+	}
+	if paskah.Version != 1 {
+		t.Errorf("version = %d after a refused update, want 1", paskah.Version)
+	}
+}
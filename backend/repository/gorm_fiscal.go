@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"gkjw-finance-backend/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormFiscalYearRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormFiscalYearRepo) List() ([]models.FiscalYear, error) {
+	var years []models.FiscalYear
+	err := r.db.Order("start_date ASC").Find(&years).Error
+	return years, err
+}
+
+func (r *gormFiscalYearRepo) FindByID(id uuid.UUID) (*models.FiscalYear, error) {
+	var year models.FiscalYear
+	if err := r.db.Where("id = ?", id).First(&year).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &year, nil
+}
+
+func (r *gormFiscalYearRepo) Create(year *models.FiscalYear) error {
+	return r.db.Create(year).Error
+}
+
+func (r *gormFiscalYearRepo) Update(year *models.FiscalYear) error {
+	return r.db.Save(year).Error
+}
+
+type gormOpeningBalanceRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormOpeningBalanceRepo) List(filter OpeningBalanceFilter) ([]models.OpeningBalance, error) {
+	query := r.db.Preload("Fund")
+	if filter.FundID != uuid.Nil {
+		query = query.Where("fund_id = ?", filter.FundID)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("as_of <= ?", filter.Until)
+	}
+
+	var balances []models.OpeningBalance
+	err := query.Order("as_of ASC").Find(&balances).Error
+	return balances, err
+}
+
+func (r *gormOpeningBalanceRepo) FindByID(id uuid.UUID) (*models.OpeningBalance, error) {
+	var balance models.OpeningBalance
+	if err := r.db.Preload("Fund").Where("id = ?", id).First(&balance).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &balance, nil
+}
+
+func (r *gormOpeningBalanceRepo) FindByKey(fundID uuid.UUID, paymentMethod string, asOf time.Time) (*models.OpeningBalance, error) {
+	var balance models.OpeningBalance
+	err := r.db.Where("fund_id = ? AND payment_method = ? AND as_of = ?", fundID, paymentMethod, asOf).
+		First(&balance).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &balance, nil
+}
+
+func (r *gormOpeningBalanceRepo) Create(balance *models.OpeningBalance) error {
+	return r.db.Omit(clause.Associations).Create(balance).Error
+}
+
+func (r *gormOpeningBalanceRepo) Update(balance *models.OpeningBalance) error {
+	return r.db.Omit(clause.Associations).Save(balance).Error
+}
+
+func (r *gormOpeningBalanceRepo) Delete(id uuid.UUID) error {
+	return deleteByID(r.db, &models.OpeningBalance{}, id)
+}
+
+type gormAccountingPeriodRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormAccountingPeriodRepo) List() ([]models.AccountingPeriod, error) {
+	var periods []models.AccountingPeriod
+	err := r.db.Order("start_date ASC").Find(&periods).Error
+	return periods, err
+}
+
+func (r *gormAccountingPeriodRepo) FindByID(id uuid.UUID) (*models.AccountingPeriod, error) {
+	var period models.AccountingPeriod
+	if err := r.db.Where("id = ?", id).First(&period).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &period, nil
+}
+
+func (r *gormAccountingPeriodRepo) FindByName(name string) (*models.AccountingPeriod, error) {
+	var period models.AccountingPeriod
+	if err := r.db.Where("name = ?", name).First(&period).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &period, nil
+}
+
+func (r *gormAccountingPeriodRepo) FindClosedOn(date time.Time) (*models.AccountingPeriod, error) {
+	var period models.AccountingPeriod
+	err := r.db.Where("status = ? AND start_date <= ? AND end_date >= ?", "closed", date, date).
+		Order("start_date ASC").
+		First(&period).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &period, nil
+}
+
+func (r *gormAccountingPeriodRepo) Create(period *models.AccountingPeriod) error {
+	return r.db.Create(period).Error
+}
+
+func (r *gormAccountingPeriodRepo) Update(period *models.AccountingPeriod) error {
+	return r.db.Save(period).Error
+}
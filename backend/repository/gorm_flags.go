@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"gkjw-finance-backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormTransactionFlagRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormTransactionFlagRepo) List(status, kind string) ([]models.TransactionFlag, error) {
+	query := r.db.Preload("Transaction").Preload("Transaction.Fund").Preload("Transaction.CreatedByUser").
+		Preload("Transaction.Lines", orderLines)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	var flags []models.TransactionFlag
+	err := query.Order("created_at DESC, id DESC").Find(&flags).Error
+	return flags, err
+}
+
+func (r *gormTransactionFlagRepo) FindByID(id uuid.UUID) (*models.TransactionFlag, error) {
+	var flag models.TransactionFlag
+	if err := r.db.Where("id = ?", id).First(&flag).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &flag, nil
+}
+
+func (r *gormTransactionFlagRepo) ListByTransaction(transactionID uuid.UUID) ([]models.TransactionFlag, error) {
+	var flags []models.TransactionFlag
+	err := r.db.Where("transaction_id = ?", transactionID).Order("created_at ASC").Find(&flags).Error
+	return flags, err
+}
+
+func (r *gormTransactionFlagRepo) Create(flag *models.TransactionFlag) error {
+	return r.db.Omit(clause.Associations).Create(flag).Error
+}
+
+func (r *gormTransactionFlagRepo) Update(flag *models.TransactionFlag) error {
+	return r.db.Omit(clause.Associations).Save(flag).Error
+}
+
+func (r *gormTransactionFlagRepo) DeleteOpen(transactionID uuid.UUID) error {
+	return r.db.Where("transaction_id = ? AND status = ?", transactionID, "open").Delete(&models.TransactionFlag{}).Error
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"gkjw-finance-backend/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormIdempotencyKeyRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormIdempotencyKeyRepo) Claim(key *models.IdempotencyKey) (bool, error) {
+	if err := r.db.Where("expires_at < ?", time.Now()).Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return false, err
+	}
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return true, nil
+	}
+	result = r.db.Model(&models.IdempotencyKey{}).
+		Where("user_id = ? AND idempotency_key = ? AND status_code = 0 AND locked_until < ?", key.UserID, key.Key, time.Now()).
+		Updates(map[string]interface{}{
+			"method":       key.Method,
+			"path":         key.Path,
+			"request_hash": key.RequestHash,
+			"created_at":   key.CreatedAt,
+			"locked_until": key.LockedUntil,
+			"expires_at":   key.ExpiresAt,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *gormIdempotencyKeyRepo) Find(userID uuid.UUID, key string) (*models.IdempotencyKey, error) {
+	var found models.IdempotencyKey
+	err := r.db.Where("user_id = ? AND idempotency_key = ?", userID, key).First(&found).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &found, nil
+}
+
+func (r *gormIdempotencyKeyRepo) Complete(key *models.IdempotencyKey) error {
+	return r.db.Model(&models.IdempotencyKey{}).
+		Where("user_id = ? AND idempotency_key = ?", key.UserID, key.Key).
+		Updates(map[string]interface{}{
+			"status_code":   key.StatusCode,
+			"response_body": key.ResponseBody,
+		}).Error
+}
+
+func (r *gormIdempotencyKeyRepo) Release(userID uuid.UUID, key string) error {
+	return r.db.Where("user_id = ? AND idempotency_key = ?", userID, key).
+		Delete(&models.IdempotencyKey{}).Error
+}
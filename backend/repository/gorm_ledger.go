@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"gkjw-finance-backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type gormAccountRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormAccountRepo) List() ([]models.Account, error) {
+	var accounts []models.Account
+	err := r.db.Order("code ASC").Find(&accounts).Error
+	return accounts, err
+}
+
+func (r *gormAccountRepo) FindByID(id uuid.UUID) (*models.Account, error) {
+	var account models.Account
+	if err := r.db.Where("id = ?", id).First(&account).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &account, nil
+}
+
+func (r *gormAccountRepo) FindByCode(code string) (*models.Account, error) {
+	var account models.Account
+	if err := r.db.Where("code = ?", code).First(&account).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &account, nil
+}
+
+func (r *gormAccountRepo) Create(account *models.Account) error {
+	return r.db.Create(account).Error
+}
+
+func (r *gormAccountRepo) Update(account *models.Account) error {
+	return r.db.Save(account).Error
+}
+
+type gormJournalRepo struct {
+	db *gorm.DB
+}
+
+// filteredLines returns a query on journal_lines joined to their entries
+// with filter applied.
+func (r *gormJournalRepo) filteredLines(filter LedgerFilter) *gorm.DB {
+	query := r.db.Model(&models.JournalLine{}).
+		Joins("JOIN journal_entries ON journal_entries.id = journal_lines.entry_id")
+
+	if filter.AccountID != uuid.Nil {
+		query = query.Where("journal_lines.account_id = ?", filter.AccountID)
+	}
+	if filter.FundID != uuid.Nil {
+		query = query.Where("journal_lines.fund_id = ?", filter.FundID)
+	}
+	if !filter.StartDate.IsZero() {
+		query = query.Where("journal_entries.date >= ?", filter.StartDate)
+	}
+	if !filter.EndDate.IsZero() {
+		query = query.Where("journal_entries.date <= ?", filter.EndDate)
+	}
+	return query
+}
+
+func (r *gormJournalRepo) withLines() *gorm.DB {
+	return r.db.Preload("Lines.Account")
+}
+
+func (r *gormJournalRepo) FindByID(id uuid.UUID) (*models.JournalEntry, error) {
+	var entry models.JournalEntry
+	if err := r.withLines().Where("id = ?", id).First(&entry).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &entry, nil
+}
+
+func (r *gormJournalRepo) List(filter LedgerFilter) ([]models.JournalEntry, error) {
+	var entries []models.JournalEntry
+	err := r.withLines().
+		Where("id IN (?)", r.filteredLines(filter).Select("journal_lines.entry_id")).
+		Order("date ASC, created_at ASC").
+		Find(&entries).Error
+	return entries, err
+}
+
+func (r *gormJournalRepo) FindActiveByTransaction(transactionID uuid.UUID) (*models.JournalEntry, error) {
+	var entry models.JournalEntry
+	err := r.withLines().
+		Where("transaction_id = ? AND source = ? AND reversed_by_id IS NULL", transactionID, "transaction").
+		Order("created_at DESC").
+		First(&entry).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &entry, nil
+}
+
+func (r *gormJournalRepo) Create(entry *models.JournalEntry) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *gormJournalRepo) MarkReversed(id, reversalID uuid.UUID) error {
+	result := r.db.Model(&models.JournalEntry{}).Where("id = ?", id).Update("reversed_by_id", reversalID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormJournalRepo) Lines(filter LedgerFilter) ([]models.JournalLine, error) {
+	var lines []models.JournalLine
+	err := r.filteredLines(filter).
+		Preload("Entry").
+		Order("journal_entries.date ASC, journal_entries.created_at ASC").
+		Find(&lines).Error
+	return lines, err
+}
+
+func (r *gormJournalRepo) Balances(filter LedgerFilter) ([]AccountBalance, error) {
+	var balances []AccountBalance
+	err := r.filteredLines(filter).
+		Select("journal_lines.account_id, COALESCE(SUM(journal_lines.debit), 0) as debit, COALESCE(SUM(journal_lines.credit), 0) as credit").
+		Group("journal_lines.account_id").
+		Scan(&balances).Error
+	return balances, err
+}
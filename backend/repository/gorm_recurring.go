@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"gkjw-finance-backend/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormRecurringTemplateRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormRecurringTemplateRepo) List() ([]models.RecurringTemplate, error) {
+	var templates []models.RecurringTemplate
+	err := r.db.Preload("Fund").Order("name ASC, created_at ASC").Find(&templates).Error
+	return templates, err
+}
+
+func (r *gormRecurringTemplateRepo) ListDue(date time.Time) ([]models.RecurringTemplate, error) {
+	var templates []models.RecurringTemplate
+	err := r.db.Where("status = ? AND next_run_date <= ?", "active", date).
+		Order("next_run_date ASC, created_at ASC").
+		Find(&templates).Error
+	return templates, err
+}
+
+func (r *gormRecurringTemplateRepo) FindByID(id uuid.UUID) (*models.RecurringTemplate, error) {
+	var template models.RecurringTemplate
+	if err := r.db.Preload("Fund").Where("id = ?", id).First(&template).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &template, nil
+}
+
+func (r *gormRecurringTemplateRepo) Create(template *models.RecurringTemplate) error {
+	return r.db.Omit(clause.Associations).Create(template).Error
+}
+
+func (r *gormRecurringTemplateRepo) Update(template *models.RecurringTemplate) error {
+	return updateVersioned(r.db, template, template.ID, &template.Version)
+}
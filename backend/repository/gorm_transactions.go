@@ -0,0 +1,316 @@
+package repository
+
+import (
+	"fmt"
+	"gkjw-finance-backend/models"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormTransactionRepo struct {
+	db *gorm.DB
+}
+
+// filtered returns a fresh query on transactions with filter applied. A
+// transaction matches the fund and category of the filter when any of its
+// lines does.
+func (r *gormTransactionRepo) filtered(filter TransactionFilter) *gorm.DB {
+	query := r.common(r.db.Model(&models.Transaction{}), filter)
+	if filter.FundID != uuid.Nil || filter.Category != "" || len(filter.CategoryIDs) > 0 {
+		lines := r.db.Model(&models.TransactionLine{}).Select("1").
+			Where("transaction_lines.transaction_id = transactions.id")
+		if filter.FundID != uuid.Nil {
+			lines = lines.Where("transaction_lines.fund_id = ?", filter.FundID)
+		}
+		if filter.Category != "" {
+			lines = lines.Where("transaction_lines.category = ?", filter.Category)
+		}
+		if len(filter.CategoryIDs) > 0 {
+			lines = lines.Where("transaction_lines.category_id IN ?", filter.CategoryIDs)
+		}
+		header := r.db
+		if filter.FundID != uuid.Nil {
+			header = header.Where("(transactions.fund_id = ? OR transactions.to_fund_id = ?)", filter.FundID, filter.FundID)
+		}
+		if filter.Category != "" {
+			header = header.Where("transactions.category = ?", filter.Category)
+		}
+		if len(filter.CategoryIDs) > 0 {
+			header = header.Where("transactions.category_id IN ?", filter.CategoryIDs)
+		}
+		// Transactions without lines (transfers) match on their own fund
+		// and category.
+		query = query.Where(
+			r.db.Where("EXISTS (?)", lines).
+				Or(r.db.Where("NOT EXISTS (?)", r.db.Model(&models.TransactionLine{}).Select("1").
+					Where("transaction_lines.transaction_id = transactions.id")).Where(header)))
+	}
+	return query
+}
+
+// splits returns a query with one row per line of the transactions matching
+// filter, and one for each transaction without lines, restricted to the
+// lines the fund and category of the filter select (see
+// TransactionFilter.Lines). splitFund, splitCategory and splitAmount are
+// the columns of a row.
+func (r *gormTransactionRepo) splits(filter TransactionFilter) *gorm.DB {
+	query := r.common(r.db.Model(&models.Transaction{}), filter).
+		Joins("LEFT JOIN transaction_lines ON transaction_lines.transaction_id = transactions.id")
+	if filter.FundID != uuid.Nil {
+		query = query.Where("("+splitFund+" = ? OR transactions.to_fund_id = ?)", filter.FundID, filter.FundID)
+	}
+	if filter.Category != "" {
+		query = query.Where(splitCategory+" = ?", filter.Category)
+	}
+	if len(filter.CategoryIDs) > 0 {
+		query = query.Where(splitCategoryID+" IN ?", filter.CategoryIDs)
+	}
+	return query
+}
+
+const (
+	splitFund       = "COALESCE(transaction_lines.fund_id, transactions.fund_id)"
+	splitCategoryID = "COALESCE(transaction_lines.category_id, transactions.category_id)"
+	splitCategory   = "COALESCE(transaction_lines.category, transactions.category)"
+	splitAmount     = "COALESCE(transaction_lines.amount, transactions.amount)"
+)
+
+// common applies every part of filter but the fund and category to query.
+func (r *gormTransactionRepo) common(query *gorm.DB, filter TransactionFilter) *gorm.DB {
+	switch filter.Status {
+	case "":
+	case StatusPosted:
+		query = query.Where("transactions.status IN ?", []string{"approved", "voided"})
+	default:
+		query = query.Where("transactions.status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		query = query.Where("transactions.type = ?", filter.Type)
+	}
+	if filter.PaymentMethod != "" {
+		query = query.Where("(transactions.payment_method = ? OR transactions.to_payment_method = ?)", filter.PaymentMethod, filter.PaymentMethod)
+	}
+	if filter.EventID != uuid.Nil {
+		query = query.Where("transactions.event_id = ?", filter.EventID)
+	}
+	if filter.CreatedBy != uuid.Nil {
+		query = query.Where("transactions.created_by = ?", filter.CreatedBy)
+	}
+	if !filter.StartDate.IsZero() {
+		query = query.Where("transactions.date >= ?", filter.StartDate)
+	}
+	if !filter.EndDate.IsZero() {
+		query = query.Where("transactions.date <= ?", filter.EndDate)
+	}
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		pattern := "%" + strings.ToLower(q) + "%"
+		lines := r.db.Model(&models.TransactionLine{}).Select("1").
+			Where("transaction_lines.transaction_id = transactions.id AND LOWER(transaction_lines.category) LIKE ?", pattern)
+		query = query.Where("(LOWER(transactions.event_name) LIKE ? OR LOWER(transactions.description) LIKE ? OR LOWER(transactions.category) LIKE ? OR EXISTS (?))",
+			pattern, pattern, pattern, lines)
+	}
+
+	return query
+}
+
+func (r *gormTransactionRepo) FindByID(id uuid.UUID) (*models.Transaction, error) {
+	var transaction models.Transaction
+	if err := r.db.Preload("CreatedByUser").Preload("Fund").Preload("ToFund").Preload("Lines", orderLines).Preload("Flags", orderFlags).Where("id = ?", id).First(&transaction).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &transaction, nil
+}
+
+func (r *gormTransactionRepo) List(filter TransactionFilter, page PageRequest) (*TransactionPage, error) {
+	column, ok := transactionSortColumns[page.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort %q", page.Sort)
+	}
+
+	result := &TransactionPage{}
+	if err := r.filtered(filter).Count(&result.Total).Error; err != nil {
+		return nil, err
+	}
+
+	// Sum income and expense within the selection; a type filter for the
+	// other type (or transfer) leaves the total at zero.
+	var err error
+	if filter.Type == "" || filter.Type == "income" {
+		incomeFilter := filter
+		incomeFilter.Type = "income"
+		if result.TotalIncome, err = r.Sum(incomeFilter); err != nil {
+			return nil, err
+		}
+	}
+	if filter.Type == "" || filter.Type == "expense" {
+		expenseFilter := filter
+		expenseFilter.Type = "expense"
+		if result.TotalExpense, err = r.Sum(expenseFilter); err != nil {
+			return nil, err
+		}
+	}
+
+	order, comparator := "ASC", ">"
+	if page.Desc {
+		order, comparator = "DESC", "<"
+	}
+
+	query := r.filtered(filter).Preload("CreatedByUser").Preload("Fund").Preload("ToFund").Preload("Lines", orderLines).Preload("Flags", orderFlags).
+		Order(column + " " + order).
+		Order("id " + order)
+
+	if page.Cursor != "" {
+		value, id, err := decodeTransactionCursor(page.Sort, page.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(
+			fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", column, comparator, column, comparator),
+			value, value, id)
+	}
+
+	if err := query.Limit(page.Limit + 1).Find(&result.Items).Error; err != nil {
+		return nil, err
+	}
+
+	if len(result.Items) > page.Limit {
+		result.Items = result.Items[:page.Limit]
+		result.HasMore = true
+		result.NextCursor = encodeTransactionCursor(page.Sort, result.Items[page.Limit-1])
+	}
+
+	return result, nil
+}
+
+func (r *gormTransactionRepo) FindAll(filter TransactionFilter) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.filtered(filter).Preload("CreatedByUser").Preload("Fund").Preload("ToFund").Preload("Lines", orderLines).Preload("Flags", orderFlags).
+		Order("date ASC").
+		Find(&transactions).Error
+	return transactions, err
+}
+
+func (r *gormTransactionRepo) Count(filter TransactionFilter) (int64, error) {
+	var count int64
+	err := r.filtered(filter).Count(&count).Error
+	return count, err
+}
+
+func (r *gormTransactionRepo) Sum(filter TransactionFilter) (models.Money, error) {
+	var sum models.Money
+	err := r.splits(filter).Select("COALESCE(SUM(" + splitAmount + "), 0)").Scan(&sum).Error
+	return sum, err
+}
+
+func (r *gormTransactionRepo) SumByCategory(filter TransactionFilter) ([]CategoryTotal, error) {
+	var totals []CategoryTotal
+	err := r.splits(filter).
+		Select(splitCategoryID + " as category_id, " + splitCategory + " as category, COALESCE(SUM(" + splitAmount + "), 0) as amount").
+		Group(splitCategoryID + ", " + splitCategory).
+		Scan(&totals).Error
+	return totals, err
+}
+
+func (r *gormTransactionRepo) SumByFundAndMethod(filter TransactionFilter) ([]FundMethodTotal, error) {
+	var totals fundMethodTotals
+
+	var outgoing []struct {
+		FundID        uuid.UUID
+		PaymentMethod string
+		Type          string
+		Amount        models.Money
+	}
+	err := r.splits(filter).
+		Select(splitFund + " as fund_id, transactions.payment_method, transactions.type, COALESCE(SUM(" + splitAmount + "), 0) as amount").
+		Group(splitFund + ", transactions.payment_method, transactions.type").
+		Scan(&outgoing).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range outgoing {
+		totals.add(row.FundID, row.PaymentMethod, row.Type, row.Amount)
+	}
+
+	if filter.Type == "" || filter.Type == "transfer" {
+		var incoming []struct {
+			FundID        uuid.UUID
+			PaymentMethod string
+			Amount        models.Money
+		}
+		err := r.filtered(filter).
+			Where("type = ?", "transfer").
+			Select("to_fund_id as fund_id, to_payment_method as payment_method, COALESCE(SUM(amount), 0) as amount").
+			Group("to_fund_id, to_payment_method").
+			Scan(&incoming).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range incoming {
+			totals.addTransferIn(row.FundID, row.PaymentMethod, row.Amount)
+		}
+	}
+
+	return totals.rows, nil
+}
+
+func orderLines(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC")
+}
+
+func orderFlags(db *gorm.DB) *gorm.DB {
+	return db.Order("created_at ASC")
+}
+
+// numberLines links the lines of transaction to it and numbers them in
+// order, giving new lines an ID.
+func numberLines(transaction *models.Transaction) {
+	for i := range transaction.Lines {
+		line := &transaction.Lines[i]
+		if line.ID == uuid.Nil {
+			line.ID = uuid.New()
+		}
+		line.TransactionID = transaction.ID
+		line.Position = i
+	}
+}
+
+// saveLines replaces the stored lines of transaction with its Lines.
+func (r *gormTransactionRepo) saveLines(transaction *models.Transaction) error {
+	if err := r.db.Where("transaction_id = ?", transaction.ID).Delete(&models.TransactionLine{}).Error; err != nil {
+		return err
+	}
+	if len(transaction.Lines) == 0 {
+		return nil
+	}
+	numberLines(transaction)
+	return r.db.Create(&transaction.Lines).Error
+}
+
+// Create and Update write the transaction and its lines; call them inside
+// WithTx so that both are saved or neither is.
+func (r *gormTransactionRepo) Create(transaction *models.Transaction) error {
+	if err := r.db.Omit(clause.Associations).Create(transaction).Error; err != nil {
+		return err
+	}
+	return r.saveLines(transaction)
+}
+
+func (r *gormTransactionRepo) Update(transaction *models.Transaction) error {
+	if err := updateVersioned(r.db, transaction, transaction.ID, &transaction.Version); err != nil {
+		return err
+	}
+	return r.saveLines(transaction)
+}
+
+func (r *gormTransactionRepo) Delete(id uuid.UUID) error {
+	if err := r.db.Where("transaction_id = ?", id).Delete(&models.TransactionLine{}).Error; err != nil {
+		return err
+	}
+	if err := r.db.Where("transaction_id = ?", id).Delete(&models.TransactionFlag{}).Error; err != nil {
+		return err
+	}
+	return deleteByID(r.db, &models.Transaction{}, id)
+}
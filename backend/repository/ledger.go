@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"gkjw-finance-backend/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LedgerFilter narrows journal queries. Zero values mean "no filter"; the
+// dates are inclusive and compare against the entry date.
+type LedgerFilter struct {
+	AccountID uuid.UUID
+	FundID    uuid.UUID
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// AccountBalance is the debit and credit total of one account.
+type AccountBalance struct {
+	AccountID uuid.UUID
+	Debit     models.Money
+	Credit    models.Money
+}
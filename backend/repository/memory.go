@@ -0,0 +1,576 @@
+package repository
+
+import (
+	"bytes"
+	"gkjw-finance-backend/models"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memoryStore holds the rows of the in-memory repositories. Rows are kept
+// without their preloaded associations; readers attach those on the way out.
+type memoryStore struct {
+	// txMu serialises WithTx calls; mu guards the maps below.
+	txMu sync.Mutex
+	mu   sync.RWMutex
+
+	transactions map[uuid.UUID]models.Transaction
+	funds        map[uuid.UUID]models.Fund
+	categories   map[uuid.UUID]models.Category
+	users        map[uuid.UUID]models.User
+	activityLogs []models.ActivityLog
+
+	accounts       map[uuid.UUID]models.Account
+	journalEntries map[uuid.UUID]models.JournalEntry
+
+	fiscalYears     map[uuid.UUID]models.FiscalYear
+	openingBalances map[uuid.UUID]models.OpeningBalance
+	periods         map[uuid.UUID]models.AccountingPeriod
+
+	approvalPolicies map[uuid.UUID]models.ApprovalPolicy
+	approvals        map[uuid.UUID]models.Approval
+
+	recurringTemplates map[uuid.UUID]models.RecurringTemplate
+	bankStatements     map[uuid.UUID]models.BankStatement
+	bankStatementLines map[uuid.UUID]models.BankStatementLine
+	transactionFlags   map[uuid.UUID]models.TransactionFlag
+
+	categorizationRules map[uuid.UUID]models.CategorizationRule
+	events              map[uuid.UUID]models.Event
+
+	// idempotencyKeys is left out of snapshots; see IdempotencyKeyRepo.
+	idempotencyKeys map[idempotencyKeyID]models.IdempotencyKey
+	// locks holds the keys taken by TryLock.
+	locks map[int64]bool
+}
+
+// snapshot returns a copy of every table, used to roll back WithTx.
+func (s *memoryStore) snapshot() *memoryStore {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return &memoryStore{
+		transactions: maps.Clone(s.transactions),
+		funds:        maps.Clone(s.funds),
+		categories:   maps.Clone(s.categories),
+		users:        maps.Clone(s.users),
+		activityLogs: append([]models.ActivityLog(nil), s.activityLogs...),
+
+		accounts:       maps.Clone(s.accounts),
+		journalEntries: maps.Clone(s.journalEntries),
+
+		fiscalYears:     maps.Clone(s.fiscalYears),
+		openingBalances: maps.Clone(s.openingBalances),
+		periods:         maps.Clone(s.periods),
+
+		approvalPolicies: maps.Clone(s.approvalPolicies),
+		approvals:        maps.Clone(s.approvals),
+
+		recurringTemplates: maps.Clone(s.recurringTemplates),
+		bankStatements:     maps.Clone(s.bankStatements),
+		bankStatementLines: maps.Clone(s.bankStatementLines),
+		transactionFlags:   maps.Clone(s.transactionFlags),
+
+		categorizationRules: maps.Clone(s.categorizationRules),
+		events:              maps.Clone(s.events),
+	}
+}
+
+func (s *memoryStore) restore(snap *memoryStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transactions = snap.transactions
+	s.funds = snap.funds
+	s.categories = snap.categories
+	s.users = snap.users
+	s.activityLogs = snap.activityLogs
+	s.accounts = snap.accounts
+	s.journalEntries = snap.journalEntries
+	s.fiscalYears = snap.fiscalYears
+	s.openingBalances = snap.openingBalances
+	s.periods = snap.periods
+	s.approvalPolicies = snap.approvalPolicies
+	s.approvals = snap.approvals
+	s.recurringTemplates = snap.recurringTemplates
+	s.bankStatements = snap.bankStatements
+	s.bankStatementLines = snap.bankStatementLines
+	s.transactionFlags = snap.transactionFlags
+	s.categorizationRules = snap.categorizationRules
+	s.events = snap.events
+}
+
+// NewMemoryRepositories returns repositories that keep everything in
+// process memory. They are meant for tests and local experiments: WithTx
+// restores a snapshot on error, and transactions run one at a time.
+func NewMemoryRepositories() *Repositories {
+	store := &memoryStore{
+		transactions: map[uuid.UUID]models.Transaction{},
+		funds:        map[uuid.UUID]models.Fund{},
+		categories:   map[uuid.UUID]models.Category{},
+		users:        map[uuid.UUID]models.User{},
+
+		accounts:       defaultAccounts(),
+		journalEntries: map[uuid.UUID]models.JournalEntry{},
+
+		fiscalYears:     map[uuid.UUID]models.FiscalYear{},
+		openingBalances: map[uuid.UUID]models.OpeningBalance{},
+		periods:         map[uuid.UUID]models.AccountingPeriod{},
+
+		approvalPolicies: map[uuid.UUID]models.ApprovalPolicy{},
+		approvals:        map[uuid.UUID]models.Approval{},
+
+		recurringTemplates: map[uuid.UUID]models.RecurringTemplate{},
+		bankStatements:     map[uuid.UUID]models.BankStatement{},
+		bankStatementLines: map[uuid.UUID]models.BankStatementLine{},
+		transactionFlags:   map[uuid.UUID]models.TransactionFlag{},
+
+		categorizationRules: defaultCategorizationRules(),
+		events:              map[uuid.UUID]models.Event{},
+
+		idempotencyKeys: map[idempotencyKeyID]models.IdempotencyKey{},
+		locks:           map[int64]bool{},
+	}
+	repos := newMemoryRepositories(store)
+	repos.transaction = func(fn func(repos *Repositories) error) error {
+		store.txMu.Lock()
+		defer store.txMu.Unlock()
+
+		snap := store.snapshot()
+		// Nested WithTx calls join the running transaction.
+		inner := newMemoryRepositories(store)
+		inner.transaction = func(fn func(repos *Repositories) error) error {
+			return fn(inner)
+		}
+		if err := fn(inner); err != nil {
+			store.restore(snap)
+			return err
+		}
+		return nil
+	}
+	return repos
+}
+
+func newMemoryRepositories(store *memoryStore) *Repositories {
+	return &Repositories{
+		Transactions: &memoryTransactionRepo{store: store},
+		Funds:        &memoryFundRepo{store: store},
+		Categories:   &memoryCategoryRepo{store: store},
+		Users:        &memoryUserRepo{store: store},
+		ActivityLogs: &memoryActivityLogRepo{store: store},
+		Accounts:     &memoryAccountRepo{store: store},
+		Journals:     &memoryJournalRepo{store: store},
+
+		FiscalYears:     &memoryFiscalYearRepo{store: store},
+		OpeningBalances: &memoryOpeningBalanceRepo{store: store},
+		Periods:         &memoryAccountingPeriodRepo{store: store},
+
+		ApprovalPolicies: &memoryApprovalPolicyRepo{store: store},
+		Approvals:        &memoryApprovalRepo{store: store},
+
+		RecurringTemplates: &memoryRecurringTemplateRepo{store: store},
+		BankStatements:     &memoryBankStatementRepo{store: store},
+		TransactionFlags:   &memoryTransactionFlagRepo{store: store},
+
+		CategorizationRules: &memoryCategorizationRuleRepo{store: store},
+		Events:              &memoryEventRepo{store: store},
+
+		IdempotencyKeys: &memoryIdempotencyKeyRepo{store: store},
+
+		tryLock: store.tryLock,
+	}
+}
+
+func (s *memoryStore) tryLock(key int64, fn func() error) (bool, error) {
+	s.mu.Lock()
+	if s.locks[key] {
+		s.mu.Unlock()
+		return false, nil
+	}
+	s.locks[key] = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.locks, key)
+		s.mu.Unlock()
+	}()
+	return true, fn()
+}
+
+// stampNew assigns an ID and timestamps to a row that is about to be
+// inserted, mirroring the database defaults.
+func stampNew(id *uuid.UUID, createdAt, updatedAt *time.Time) {
+	if *id == uuid.Nil {
+		*id = uuid.New()
+	}
+	now := time.Now()
+	if createdAt != nil && createdAt.IsZero() {
+		*createdAt = now
+	}
+	if updatedAt != nil {
+		*updatedAt = now
+	}
+}
+
+// checkVersion returns ErrNotFound or ErrVersionConflict unless a row is
+// stored at version, and raises version otherwise.
+func checkVersion(stored int, found bool, version *int) error {
+	if !found {
+		return ErrNotFound
+	}
+	if stored != *version {
+		return ErrVersionConflict
+	}
+	*version++
+	return nil
+}
+
+type memoryFundRepo struct {
+	store *memoryStore
+}
+
+func (r *memoryFundRepo) List() ([]models.Fund, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	funds := make([]models.Fund, 0, len(r.store.funds))
+	for _, fund := range r.store.funds {
+		funds = append(funds, fund)
+	}
+	sort.Slice(funds, func(i, j int) bool { return funds[i].CreatedAt.Before(funds[j].CreatedAt) })
+	return funds, nil
+}
+
+func (r *memoryFundRepo) FindByID(id uuid.UUID) (*models.Fund, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	fund, ok := r.store.funds[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &fund, nil
+}
+
+func (r *memoryFundRepo) Create(fund *models.Fund) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stampNew(&fund.ID, &fund.CreatedAt, &fund.UpdatedAt)
+	fund.Version = 1
+	if fund.Status == "" {
+		fund.Status = "active"
+	}
+	r.store.funds[fund.ID] = *fund
+	return nil
+}
+
+func (r *memoryFundRepo) Update(fund *models.Fund) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stored, found := r.store.funds[fund.ID]
+	if err := checkVersion(stored.Version, found, &fund.Version); err != nil {
+		return err
+	}
+	fund.UpdatedAt = time.Now()
+	r.store.funds[fund.ID] = *fund
+	return nil
+}
+
+func (r *memoryFundRepo) Delete(id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if _, ok := r.store.funds[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.store.funds, id)
+	return nil
+}
+
+type memoryCategoryRepo struct {
+	store *memoryStore
+}
+
+func (r *memoryCategoryRepo) List() ([]models.Category, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	categories := make([]models.Category, 0, len(r.store.categories))
+	for _, category := range r.store.categories {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].CreatedAt.Before(categories[j].CreatedAt) })
+	return categories, nil
+}
+
+func (r *memoryCategoryRepo) FindByID(id uuid.UUID) (*models.Category, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	category, ok := r.store.categories[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &category, nil
+}
+
+func (r *memoryCategoryRepo) Create(category *models.Category) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stampNew(&category.ID, &category.CreatedAt, &category.UpdatedAt)
+	category.Version = 1
+	if category.Type == "" {
+		category.Type = "general"
+	}
+	r.store.categories[category.ID] = *category
+	return nil
+}
+
+func (r *memoryCategoryRepo) Update(category *models.Category) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stored, found := r.store.categories[category.ID]
+	if err := checkVersion(stored.Version, found, &category.Version); err != nil {
+		return err
+	}
+	category.UpdatedAt = time.Now()
+	r.store.categories[category.ID] = *category
+	return nil
+}
+
+func (r *memoryCategoryRepo) Delete(id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if _, ok := r.store.categories[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.store.categories, id)
+	return nil
+}
+
+func (r *memoryCategoryRepo) CountTransactions(id uuid.UUID) (map[string]int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	counts := map[string]int{}
+	for _, tx := range r.store.transactions {
+		for _, line := range tx.Splits() {
+			if line.CategoryID != nil && *line.CategoryID == id {
+				counts[tx.Type]++
+				break
+			}
+		}
+	}
+	return counts, nil
+}
+
+func (r *memoryCategoryRepo) TransactionDates(id uuid.UUID) ([]time.Time, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	seen := map[time.Time]bool{}
+	var dates []time.Time
+	for _, tx := range r.store.transactions {
+		for _, line := range tx.Splits() {
+			if line.CategoryID != nil && *line.CategoryID == id {
+				if !seen[tx.Date] {
+					seen[tx.Date] = true
+					dates = append(dates, tx.Date)
+				}
+				break
+			}
+		}
+	}
+	return dates, nil
+}
+
+// relabelLocked gives the transactions and lines booked to the category id
+// the category into, copying the lines so that snapshots keep theirs.
+func (r *memoryCategoryRepo) relabelLocked(id uuid.UUID, into *models.Category) {
+	intoID := func() *uuid.UUID {
+		id := into.ID
+		return &id
+	}
+	for txID, tx := range r.store.transactions {
+		changed := false
+		if tx.CategoryID != nil && *tx.CategoryID == id {
+			tx.CategoryID = intoID()
+			tx.Category = into.Name
+			changed = true
+		}
+		tx.Lines = append([]models.TransactionLine(nil), tx.Lines...)
+		for i := range tx.Lines {
+			if tx.Lines[i].CategoryID != nil && *tx.Lines[i].CategoryID == id {
+				tx.Lines[i].CategoryID = intoID()
+				tx.Lines[i].Category = into.Name
+				changed = true
+			}
+		}
+		if changed {
+			r.store.transactions[txID] = tx
+		}
+	}
+}
+
+func (r *memoryCategoryRepo) Relabel(id uuid.UUID, name string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	r.relabelLocked(id, &models.Category{ID: id, Name: name})
+	return nil
+}
+
+func (r *memoryCategoryRepo) Merge(from uuid.UUID, into *models.Category) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if _, ok := r.store.categories[from]; !ok {
+		return ErrNotFound
+	}
+	r.relabelLocked(from, into)
+	for id, category := range r.store.categories {
+		if category.ParentID != nil && *category.ParentID == from {
+			parentID := into.ID
+			category.ParentID = &parentID
+			category.Version++
+			r.store.categories[id] = category
+		}
+	}
+	delete(r.store.categories, from)
+	return nil
+}
+
+type memoryUserRepo struct {
+	store *memoryStore
+}
+
+func (r *memoryUserRepo) List() ([]models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	users := make([]models.User, 0, len(r.store.users))
+	for _, user := range r.store.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].CreatedAt.After(users[j].CreatedAt) })
+	return users, nil
+}
+
+func (r *memoryUserRepo) FindByID(id uuid.UUID) (*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	user, ok := r.store.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &user, nil
+}
+
+func (r *memoryUserRepo) FindByEmail(email string) (*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	for _, user := range r.store.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryUserRepo) Create(user *models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stampNew(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	r.store.users[user.ID] = *user
+	return nil
+}
+
+func (r *memoryUserRepo) Update(user *models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	user.UpdatedAt = time.Now()
+	r.store.users[user.ID] = *user
+	return nil
+}
+
+func (r *memoryUserRepo) Delete(id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if _, ok := r.store.users[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.store.users, id)
+	return nil
+}
+
+type memoryActivityLogRepo struct {
+	store *memoryStore
+}
+
+func (r *memoryActivityLogRepo) Create(log *models.ActivityLog) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stampNew(&log.ID, &log.Timestamp, nil)
+	log.User = nil
+	r.store.activityLogs = append(r.store.activityLogs, *log)
+	return nil
+}
+
+func (f ActivityLogFilter) matches(log models.ActivityLog) bool {
+	if f.EntityType != "" && log.EntityType != f.EntityType {
+		return false
+	}
+	if f.EntityID != uuid.Nil && (log.EntityID == nil || *log.EntityID != f.EntityID) {
+		return false
+	}
+	if f.UserID != uuid.Nil && log.UserID != f.UserID {
+		return false
+	}
+	if f.Action != "" && log.Action != f.Action {
+		return false
+	}
+	if !f.StartDate.IsZero() && log.Timestamp.Before(f.StartDate) {
+		return false
+	}
+	if !f.EndDate.IsZero() && !log.Timestamp.Before(f.EndDate.AddDate(0, 0, 1)) {
+		return false
+	}
+	return true
+}
+
+// newerLog orders logs newest first, by timestamp and then id.
+func newerLog(a, b models.ActivityLog) bool {
+	if !a.Timestamp.Equal(b.Timestamp) {
+		return a.Timestamp.After(b.Timestamp)
+	}
+	return bytes.Compare(a.ID[:], b.ID[:]) > 0
+}
+
+func (r *memoryActivityLogRepo) List(filter ActivityLogFilter, limit int, cursor string) (*ActivityLogPage, error) {
+	var position *models.ActivityLog
+	if cursor != "" {
+		decoded, err := decodeActivityLogCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		position = &models.ActivityLog{ID: decoded.ID, Timestamp: decoded.Timestamp}
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	var matched []models.ActivityLog
+	for _, log := range r.store.activityLogs {
+		if filter.matches(log) {
+			matched = append(matched, log)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return newerLog(matched[i], matched[j]) })
+
+	logs := make([]models.ActivityLog, 0, limit+1)
+	for _, log := range matched {
+		if len(logs) > limit {
+			break
+		}
+		if position != nil && !newerLog(*position, log) {
+			continue
+		}
+		if user, ok := r.store.users[log.UserID]; ok {
+			log.User = &user
+		}
+		logs = append(logs, log)
+	}
+	return activityLogPage(logs, int64(len(matched)), limit), nil
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"gkjw-finance-backend/models"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryApprovalPolicyRepo struct {
+	store *memoryStore
+}
+
+// withRelations attaches the fund and step users. The caller must hold the
+// store lock.
+func (r *memoryApprovalPolicyRepo) withRelations(policy models.ApprovalPolicy) models.ApprovalPolicy {
+	if policy.FundID != nil {
+		if fund, ok := r.store.funds[*policy.FundID]; ok {
+			policy.Fund = &fund
+		}
+	}
+	steps := make([]models.ApprovalStep, len(policy.Steps))
+	for i, step := range policy.Steps {
+		if step.UserID != nil {
+			if user, ok := r.store.users[*step.UserID]; ok {
+				step.User = &user
+			}
+		}
+		steps[i] = step
+	}
+	policy.Steps = steps
+	return policy
+}
+
+func (r *memoryApprovalPolicyRepo) List() ([]models.ApprovalPolicy, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	policies := make([]models.ApprovalPolicy, 0, len(r.store.approvalPolicies))
+	for _, policy := range r.store.approvalPolicies {
+		policies = append(policies, r.withRelations(policy))
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		if policies[i].Priority != policies[j].Priority {
+			return policies[i].Priority < policies[j].Priority
+		}
+		return policies[i].CreatedAt.Before(policies[j].CreatedAt)
+	})
+	return policies, nil
+}
+
+func (r *memoryApprovalPolicyRepo) FindByID(id uuid.UUID) (*models.ApprovalPolicy, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	policy, ok := r.store.approvalPolicies[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	policy = r.withRelations(policy)
+	return &policy, nil
+}
+
+// saveLocked stores policy and its steps in order. The caller must hold the
+// store lock.
+func (r *memoryApprovalPolicyRepo) saveLocked(policy *models.ApprovalPolicy) {
+	sort.SliceStable(policy.Steps, func(i, j int) bool { return policy.Steps[i].StepOrder < policy.Steps[j].StepOrder })
+	steps := make([]models.ApprovalStep, len(policy.Steps))
+	for i := range policy.Steps {
+		policy.Steps[i].ID = uuid.Nil
+		stampNew(&policy.Steps[i].ID, nil, nil)
+		policy.Steps[i].PolicyID = policy.ID
+		steps[i] = policy.Steps[i]
+		steps[i].User = nil
+	}
+	row := *policy
+	row.Fund = nil
+	row.Steps = steps
+	r.store.approvalPolicies[row.ID] = row
+}
+
+func (r *memoryApprovalPolicyRepo) Create(policy *models.ApprovalPolicy) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stampNew(&policy.ID, &policy.CreatedAt, &policy.UpdatedAt)
+	if policy.Status == "" {
+		policy.Status = "active"
+	}
+	r.saveLocked(policy)
+	return nil
+}
+
+func (r *memoryApprovalPolicyRepo) Update(policy *models.ApprovalPolicy) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	policy.UpdatedAt = time.Now()
+	r.saveLocked(policy)
+	return nil
+}
+
+func (r *memoryApprovalPolicyRepo) Delete(id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if _, ok := r.store.approvalPolicies[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.store.approvalPolicies, id)
+	// Signatures outlive their policy.
+	for approvalID, approval := range r.store.approvals {
+		if approval.PolicyID != nil && *approval.PolicyID == id {
+			approval.PolicyID = nil
+			r.store.approvals[approvalID] = approval
+		}
+	}
+	return nil
+}
+
+type memoryApprovalRepo struct {
+	store *memoryStore
+}
+
+func (r *memoryApprovalRepo) ListByTransaction(transactionID uuid.UUID) ([]models.Approval, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	var approvals []models.Approval
+	for _, approval := range r.store.approvals {
+		if approval.TransactionID != transactionID {
+			continue
+		}
+		if user, ok := r.store.users[approval.ApproverID]; ok {
+			approval.Approver = &user
+		}
+		approvals = append(approvals, approval)
+	}
+	sort.Slice(approvals, func(i, j int) bool { return approvals[i].CreatedAt.Before(approvals[j].CreatedAt) })
+	return approvals, nil
+}
+
+func (r *memoryApprovalRepo) Create(approval *models.Approval) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stampNew(&approval.ID, &approval.CreatedAt, nil)
+	row := *approval
+	row.Approver = nil
+	r.store.approvals[row.ID] = row
+	return nil
+}
+
+func (r *memoryApprovalRepo) Supersede(transactionID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for id, approval := range r.store.approvals {
+		if approval.TransactionID == transactionID && !approval.Superseded {
+			approval.Superseded = true
+			r.store.approvals[id] = approval
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"gkjw-finance-backend/models"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+type memoryBankStatementRepo struct {
+	store *memoryStore
+}
+
+// withFund attaches the fund. The caller must hold the store lock.
+func (r *memoryBankStatementRepo) withFund(statement models.BankStatement) models.BankStatement {
+	if statement.FundID != nil {
+		if fund, ok := r.store.funds[*statement.FundID]; ok {
+			statement.Fund = &fund
+		}
+	}
+	return statement
+}
+
+// sortLines orders lines by date, then by statement and position.
+func sortLines(lines []models.BankStatementLine) {
+	sort.Slice(lines, func(i, j int) bool {
+		a, b := lines[i], lines[j]
+		if !a.Date.Equal(b.Date) {
+			return a.Date.Before(b.Date)
+		}
+		if a.StatementID != b.StatementID {
+			return a.StatementID.String() < b.StatementID.String()
+		}
+		return a.Position < b.Position
+	})
+}
+
+func (r *memoryBankStatementRepo) List() ([]models.BankStatement, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	statements := make([]models.BankStatement, 0, len(r.store.bankStatements))
+	for _, statement := range r.store.bankStatements {
+		statements = append(statements, r.withFund(statement))
+	}
+	sort.Slice(statements, func(i, j int) bool {
+		if !statements[i].EndDate.Equal(statements[j].EndDate) {
+			return statements[i].EndDate.After(statements[j].EndDate)
+		}
+		return statements[i].CreatedAt.After(statements[j].CreatedAt)
+	})
+	return statements, nil
+}
+
+func (r *memoryBankStatementRepo) FindByID(id uuid.UUID) (*models.BankStatement, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	statement, ok := r.store.bankStatements[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	statement = r.withFund(statement)
+	for _, line := range r.store.bankStatementLines {
+		if line.StatementID == id {
+			statement.Lines = append(statement.Lines, line)
+		}
+	}
+	sort.Slice(statement.Lines, func(i, j int) bool { return statement.Lines[i].Position < statement.Lines[j].Position })
+	return &statement, nil
+}
+
+func (r *memoryBankStatementRepo) FindByChecksum(checksum string) (*models.BankStatement, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	for _, statement := range r.store.bankStatements {
+		if statement.Checksum == checksum {
+			return &statement, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryBankStatementRepo) Create(statement *models.BankStatement) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stampNew(&statement.ID, &statement.CreatedAt, nil)
+	for i := range statement.Lines {
+		line := &statement.Lines[i]
+		stampNew(&line.ID, nil, nil)
+		line.StatementID = statement.ID
+		line.Position = i
+		if line.Status == "" {
+			line.Status = "unmatched"
+		}
+		r.store.bankStatementLines[line.ID] = *line
+	}
+	row := *statement
+	row.Fund, row.Lines = nil, nil
+	r.store.bankStatements[row.ID] = row
+	return nil
+}
+
+func (r *memoryBankStatementRepo) Delete(id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if _, ok := r.store.bankStatements[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.store.bankStatements, id)
+	for lineID, line := range r.store.bankStatementLines {
+		if line.StatementID == id {
+			delete(r.store.bankStatementLines, lineID)
+		}
+	}
+	return nil
+}
+
+func (r *memoryBankStatementRepo) ListLines(status string) ([]models.BankStatementLine, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	var lines []models.BankStatementLine
+	for _, line := range r.store.bankStatementLines {
+		if status == "" || line.Status == status {
+			lines = append(lines, line)
+		}
+	}
+	sortLines(lines)
+	return lines, nil
+}
+
+func (r *memoryBankStatementRepo) UpdateLine(line *models.BankStatementLine) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if _, ok := r.store.bankStatementLines[line.ID]; !ok {
+		return ErrNotFound
+	}
+	row := *line
+	row.Transaction = nil
+	r.store.bankStatementLines[row.ID] = row
+	return nil
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"gkjw-finance-backend/models"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultCategorizationRules mirrors the rule seeded by the categorization
+// rules migration.
+func defaultCategorizationRules() map[uuid.UUID]models.CategorizationRule {
+	now := time.Now()
+	rule := models.CategorizationRule{
+		ID:        uuid.MustParse("00000000-0000-0000-0000-00000000c001"),
+		Name:      "Kata kunci pemasukan",
+		Priority:  100,
+		Pattern:   `\b(sumbangan|donasi|setor|masuk|pemasukan|usaha|penjualan|pigora|sisa dana|transfer hadiah|pengembalian|reimburse)`,
+		Field:     "any",
+		SetType:   "income",
+		Mode:      "suggest",
+		Status:    "active",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	return map[uuid.UUID]models.CategorizationRule{rule.ID: rule}
+}
+
+type memoryCategorizationRuleRepo struct {
+	store *memoryStore
+}
+
+// withFund attaches the fund the rule sets. The caller must hold the store
+// lock.
+func (r *memoryCategorizationRuleRepo) withFund(rule models.CategorizationRule) models.CategorizationRule {
+	if rule.SetFundID != nil {
+		if fund, ok := r.store.funds[*rule.SetFundID]; ok {
+			rule.SetFund = &fund
+		}
+	}
+	return rule
+}
+
+func (r *memoryCategorizationRuleRepo) List() ([]models.CategorizationRule, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	rules := make([]models.CategorizationRule, 0, len(r.store.categorizationRules))
+	for _, rule := range r.store.categorizationRules {
+		rules = append(rules, r.withFund(rule))
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority < rules[j].Priority
+		}
+		return rules[i].CreatedAt.Before(rules[j].CreatedAt)
+	})
+	return rules, nil
+}
+
+func (r *memoryCategorizationRuleRepo) FindByID(id uuid.UUID) (*models.CategorizationRule, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	rule, ok := r.store.categorizationRules[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	rule = r.withFund(rule)
+	return &rule, nil
+}
+
+func (r *memoryCategorizationRuleRepo) Create(rule *models.CategorizationRule) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stampNew(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+	row := *rule
+	row.SetFund = nil
+	r.store.categorizationRules[row.ID] = row
+	return nil
+}
+
+func (r *memoryCategorizationRuleRepo) Update(rule *models.CategorizationRule) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if _, ok := r.store.categorizationRules[rule.ID]; !ok {
+		return ErrNotFound
+	}
+	rule.UpdatedAt = time.Now()
+	row := *rule
+	row.SetFund = nil
+	r.store.categorizationRules[row.ID] = row
+	return nil
+}
+
+func (r *memoryCategorizationRuleRepo) Delete(id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if _, ok := r.store.categorizationRules[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.store.categorizationRules, id)
+	return nil
+}
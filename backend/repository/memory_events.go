@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"gkjw-finance-backend/models"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryEventRepo struct {
+	store *memoryStore
+}
+
+// withFund attaches the fund of the event. The caller must hold the store
+// lock.
+func (r *memoryEventRepo) withFund(event models.Event) models.Event {
+	if event.FundID != nil {
+		if fund, ok := r.store.funds[*event.FundID]; ok {
+			event.Fund = &fund
+		}
+	}
+	return event
+}
+
+func (r *memoryEventRepo) List() ([]models.Event, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	events := make([]models.Event, 0, len(r.store.events))
+	for _, event := range r.store.events {
+		events = append(events, r.withFund(event))
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].StartDate.Equal(events[j].StartDate) {
+			return events[i].StartDate.After(events[j].StartDate)
+		}
+		return events[i].CreatedAt.After(events[j].CreatedAt)
+	})
+	return events, nil
+}
+
+func (r *memoryEventRepo) FindByID(id uuid.UUID) (*models.Event, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	event, ok := r.store.events[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	event = r.withFund(event)
+	return &event, nil
+}
+
+// nameTaken reports whether an event other than id is called name,
+// ignoring case, as the unique index on LOWER(name) does. The caller must
+// hold the store lock.
+func (r *memoryEventRepo) nameTaken(id uuid.UUID, name string) bool {
+	for _, event := range r.store.events {
+		if event.ID != id && strings.ToLower(event.Name) == strings.ToLower(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryEventRepo) Create(event *models.Event) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if r.nameTaken(event.ID, event.Name) {
+		return ErrDuplicate
+	}
+	stampNew(&event.ID, &event.CreatedAt, &event.UpdatedAt)
+	event.Version = 1
+	row := *event
+	row.Fund = nil
+	r.store.events[row.ID] = row
+	return nil
+}
+
+func (r *memoryEventRepo) Update(event *models.Event) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stored, found := r.store.events[event.ID]
+	if found && r.nameTaken(event.ID, event.Name) {
+		return ErrDuplicate
+	}
+	if err := checkVersion(stored.Version, found, &event.Version); err != nil {
+		return err
+	}
+	event.UpdatedAt = time.Now()
+	row := *event
+	row.Fund = nil
+	r.store.events[row.ID] = row
+	return nil
+}
+
+func (r *memoryEventRepo) Delete(id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if _, ok := r.store.events[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.store.events, id)
+	return nil
+}
+
+func (r *memoryEventRepo) Relabel(id uuid.UUID, name string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for txID, tx := range r.store.transactions {
+		if tx.EventID != nil && *tx.EventID == id {
+			tx.EventName = name
+			tx.Version++
+			r.store.transactions[txID] = tx
+		}
+	}
+	return nil
+}
+
+func (r *memoryEventRepo) Link(event *models.Event, transactionIDs []uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, txID := range transactionIDs {
+		tx, ok := r.store.transactions[txID]
+		if !ok {
+			continue
+		}
+		id := event.ID
+		tx.EventID = &id
+		tx.EventName = event.Name
+		tx.Version++
+		r.store.transactions[txID] = tx
+	}
+	return nil
+}
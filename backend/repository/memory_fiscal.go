@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"gkjw-finance-backend/models"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryFiscalYearRepo struct {
+	store *memoryStore
+}
+
+func (r *memoryFiscalYearRepo) List() ([]models.FiscalYear, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	years := make([]models.FiscalYear, 0, len(r.store.fiscalYears))
+	for _, year := range r.store.fiscalYears {
+		years = append(years, year)
+	}
+	sort.Slice(years, func(i, j int) bool { return years[i].StartDate.Before(years[j].StartDate) })
+	return years, nil
+}
+
+func (r *memoryFiscalYearRepo) FindByID(id uuid.UUID) (*models.FiscalYear, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	year, ok := r.store.fiscalYears[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &year, nil
+}
+
+func (r *memoryFiscalYearRepo) Create(year *models.FiscalYear) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stampNew(&year.ID, &year.CreatedAt, &year.UpdatedAt)
+	if year.Status == "" {
+		year.Status = "open"
+	}
+	r.store.fiscalYears[year.ID] = *year
+	return nil
+}
+
+func (r *memoryFiscalYearRepo) Update(year *models.FiscalYear) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	year.UpdatedAt = time.Now()
+	r.store.fiscalYears[year.ID] = *year
+	return nil
+}
+
+type memoryOpeningBalanceRepo struct {
+	store *memoryStore
+}
+
+// withFund attaches the fund. The caller must hold the store lock.
+func (r *memoryOpeningBalanceRepo) withFund(balance models.OpeningBalance) models.OpeningBalance {
+	if fund, ok := r.store.funds[balance.FundID]; ok {
+		balance.Fund = &fund
+	}
+	return balance
+}
+
+func (r *memoryOpeningBalanceRepo) List(filter OpeningBalanceFilter) ([]models.OpeningBalance, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	var balances []models.OpeningBalance
+	for _, balance := range r.store.openingBalances {
+		if filter.FundID != uuid.Nil && balance.FundID != filter.FundID {
+			continue
+		}
+		if !filter.Until.IsZero() && balance.AsOf.After(filter.Until) {
+			continue
+		}
+		balances = append(balances, r.withFund(balance))
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].AsOf.Before(balances[j].AsOf) })
+	return balances, nil
+}
+
+func (r *memoryOpeningBalanceRepo) FindByID(id uuid.UUID) (*models.OpeningBalance, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	balance, ok := r.store.openingBalances[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	balance = r.withFund(balance)
+	return &balance, nil
+}
+
+func (r *memoryOpeningBalanceRepo) FindByKey(fundID uuid.UUID, paymentMethod string, asOf time.Time) (*models.OpeningBalance, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	for _, balance := range r.store.openingBalances {
+		if balance.FundID == fundID && balance.PaymentMethod == paymentMethod && balance.AsOf.Equal(asOf) {
+			return &balance, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryOpeningBalanceRepo) Create(balance *models.OpeningBalance) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stampNew(&balance.ID, &balance.CreatedAt, &balance.UpdatedAt)
+	row := *balance
+	row.Fund = nil
+	r.store.openingBalances[row.ID] = row
+	return nil
+}
+
+func (r *memoryOpeningBalanceRepo) Update(balance *models.OpeningBalance) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	balance.UpdatedAt = time.Now()
+	row := *balance
+	row.Fund = nil
+	r.store.openingBalances[row.ID] = row
+	return nil
+}
+
+func (r *memoryOpeningBalanceRepo) Delete(id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if _, ok := r.store.openingBalances[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.store.openingBalances, id)
+	return nil
+}
+
+type memoryAccountingPeriodRepo struct {
+	store *memoryStore
+}
+
+func (r *memoryAccountingPeriodRepo) List() ([]models.AccountingPeriod, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	periods := make([]models.AccountingPeriod, 0, len(r.store.periods))
+	for _, period := range r.store.periods {
+		periods = append(periods, period)
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].StartDate.Before(periods[j].StartDate) })
+	return periods, nil
+}
+
+func (r *memoryAccountingPeriodRepo) FindByID(id uuid.UUID) (*models.AccountingPeriod, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	period, ok := r.store.periods[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &period, nil
+}
+
+func (r *memoryAccountingPeriodRepo) FindByName(name string) (*models.AccountingPeriod, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	for _, period := range r.store.periods {
+		if period.Name == name {
+			return &period, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryAccountingPeriodRepo) FindClosedOn(date time.Time) (*models.AccountingPeriod, error) {
+	periods, _ := r.List()
+	for _, period := range periods {
+		if period.Status == "closed" && !date.Before(period.StartDate) && !date.After(period.EndDate) {
+			return &period, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryAccountingPeriodRepo) Create(period *models.AccountingPeriod) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stampNew(&period.ID, &period.CreatedAt, &period.UpdatedAt)
+	if period.Status == "" {
+		period.Status = "closed"
+	}
+	r.store.periods[period.ID] = *period
+	return nil
+}
+
+func (r *memoryAccountingPeriodRepo) Update(period *models.AccountingPeriod) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	period.UpdatedAt = time.Now()
+	r.store.periods[period.ID] = *period
+	return nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"gkjw-finance-backend/models"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+type memoryTransactionFlagRepo struct {
+	store *memoryStore
+}
+
+// flagsOf returns the flags on a transaction, oldest first. The caller
+// must hold the store lock.
+func (s *memoryStore) flagsOf(transactionID uuid.UUID) []models.TransactionFlag {
+	var flags []models.TransactionFlag
+	for _, flag := range s.transactionFlags {
+		if flag.TransactionID == transactionID {
+			flags = append(flags, flag)
+		}
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].CreatedAt.Before(flags[j].CreatedAt) })
+	return flags
+}
+
+func (r *memoryTransactionFlagRepo) List(status, kind string) ([]models.TransactionFlag, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	transactions := &memoryTransactionRepo{store: r.store}
+	var flags []models.TransactionFlag
+	for _, flag := range r.store.transactionFlags {
+		if status != "" && flag.Status != status || kind != "" && flag.Kind != kind {
+			continue
+		}
+		if tx, ok := r.store.transactions[flag.TransactionID]; ok {
+			tx = transactions.withAssociations(tx)
+			tx.Flags = nil
+			flag.Transaction = &tx
+		}
+		flags = append(flags, flag)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].CreatedAt.After(flags[j].CreatedAt) })
+	return flags, nil
+}
+
+func (r *memoryTransactionFlagRepo) FindByID(id uuid.UUID) (*models.TransactionFlag, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	flag, ok := r.store.transactionFlags[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &flag, nil
+}
+
+func (r *memoryTransactionFlagRepo) ListByTransaction(transactionID uuid.UUID) ([]models.TransactionFlag, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return r.store.flagsOf(transactionID), nil
+}
+
+func (r *memoryTransactionFlagRepo) Create(flag *models.TransactionFlag) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stampNew(&flag.ID, &flag.CreatedAt, nil)
+	if flag.Status == "" {
+		flag.Status = "open"
+	}
+	row := *flag
+	row.Transaction = nil
+	r.store.transactionFlags[row.ID] = row
+	return nil
+}
+
+func (r *memoryTransactionFlagRepo) Update(flag *models.TransactionFlag) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if _, ok := r.store.transactionFlags[flag.ID]; !ok {
+		return ErrNotFound
+	}
+	row := *flag
+	row.Transaction = nil
+	r.store.transactionFlags[row.ID] = row
+	return nil
+}
+
+func (r *memoryTransactionFlagRepo) DeleteOpen(transactionID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for id, flag := range r.store.transactionFlags {
+		if flag.TransactionID == transactionID && flag.Status == "open" {
+			delete(r.store.transactionFlags, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"gkjw-finance-backend/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type idempotencyKeyID struct {
+	userID uuid.UUID
+	key    string
+}
+
+type memoryIdempotencyKeyRepo struct {
+	store *memoryStore
+}
+
+func (r *memoryIdempotencyKeyRepo) Claim(key *models.IdempotencyKey) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	now := time.Now()
+	for id, stored := range r.store.idempotencyKeys {
+		if stored.ExpiresAt.Before(now) {
+			delete(r.store.idempotencyKeys, id)
+		}
+	}
+
+	id := idempotencyKeyID{key.UserID, key.Key}
+	if stored, ok := r.store.idempotencyKeys[id]; ok && (stored.StatusCode != 0 || !stored.LockedUntil.Before(now)) {
+		return false, nil
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = now
+	}
+	r.store.idempotencyKeys[id] = *key
+	return true, nil
+}
+
+func (r *memoryIdempotencyKeyRepo) Find(userID uuid.UUID, key string) (*models.IdempotencyKey, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	found, ok := r.store.idempotencyKeys[idempotencyKeyID{userID, key}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &found, nil
+}
+
+func (r *memoryIdempotencyKeyRepo) Complete(key *models.IdempotencyKey) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	id := idempotencyKeyID{key.UserID, key.Key}
+	stored, ok := r.store.idempotencyKeys[id]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.StatusCode = key.StatusCode
+	stored.ResponseBody = key.ResponseBody
+	r.store.idempotencyKeys[id] = stored
+	return nil
+}
+
+func (r *memoryIdempotencyKeyRepo) Release(userID uuid.UUID, key string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	delete(r.store.idempotencyKeys, idempotencyKeyID{userID, key})
+	return nil
+}
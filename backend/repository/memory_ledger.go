@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"gkjw-finance-backend/models"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultAccounts mirrors the chart of accounts seeded by the general
+// ledger migration.
+func defaultAccounts() map[uuid.UUID]models.Account {
+	seed := []models.Account{
+		{ID: uuid.MustParse("00000000-0000-0000-0000-000000001101"), Code: models.AccountCodeCash, Name: "Kas", Type: models.AccountAsset},
+		{ID: uuid.MustParse("00000000-0000-0000-0000-000000001102"), Code: models.AccountCodeBank, Name: "Bank", Type: models.AccountAsset},
+		{ID: uuid.MustParse("00000000-0000-0000-0000-000000003100"), Code: models.AccountCodeFundBalance, Name: "Saldo Dana", Type: models.AccountEquity},
+		{ID: uuid.MustParse("00000000-0000-0000-0000-000000004100"), Code: models.AccountCodeIncome, Name: "Pendapatan", Type: models.AccountIncome},
+		{ID: uuid.MustParse("00000000-0000-0000-0000-000000005100"), Code: models.AccountCodeExpense, Name: "Beban", Type: models.AccountExpense},
+	}
+	accounts := make(map[uuid.UUID]models.Account, len(seed))
+	now := time.Now()
+	for _, account := range seed {
+		account.Status = "active"
+		account.CreatedAt, account.UpdatedAt = now, now
+		accounts[account.ID] = account
+	}
+	return accounts
+}
+
+type memoryAccountRepo struct {
+	store *memoryStore
+}
+
+func (r *memoryAccountRepo) List() ([]models.Account, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	accounts := make([]models.Account, 0, len(r.store.accounts))
+	for _, account := range r.store.accounts {
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].Code < accounts[j].Code })
+	return accounts, nil
+}
+
+func (r *memoryAccountRepo) FindByID(id uuid.UUID) (*models.Account, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	account, ok := r.store.accounts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &account, nil
+}
+
+func (r *memoryAccountRepo) FindByCode(code string) (*models.Account, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	for _, account := range r.store.accounts {
+		if account.Code == code {
+			return &account, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryAccountRepo) Create(account *models.Account) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stampNew(&account.ID, &account.CreatedAt, &account.UpdatedAt)
+	if account.Status == "" {
+		account.Status = "active"
+	}
+	r.store.accounts[account.ID] = *account
+	return nil
+}
+
+func (r *memoryAccountRepo) Update(account *models.Account) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	account.UpdatedAt = time.Now()
+	r.store.accounts[account.ID] = *account
+	return nil
+}
+
+type memoryJournalRepo struct {
+	store *memoryStore
+}
+
+func (f LedgerFilter) matches(entry models.JournalEntry, line models.JournalLine) bool {
+	if f.AccountID != uuid.Nil && line.AccountID != f.AccountID {
+		return false
+	}
+	if f.FundID != uuid.Nil && (line.FundID == nil || *line.FundID != f.FundID) {
+		return false
+	}
+	if !f.StartDate.IsZero() && entry.Date.Before(f.StartDate) {
+		return false
+	}
+	if !f.EndDate.IsZero() && entry.Date.After(f.EndDate) {
+		return false
+	}
+	return true
+}
+
+// withAccounts returns a copy of entry whose lines carry their accounts.
+// The caller must hold the store lock.
+func (r *memoryJournalRepo) withAccounts(entry models.JournalEntry) models.JournalEntry {
+	lines := make([]models.JournalLine, len(entry.Lines))
+	for i, line := range entry.Lines {
+		if account, ok := r.store.accounts[line.AccountID]; ok {
+			line.Account = &account
+		}
+		lines[i] = line
+	}
+	entry.Lines = lines
+	return entry
+}
+
+// sortedLocked returns every entry, oldest first. The caller must hold the
+// store lock.
+func (r *memoryJournalRepo) sortedLocked() []models.JournalEntry {
+	entries := make([]models.JournalEntry, 0, len(r.store.journalEntries))
+	for _, entry := range r.store.journalEntries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].Date.Equal(entries[j].Date) {
+			return entries[i].Date.Before(entries[j].Date)
+		}
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+	return entries
+}
+
+func (r *memoryJournalRepo) FindByID(id uuid.UUID) (*models.JournalEntry, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	entry, ok := r.store.journalEntries[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	entry = r.withAccounts(entry)
+	return &entry, nil
+}
+
+func (r *memoryJournalRepo) List(filter LedgerFilter) ([]models.JournalEntry, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	var entries []models.JournalEntry
+	for _, entry := range r.sortedLocked() {
+		for _, line := range entry.Lines {
+			if filter.matches(entry, line) {
+				entries = append(entries, r.withAccounts(entry))
+				break
+			}
+		}
+	}
+	return entries, nil
+}
+
+func (r *memoryJournalRepo) FindActiveByTransaction(transactionID uuid.UUID) (*models.JournalEntry, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	entries := r.sortedLocked()
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if entry.TransactionID != nil && *entry.TransactionID == transactionID &&
+			entry.Source == "transaction" && entry.ReversedByID == nil {
+			entry = r.withAccounts(entry)
+			return &entry, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryJournalRepo) Create(entry *models.JournalEntry) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stampNew(&entry.ID, &entry.CreatedAt, nil)
+	lines := make([]models.JournalLine, len(entry.Lines))
+	for i := range entry.Lines {
+		stampNew(&entry.Lines[i].ID, nil, nil)
+		entry.Lines[i].EntryID = entry.ID
+		lines[i] = entry.Lines[i]
+		lines[i].Account = nil
+		lines[i].Entry = nil
+	}
+	stored := *entry
+	stored.Lines = lines
+	r.store.journalEntries[entry.ID] = stored
+	return nil
+}
+
+func (r *memoryJournalRepo) MarkReversed(id, reversalID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	entry, ok := r.store.journalEntries[id]
+	if !ok {
+		return ErrNotFound
+	}
+	entry.ReversedByID = &reversalID
+	r.store.journalEntries[id] = entry
+	return nil
+}
+
+func (r *memoryJournalRepo) Lines(filter LedgerFilter) ([]models.JournalLine, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	var lines []models.JournalLine
+	for _, entry := range r.sortedLocked() {
+		for _, line := range entry.Lines {
+			if filter.matches(entry, line) {
+				header := entry
+				header.Lines = nil
+				line.Entry = &header
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines, nil
+}
+
+func (r *memoryJournalRepo) Balances(filter LedgerFilter) ([]AccountBalance, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	totals := map[uuid.UUID]*AccountBalance{}
+	var balances []AccountBalance
+	var order []uuid.UUID
+	for _, entry := range r.store.journalEntries {
+		for _, line := range entry.Lines {
+			if !filter.matches(entry, line) {
+				continue
+			}
+			total, ok := totals[line.AccountID]
+			if !ok {
+				total = &AccountBalance{AccountID: line.AccountID}
+				totals[line.AccountID] = total
+				order = append(order, line.AccountID)
+			}
+			total.Debit += line.Debit
+			total.Credit += line.Credit
+		}
+	}
+	for _, id := range order {
+		balances = append(balances, *totals[id])
+	}
+	return balances, nil
+}
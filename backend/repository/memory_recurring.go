@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"gkjw-finance-backend/models"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryRecurringTemplateRepo struct {
+	store *memoryStore
+}
+
+// withFund attaches the fund. The caller must hold the store lock.
+func (r *memoryRecurringTemplateRepo) withFund(template models.RecurringTemplate) models.RecurringTemplate {
+	if fund, ok := r.store.funds[template.FundID]; ok {
+		template.Fund = &fund
+	}
+	return template
+}
+
+func (r *memoryRecurringTemplateRepo) List() ([]models.RecurringTemplate, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	templates := make([]models.RecurringTemplate, 0, len(r.store.recurringTemplates))
+	for _, template := range r.store.recurringTemplates {
+		templates = append(templates, r.withFund(template))
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].Name != templates[j].Name {
+			return templates[i].Name < templates[j].Name
+		}
+		return templates[i].CreatedAt.Before(templates[j].CreatedAt)
+	})
+	return templates, nil
+}
+
+func (r *memoryRecurringTemplateRepo) ListDue(date time.Time) ([]models.RecurringTemplate, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	var templates []models.RecurringTemplate
+	for _, template := range r.store.recurringTemplates {
+		if template.Status == "active" && !template.NextRunDate.After(date) {
+			templates = append(templates, template)
+		}
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		if !templates[i].NextRunDate.Equal(templates[j].NextRunDate) {
+			return templates[i].NextRunDate.Before(templates[j].NextRunDate)
+		}
+		return templates[i].CreatedAt.Before(templates[j].CreatedAt)
+	})
+	return templates, nil
+}
+
+func (r *memoryRecurringTemplateRepo) FindByID(id uuid.UUID) (*models.RecurringTemplate, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	template, ok := r.store.recurringTemplates[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	template = r.withFund(template)
+	return &template, nil
+}
+
+func (r *memoryRecurringTemplateRepo) Create(template *models.RecurringTemplate) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stampNew(&template.ID, &template.CreatedAt, &template.UpdatedAt)
+	template.Version = 1
+	if template.Status == "" {
+		template.Status = "active"
+	}
+	row := *template
+	row.Fund = nil
+	r.store.recurringTemplates[row.ID] = row
+	return nil
+}
+
+func (r *memoryRecurringTemplateRepo) Update(template *models.RecurringTemplate) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stored, found := r.store.recurringTemplates[template.ID]
+	if err := checkVersion(stored.Version, found, &template.Version); err != nil {
+		return err
+	}
+	template.UpdatedAt = time.Now()
+	row := *template
+	row.Fund = nil
+	r.store.recurringTemplates[row.ID] = row
+	return nil
+}
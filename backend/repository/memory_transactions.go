@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"bytes"
+	"gkjw-finance-backend/models"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryTransactionRepo struct {
+	store *memoryStore
+}
+
+func (f TransactionFilter) matches(tx models.Transaction) bool {
+	if f.Status == StatusPosted {
+		if !tx.Posted() {
+			return false
+		}
+	} else if f.Status != "" && tx.Status != f.Status {
+		return false
+	}
+	if f.Type != "" && tx.Type != f.Type {
+		return false
+	}
+	if f.PaymentMethod != "" && tx.PaymentMethod != f.PaymentMethod && tx.ToPaymentMethod != f.PaymentMethod {
+		return false
+	}
+	if (f.Category != "" || len(f.CategoryIDs) > 0 || f.FundID != uuid.Nil) && len(f.Lines(tx)) == 0 {
+		return false
+	}
+	if f.EventID != uuid.Nil && (tx.EventID == nil || *tx.EventID != f.EventID) {
+		return false
+	}
+	if f.CreatedBy != uuid.Nil && tx.CreatedBy != f.CreatedBy {
+		return false
+	}
+	if !f.StartDate.IsZero() && tx.Date.Before(f.StartDate) {
+		return false
+	}
+	if !f.EndDate.IsZero() && tx.Date.After(f.EndDate) {
+		return false
+	}
+	if q := strings.ToLower(strings.TrimSpace(f.Query)); q != "" {
+		if !strings.Contains(strings.ToLower(tx.EventName), q) &&
+			!strings.Contains(strings.ToLower(tx.Description), q) &&
+			!strings.Contains(strings.ToLower(tx.Category), q) &&
+			!lineCategoryContains(tx, q) {
+			return false
+		}
+	}
+	return true
+}
+
+// lineCategoryContains reports whether the category of a line of tx
+// contains q, which is lower case.
+func lineCategoryContains(tx models.Transaction, q string) bool {
+	for _, line := range tx.Lines {
+		if strings.Contains(strings.ToLower(line.Category), q) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectLocked returns the matching rows with associations attached.
+// The caller must hold the store lock.
+func (r *memoryTransactionRepo) selectLocked(filter TransactionFilter) []models.Transaction {
+	var result []models.Transaction
+	for _, tx := range r.store.transactions {
+		if filter.matches(tx) {
+			result = append(result, r.withAssociations(tx))
+		}
+	}
+	return result
+}
+
+func (r *memoryTransactionRepo) withAssociations(tx models.Transaction) models.Transaction {
+	tx.Lines = append([]models.TransactionLine(nil), tx.Lines...)
+	if fund, ok := r.store.funds[tx.FundID]; ok {
+		tx.Fund = &fund
+	}
+	if tx.ToFundID != nil {
+		if fund, ok := r.store.funds[*tx.ToFundID]; ok {
+			tx.ToFund = &fund
+		}
+	}
+	if user, ok := r.store.users[tx.CreatedBy]; ok {
+		tx.CreatedByUser = &user
+	}
+	tx.Flags = r.store.flagsOf(tx.ID)
+	return tx
+}
+
+// compareTransactions orders two transactions by the sort key, then by ID.
+func compareTransactions(sortKey string, a, b models.Transaction) int {
+	switch sortKey {
+	case "date":
+		if c := a.Date.Compare(b.Date); c != 0 {
+			return c
+		}
+	case "amount":
+		if a.Amount != b.Amount {
+			if a.Amount < b.Amount {
+				return -1
+			}
+			return 1
+		}
+	default:
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+	}
+	return bytes.Compare(a.ID[:], b.ID[:])
+}
+
+func (r *memoryTransactionRepo) FindByID(id uuid.UUID) (*models.Transaction, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	tx, ok := r.store.transactions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	tx = r.withAssociations(tx)
+	return &tx, nil
+}
+
+func (r *memoryTransactionRepo) List(filter TransactionFilter, page PageRequest) (*TransactionPage, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.selectLocked(filter)
+	result := &TransactionPage{Total: int64(len(rows))}
+	for _, tx := range rows {
+		switch tx.Type {
+		case "income":
+			result.TotalIncome += filter.Amount(tx)
+		case "expense":
+			result.TotalExpense += filter.Amount(tx)
+		}
+	}
+
+	sign := 1
+	if page.Desc {
+		sign = -1
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return sign*compareTransactions(page.Sort, rows[i], rows[j]) < 0
+	})
+
+	if page.Cursor != "" {
+		value, id, err := decodeTransactionCursor(page.Sort, page.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		last := models.Transaction{ID: id}
+		switch v := value.(type) {
+		case models.Money:
+			last.Amount = v
+		case time.Time:
+			last.Date, last.CreatedAt = v, v
+		}
+		start := sort.Search(len(rows), func(i int) bool {
+			return sign*compareTransactions(page.Sort, rows[i], last) > 0
+		})
+		rows = rows[start:]
+	}
+
+	if len(rows) > page.Limit {
+		rows = rows[:page.Limit]
+		result.HasMore = true
+		result.NextCursor = encodeTransactionCursor(page.Sort, rows[page.Limit-1])
+	}
+	result.Items = rows
+	return result, nil
+}
+
+func (r *memoryTransactionRepo) FindAll(filter TransactionFilter) ([]models.Transaction, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	rows := r.selectLocked(filter)
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Date.Before(rows[j].Date) })
+	return rows, nil
+}
+
+func (r *memoryTransactionRepo) Count(filter TransactionFilter) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return int64(len(r.selectLocked(filter))), nil
+}
+
+func (r *memoryTransactionRepo) Sum(filter TransactionFilter) (models.Money, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	var sum models.Money
+	for _, tx := range r.selectLocked(filter) {
+		sum += filter.Amount(tx)
+	}
+	return sum, nil
+}
+
+func (r *memoryTransactionRepo) SumByCategory(filter TransactionFilter) ([]CategoryTotal, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	type categoryKey struct {
+		id   uuid.UUID
+		name string
+	}
+	var totals []CategoryTotal
+	index := map[categoryKey]int{}
+	for _, tx := range r.selectLocked(filter) {
+		for _, line := range filter.Lines(tx) {
+			key := categoryKey{name: line.Category}
+			if line.CategoryID != nil {
+				key.id = *line.CategoryID
+			}
+			i, ok := index[key]
+			if !ok {
+				i = len(totals)
+				index[key] = i
+				totals = append(totals, CategoryTotal{CategoryID: line.CategoryID, Category: line.Category})
+			}
+			totals[i].Amount += line.Amount
+		}
+	}
+	return totals, nil
+}
+
+func (r *memoryTransactionRepo) SumByFundAndMethod(filter TransactionFilter) ([]FundMethodTotal, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	var totals fundMethodTotals
+	for _, tx := range r.selectLocked(filter) {
+		for _, line := range filter.Lines(tx) {
+			totals.add(line.FundID, tx.PaymentMethod, tx.Type, line.Amount)
+		}
+		if tx.Type == "transfer" && tx.ToFundID != nil {
+			totals.addTransferIn(*tx.ToFundID, tx.ToPaymentMethod, tx.Amount)
+		}
+	}
+	return totals.rows, nil
+}
+
+func (r *memoryTransactionRepo) Create(transaction *models.Transaction) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stampNew(&transaction.ID, &transaction.CreatedAt, &transaction.UpdatedAt)
+	transaction.Version = 1
+	numberLines(transaction)
+	row := *transaction
+	row.Fund, row.ToFund, row.CreatedByUser, row.Flags = nil, nil, nil, nil
+	row.Lines = append([]models.TransactionLine(nil), transaction.Lines...)
+	r.store.transactions[row.ID] = row
+	return nil
+}
+
+func (r *memoryTransactionRepo) Update(transaction *models.Transaction) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stored, found := r.store.transactions[transaction.ID]
+	if err := checkVersion(stored.Version, found, &transaction.Version); err != nil {
+		return err
+	}
+	transaction.UpdatedAt = time.Now()
+	numberLines(transaction)
+	row := *transaction
+	row.Fund, row.ToFund, row.CreatedByUser, row.Flags = nil, nil, nil, nil
+	row.Lines = append([]models.TransactionLine(nil), transaction.Lines...)
+	r.store.transactions[row.ID] = row
+	return nil
+}
+
+func (r *memoryTransactionRepo) Delete(id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if _, ok := r.store.transactions[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.store.transactions, id)
+	for flagID, flag := range r.store.transactionFlags {
+		if flag.TransactionID == id {
+			delete(r.store.transactionFlags, flagID)
+		} else if flag.RelatedTransactionID != nil && *flag.RelatedTransactionID == id {
+			flag.RelatedTransactionID = nil
+			r.store.transactionFlags[flagID] = flag
+		}
+	}
+	return nil
+}
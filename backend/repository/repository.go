@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"errors"
+	"gkjw-finance-backend/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned when the requested row does not exist.
+var ErrNotFound = errors.New("record not found")
+
+// ErrVersionConflict is returned by Update when the row was changed since
+// it was read: its Version no longer matches the one being saved. On
+// success Update raises the Version of the saved value by one.
+var ErrVersionConflict = errors.New("record was changed by someone else")
+
+// ErrDuplicate is returned by Create and Update when the row would break a
+// unique constraint, such as a second event with the same name.
+var ErrDuplicate = errors.New("record already exists")
+
+type TransactionRepo interface {
+	FindByID(id uuid.UUID) (*models.Transaction, error)
+	// List returns one keyset-paginated page of the matching transactions.
+	List(filter TransactionFilter, page PageRequest) (*TransactionPage, error)
+	// FindAll returns every matching transaction ordered by date.
+	FindAll(filter TransactionFilter) ([]models.Transaction, error)
+	Count(filter TransactionFilter) (int64, error)
+	Sum(filter TransactionFilter) (models.Money, error)
+	SumByCategory(filter TransactionFilter) ([]CategoryTotal, error)
+	// SumByFundAndMethod returns the income, expense and transfer totals of
+	// the matching transactions per fund and payment method. Transfers count
+	// out of their source and into their destination.
+	SumByFundAndMethod(filter TransactionFilter) ([]FundMethodTotal, error)
+	Create(transaction *models.Transaction) error
+	Update(transaction *models.Transaction) error
+	Delete(id uuid.UUID) error
+}
+
+type FundRepo interface {
+	List() ([]models.Fund, error)
+	FindByID(id uuid.UUID) (*models.Fund, error)
+	Create(fund *models.Fund) error
+	Update(fund *models.Fund) error
+	Delete(id uuid.UUID) error
+}
+
+type CategoryRepo interface {
+	List() ([]models.Category, error)
+	FindByID(id uuid.UUID) (*models.Category, error)
+	Create(category *models.Category) error
+	Update(category *models.Category) error
+	Delete(id uuid.UUID) error
+	// CountTransactions counts the transactions with a line booked to the
+	// category, by transaction type.
+	CountTransactions(id uuid.UUID) (map[string]int, error)
+	// TransactionDates returns the distinct dates of the transactions with
+	// a line booked to the category.
+	TransactionDates(id uuid.UUID) ([]time.Time, error)
+	// Relabel sets name as the category name of the transactions and lines
+	// booked to the category.
+	Relabel(id uuid.UUID, name string) error
+	// Merge books the transactions and lines of the category from to into,
+	// moves the subcategories of from under into and deletes from.
+	Merge(from uuid.UUID, into *models.Category) error
+}
+
+type UserRepo interface {
+	// List returns all users, newest first.
+	List() ([]models.User, error)
+	FindByID(id uuid.UUID) (*models.User, error)
+	FindByEmail(email string) (*models.User, error)
+	Create(user *models.User) error
+	Update(user *models.User) error
+	Delete(id uuid.UUID) error
+}
+
+type ActivityLogRepo interface {
+	Create(log *models.ActivityLog) error
+	// List returns one keyset-paginated page of the matching logs with
+	// their users, newest first.
+	List(filter ActivityLogFilter, limit int, cursor string) (*ActivityLogPage, error)
+}
+
+type AccountRepo interface {
+	// List returns the chart of accounts ordered by code.
+	List() ([]models.Account, error)
+	FindByID(id uuid.UUID) (*models.Account, error)
+	FindByCode(code string) (*models.Account, error)
+	Create(account *models.Account) error
+	Update(account *models.Account) error
+}
+
+type JournalRepo interface {
+	// FindByID returns the entry with its lines and their accounts.
+	FindByID(id uuid.UUID) (*models.JournalEntry, error)
+	// List returns the entries matching filter with their lines, oldest first.
+	List(filter LedgerFilter) ([]models.JournalEntry, error)
+	// FindActiveByTransaction returns the posting of a transaction that has
+	// not been reversed yet, or ErrNotFound.
+	FindActiveByTransaction(transactionID uuid.UUID) (*models.JournalEntry, error)
+	// Create inserts the entry together with its lines.
+	Create(entry *models.JournalEntry) error
+	MarkReversed(id, reversalID uuid.UUID) error
+	// Lines returns the matching lines with their entries, ordered by entry date.
+	Lines(filter LedgerFilter) ([]models.JournalLine, error)
+	// Balances returns the debit and credit totals of every account that
+	// has matching lines.
+	Balances(filter LedgerFilter) ([]AccountBalance, error)
+}
+
+type FiscalYearRepo interface {
+	// List returns all fiscal years ordered by start date.
+	List() ([]models.FiscalYear, error)
+	FindByID(id uuid.UUID) (*models.FiscalYear, error)
+	Create(year *models.FiscalYear) error
+	Update(year *models.FiscalYear) error
+}
+
+type OpeningBalanceRepo interface {
+	// List returns the matching opening balances with their funds, oldest
+	// as-of date first.
+	List(filter OpeningBalanceFilter) ([]models.OpeningBalance, error)
+	FindByID(id uuid.UUID) (*models.OpeningBalance, error)
+	// FindByKey returns the opening balance of a fund and payment method on
+	// one date, or ErrNotFound.
+	FindByKey(fundID uuid.UUID, paymentMethod string, asOf time.Time) (*models.OpeningBalance, error)
+	Create(balance *models.OpeningBalance) error
+	Update(balance *models.OpeningBalance) error
+	Delete(id uuid.UUID) error
+}
+
+type AccountingPeriodRepo interface {
+	// List returns all accounting periods ordered by start date.
+	List() ([]models.AccountingPeriod, error)
+	FindByID(id uuid.UUID) (*models.AccountingPeriod, error)
+	FindByName(name string) (*models.AccountingPeriod, error)
+	// FindClosedOn returns a closed period containing date, or ErrNotFound.
+	FindClosedOn(date time.Time) (*models.AccountingPeriod, error)
+	Create(period *models.AccountingPeriod) error
+	Update(period *models.AccountingPeriod) error
+}
+
+type ApprovalPolicyRepo interface {
+	// List returns all policies with their steps in order, lowest priority
+	// first.
+	List() ([]models.ApprovalPolicy, error)
+	FindByID(id uuid.UUID) (*models.ApprovalPolicy, error)
+	// Create and Update save the policy together with its steps; Update
+	// replaces the existing steps.
+	Create(policy *models.ApprovalPolicy) error
+	Update(policy *models.ApprovalPolicy) error
+	Delete(id uuid.UUID) error
+}
+
+type ApprovalRepo interface {
+	// ListByTransaction returns the signatures on a transaction with their
+	// approvers, oldest first.
+	ListByTransaction(transactionID uuid.UUID) ([]models.Approval, error)
+	Create(approval *models.Approval) error
+	// Supersede marks the current signatures on a transaction superseded.
+	Supersede(transactionID uuid.UUID) error
+}
+
+type RecurringTemplateRepo interface {
+	// List returns all templates with their funds ordered by name.
+	List() ([]models.RecurringTemplate, error)
+	// ListDue returns the active templates whose next occurrence is on or
+	// before date, soonest first.
+	ListDue(date time.Time) ([]models.RecurringTemplate, error)
+	FindByID(id uuid.UUID) (*models.RecurringTemplate, error)
+	Create(template *models.RecurringTemplate) error
+	Update(template *models.RecurringTemplate) error
+}
+
+type BankStatementRepo interface {
+	// List returns the statements without their lines, latest first.
+	List() ([]models.BankStatement, error)
+	// FindByID returns the statement with its lines in order.
+	FindByID(id uuid.UUID) (*models.BankStatement, error)
+	FindByChecksum(checksum string) (*models.BankStatement, error)
+	// Create inserts the statement together with its lines.
+	Create(statement *models.BankStatement) error
+	// Delete removes the statement and its lines.
+	Delete(id uuid.UUID) error
+	// ListLines returns the lines of every statement with the given status
+	// (any when empty), oldest first.
+	ListLines(status string) ([]models.BankStatementLine, error)
+	UpdateLine(line *models.BankStatementLine) error
+}
+
+type CategorizationRuleRepo interface {
+	// List returns all rules with their funds, lowest priority first.
+	List() ([]models.CategorizationRule, error)
+	FindByID(id uuid.UUID) (*models.CategorizationRule, error)
+	Create(rule *models.CategorizationRule) error
+	Update(rule *models.CategorizationRule) error
+	Delete(id uuid.UUID) error
+}
+
+type EventRepo interface {
+	// List returns all events with their funds, latest first.
+	List() ([]models.Event, error)
+	FindByID(id uuid.UUID) (*models.Event, error)
+	Create(event *models.Event) error
+	Update(event *models.Event) error
+	Delete(id uuid.UUID) error
+	// Relabel sets name as the event name of the transactions linked to
+	// the event and raises their versions.
+	Relabel(id uuid.UUID, name string) error
+	// Link links the transactions to the event, sets their event name to
+	// that of the event and raises their versions.
+	Link(event *models.Event, transactionIDs []uuid.UUID) error
+}
+
+type TransactionFlagRepo interface {
+	// List returns the flags with the given status and kind (any when
+	// empty) with their transactions, newest first.
+	List(status, kind string) ([]models.TransactionFlag, error)
+	FindByID(id uuid.UUID) (*models.TransactionFlag, error)
+	// ListByTransaction returns the flags on a transaction, oldest first.
+	ListByTransaction(transactionID uuid.UUID) ([]models.TransactionFlag, error)
+	Create(flag *models.TransactionFlag) error
+	Update(flag *models.TransactionFlag) error
+	// DeleteOpen removes the open flags on a transaction, leaving the
+	// dismissed ones.
+	DeleteOpen(transactionID uuid.UUID) error
+}
+
+// IdempotencyKeyRepo stores Idempotency-Key responses. It is used outside
+// WithTx: a key is claimed before the request runs and must outlive a
+// rollback of that request.
+type IdempotencyKeyRepo interface {
+	// Claim stores key unless the same user already holds a key with that
+	// name, and reports whether it did. Expired keys are removed first,
+	// and a key still running past its LockedUntil is taken over.
+	Claim(key *models.IdempotencyKey) (bool, error)
+	Find(userID uuid.UUID, key string) (*models.IdempotencyKey, error)
+	// Complete records the status code and response body of a claimed key.
+	Complete(key *models.IdempotencyKey) error
+	// Release removes a claimed key so that the request can be retried.
+	Release(userID uuid.UUID, key string) error
+}
+
+// Repositories bundles every repository used by the handlers. It is built
+// once by NewGormRepositories or NewMemoryRepositories and shared by all
+// handler structs.
+type Repositories struct {
+	Transactions TransactionRepo
+	Funds        FundRepo
+	Categories   CategoryRepo
+	Users        UserRepo
+	ActivityLogs ActivityLogRepo
+	Accounts     AccountRepo
+	Journals     JournalRepo
+
+	FiscalYears     FiscalYearRepo
+	OpeningBalances OpeningBalanceRepo
+	Periods         AccountingPeriodRepo
+
+	ApprovalPolicies ApprovalPolicyRepo
+	Approvals        ApprovalRepo
+
+	RecurringTemplates RecurringTemplateRepo
+	BankStatements     BankStatementRepo
+	TransactionFlags   TransactionFlagRepo
+
+	CategorizationRules CategorizationRuleRepo
+	Events              EventRepo
+
+	IdempotencyKeys IdempotencyKeyRepo
+
+	transaction func(fn func(repos *Repositories) error) error
+	tryLock     func(key int64, fn func() error) (bool, error)
+}
+
+// WithTx runs fn inside a database transaction. The Repositories passed to
+// fn are bound to that transaction; when fn returns an error every change
+// made through them is rolled back.
+func (r *Repositories) WithTx(fn func(repos *Repositories) error) error {
+	return r.transaction(fn)
+}
+
+// TryLock runs fn while holding the lock named by key, which is shared by
+// every server using the same database. When another holder has the lock
+// it returns false without running fn.
+func (r *Repositories) TryLock(key int64, fn func() error) (bool, error) {
+	return r.tryLock(key, fn)
+}
@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"gkjw-finance-backend/models"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidCursor is returned when a page cursor cannot be decoded or was
+// issued for a different sort.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// StatusPosted is a TransactionFilter status that selects the transactions
+// counted in balances and reports: approved and voided ones (see
+// models.Transaction.Posted).
+const StatusPosted = "posted"
+
+// TransactionFilter selects transactions. Zero values mean "no filter".
+// StartDate and EndDate are inclusive. FundID and PaymentMethod match either
+// side of a transfer. FundID, Category and CategoryIDs select a transaction
+// when any of its lines matches; sums only count the matching lines.
+type TransactionFilter struct {
+	Status        string
+	Type          string
+	PaymentMethod string
+	Category      string
+	// CategoryIDs selects lines booked to any of the categories, such as a
+	// category and its subcategories.
+	CategoryIDs []uuid.UUID
+	FundID      uuid.UUID
+	EventID     uuid.UUID
+	CreatedBy   uuid.UUID
+	StartDate   time.Time
+	EndDate     time.Time
+	// Query is a case-insensitive search on event name, description and
+	// category.
+	Query string
+}
+
+// Selects reports whether money held in fundID with paymentMethod belongs
+// to the funds and payment methods the filter selects.
+func (f TransactionFilter) Selects(fundID uuid.UUID, paymentMethod string) bool {
+	return (f.FundID == uuid.Nil || fundID == f.FundID) &&
+		(f.PaymentMethod == "" || paymentMethod == f.PaymentMethod)
+}
+
+// Lines returns the lines of tx (see models.Transaction.Splits) that the
+// fund and category of the filter select. A transfer also counts when its
+// destination is the selected fund.
+func (f TransactionFilter) Lines(tx models.Transaction) []models.TransactionLine {
+	var lines []models.TransactionLine
+	for _, line := range tx.Splits() {
+		if f.FundID != uuid.Nil && line.FundID != f.FundID && (tx.ToFundID == nil || *tx.ToFundID != f.FundID) {
+			continue
+		}
+		if f.Category != "" && line.Category != f.Category {
+			continue
+		}
+		if len(f.CategoryIDs) > 0 && (line.CategoryID == nil || !slices.Contains(f.CategoryIDs, *line.CategoryID)) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Amount returns the part of the amount of tx that the filter selects: the
+// sum of its selected lines.
+func (f TransactionFilter) Amount(tx models.Transaction) models.Money {
+	var amount models.Money
+	for _, line := range f.Lines(tx) {
+		amount += line.Amount
+	}
+	return amount
+}
+
+// BalanceEffect returns how tx changes the balance of the funds and payment
+// methods the filter selects. Income adds and expense subtracts the selected
+// lines; a transfer only counts when it moves money into or out of the
+// selection.
+func (f TransactionFilter) BalanceEffect(tx models.Transaction) models.Money {
+	switch tx.Type {
+	case "income":
+		return f.Amount(tx)
+	case "expense":
+		return -f.Amount(tx)
+	case "transfer":
+		var effect models.Money
+		if f.Selects(tx.FundID, tx.PaymentMethod) {
+			effect -= tx.Amount
+		}
+		if tx.ToFundID != nil && f.Selects(*tx.ToFundID, tx.ToPaymentMethod) {
+			effect += tx.Amount
+		}
+		return effect
+	}
+	return 0
+}
+
+// PageRequest describes one page of a keyset-paginated listing.
+type PageRequest struct {
+	Sort   string // date, amount or createdAt
+	Desc   bool
+	Limit  int
+	Cursor string
+}
+
+// TransactionPage is one page of transactions plus the totals of the whole
+// filtered selection.
+type TransactionPage struct {
+	Items        []models.Transaction
+	Total        int64
+	TotalIncome  models.Money
+	TotalExpense models.Money
+	HasMore      bool
+	NextCursor   string
+}
+
+// CategoryTotal is the amount booked to one category. CategoryID is nil
+// for transfers.
+type CategoryTotal struct {
+	CategoryID *uuid.UUID
+	Category   string
+	Amount     models.Money
+}
+
+// FundMethodTotal is the movement of one fund and payment method.
+type FundMethodTotal struct {
+	FundID        uuid.UUID
+	PaymentMethod string
+	Income        models.Money
+	Expense       models.Money
+	TransferIn    models.Money
+	TransferOut   models.Money
+}
+
+// Net returns the change in balance the movement causes.
+func (t FundMethodTotal) Net() models.Money {
+	return t.Income - t.Expense + t.TransferIn - t.TransferOut
+}
+
+// fundMethodTotals accumulates FundMethodTotal rows in first-seen order.
+type fundMethodTotals struct {
+	index map[fundMethodKey]int
+	rows  []FundMethodTotal
+}
+
+type fundMethodKey struct {
+	fundID        uuid.UUID
+	paymentMethod string
+}
+
+func (t *fundMethodTotals) get(fundID uuid.UUID, paymentMethod string) *FundMethodTotal {
+	key := fundMethodKey{fundID, paymentMethod}
+	if t.index == nil {
+		t.index = map[fundMethodKey]int{}
+	}
+	i, ok := t.index[key]
+	if !ok {
+		i = len(t.rows)
+		t.index[key] = i
+		t.rows = append(t.rows, FundMethodTotal{FundID: fundID, PaymentMethod: paymentMethod})
+	}
+	return &t.rows[i]
+}
+
+// add records amount moved by a transaction of type txType. For transfers
+// it records the outgoing side only; see addTransferIn.
+func (t *fundMethodTotals) add(fundID uuid.UUID, paymentMethod, txType string, amount models.Money) {
+	row := t.get(fundID, paymentMethod)
+	switch txType {
+	case "income":
+		row.Income += amount
+	case "expense":
+		row.Expense += amount
+	case "transfer":
+		row.TransferOut += amount
+	}
+}
+
+func (t *fundMethodTotals) addTransferIn(fundID uuid.UUID, paymentMethod string, amount models.Money) {
+	t.get(fundID, paymentMethod).TransferIn += amount
+}
+
+// transactionSortColumns maps the public sort keys to their columns.
+var transactionSortColumns = map[string]string{
+	"date":      "date",
+	"amount":    "amount",
+	"createdAt": "created_at",
+}
+
+// ValidTransactionSort reports whether sort is a supported sort key.
+func ValidTransactionSort(sort string) bool {
+	_, ok := transactionSortColumns[sort]
+	return ok
+}
+
+// transactionCursor is the keyset position of the last row on a page.
+// It is handed to clients as an opaque base64 string.
+type transactionCursor struct {
+	Sort  string    `json:"s"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+func encodeTransactionCursor(sort string, tx models.Transaction) string {
+	cursor := transactionCursor{Sort: sort, ID: tx.ID}
+	switch sort {
+	case "date":
+		cursor.Value = tx.Date.Format(time.RFC3339Nano)
+	case "amount":
+		cursor.Value = tx.Amount.String()
+	default:
+		cursor.Value = tx.CreatedAt.Format(time.RFC3339Nano)
+	}
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeTransactionCursor returns the typed sort value (time.Time or
+// models.Money) and row ID stored in an opaque cursor.
+func decodeTransactionCursor(sort, encoded string) (interface{}, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, uuid.Nil, ErrInvalidCursor
+	}
+	var cursor transactionCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.Sort != sort {
+		return nil, uuid.Nil, ErrInvalidCursor
+	}
+	if sort == "amount" {
+		value, err := models.ParseMoney(cursor.Value)
+		if err != nil {
+			return nil, uuid.Nil, ErrInvalidCursor
+		}
+		return value, cursor.ID, nil
+	}
+	value, err := time.Parse(time.RFC3339Nano, cursor.Value)
+	if err != nil {
+		return nil, uuid.Nil, ErrInvalidCursor
+	}
+	return value, cursor.ID, nil
+}
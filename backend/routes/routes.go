@@ -3,11 +3,33 @@ package routes
 import (
 	"gkjw-finance-backend/handlers"
 	"gkjw-finance-backend/middleware"
+	"gkjw-finance-backend/repository"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.Engine) {
+func SetupRoutes(router *gin.Engine, repos *repository.Repositories) {
+	router.Use(middleware.RequestID())
+
+	authHandler := handlers.NewAuthHandler(repos)
+	dashboardHandler := handlers.NewDashboardHandler(repos)
+	transactionHandler := handlers.NewTransactionHandler(repos)
+	reportHandler := handlers.NewReportHandler(repos)
+	categoryHandler := handlers.NewCategoryHandler(repos)
+	fundHandler := handlers.NewFundHandler(repos)
+	userHandler := handlers.NewUserHandler(repos)
+	ledgerHandler := handlers.NewLedgerHandler(repos)
+	fiscalYearHandler := handlers.NewFiscalYearHandler(repos)
+	periodHandler := handlers.NewPeriodHandler(repos)
+	approvalPolicyHandler := handlers.NewApprovalPolicyHandler(repos)
+	recurringHandler := handlers.NewRecurringHandler(repos)
+	bankStatementHandler := handlers.NewBankStatementHandler(repos)
+	importHandler := handlers.NewImportHandler(repos)
+	flagHandler := handlers.NewTransactionFlagHandler(repos)
+	ruleHandler := handlers.NewCategorizationRuleHandler(repos)
+	eventHandler := handlers.NewEventHandler(repos)
+	idempotent := middleware.Idempotency(repos)
+
 	// Health check endpoint
 	router.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -16,7 +38,7 @@ func SetupRoutes(router *gin.Engine) {
 			"version": "1.0.0",
 		})
 	})
-	
+
 	// Leapcell healthcheck endpoints
 	router.GET("/kaithheathcheck", func(c *gin.Context) {
 		c.String(200, "OK")
@@ -29,30 +51,33 @@ func SetupRoutes(router *gin.Engine) {
 	// Auth endpoints
 	auth := router.Group("/api/auth")
 	{
-		auth.POST("/login", handlers.Login)
-		auth.POST("/register", handlers.Register)
+		auth.POST("/login", authHandler.Login)
+		auth.POST("/register", authHandler.Register)
 	}
 
 	// Public Dashboard endpoints
 	dashboard := router.Group("/api/dashboard")
 	{
-		dashboard.GET("/stats", handlers.GetDashboardStats)
-		dashboard.GET("/monthly", handlers.GetMonthlyData)
-		dashboard.GET("/category", handlers.GetCategoryData)
+		dashboard.GET("/stats", dashboardHandler.GetDashboardStats)
+		dashboard.GET("/monthly", dashboardHandler.GetMonthlyData)
+		dashboard.GET("/category", dashboardHandler.GetCategoryData)
+		dashboard.GET("/balances", dashboardHandler.GetBalances)
 	}
 
-	// Public Categories endpoint (GET only - for form dropdowns)
-	router.GET("/api/categories", handlers.GetCategories)
+	// Public Categories endpoints (GET only - for form dropdowns)
+	router.GET("/api/categories", categoryHandler.GetCategories)
+	router.GET("/api/categories/:id", categoryHandler.GetCategory)
 
-	// Public Funds endpoint (GET only - for form dropdowns)
-	router.GET("/api/funds", handlers.GetFunds)
+	// Public Funds endpoints (GET only - for form dropdowns)
+	router.GET("/api/funds", fundHandler.GetFunds)
+	router.GET("/api/funds/:id", fundHandler.GetFund)
 
 	// Public Reports endpoints
 	reports := router.Group("/api/reports")
 	{
-		reports.GET("", handlers.GetReports)
-		reports.GET("/export/pdf", handlers.ExportPDF)
-		reports.GET("/export/excel", handlers.ExportExcel)
+		reports.GET("", reportHandler.GetReports)
+		reports.GET("/export/pdf", reportHandler.ExportPDF)
+		reports.GET("/export/excel", reportHandler.ExportExcel)
 	}
 
 	// ==================== PROTECTED ROUTES (AUTH REQUIRED) ====================
@@ -65,38 +90,50 @@ func SetupRoutes(router *gin.Engine) {
 			adminCategories := categories.Group("")
 			adminCategories.Use(middleware.AdminOnly())
 			{
-				adminCategories.POST("", handlers.CreateCategory)
-				adminCategories.PUT(":id", handlers.UpdateCategory)
-				adminCategories.DELETE(":id", handlers.DeleteCategory)
+				adminCategories.POST("", categoryHandler.CreateCategory)
+				adminCategories.PUT(":id", categoryHandler.UpdateCategory)
+				adminCategories.DELETE(":id", categoryHandler.DeleteCategory)
+				adminCategories.POST(":id/merge", categoryHandler.MergeCategory)
 			}
 		}
 
 		// Dashboard POST (create transactions - protected)
 		dashboardProtected := api.Group("/dashboard")
 		{
-			dashboardProtected.POST("", handlers.CreateTransaction)
+			dashboardProtected.POST("", idempotent, transactionHandler.CreateTransaction)
 		}
 
 		// Transactions (protected operations)
 		transactions := api.Group("/transactions")
 		{
-			transactions.GET("", handlers.GetTransactions)
-			transactions.GET("/:id", handlers.GetTransactionByID)
-			transactions.POST("", handlers.CreateTransaction)
-			transactions.PUT("/:id", handlers.UpdateTransaction)
-			transactions.PUT("/:id/status", handlers.UpdateTransactionStatus)
-			transactions.DELETE("/:id", middleware.AdminOnly(), handlers.DeleteTransaction)
+			transactions.GET("", transactionHandler.GetTransactions)
+			transactions.GET("/:id", transactionHandler.GetTransactionByID)
+			transactions.GET("/:id/approvals", transactionHandler.GetTransactionApprovals)
+			transactions.POST("", idempotent, transactionHandler.CreateTransaction)
+			transactions.POST("/batch", idempotent, transactionHandler.BatchCreateTransactions)
+			transactions.POST("/batch/status", transactionHandler.BatchUpdateTransactionStatus)
+			transactions.PUT("/:id", transactionHandler.UpdateTransaction)
+			transactions.PUT("/:id/status", transactionHandler.UpdateTransactionStatus)
+			transactions.POST("/:id/void", middleware.AdminOnly(), transactionHandler.VoidTransaction)
+			transactions.DELETE("/:id", middleware.AdminOnly(), transactionHandler.DeleteTransaction)
+		}
+
+		// Transaction flags (reviewed by the approvers of each transaction)
+		flags := api.Group("/transaction-flags")
+		{
+			flags.GET("", flagHandler.GetFlags)
+			flags.POST("/:id/dismiss", flagHandler.DismissFlag)
 		}
 
 		// Users (Admin only)
 		users := api.Group("/users")
 		users.Use(middleware.AdminOnly())
 		{
-			users.GET("", handlers.GetUsers)
-			users.GET("/:id", handlers.GetUserByID)
-			users.POST("", handlers.CreateUser)
-			users.PUT("/:id", handlers.UpdateUser)
-			users.DELETE("/:id", handlers.DeleteUser)
+			users.GET("", userHandler.GetUsers)
+			users.GET("/:id", userHandler.GetUserByID)
+			users.POST("", userHandler.CreateUser)
+			users.PUT("/:id", userHandler.UpdateUser)
+			users.DELETE("/:id", userHandler.DeleteUser)
 		}
 
 		// Funds (protected - write operations only)
@@ -105,16 +142,154 @@ func SetupRoutes(router *gin.Engine) {
 			fundsAdmin := funds.Group("")
 			fundsAdmin.Use(middleware.AdminOnly())
 			{
-				fundsAdmin.POST("", handlers.CreateFund)
-				fundsAdmin.PUT("/:id", handlers.UpdateFund)
-				fundsAdmin.DELETE("/:id", handlers.DeleteFund)
+				fundsAdmin.POST("", fundHandler.CreateFund)
+				fundsAdmin.PUT("/:id", fundHandler.UpdateFund)
+				fundsAdmin.DELETE("/:id", fundHandler.DeleteFund)
+			}
+		}
+
+		// Events (reads for any user, writes admin only)
+		events := api.Group("/events")
+		{
+			events.GET("", eventHandler.GetEvents)
+			events.GET("/:id", eventHandler.GetEvent)
+			events.GET("/:id/report", eventHandler.GetEventReport)
+
+			eventsAdmin := events.Group("")
+			eventsAdmin.Use(middleware.AdminOnly())
+			{
+				eventsAdmin.POST("", eventHandler.CreateEvent)
+				eventsAdmin.PUT("/:id", eventHandler.UpdateEvent)
+				eventsAdmin.DELETE("/:id", eventHandler.DeleteEvent)
+				eventsAdmin.POST("/:id/link", eventHandler.LinkTransactions)
+			}
+		}
+
+		// General ledger (reads for any user, writes admin only)
+		ledger := api.Group("/ledger")
+		{
+			ledger.GET("/accounts", ledgerHandler.GetAccounts)
+			ledger.GET("/journals", ledgerHandler.GetJournals)
+			ledger.GET("/journals/:id", ledgerHandler.GetJournalByID)
+			ledger.GET("/general-ledger", ledgerHandler.GetGeneralLedger)
+			ledger.GET("/trial-balance", ledgerHandler.GetTrialBalance)
+
+			ledgerAdmin := ledger.Group("")
+			ledgerAdmin.Use(middleware.AdminOnly())
+			{
+				ledgerAdmin.POST("/accounts", ledgerHandler.CreateAccount)
+				ledgerAdmin.PUT("/accounts/:id", ledgerHandler.UpdateAccount)
+				ledgerAdmin.POST("/journals", ledgerHandler.CreateJournal)
+				ledgerAdmin.POST("/journals/:id/reverse", ledgerHandler.ReverseJournal)
+				ledgerAdmin.POST("/post-approved", ledgerHandler.PostApprovedTransactions)
+			}
+		}
+
+		// Fiscal years and opening balances (reads for any user, writes admin only)
+		fiscalYears := api.Group("/fiscal-years")
+		{
+			fiscalYears.GET("", fiscalYearHandler.GetFiscalYears)
+
+			fiscalYearsAdmin := fiscalYears.Group("")
+			fiscalYearsAdmin.Use(middleware.AdminOnly())
+			{
+				fiscalYearsAdmin.POST("", fiscalYearHandler.CreateFiscalYear)
+				fiscalYearsAdmin.POST("/:id/close", fiscalYearHandler.CloseFiscalYear)
+			}
+		}
+
+		openingBalances := api.Group("/opening-balances")
+		{
+			openingBalances.GET("", fiscalYearHandler.GetOpeningBalances)
+
+			openingBalancesAdmin := openingBalances.Group("")
+			openingBalancesAdmin.Use(middleware.AdminOnly())
+			{
+				openingBalancesAdmin.POST("", fiscalYearHandler.SetOpeningBalance)
+				openingBalancesAdmin.DELETE("/:id", fiscalYearHandler.DeleteOpeningBalance)
+			}
+		}
+
+		// Accounting periods (reads for any user, close/reopen admin only)
+		periods := api.Group("/periods")
+		{
+			periods.GET("", periodHandler.GetPeriods)
+
+			periodsAdmin := periods.Group("")
+			periodsAdmin.Use(middleware.AdminOnly())
+			{
+				periodsAdmin.POST("/close", periodHandler.ClosePeriod)
+				periodsAdmin.POST("/:id/reopen", periodHandler.ReopenPeriod)
 			}
 		}
 
+		// Approval policies (reads for any user, writes admin only)
+		approvalPolicies := api.Group("/approval-policies")
+		{
+			approvalPolicies.GET("", approvalPolicyHandler.GetPolicies)
+
+			approvalPoliciesAdmin := approvalPolicies.Group("")
+			approvalPoliciesAdmin.Use(middleware.AdminOnly())
+			{
+				approvalPoliciesAdmin.POST("", approvalPolicyHandler.CreatePolicy)
+				approvalPoliciesAdmin.PUT("/:id", approvalPolicyHandler.UpdatePolicy)
+				approvalPoliciesAdmin.DELETE("/:id", approvalPolicyHandler.DeletePolicy)
+			}
+		}
+
+		// Recurring templates (Admin only)
+		recurring := api.Group("/recurring")
+		recurring.Use(middleware.AdminOnly())
+		{
+			recurring.GET("", recurringHandler.GetTemplates)
+			recurring.POST("", recurringHandler.CreateTemplate)
+			recurring.POST("/run", recurringHandler.RunTemplates)
+			recurring.GET("/:id", recurringHandler.GetTemplate)
+			recurring.PUT("/:id", recurringHandler.UpdateTemplate)
+			recurring.POST("/:id/pause", recurringHandler.PauseTemplate)
+			recurring.POST("/:id/resume", recurringHandler.ResumeTemplate)
+			recurring.POST("/:id/end", recurringHandler.EndTemplate)
+		}
+
+		// Bank statements and reconciliation (Admin only)
+		bankStatements := api.Group("/bank-statements")
+		bankStatements.Use(middleware.AdminOnly())
+		{
+			bankStatements.GET("", bankStatementHandler.GetStatements)
+			bankStatements.POST("", bankStatementHandler.ImportStatement)
+			bankStatements.GET("/queue", bankStatementHandler.GetQueue)
+			bankStatements.GET("/:id", bankStatementHandler.GetStatement)
+			bankStatements.DELETE("/:id", bankStatementHandler.DeleteStatement)
+			bankStatements.GET("/:id/reconciliation", bankStatementHandler.GetReconciliation)
+			bankStatements.POST("/:id/match", bankStatementHandler.RematchStatement)
+			bankStatements.POST("/:id/lines/:lineId/match", bankStatementHandler.MatchLine)
+			bankStatements.POST("/:id/lines/:lineId/unmatch", bankStatementHandler.UnmatchLine)
+			bankStatements.POST("/:id/lines/:lineId/ignore", bankStatementHandler.IgnoreLine)
+		}
+
+		// Transaction imports (Admin only)
+		imports := api.Group("/imports")
+		imports.Use(middleware.AdminOnly())
+		{
+			imports.POST("/transactions", idempotent, importHandler.ImportTransactionFile)
+			imports.POST("/transactions/error-report", importHandler.GetImportErrorReport)
+		}
+
+		// Categorization rules (Admin only)
+		rules := api.Group("/categorization-rules")
+		rules.Use(middleware.AdminOnly())
+		{
+			rules.GET("", ruleHandler.GetRules)
+			rules.POST("", ruleHandler.CreateRule)
+			rules.POST("/run", ruleHandler.RunRules)
+			rules.PUT("/:id", ruleHandler.UpdateRule)
+			rules.DELETE("/:id", ruleHandler.DeleteRule)
+		}
+
 		// Activity Logs (Admin only)
 		logs := api.Group("/logs")
 		{
-			logs.GET("", middleware.AdminOnly(), handlers.GetActivityLogs)
+			logs.GET("", middleware.AdminOnly(), userHandler.GetActivityLogs)
 		}
 
 		// File Upload (protected)
@@ -47,6 +47,9 @@ export default function TransactionsPage() {
   const [user, setUser] = useState<any>(null);
   const [funds, setFunds] = useState<any[]>([]);
   const [filterPending, setFilterPending] = useState(true);
+  const [nextCursor, setNextCursor] = useState("");
+  const [totalCount, setTotalCount] = useState(0);
+  const [loadingMore, setLoadingMore] = useState(false);
   const [editFormData, setEditFormData] = useState<any>({
     amount: "",
     category: "",
@@ -64,10 +67,16 @@ export default function TransactionsPage() {
       setUser(parsedUser);
       console.log("User data:", parsedUser); // Debug: lihat role user
     }
-    fetchTransactions();
     fetchFunds();
   }, []);
 
+  // Admin melihat transaksi pending saja kecuali filter dimatikan
+  const pendingOnly = filterPending && user?.role === "admin";
+
+  useEffect(() => {
+    fetchTransactions();
+  }, [pendingOnly]);
+
   const fetchFunds = async () => {
     try {
       const res = await api.get("/funds");
@@ -77,15 +86,24 @@ export default function TransactionsPage() {
     }
   };
 
+  // fetchPage loads one page of transactions; without a cursor it starts
+  // over from the first page, otherwise it appends the next page.
+  const fetchPage = async (cursor = "") => {
+    const response = await api.get("/transactions", {
+      params: {
+        ...(pendingOnly ? { status: "pending" } : {}),
+        ...(cursor ? { cursor } : {}),
+      },
+    });
+    const page = response.data.data || [];
+    setTransactions((current) => (cursor ? [...current, ...page] : page));
+    setNextCursor(response.data.pagination?.nextCursor || "");
+    setTotalCount(response.data.pagination?.total ?? page.length);
+  };
+
   const fetchTransactions = async () => {
     try {
-      const response = await api.get("/transactions");
-      const allTransactions = response.data.data;
-      console.log("All transactions from API:", allTransactions); // Debug
-      allTransactions?.forEach((tx: any) => {
-        console.log(`Transaction: ${tx.eventName}, Status: ${tx.status}`); // Debug setiap transaksi
-      });
-      setTransactions(allTransactions);
+      await fetchPage();
     } catch (error) {
       console.error("Error fetching transactions:", error);
     } finally {
@@ -93,6 +111,18 @@ export default function TransactionsPage() {
     }
   };
 
+  const loadMore = async () => {
+    if (!nextCursor) return;
+    setLoadingMore(true);
+    try {
+      await fetchPage(nextCursor);
+    } catch (error) {
+      console.error("Error fetching transactions:", error);
+    } finally {
+      setLoadingMore(false);
+    }
+  };
+
   const handleApprove = async (id: string) => {
     try {
       await api.put(`/transactions/${id}/status`, { status: "approved" });
@@ -159,12 +189,19 @@ export default function TransactionsPage() {
         return <Badge variant="destructive">Ditolak</Badge>;
       case "pending":
         return <Badge variant="pending">Pending</Badge>;
+      case "draft":
+        return <Badge variant="secondary">Draft</Badge>;
+      case "voided":
+        return <Badge variant="secondary">Dibatalkan</Badge>;
       default:
         return <Badge>{status}</Badge>;
     }
   };
 
   const getTypeBadge = (type: string) => {
+    if (type === "transfer") {
+      return <Badge variant="secondary">Transfer</Badge>;
+    }
     return type === "income" ? (
       <Badge variant="success">Pemasukan</Badge>
     ) : (
@@ -173,7 +210,7 @@ export default function TransactionsPage() {
   };
 
   // Filter transaksi berdasarkan status jika admin
-  const filteredTransactions = filterPending && user?.role === "admin" 
+  const filteredTransactions = pendingOnly
     ? transactions.filter((tx) => tx.status === "pending")
     : transactions;
 
@@ -200,7 +237,7 @@ export default function TransactionsPage() {
                 size="sm"
                 onClick={() => setFilterPending(!filterPending)}
               >
-                {filterPending ? `⏳ Pending (${transactions.filter(t => t.status === "pending").length})` : "📋 Semua"}
+                {pendingOnly ? `⏳ Pending (${totalCount})` : "📋 Semua"}
               </Button>
             )}
           </div>
@@ -286,6 +323,15 @@ export default function TransactionsPage() {
               ))}
             </TableBody>
           </Table>
+          {nextCursor && (
+            <div className="flex justify-center pt-4">
+              <Button variant="outline" size="sm" onClick={loadMore} disabled={loadingMore}>
+                {loadingMore
+                  ? "Memuat..."
+                  : `Muat lebih banyak (${transactions.length} dari ${totalCount})`}
+              </Button>
+            </div>
+          )}
         </CardContent>
       </Card>
 
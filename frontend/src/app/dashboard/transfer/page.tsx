@@ -37,6 +37,7 @@ export default function TransferPage() {
     description: "",
     date: new Date().toISOString().split("T")[0],
     fundId: "",
+    toFundId: "",
   });
 
   useEffect(() => {
@@ -48,6 +49,7 @@ export default function TransferPage() {
           setFormData((prev) => ({
             ...prev,
             fundId: prev.fundId || res.data.data[0].id,
+            toFundId: prev.toFundId || res.data.data[0].id,
           }));
         }
       } catch (err) {
@@ -68,7 +70,10 @@ export default function TransferPage() {
   const handleSubmit = async (e: React.FormEvent) => {
     e.preventDefault();
 
-    if (formData.fromMethod === formData.toMethod) {
+    if (
+      formData.fromMethod === formData.toMethod &&
+      formData.fundId === formData.toFundId
+    ) {
       alert("Sumber dan tujuan tidak boleh sama!");
       return;
     }
@@ -81,40 +86,24 @@ export default function TransferPage() {
     setLoading(true);
 
     try {
-      // Transfer dilakukan dengan 2 transaksi:
-      // 1. Pengeluaran dari sumber (expense)
-      // 2. Pemasukan ke tujuan (income)
-
-      const amount = parseFloat(formData.amount);
       const description =
         formData.description ||
         `Transfer dari ${formData.fromMethod === "bank" ? "Bank" : "Cash"} ke ${
           formData.toMethod === "bank" ? "Bank" : "Cash"
         }`;
 
-      // Transaksi 1: Pengeluaran dari sumber
+      // Satu transaksi transfer: tidak dihitung sebagai pemasukan/pengeluaran
       await api.post("/transactions", {
-        type: "expense",
-        amount: amount,
+        type: "transfer",
+        amount: formData.amount,
         category: "Transfer",
-        description: `${description} (Pengeluaran)`,
+        description: description,
         eventName: "Transfer Saldo",
         date: formData.date,
         fundId: formData.fundId,
         paymentMethod: formData.fromMethod,
-        noteUrl: "",
-      });
-
-      // Transaksi 2: Pemasukan ke tujuan
-      await api.post("/transactions", {
-        type: "income",
-        amount: amount,
-        category: "Transfer",
-        description: `${description} (Pemasukan)`,
-        eventName: "Transfer Saldo",
-        date: formData.date,
-        fundId: formData.fundId,
-        paymentMethod: formData.toMethod,
+        toFundId: formData.toFundId,
+        toPaymentMethod: formData.toMethod,
         noteUrl: "",
       });
 
@@ -148,8 +137,8 @@ export default function TransferPage() {
         <CardHeader className="p-4 sm:p-6">
           <CardTitle className="text-lg sm:text-xl">Form Transfer</CardTitle>
           <CardDescription className="text-sm">
-            Transfer saldo akan mencatat 2 transaksi: pengeluaran dari sumber
-            dan pemasukan ke tujuan
+            Pindahkan saldo antar metode pembayaran atau antar fund dalam satu
+            transaksi
           </CardDescription>
         </CardHeader>
         <CardContent className="p-4 sm:p-6">
@@ -236,7 +225,7 @@ export default function TransferPage() {
 
             {/* Fund */}
             <div className="space-y-2">
-              <Label htmlFor="fund">Fund / Proker *</Label>
+              <Label htmlFor="fund">Fund / Proker Sumber *</Label>
               <Select
                 value={formData.fundId}
                 onValueChange={(value) =>
@@ -257,6 +246,29 @@ export default function TransferPage() {
               </Select>
             </div>
 
+            {/* Destination Fund */}
+            <div className="space-y-2">
+              <Label htmlFor="toFund">Fund / Proker Tujuan *</Label>
+              <Select
+                value={formData.toFundId}
+                onValueChange={(value) =>
+                  setFormData({ ...formData, toFundId: value })
+                }
+                required
+              >
+                <SelectTrigger>
+                  <SelectValue placeholder="Pilih fund" />
+                </SelectTrigger>
+                <SelectContent>
+                  {funds.map((fund) => (
+                    <SelectItem key={fund.id} value={fund.id}>
+                      {fund.name}
+                    </SelectItem>
+                  ))}
+                </SelectContent>
+              </Select>
+            </div>
+
             {/* Date */}
             <div className="space-y-2">
               <Label htmlFor="date">Tanggal Transfer *</Label>
@@ -288,19 +300,10 @@ export default function TransferPage() {
             {/* Info Alert */}
             <div className="p-3 sm:p-4 bg-blue-50 border border-blue-200 rounded-md">
               <p className="text-xs sm:text-sm text-blue-800">
-                <strong>ℹ️ Catatan:</strong> Transfer akan mencatat 2 transaksi:
-                <br />
-                1. Pengeluaran dari{" "}
-                <strong>
-                  {formData.fromMethod === "bank" ? "Bank" : "Cash"}
-                </strong>
-                <br />
-                2. Pemasukan ke{" "}
-                <strong>
-                  {formData.toMethod === "bank" ? "Bank" : "Cash"}
-                </strong>
-                <br />
-                Saldo total tidak berubah, hanya berpindah metode pembayaran.
+                <strong>ℹ️ Catatan:</strong> Transfer dicatat sebagai satu
+                transaksi dan disetujui sebagai satu kesatuan. Transfer tidak
+                dihitung sebagai pemasukan maupun pengeluaran; saldo hanya
+                berpindah dari sumber ke tujuan.
               </p>
             </div>
 
@@ -8,26 +8,250 @@ export interface User {
 
 export interface Transaction {
   id: string;
-  type: "income" | "expense";
+  type: "income" | "expense" | "transfer";
   amount: number;
+  categoryId?: string;
   category: string;
+  lines?: TransactionLine[];
   description: string;
+  eventId?: string;
   eventName: string;
   date: string;
   createdBy: string;
   createdByUser?: User;
-  status: "pending" | "approved" | "rejected";
+  status: "draft" | "pending" | "approved" | "rejected" | "voided";
+  fundId?: string;
+  paymentMethod?: "cash" | "bank";
+  toFundId?: string;
+  toPaymentMethod?: "cash" | "bank";
   noteUrl?: string;
   rejectionReason?: string;
+  reviewedBy?: string;
+  reviewedAt?: string;
+  reversalOfId?: string;
+  voidReason?: string;
+  recurringTemplateId?: string;
+  occurrenceDate?: string;
+  flags?: TransactionFlag[];
+  version: number;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface TransactionLine {
+  id: string;
+  transactionId: string;
+  position: number;
+  fundId: string;
+  categoryId?: string;
+  category: string;
+  amount: number;
+  description?: string;
+}
+
+export interface TransactionFlag {
+  id: string;
+  transactionId: string;
+  transaction?: Transaction;
+  kind: "duplicate" | "outlier" | "type_mismatch" | "suggestion";
+  message: string;
+  relatedTransactionId?: string;
+  status: "open" | "dismissed";
+  dismissedBy?: string;
+  dismissedAt?: string;
+  note?: string;
+  createdAt: string;
+}
+
+export interface CategorizationRule {
+  id: string;
+  name: string;
+  priority: number;
+  pattern: string;
+  field: "any" | "eventName" | "description";
+  minAmount: number;
+  maxAmount?: number;
+  paymentMethod?: "cash" | "bank";
+  setType?: "income" | "expense";
+  setCategory?: string;
+  setFundId?: string;
+  mode: "set" | "suggest";
+  status: "active" | "archived";
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface ApprovalStep {
+  id: string;
+  policyId: string;
+  stepOrder: number;
+  role?: "admin" | "member" | "viewer";
+  userId?: string;
+  user?: User;
+}
+
+export interface ApprovalPolicy {
+  id: string;
+  name: string;
+  priority: number;
+  type?: "income" | "expense" | "transfer";
+  fundId?: string;
+  category?: string;
+  minAmount: number;
+  maxAmount?: number;
+  status: "active" | "archived";
+  steps: ApprovalStep[];
+}
+
+export interface Approval {
+  id: string;
+  transactionId: string;
+  policyId?: string;
+  stepOrder: number;
+  approverId: string;
+  approver?: User;
+  decision: "approved" | "rejected";
+  note?: string;
+  superseded: boolean;
+  createdAt: string;
+}
+
+export interface RecurringTemplate {
+  id: string;
+  name: string;
+  type: "income" | "expense";
+  amount: number;
+  fundId: string;
+  category: string;
+  paymentMethod: "cash" | "bank";
+  eventName: string;
+  description: string;
+  frequency: "weekly" | "monthly" | "yearly";
+  dayOfMonth: number;
+  weekday: number;
+  monthOfYear: number;
+  startDate: string;
+  endDate?: string;
+  nextRunDate: string;
+  lastRunDate?: string;
+  status: "active" | "paused" | "ended";
+  createdBy: string;
+  version: number;
   createdAt: string;
   updatedAt: string;
 }
 
+export interface Event {
+  id: string;
+  name: string;
+  startDate: string;
+  endDate?: string;
+  commission: string;
+  fundId?: string;
+  budgetIncome: number;
+  budgetExpense: number;
+  description: string;
+  version: number;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface EventReport {
+  event: Event;
+  budgetIncome: number;
+  actualIncome: number;
+  incomeVariance: number;
+  budgetExpense: number;
+  actualExpense: number;
+  expenseVariance: number;
+  budgetNet: number;
+  actualNet: number;
+  incomeByCategory: CategoryData[];
+  expenseByCategory: CategoryData[];
+  transactionCount: number;
+}
+
+export interface BankStatementLine {
+  id: string;
+  statementId: string;
+  position: number;
+  date: string;
+  amount: number;
+  description: string;
+  reference: string;
+  status: "unmatched" | "matched" | "ignored";
+  transactionId?: string;
+  matchedBy?: string;
+  matchedAt?: string;
+  note?: string;
+}
+
+export interface BankStatement {
+  id: string;
+  fundId?: string;
+  format: "csv" | "ofx" | "mt940";
+  fileName: string;
+  accountNumber: string;
+  startDate: string;
+  endDate: string;
+  openingBalance?: number;
+  closingBalance?: number;
+  importedBy: string;
+  lines?: BankStatementLine[];
+  createdAt: string;
+}
+
+export interface ReconciliationReport {
+  statementId: string;
+  fundId?: string;
+  startDate: string;
+  endDate: string;
+  bankBalance: number | null;
+  bookBalance: number;
+  matchedLines: number;
+  unmatchedLines: number;
+  ignoredLines: number;
+  bankOnlyLines: BankStatementLine[];
+  bankOnlyTotal: number;
+  outstandingTransactions: {
+    id: string;
+    date: string;
+    type: string;
+    eventName: string;
+    amount: number;
+  }[];
+  outstandingTotal: number;
+  adjustedBankBalance: number | null;
+  adjustedBookBalance: number;
+  difference: number | null;
+  reconciled: boolean;
+}
+
+export interface ImportResult {
+  dryRun: boolean;
+  rows: number;
+  valid: number;
+  imported: number;
+  errors: { row: number; errors: string[] }[];
+}
+
+export interface FieldChange {
+  before: unknown;
+  after: unknown;
+}
+
 export interface ActivityLog {
   id: string;
   userId: string;
   user?: User;
+  entityType: string;
+  entityId?: string;
   action: string;
+  summary: string;
+  changes?: Record<string, FieldChange>;
+  ipAddress?: string;
+  userAgent?: string;
+  requestId?: string;
   timestamp: string;
 }
 
@@ -47,6 +271,7 @@ export interface MonthlyData {
 }
 
 export interface CategoryData {
+  categoryId?: string;
   category: string;
   amount: number;
   percentage: number;
@@ -63,12 +288,17 @@ export interface LoginResponse {
 }
 
 export interface CreateTransactionRequest {
-  type: "income" | "expense";
+  type: "income" | "expense" | "transfer";
   amount: number;
   category: string;
   description: string;
+  eventId?: string;
   eventName: string;
   date: string;
+  fundId: string;
+  paymentMethod?: "cash" | "bank";
+  toFundId?: string;
+  toPaymentMethod?: "cash" | "bank";
   noteUrl?: string;
 }
 